@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+// Manages the referential ConnectionLinks, saved in database with the
+// referentials
+type ConnectionLinkController struct {
+	referential *core.Referential
+}
+
+func NewConnectionLinkController(referential *core.Referential) ControllerInterface {
+	return &Controller{
+		restfulResource: &ConnectionLinkController{
+			referential: referential,
+		},
+	}
+}
+
+func (controller *ConnectionLinkController) Index(response http.ResponseWriter, filters url.Values) {
+	logger.Log.Debugf("ConnectionLinks Index")
+
+	jsonBytes, _ := json.Marshal(controller.referential.ConnectionLinks().FindAll())
+	response.Write(jsonBytes)
+}
+
+func (controller *ConnectionLinkController) Show(response http.ResponseWriter, identifier string) {
+	link, ok := controller.referential.ConnectionLinks().Find(identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("ConnectionLink not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Get connection link %s", identifier)
+
+	jsonBytes, _ := json.Marshal(&link)
+	response.Write(jsonBytes)
+}
+
+func (controller *ConnectionLinkController) Delete(response http.ResponseWriter, identifier string) {
+	link, ok := controller.referential.ConnectionLinks().Find(identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("ConnectionLink not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Delete connection link %s", identifier)
+
+	controller.referential.ConnectionLinks().Delete(identifier)
+
+	jsonBytes, _ := json.Marshal(&link)
+	response.Write(jsonBytes)
+}
+
+func (controller *ConnectionLinkController) Update(response http.ResponseWriter, identifier string, body []byte) {
+	link, ok := controller.referential.ConnectionLinks().Find(identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("ConnectionLink not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+
+	logger.Log.Debugf("Update connection link %s: %s", identifier, string(body))
+
+	err := json.Unmarshal(body, &link)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	link.Id = identifier
+
+	if err := controller.referential.ConnectionLinks().Save(&link); err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	jsonBytes, _ := json.Marshal(&link)
+	response.Write(jsonBytes)
+}
+
+func (controller *ConnectionLinkController) Create(response http.ResponseWriter, body []byte) {
+	logger.Log.Debugf("Create connection link: %s", string(body))
+
+	link := core.ConnectionLink{}
+	err := json.Unmarshal(body, &link)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if link.Id != "" {
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := controller.referential.ConnectionLinks().Save(&link); err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	jsonBytes, _ := json.Marshal(&link)
+	response.Write(jsonBytes)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_ConnectionLinkController(t *testing.T) {
+	server, referential := createReferential()
+	referential.ConnectionLinks().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Authorization", "Token token=testToken")
+		responseRecorder := httptest.NewRecorder()
+		server.HandleFlow(responseRecorder, request)
+		return responseRecorder
+	}
+
+	responseRecorder := send("POST", "/default/connection_links", `{"ObjectIDKind":"internal","FeederStopArea":"rail","DistributorStopArea":"bus","TransferTime":300}`)
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+	link := core.ConnectionLink{}
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Id == "" || link.TransferTime != 300 {
+		t.Errorf("Wrong created ConnectionLink: %v", link)
+	}
+
+	responseRecorder = send("PUT", "/default/connection_links/"+link.Id, `{"FeederLine":"RER"}`)
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+	if saved, _ := referential.ConnectionLinks().Find(link.Id); saved.FeederLine != "RER" || saved.FeederStopArea != "rail" {
+		t.Errorf("Wrong updated ConnectionLink: %v", saved)
+	}
+
+	responseRecorder = send("GET", "/default/connection_links", "")
+	links := []core.ConnectionLink{}
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &links); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 {
+		t.Errorf("Index should return the ConnectionLink: %v", links)
+	}
+
+	responseRecorder = send("DELETE", "/default/connection_links/"+link.Id, "")
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+	if _, ok := referential.ConnectionLinks().Find(link.Id); ok {
+		t.Error("ConnectionLink should be deleted")
+	}
+
+	responseRecorder = send("GET", "/default/connection_links/"+link.Id, "")
+	if responseRecorder.Code != http.StatusNotFound {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusNotFound)
+	}
+}
+
+func Test_ConnectionLinkController_Invalid(t *testing.T) {
+	server, _ := createReferential()
+
+	request, _ := http.NewRequest("POST", "/default/connection_links", strings.NewReader(`{"ObjectIDKind":"internal","FeederStopArea":"rail"}`))
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusBadRequest {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusBadRequest)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"bitbucket.org/enroute-mobi/ara/core"
 )
@@ -15,15 +16,20 @@ var newControllerMap = map[string](func(*Server) ControllerInterface){
 }
 
 var newWithReferentialControllerMap = map[string](func(*core.Referential) ControllerInterface){
-	"stop_areas":       NewStopAreaController,
-	"partners":         NewPartnerController,
-	"lines":            NewLineController,
-	"stop_visits":      NewStopVisitController,
-	"vehicle_journeys": NewVehicleJourneyController,
-	"situations":       NewSituationController,
-	"operators":        NewOperatorController,
-	"vehicles":         NewVehicleController,
-	"import":           NewImportController,
+	"stop_areas":          NewStopAreaController,
+	"partners":            NewPartnerController,
+	"lines":               NewLineController,
+	"stop_visits":         NewStopVisitController,
+	"vehicle_journeys":    NewVehicleJourneyController,
+	"situations":          NewSituationController,
+	"operators":           NewOperatorController,
+	"vehicles":            NewVehicleController,
+	"facilities":          NewFacilityController,
+	"import":              NewImportController,
+	"subscriptions":       NewSubscriptionController,
+	"identifier_mappings": NewIdentifierMappingController,
+	"stop_area_matches":   NewStopAreaMatchController,
+	"connection_links":    NewConnectionLinkController,
 }
 
 type RestfulResource interface {
@@ -69,7 +75,7 @@ func getRequestBody(response http.ResponseWriter, request *http.Request) []byte
 
 func (controller *Controller) serve(response http.ResponseWriter, request *http.Request, requestData *RequestData) {
 	// Check request body
-	if requestData.Method == "PUT" || (requestData.Method == "POST" && requestData.Id != "save" && requestData.Action != "reload") {
+	if requestData.Method == "PUT" || (requestData.Method == "POST" && requestData.Id != "save" && requestData.Action != "reload" && !strings.HasSuffix(requestData.Url, "/resync")) {
 		requestData.Body = getRequestBody(response, request)
 		if requestData.Body == nil {
 			return
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+type FacilityController struct {
+	referential *core.Referential
+}
+
+func NewFacilityController(referential *core.Referential) ControllerInterface {
+	return &Controller{
+		restfulResource: &FacilityController{
+			referential: referential,
+		},
+	}
+}
+
+func (controller *FacilityController) findFacility(tx *model.Transaction, identifier string) (model.Facility, bool) {
+	idRegexp := "([0-9a-zA-Z-]+):([0-9a-zA-Z-:]+)"
+	pattern := regexp.MustCompile(idRegexp)
+	foundStrings := pattern.FindStringSubmatch(identifier)
+	if foundStrings != nil {
+		objectid := model.NewObjectID(foundStrings[1], foundStrings[2])
+		return tx.Model().Facilities().FindByObjectId(objectid)
+	}
+	return tx.Model().Facilities().Find(model.FacilityId(identifier))
+}
+
+func (controller *FacilityController) Index(response http.ResponseWriter, filters url.Values) {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	logger.Log.Debugf("Facilities Index")
+
+	stime := controller.referential.Clock().Now()
+	facilities := tx.Model().Facilities().FindAll()
+	logger.Log.Debugf("FacilityController FindAll time : %v", controller.referential.Clock().Since(stime))
+	stime = controller.referential.Clock().Now()
+	jsonBytes, _ := json.Marshal(facilities)
+	logger.Log.Debugf("FacilityController Json Marshal time : %v ", controller.referential.Clock().Since(stime))
+	response.Write(jsonBytes)
+}
+
+func (controller *FacilityController) Show(response http.ResponseWriter, identifier string) {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	facility, ok := controller.findFacility(tx, identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("Facility not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Get facility %s", identifier)
+
+	jsonBytes, _ := facility.MarshalJSON()
+	response.Write(jsonBytes)
+}
+
+func (controller *FacilityController) Delete(response http.ResponseWriter, identifier string) {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	facility, ok := controller.findFacility(tx, identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("Facility not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Delete facility %s", identifier)
+
+	jsonBytes, _ := facility.MarshalJSON()
+	tx.Model().Facilities().Delete(&facility)
+	err := tx.Commit()
+	if err != nil {
+		logger.Log.Debugf("Transaction error: %v", err)
+		http.Error(response, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	response.Write(jsonBytes)
+}
+
+func (controller *FacilityController) Update(response http.ResponseWriter, identifier string, body []byte) {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	facility, ok := controller.findFacility(tx, identifier)
+	if !ok {
+		http.Error(response, fmt.Sprintf("Facility not found: %s", identifier), http.StatusNotFound)
+		return
+	}
+
+	logger.Log.Debugf("Update facility %s: %s", identifier, string(body))
+
+	err := json.Unmarshal(body, &facility)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := tx.Model().StopAreas().Find(facility.StopAreaId); !ok {
+		http.Error(response, fmt.Sprintf("Invalid request: stop area not found: %s", facility.StopAreaId), http.StatusBadRequest)
+		return
+	}
+
+	for _, obj := range facility.ObjectIDs() {
+		v, ok := tx.Model().Facilities().FindByObjectId(obj)
+		if ok && v.Id() != facility.Id() {
+			http.Error(response, fmt.Sprintf("Invalid request: facility %v already have an objectid %v", v.Id(), obj.String()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx.Model().Facilities().Save(&facility)
+	err = tx.Commit()
+	if err != nil {
+		logger.Log.Debugf("Transaction error: %v", err)
+		http.Error(response, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	jsonBytes, _ := facility.MarshalJSON()
+	response.Write(jsonBytes)
+}
+
+func (controller *FacilityController) Create(response http.ResponseWriter, body []byte) {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	logger.Log.Debugf("Create facility: %s", string(body))
+
+	facility := tx.Model().Facilities().New()
+
+	err := json.Unmarshal(body, &facility)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if facility.Id() != "" {
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := tx.Model().StopAreas().Find(facility.StopAreaId); !ok {
+		http.Error(response, fmt.Sprintf("Invalid request: stop area not found: %s", facility.StopAreaId), http.StatusBadRequest)
+		return
+	}
+
+	for _, obj := range facility.ObjectIDs() {
+		v, ok := tx.Model().Facilities().FindByObjectId(obj)
+		if ok {
+			http.Error(response, fmt.Sprintf("Invalid request: facility %v already have an objectid %v", v.Id(), obj.String()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx.Model().Facilities().Save(&facility)
+	err = tx.Commit()
+	if err != nil {
+		logger.Log.Debugf("Transaction error: %v", err)
+		http.Error(response, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	jsonBytes, _ := facility.MarshalJSON()
+	response.Write(jsonBytes)
+}
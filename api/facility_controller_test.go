@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func prepareFacilityRequest(method string, sendIdentifier bool, body []byte, t *testing.T) (facility model.Facility, responseRecorder *httptest.ResponseRecorder, referential *core.Referential) {
+	// Create a referential
+	referentials := core.NewMemoryReferentials()
+	server := &Server{}
+	server.SetReferentials(referentials)
+	referential = referentials.New("default")
+	referential.Tokens = []string{"testToken"}
+	referential.Save()
+
+	// Set the fake UUID generator
+	uuid.SetDefaultUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	// Save a new stop area and its facility
+	stopArea := referential.Model().StopAreas().New()
+	referential.Model().StopAreas().Save(&stopArea)
+
+	facility = referential.Model().Facilities().New()
+	facility.StopAreaId = stopArea.Id()
+	facility.Name = "Lift 1"
+	facility.Status = model.FACILITY_STATUS_AVAILABLE
+	referential.Model().Facilities().Save(&facility)
+
+	// Create a request
+	address := []byte("/default/facilities")
+	if sendIdentifier {
+		address = append(address, fmt.Sprintf("/%s", facility.Id())...)
+	}
+	request, err := http.NewRequest(method, string(address), bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	return
+}
+
+func Test_FacilityController_Delete(t *testing.T) {
+	facility, responseRecorder, referential := prepareFacilityRequest("DELETE", true, nil, t)
+
+	if status := responseRecorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusOK)
+	}
+	if _, ok := referential.Model().Facilities().Find(facility.Id()); ok {
+		t.Errorf("Facility shouldn't be found after DELETE request")
+	}
+}
+
+func Test_FacilityController_Update(t *testing.T) {
+	body := []byte(`{ "Status": "notAvailable", "Description": "Maintenance" }`)
+	facility, responseRecorder, referential := prepareFacilityRequest("PUT", true, body, t)
+
+	if status := responseRecorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusOK)
+	}
+
+	updatedFacility, ok := referential.Model().Facilities().Find(facility.Id())
+	if !ok {
+		t.Fatalf("Facility should be found after PUT request")
+	}
+	if updatedFacility.Status != model.FACILITY_STATUS_NOT_AVAILABLE {
+		t.Errorf("Facility status should be updated after PUT request:\n got: %v\n want: notAvailable", updatedFacility.Status)
+	}
+	if expected, _ := updatedFacility.MarshalJSON(); responseRecorder.Body.String() != string(expected) {
+		t.Errorf("Wrong body for PUT response request:\n got: %v\n want: %v", responseRecorder.Body.String(), string(expected))
+	}
+}
+
+func Test_FacilityController_Create(t *testing.T) {
+	// Using the fake uuid generator, the stop area uuid is 6ba7b814-9dad-11d1-0-00c04fd430c8
+	body := []byte(`{ "StopAreaId": "6ba7b814-9dad-11d1-0-00c04fd430c8", "ObjectIDs": { "internal": "lift-2" }, "Status": "available" }`)
+	_, responseRecorder, referential := prepareFacilityRequest("POST", false, body, t)
+
+	if status := responseRecorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v (%v)", status, http.StatusOK, responseRecorder.Body.String())
+	}
+
+	facility, ok := referential.Model().Facilities().FindByObjectId(model.NewObjectID("internal", "lift-2"))
+	if !ok {
+		t.Fatalf("Facility should be found after POST request")
+	}
+	if facility.Status != model.FACILITY_STATUS_AVAILABLE {
+		t.Errorf("Invalid facility status after POST request:\n got: %v\n want: available", facility.Status)
+	}
+}
+
+func Test_FacilityController_Create_UnknownStopArea(t *testing.T) {
+	body := []byte(`{ "StopAreaId": "unknown" }`)
+	_, responseRecorder, _ := prepareFacilityRequest("POST", false, body, t)
+
+	if status := responseRecorder.Code; status != http.StatusBadRequest {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusBadRequest)
+	}
+}
+
+func Test_FacilityController_Index(t *testing.T) {
+	_, responseRecorder, _ := prepareFacilityRequest("GET", false, nil, t)
+
+	if status := responseRecorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"Id":"6ba7b814-9dad-11d1-1-00c04fd430c8","StopAreaId":"6ba7b814-9dad-11d1-0-00c04fd430c8","Name":"Lift 1","Status":"available"}]`
+	if responseRecorder.Body.String() != expected {
+		t.Errorf("Wrong body for GET (index) response request:\n got: %v\n want: %v", responseRecorder.Body.String(), expected)
+	}
+}
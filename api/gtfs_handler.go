@@ -44,6 +44,19 @@ func (handler *GtfsHandler) serve(response http.ResponseWriter, request *http.Re
 	message := handler.newBQMessage(string(partner.Slug()), request.RemoteAddr)
 	defer audit.CurrentBigQuery(string(handler.referential.Slug())).WriteEvent(message)
 
+	if err := partner.RateLimiter().Allow(); err != nil {
+		handler.logError(message, startTime, "%v", err)
+		message.Status = "Throttled"
+		message.Type = resource
+		httpThrottledError(err, response)
+		return
+	}
+
+	if resource == "static.zip" {
+		handler.serveStatic(response, partner, message, logStashEvent, startTime)
+		return
+	}
+
 	var gc []core.GtfsConnector
 	var c core.Connector
 	messageType := resource
@@ -116,6 +129,42 @@ func (handler *GtfsHandler) serve(response http.ResponseWriter, request *http.Re
 	response.Write(buffer.Bytes())
 }
 
+// Serves the GTFS static feed built with the same identifiers than the TripUpdates feed
+func (handler *GtfsHandler) serveStatic(response http.ResponseWriter, partner *core.Partner, message *audit.BigQueryMessage, logStashEvent audit.LogStashEvent, startTime time.Time) {
+	message.Type = "static"
+
+	c, ok := partner.Connector(core.GTFS_RT_TRIP_UPDATES_BROADCASTER)
+	if !ok {
+		handler.logError(message, startTime, "Partner %v doesn't have the required Gtfs connector %v", partner.Slug(), core.GTFS_RT_TRIP_UPDATES_BROADCASTER)
+		http.Error(response, "Partner doesn't have the required Gtfs connector", http.StatusNotImplemented)
+		return
+	}
+
+	d, err := partner.GtfsCache().Fetch("static", func() (interface{}, error) {
+		return c.(*core.TripUpdatesBroadcaster).GtfsStaticFeed()
+	})
+	if err != nil {
+		handler.logError(message, startTime, "%v", err)
+		http.Error(response, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	data := d.([]byte)
+
+	processingTime := handler.referential.Clock().Since(startTime)
+
+	logStashEvent["zip_size"] = strconv.Itoa(len(data))
+	logStashEvent["response_time"] = processingTime.String()
+	audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	message.ResponseSize = int64(len(data))
+	message.ProcessingTime = processingTime.Seconds()
+
+	response.Header().Set("Content-Type", "application/zip")
+	response.Header().Set("Content-Disposition", "attachment; filename=\"static.zip\"")
+	response.WriteHeader(http.StatusOK)
+	response.Write(data)
+}
+
 func (handler *GtfsHandler) getFeed(gc []core.GtfsConnector, logStashEvent audit.LogStashEvent) ([]byte, error) {
 	version := "2.0"
 	timestamp := uint64(handler.referential.Clock().Now().Unix())
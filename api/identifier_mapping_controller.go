@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+// Manages the referential IdentifierMappings tables:
+//
+//	GET /ref/identifier_mappings          table names with their size
+//	GET /ref/identifier_mappings/:table   table content (CSV with Accept: text/csv)
+//	PUT /ref/identifier_mappings/:table   replaces the table with a JSON object or a CSV file (Content-Type: text/csv)
+//	DELETE /ref/identifier_mappings/:table
+//
+// The tables are saved in database with the referentials
+type IdentifierMappingController struct {
+	referential *core.Referential
+}
+
+func NewIdentifierMappingController(referential *core.Referential) ControllerInterface {
+	return &IdentifierMappingController{
+		referential: referential,
+	}
+}
+
+func (controller *IdentifierMappingController) serve(response http.ResponseWriter, request *http.Request, requestData *RequestData) {
+	if requestData.Action != "" || (requestData.Id == "" && requestData.Method != "GET") {
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	switch requestData.Method {
+	case "GET":
+		if requestData.Id == "" {
+			controller.index(response)
+			return
+		}
+		controller.show(response, request, requestData.Id)
+	case "PUT":
+		body := getRequestBody(response, request)
+		if body == nil {
+			return
+		}
+		controller.update(response, request, requestData.Id, body)
+	case "DELETE":
+		controller.delete(response, requestData.Id)
+	default:
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+func (controller *IdentifierMappingController) index(response http.ResponseWriter) {
+	logger.Log.Debugf("IdentifierMappings Index")
+
+	jsonBytes, _ := json.Marshal(controller.referential.IdentifierMappings().Tables())
+	response.Write(jsonBytes)
+}
+
+func (controller *IdentifierMappingController) show(response http.ResponseWriter, request *http.Request, name string) {
+	mappings := controller.referential.IdentifierMappings()
+
+	table, ok := mappings.Table(name)
+	if !ok {
+		http.Error(response, fmt.Sprintf("IdentifierMapping table not found: %s", name), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Get IdentifierMapping table %s", name)
+
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Accept")); mediaType == "text/csv" {
+		buffer := new(bytes.Buffer)
+		mappings.WriteCSV(name, buffer)
+
+		response.Header().Set("Content-Type", "text/csv")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v-%v.csv\"", controller.referential.Slug(), name))
+		response.Write(buffer.Bytes())
+		return
+	}
+
+	jsonBytes, _ := json.Marshal(table)
+	response.Write(jsonBytes)
+}
+
+func (controller *IdentifierMappingController) update(response http.ResponseWriter, request *http.Request, name string, body []byte) {
+	logger.Log.Debugf("Update IdentifierMapping table %s", name)
+
+	mappings := controller.referential.IdentifierMappings()
+
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); mediaType == "text/csv" {
+		if _, err := mappings.LoadCSV(name, bytes.NewReader(body)); err != nil {
+			http.Error(response, fmt.Sprintf("Invalid request: can't parse CSV content: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else {
+		table := make(map[string]string)
+		if err := json.Unmarshal(body, &table); err != nil {
+			http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		mappings.SetTable(name, table)
+	}
+
+	table, _ := mappings.Table(name)
+	jsonBytes, _ := json.Marshal(table)
+	response.Write(jsonBytes)
+}
+
+func (controller *IdentifierMappingController) delete(response http.ResponseWriter, name string) {
+	if !controller.referential.IdentifierMappings().DeleteTable(name) {
+		http.Error(response, fmt.Sprintf("IdentifierMapping table not found: %s", name), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Delete IdentifierMapping table %s", name)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_IdentifierMappingController_CSV(t *testing.T) {
+	server, referential := createReferential()
+
+	request, _ := http.NewRequest("PUT", "/default/identifier_mappings/stops", strings.NewReader("STIF:1,SNCF:A\nSTIF:2,SNCF:B\n"))
+	request.Header.Set("Authorization", "Token token=testToken")
+	request.Header.Set("Content-Type", "text/csv")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+	if mapped, ok := referential.IdentifierMappings().Lookup("stops", "STIF:2"); !ok || mapped != "SNCF:B" {
+		t.Errorf("Wrong mapping after CSV import: %v %v", mapped, ok)
+	}
+
+	request, _ = http.NewRequest("GET", "/default/identifier_mappings", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	tables := make(map[string]int)
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables["stops"] != 2 {
+		t.Errorf("Wrong table index: %v", tables)
+	}
+
+	request, _ = http.NewRequest("GET", "/default/identifier_mappings/stops", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	request.Header.Set("Accept", "text/csv")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if expected := "STIF:1,SNCF:A\nSTIF:2,SNCF:B\n"; responseRecorder.Body.String() != expected {
+		t.Errorf("Wrong CSV export:\n got: %q\n want: %q", responseRecorder.Body.String(), expected)
+	}
+}
+
+func Test_IdentifierMappingController_JSON(t *testing.T) {
+	server, referential := createReferential()
+
+	request, _ := http.NewRequest("PUT", "/default/identifier_mappings/lines", strings.NewReader(`{"1":"A"}`))
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if mapped, ok := referential.IdentifierMappings().Lookup("lines", "1"); !ok || mapped != "A" {
+		t.Errorf("Wrong mapping after JSON update: %v %v", mapped, ok)
+	}
+
+	request, _ = http.NewRequest("DELETE", "/default/identifier_mappings/lines", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if _, ok := referential.IdentifierMappings().Table("lines"); ok {
+		t.Errorf("Table should be deleted")
+	}
+
+	request, _ = http.NewRequest("GET", "/default/identifier_mappings/lines", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusNotFound {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusNotFound)
+	}
+}
@@ -3,11 +3,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"bitbucket.org/enroute-mobi/ara/core"
 	"bitbucket.org/enroute-mobi/ara/logger"
@@ -32,6 +35,15 @@ func NewImportController(referential *core.Referential) ControllerInterface {
 }
 
 func (controller *ImportController) serve(response http.ResponseWriter, request *http.Request, requestData *RequestData) {
+	if request.Method == "GET" {
+		if requestData.Id != "export" {
+			http.Error(response, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		controller.export(response, requestData.Filters)
+		return
+	}
+
 	mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
 	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
 		http.Error(response, "Expected multipart content", http.StatusUnsupportedMediaType)
@@ -70,3 +82,33 @@ func (controller *ImportController) serve(response http.ResponseWriter, request
 	logger.Log.Debugf("ImportController Json Marshal time : %v ", controller.referential.Clock().Since(stime))
 	response.Write(jsonBytes)
 }
+
+// Writes the in-memory model, or the database rows of the model_name filter, in the Loader CSV format
+func (controller *ImportController) export(response http.ResponseWriter, filters url.Values) {
+	stime := controller.referential.Clock().Now()
+
+	var err error
+	buffer := new(bytes.Buffer)
+	modelName := filters.Get("model_name")
+	if modelName != "" {
+		if _, err := time.Parse("2006-01-02", modelName); err != nil {
+			http.Error(response, fmt.Sprintf("Invalid model_name '%v', expected a YYYY-MM-DD date", modelName), http.StatusBadRequest)
+			return
+		}
+		_, err = model.NewExporter(buffer, modelName).ExportDatabase(string(controller.referential.Slug()))
+	} else {
+		date := controller.referential.Model().Date()
+		modelName = date.String()
+		_, err = model.NewExporter(buffer, modelName).ExportModel(controller.referential.Model())
+	}
+	if err != nil {
+		logger.Log.Debugf("Export error: %v", err)
+		http.Error(response, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	logger.Log.Debugf("ImportController Export time : %v", controller.referential.Clock().Since(stime))
+
+	response.Header().Set("Content-Type", "text/csv")
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v-%v.csv\"", controller.referential.Slug(), modelName))
+	response.Write(buffer.Bytes())
+}
@@ -174,3 +174,68 @@ func Test_Serve(t *testing.T) {
 		t.Errorf("Handler returned wrong number of errors:\n got %v\n want 0", result3.Import["Errors"])
 	}
 }
+
+func Test_ImportController_Export(t *testing.T) {
+	clock.SetDefaultClock(clock.NewFakeClockAt(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)))
+
+	referentials := core.NewMemoryReferentials()
+	referential := referentials.New("test")
+	referential.Tokens = []string{"testToken"}
+	referentials.Save(referential)
+
+	line := referential.Model().Lines().New()
+	line.Name = "Name"
+	line.SetObjectID(model.NewObjectID("internal", "lineObjectid"))
+	referential.Model().Lines().Save(&line)
+
+	server := &Server{}
+	server.SetReferentials(referentials)
+
+	request, err := http.NewRequest("GET", "/test/import/export", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Token token=testToken")
+
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if status := responseRecorder.Code; status != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusOK)
+	}
+	if contentType := responseRecorder.Header().Get("Content-Type"); contentType != "text/csv" {
+		t.Errorf("Handler returned wrong Content-Type:\n got: %v\n want: %v", contentType, "text/csv")
+	}
+
+	date := referential.Model().Date()
+	expectedBody := "line," + string(line.Id()) + "," + date.String() + ",Name,\"{\"\"internal\"\":\"\"lineObjectid\"\"}\",{},{},false\n"
+	if responseRecorder.Body.String() != expectedBody {
+		t.Errorf("Handler returned wrong body:\n got %v\n want %v", responseRecorder.Body.String(), expectedBody)
+	}
+}
+
+func Test_ImportController_Export_InvalidModelName(t *testing.T) {
+	referentials := core.NewMemoryReferentials()
+	referential := referentials.New("test")
+	referential.Tokens = []string{"testToken"}
+	referentials.Save(referential)
+
+	server := &Server{}
+	server.SetReferentials(referentials)
+
+	request, err := http.NewRequest("GET", "/test/import/export?model_name=2017-01-01'%20or%20'1'='1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Token token=testToken")
+
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if !strings.Contains(responseRecorder.Body.String(), "Invalid model_name") {
+		t.Errorf("Handler returned wrong body: %v", responseRecorder.Body.String())
+	}
+	if status := responseRecorder.Code; status != http.StatusBadRequest {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", status, http.StatusBadRequest)
+	}
+}
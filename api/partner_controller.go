@@ -46,8 +46,21 @@ func (controller *PartnerController) subscriptionsIndex(response http.ResponseWr
 	}
 	logger.Log.Debugf("Get partner %s for Subscriptions", requestData.Id)
 
-	subscriptions := partner.Subscriptions()
-	jsonBytes, _ := json.Marshal(subscriptions.FindAll())
+	kind := requestData.Filters.Get("kind")
+	health := requestData.Filters.Get("health")
+
+	subscriptions := []*core.Subscription{}
+	for _, subscription := range partner.Subscriptions().FindAll() {
+		if kind != "" && subscription.Kind() != kind {
+			continue
+		}
+		if health != "" && subscription.Health() != health {
+			continue
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	jsonBytes, _ := json.Marshal(subscriptions)
 	response.Write(jsonBytes)
 }
 
@@ -92,7 +105,62 @@ func (controller *PartnerController) subscriptionsCreate(response http.ResponseW
 	response.Write(jsonBytes)
 }
 
+func (controller *PartnerController) subscriptionsShow(response http.ResponseWriter, requestData *RequestData, id string) {
+	partner := controller.findPartner(requestData.Id)
+	if partner == nil {
+		http.Error(response, fmt.Sprintf("Partner not found: %s", requestData.Id), http.StatusInternalServerError)
+		return
+	}
+
+	subscription, ok := partner.Subscriptions().Find(core.SubscriptionId(id))
+	if !ok {
+		http.Error(response, fmt.Sprintf("Subscription not found: %s", id), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Get subscription %s of partner %s", id, requestData.Id)
+
+	jsonBytes, _ := json.Marshal(subscription.Diagnostic())
+	response.Write(jsonBytes)
+}
+
+func (controller *PartnerController) subscriptionsResync(response http.ResponseWriter, requestData *RequestData, id string) {
+	partner := controller.findPartner(requestData.Id)
+	if partner == nil {
+		http.Error(response, fmt.Sprintf("Partner not found: %s", requestData.Id), http.StatusInternalServerError)
+		return
+	}
+
+	subscription, ok := partner.Subscriptions().Find(core.SubscriptionId(id))
+	if !ok {
+		http.Error(response, fmt.Sprintf("Subscription not found: %s", id), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Resync subscription %s of partner %s", id, requestData.Id)
+
+	if err := partner.ResyncSubscription(subscription); err != nil {
+		http.Error(response, fmt.Sprintf("Can't resync subscription %s: %v", id, err), http.StatusBadRequest)
+		return
+	}
+
+	jsonBytes, _ := json.Marshal(subscription.Diagnostic())
+	response.Write(jsonBytes)
+}
+
 func (controller *PartnerController) subscriptions(response http.ResponseWriter, requestData *RequestData) {
+	// subscriptions/:id and subscriptions/:id/resync
+	if id, ok := controller.getActionId(requestData.Action, requestData.Url); ok && requestData.Method != "DELETE" {
+		path := strings.Split(id, "/")
+		switch {
+		case len(path) == 1 && requestData.Method == "GET":
+			controller.subscriptionsShow(response, requestData, path[0])
+		case len(path) == 2 && path[1] == "resync" && requestData.Method == "POST":
+			controller.subscriptionsResync(response, requestData, path[0])
+		default:
+			http.Error(response, "Invalid request", http.StatusBadRequest)
+		}
+		return
+	}
+
 	switch requestData.Method {
 	case "GET":
 		controller.subscriptionsIndex(response, requestData)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -133,6 +134,69 @@ func Test_PartnerController_Action_Subscriber(t *testing.T) {
 
 }
 
+func Test_PartnerController_Action_SubscriptionShow(t *testing.T) {
+	server, referential := createReferential()
+	partner := createPartner(referential)
+
+	subscription := partner.Subscriptions().New("StopMonitoringCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("internal", "value")))
+	resource.RetryCount = 2
+
+	rdata := &RequestTestData{
+		Server: server,
+		Id:     string(partner.Id()),
+		Method: "GET",
+		Action: fmt.Sprintf("subscriptions/%s", subscription.Id()),
+	}
+	responseRecorder := sendRequest(rdata, t)
+	partnerCheckResponseStatus(responseRecorder, t)
+
+	diagnostic := &core.SubscriptionDiagnostic{}
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), diagnostic); err != nil {
+		t.Fatal(err)
+	}
+	if diagnostic.Id != subscription.Id() {
+		t.Errorf("Wrong subscription:\n got: %v\n want: %v", diagnostic.Id, subscription.Id())
+	}
+	if diagnostic.Errors != 2 {
+		t.Errorf("Wrong error count:\n got: %v\n want: 2", diagnostic.Errors)
+	}
+	if len(diagnostic.Resources) != 1 {
+		t.Errorf("Diagnostic should have one resource, got: %v", len(diagnostic.Resources))
+	}
+
+	rdata.Action = "subscriptions/unknown"
+	responseRecorder = sendRequest(rdata, t)
+	if responseRecorder.Code != http.StatusNotFound {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusNotFound)
+	}
+}
+
+func Test_PartnerController_Action_SubscriptionResync(t *testing.T) {
+	server, referential := createReferential()
+	partner := createPartner(referential)
+
+	subscription := partner.Subscriptions().New("StopMonitoringCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("internal", "value")))
+	resource.SubscribedAt = referential.Clock().Now()
+
+	rdata := &RequestTestData{
+		Server: server,
+		Id:     string(partner.Id()),
+		Method: "POST",
+		Action: fmt.Sprintf("subscriptions/%s/resync", subscription.Id()),
+	}
+	responseRecorder := sendRequest(rdata, t)
+	partnerCheckResponseStatus(responseRecorder, t)
+
+	if !resource.SubscribedAt.IsZero() {
+		t.Errorf("Collect subscription resource should be resubscribed")
+	}
+	if subscription.Health() != core.SUBSCRIPTION_HEALTH_PENDING {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), core.SUBSCRIPTION_HEALTH_PENDING)
+	}
+}
+
 func Test_PartnerController_Delete(t *testing.T) {
 	// Send request
 	//	partner, responseRecorder, referential := partnerPrepareRequest("DELETE", true, nil, t)
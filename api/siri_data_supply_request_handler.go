@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type SIRIDataSupplyRequestHandler struct {
+	xmlRequest  *siri.XMLDataSupplyRequest
+	referential *core.Referential
+}
+
+func (handler *SIRIDataSupplyRequestHandler) RequestorRef() string {
+	return handler.xmlRequest.RequestorRef()
+}
+
+func (handler *SIRIDataSupplyRequestHandler) ConnectorType() string {
+	return core.SIRI_SUBSCRIPTION_REQUEST_DISPATCHER
+}
+
+func (handler *SIRIDataSupplyRequestHandler) Respond(connector core.Connector, rw http.ResponseWriter, message *audit.BigQueryMessage) {
+	logger.Log.Debugf("DataSupply %s", handler.xmlRequest.MessageIdentifier())
+
+	t := clock.DefaultClock().Now()
+
+	var xmlResponse string
+	var n int64
+	var writeErr error
+
+	// The notifications are removed from the partner queue when the response
+	// is written
+	err := connector.(core.SubscriptionRequestDispatcher).HandleDataSupply(handler.xmlRequest, message, func(response *siri.SIRIDataSupplyResponse) (err error) {
+		xmlResponse, err = response.BuildXML()
+		if err != nil {
+			return err
+		}
+
+		// Wrap soap and send response
+		soapEnvelope := siri.NewSOAPEnvelopeBuffer()
+		soapEnvelope.WriteXML(xmlResponse)
+
+		// The response can have been partially received: the notifications
+		// aren't fetched again and no error can be written
+		n, writeErr = soapEnvelope.WriteTo(rw)
+		return nil
+	})
+	if err != nil {
+		siriError("InternalServiceError", fmt.Sprintf("Internal Error: %v", err), string(handler.referential.Slug()), rw)
+		return
+	}
+	if writeErr != nil {
+		logger.Log.Debugf("Can't write DataSupply response: %v", writeErr)
+		message.Status = "Error"
+		message.ErrorDetails = writeErr.Error()
+	}
+
+	message.Type = "DataSupplyRequest"
+	message.RequestRawMessage = handler.xmlRequest.RawXML()
+	message.ResponseRawMessage = xmlResponse
+	message.ResponseSize = n
+	message.ProcessingTime = clock.DefaultClock().Since(t).Seconds()
+	audit.CurrentBigQuery(string(handler.referential.Slug())).WriteEvent(message)
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/core"
 	"bitbucket.org/enroute-mobi/ara/logger"
 	"bitbucket.org/enroute-mobi/ara/siri"
 )
@@ -14,6 +17,8 @@ type SiriErrorResponse struct {
 	errCode        string
 	errDescription string
 	request        string
+	status         string
+	partner        string
 }
 
 func siriError(errCode, errDescription, referentialSlug string, response http.ResponseWriter) {
@@ -29,6 +34,19 @@ func siriErrorWithRequest(errCode, errDescription, referentialSlug, request stri
 	}.sendSiriError(referentialSlug)
 }
 
+// Refuses a request when the partner exceeds its rate limit or its quota
+func siriThrottledError(err *core.RateLimitError, referentialSlug, partnerSlug, request string, response http.ResponseWriter) {
+	setRetryAfter(err, response)
+	SiriErrorResponse{
+		response:       response,
+		errCode:        "AllowedResourceUsageExceeded",
+		errDescription: err.Error(),
+		request:        request,
+		status:         "Throttled",
+		partner:        partnerSlug,
+	}.sendSiriError(referentialSlug)
+}
+
 func (siriError SiriErrorResponse) sendSiriError(referentialSlug string) {
 	logger.Log.Debugf("Send SIRI error %v : %v", siriError.errCode, siriError.errDescription)
 
@@ -49,10 +67,14 @@ func (siriError SiriErrorResponse) sendSiriError(referentialSlug string) {
 		Protocol:  "siri",
 		Direction: "received",
 		Status:    "Error",
+		Partner:   siriError.partner,
 		// Type:         "siri-error",
 		ErrorDetails: fmt.Sprintf("%v: %v", siriError.errCode, siriError.errDescription),
 		// ResponseRawMessage: soapEnvelope.String(),
 	}
+	if siriError.status != "" {
+		message.Status = siriError.status
+	}
 
 	if siriError.request != "" {
 		logStashEvent["requestXML"] = siriError.request
@@ -65,3 +87,14 @@ func (siriError SiriErrorResponse) sendSiriError(referentialSlug string) {
 	audit.CurrentLogStash().WriteEvent(logStashEvent)
 	audit.CurrentBigQuery(referentialSlug).WriteEvent(message)
 }
+
+// Refuses a non SIRI request when the partner exceeds its rate limit or its
+// quota
+func httpThrottledError(err *core.RateLimitError, response http.ResponseWriter) {
+	setRetryAfter(err, response)
+	http.Error(response, err.Error(), http.StatusTooManyRequests)
+}
+
+func setRetryAfter(err *core.RateLimitError, response http.ResponseWriter) {
+	response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+}
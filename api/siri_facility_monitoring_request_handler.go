@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type SIRIFacilityMonitoringRequestHandler struct {
+	xmlRequest  *siri.XMLGetFacilityMonitoring
+	referential *core.Referential
+}
+
+func (handler *SIRIFacilityMonitoringRequestHandler) RequestorRef() string {
+	return handler.xmlRequest.RequestorRef()
+}
+
+func (handler *SIRIFacilityMonitoringRequestHandler) ConnectorType() string {
+	return core.SIRI_FACILITY_MONITORING_REQUEST_BROADCASTER
+}
+
+func (handler *SIRIFacilityMonitoringRequestHandler) Respond(connector core.Connector, rw http.ResponseWriter, message *audit.BigQueryMessage) {
+	logger.Log.Debugf("Facility Monitoring %s\n", handler.xmlRequest.MessageIdentifier())
+
+	t := clock.DefaultClock().Now()
+
+	response := connector.(core.FacilityMonitoringBroadcaster).RequestFacilities(handler.xmlRequest, message)
+	xmlResponse, err := response.BuildXML()
+	if err != nil {
+		siriError("InternalServiceError", fmt.Sprintf("Internal Error: %v", err), string(handler.referential.Slug()), rw)
+		return
+	}
+
+	// Wrap soap and send response
+	soapEnvelope := siri.NewSOAPEnvelopeBuffer()
+	soapEnvelope.WriteXML(xmlResponse)
+
+	n, err := soapEnvelope.WriteTo(rw)
+	if err != nil {
+		siriError("InternalServiceError", fmt.Sprintf("Internal Error: %v", err), string(handler.referential.Slug()), rw)
+		return
+	}
+
+	message.Type = "FacilityMonitoringRequest"
+	message.RequestRawMessage = handler.xmlRequest.RawXML()
+	message.ResponseRawMessage = xmlResponse
+	message.ResponseSize = n
+	message.ProcessingTime = clock.DefaultClock().Since(t).Seconds()
+	audit.CurrentBigQuery(string(handler.referential.Slug())).WriteEvent(message)
+}
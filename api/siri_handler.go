@@ -87,11 +87,31 @@ func (handler *SIRIHandler) requestHandler(envelope *siri.SOAPEnvelope) SIRIRequ
 			xmlRequest:  siri.NewXMLGetGeneralMessage(envelope.Body()),
 			referential: handler.referential,
 		}
+	case "DataSupply":
+		return &SIRIDataSupplyRequestHandler{
+			xmlRequest:  siri.NewXMLDataSupplyRequest(envelope.Body()),
+			referential: handler.referential,
+		}
 	case "GetEstimatedTimetable":
 		return &SIRIEstimatedTimetableRequestHandler{
 			xmlRequest:  siri.NewXMLGetEstimatedTimetable(envelope.Body()),
 			referential: handler.referential,
 		}
+	case "GetProductionTimetable":
+		return &SIRIProductionTimetableRequestHandler{
+			xmlRequest:  siri.NewXMLGetProductionTimetable(envelope.Body()),
+			referential: handler.referential,
+		}
+	case "GetConnectionMonitoring":
+		return &SIRIConnectionMonitoringRequestHandler{
+			xmlRequest:  siri.NewXMLGetConnectionMonitoring(envelope.Body()),
+			referential: handler.referential,
+		}
+	case "GetFacilityMonitoring":
+		return &SIRIFacilityMonitoringRequestHandler{
+			xmlRequest:  siri.NewXMLGetFacilityMonitoring(envelope.Body()),
+			referential: handler.referential,
+		}
 	}
 	return nil
 }
@@ -139,6 +159,10 @@ func (handler *SIRIHandler) serve(response http.ResponseWriter, request *http.Re
 		siriErrorWithRequest("UnknownCredential", fmt.Sprintf("RequestorRef Unknown '%s'", requestHandler.RequestorRef()), string(handler.referential.Slug()), envelope.Body().String(), response)
 		return
 	}
+	if err := partner.RateLimiter().Allow(); err != nil {
+		siriThrottledError(err, string(handler.referential.Slug()), string(partner.Slug()), envelope.Body().String(), response)
+		return
+	}
 	connector, ok := partner.Connector(requestHandler.ConnectorType())
 	if !ok {
 		siriErrorWithRequest("NotFound", fmt.Sprintf("No Connectors for %v", envelope.BodyType()), string(handler.referential.Slug()), envelope.Body().String(), response)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -772,3 +773,64 @@ func Test_SIRIHandler_LinesDiscovery(t *testing.T) {
 		t.Errorf("Unexpected response body:\n expected: %v\n got: %v", expectedResponseBody, responseBody)
 	}
 }
+
+func Test_SIRIHandler_DataSupply(t *testing.T) {
+	soapEnvelope := siri.NewSOAPEnvelopeBuffer()
+
+	content, err := ioutil.ReadFile("../siri/testdata/data_supply_request.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	soapEnvelope.WriteXML(strings.Replace(string(content), "Subscriber", "Ara", 1))
+
+	server, referential := siriHandler_PrepareServer()
+	partner := referential.Partners().FindAll()[0]
+	partner.CreateSubscriptionRequestDispatcher()
+
+	responseRecorder := siriHandler_Request(server, soapEnvelope, t)
+
+	envelope, err := siri.NewSOAPEnvelope(responseRecorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.BodyType() != "DataSupplyResponse" {
+		t.Errorf("Wrong response type:\n got: %v\n want: DataSupplyResponse", envelope.BodyType())
+	}
+	if !strings.Contains(envelope.Body().String(), "<siri:RequestMessageRef>DataSupply:1</siri:RequestMessageRef>") {
+		t.Errorf("Response should reference the DataSupply request:\n%v", envelope.Body().String())
+	}
+	// The request fixture asks for AllData
+	if !strings.Contains(envelope.Body().String(), "<siri:CapabilityNotSupportedError>") {
+		t.Errorf("Response should refuse AllData:\n%v", envelope.Body().String())
+	}
+}
+
+func Test_SIRIHandler_RateLimit(t *testing.T) {
+	server, referential := siriHandler_PrepareServer()
+	partner := referential.Partners().FindAll()[0]
+	partner.Settings[core.RATE_LIMIT_PER_MINUTE] = "1"
+
+	checkStatusRequest := func() *httptest.ResponseRecorder {
+		soapEnvelope := siri.NewSOAPEnvelopeBuffer()
+		request, err := siri.NewSIRICheckStatusRequest("Ara",
+			clock.DefaultClock().Now(),
+			"Ara:Message::6ba7b814-9dad-11d1-0-00c04fd430c8:LOC").BuildXML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		soapEnvelope.WriteXML(request)
+		return siriHandler_Request(server, soapEnvelope, t)
+	}
+
+	if responseRecorder := checkStatusRequest(); strings.Contains(responseRecorder.Body.String(), "Fault") {
+		t.Errorf("First request should be accepted:\n%v", responseRecorder.Body.String())
+	}
+
+	responseRecorder := checkStatusRequest()
+	if !strings.Contains(responseRecorder.Body.String(), "<faultcode>S:AllowedResourceUsageExceeded</faultcode>") {
+		t.Errorf("Second request should be throttled:\n%v", responseRecorder.Body.String())
+	}
+	if expected := "60"; responseRecorder.Header().Get("Retry-After") != expected {
+		t.Errorf("Wrong Retry-After header:\n got: %v\n want: %v", responseRecorder.Header().Get("Retry-After"), expected)
+	}
+}
@@ -67,6 +67,19 @@ func (handler *SIRILiteHandler) serve(response http.ResponseWriter, request *htt
 		return
 	}
 
+	if err := partner.RateLimiter().Allow(); err != nil {
+		audit.CurrentBigQuery(string(handler.referential.Slug())).WriteEvent(&audit.BigQueryMessage{
+			Protocol:     "siri-lite",
+			Direction:    "received",
+			Partner:      string(partner.Slug()),
+			IPAddress:    request.RemoteAddr,
+			Status:       "Throttled",
+			ErrorDetails: err.Error(),
+		})
+		httpThrottledError(err, response)
+		return
+	}
+
 	requestHandler := handler.requestHandler(requestData)
 	if requestHandler == nil {
 		http.Error(response, "The SIRI Lite request doesn’t match a defined broadcast", http.StatusNotFound)
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type SIRIProductionTimetableRequestHandler struct {
+	xmlRequest  *siri.XMLGetProductionTimetable
+	referential *core.Referential
+}
+
+func (handler *SIRIProductionTimetableRequestHandler) RequestorRef() string {
+	return handler.xmlRequest.RequestorRef()
+}
+
+func (handler *SIRIProductionTimetableRequestHandler) ConnectorType() string {
+	return core.SIRI_PRODUCTION_TIMETABLE_REQUEST_BROADCASTER
+}
+
+func (handler *SIRIProductionTimetableRequestHandler) Respond(connector core.Connector, rw http.ResponseWriter, message *audit.BigQueryMessage) {
+	logger.Log.Debugf("Production Timetable %s\n", handler.xmlRequest.MessageIdentifier())
+
+	t := clock.DefaultClock().Now()
+
+	response := connector.(core.ProductionTimetableBroadcaster).RequestLine(handler.xmlRequest, message)
+	xmlResponse, err := response.BuildXML()
+	if err != nil {
+		siriError("InternalServiceError", fmt.Sprintf("Internal Error: %v", err), string(handler.referential.Slug()), rw)
+		return
+	}
+
+	// Wrap soap and send response
+	soapEnvelope := siri.NewSOAPEnvelopeBuffer()
+	soapEnvelope.WriteXML(xmlResponse)
+
+	n, err := soapEnvelope.WriteTo(rw)
+	if err != nil {
+		siriError("InternalServiceError", fmt.Sprintf("Internal Error: %v", err), string(handler.referential.Slug()), rw)
+		return
+	}
+
+	message.Type = "ProductionTimetableRequest"
+	message.RequestRawMessage = handler.xmlRequest.RawXML()
+	message.ResponseRawMessage = xmlResponse
+	message.ResponseSize = n
+	message.ProcessingTime = clock.DefaultClock().Since(t).Seconds()
+	audit.CurrentBigQuery(string(handler.referential.Slug())).WriteEvent(message)
+}
@@ -6,12 +6,16 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"bitbucket.org/enroute-mobi/ara/core"
 	"bitbucket.org/enroute-mobi/ara/logger"
 	"bitbucket.org/enroute-mobi/ara/model"
 )
 
+const DEFAULT_NEAR_RADIUS = 500.0 // meters
+
 type StopAreaController struct {
 	referential *core.Referential
 }
@@ -41,15 +45,52 @@ func (controller *StopAreaController) Index(response http.ResponseWriter, filter
 
 	logger.Log.Debugf("StopAreas Index")
 
+	var sas []model.StopArea
 	stime := controller.referential.Clock().Now()
-	sas := tx.Model().StopAreas().FindAll()
-	logger.Log.Debugf("StopAreaController FindAll time : %v", controller.referential.Clock().Since(stime))
+	if near := filters.Get("near"); near != "" {
+		latitude, longitude, radius, err := parseNearFilter(near, filters.Get("radius"))
+		if err != nil {
+			http.Error(response, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		sas = tx.Model().StopAreas().FindNear(latitude, longitude, radius)
+		logger.Log.Debugf("StopAreaController FindNear time : %v", controller.referential.Clock().Since(stime))
+	} else {
+		sas = tx.Model().StopAreas().FindAll()
+		logger.Log.Debugf("StopAreaController FindAll time : %v", controller.referential.Clock().Since(stime))
+	}
 	stime = controller.referential.Clock().Now()
 	jsonBytes, _ := json.Marshal(sas)
 	logger.Log.Debugf("StopAreaController Json Marshal time : %v ", controller.referential.Clock().Since(stime))
 	response.Write(jsonBytes)
 }
 
+// Parses the near filter "latitude,longitude" and the optional radius in meters
+func parseNearFilter(near, radiusFilter string) (latitude, longitude, radius float64, err error) {
+	coordinates := strings.Split(near, ",")
+	if len(coordinates) != 2 {
+		err = fmt.Errorf("near filter should be latitude,longitude: %v", near)
+		return
+	}
+	if latitude, err = strconv.ParseFloat(strings.TrimSpace(coordinates[0]), 64); err != nil || latitude < -90 || latitude > 90 {
+		err = fmt.Errorf("invalid latitude in near filter: %v", coordinates[0])
+		return
+	}
+	if longitude, err = strconv.ParseFloat(strings.TrimSpace(coordinates[1]), 64); err != nil || longitude < -180 || longitude > 180 {
+		err = fmt.Errorf("invalid longitude in near filter: %v", coordinates[1])
+		return
+	}
+
+	radius = DEFAULT_NEAR_RADIUS
+	if radiusFilter != "" {
+		if radius, err = strconv.ParseFloat(radiusFilter, 64); err != nil || radius <= 0 {
+			err = fmt.Errorf("invalid radius: %v", radiusFilter)
+			return
+		}
+	}
+	return
+}
+
 func (controller *StopAreaController) Show(response http.ResponseWriter, identifier string) {
 	tx := controller.referential.NewTransaction()
 	defer tx.Close()
@@ -171,3 +171,26 @@ func Test_StopAreaController_FindStopArea(t *testing.T) {
 		t.Error("Can't find StopArea by Id")
 	}
 }
+
+func Test_StopAreaController_parseNearFilter(t *testing.T) {
+	latitude, longitude, radius, err := parseNearFilter("48.85,2.35", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latitude != 48.85 || longitude != 2.35 || radius != DEFAULT_NEAR_RADIUS {
+		t.Errorf("Wrong near filter:\n got: %v,%v,%v\n want: 48.85,2.35,%v", latitude, longitude, radius, DEFAULT_NEAR_RADIUS)
+	}
+
+	if _, _, radius, _ = parseNearFilter("48.85,2.35", "1000"); radius != 1000 {
+		t.Errorf("Wrong radius:\n got: %v\n want: 1000", radius)
+	}
+
+	for _, near := range []string{"48.85", "a,2.35", "91,2.35", "48.85,181"} {
+		if _, _, _, err := parseNearFilter(near, ""); err == nil {
+			t.Errorf("parseNearFilter should return an error with %v", near)
+		}
+	}
+	if _, _, _, err := parseNearFilter("48.85,2.35", "-1"); err == nil {
+		t.Errorf("parseNearFilter should return an error with a negative radius")
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+// Reviews the StopArea matches which can't be applied automatically:
+//
+//	GET /ref/stop_area_matches                pending matches with their candidates
+//	GET /ref/stop_area_matches/:stop_area_id
+//	PUT /ref/stop_area_matches/:stop_area_id  applies the match with {"CandidateId":"..."}
+//	DELETE /ref/stop_area_matches/:stop_area_id rejects the match
+type StopAreaMatchController struct {
+	referential *core.Referential
+}
+
+type stopAreaMatchAcceptance struct {
+	CandidateId model.StopAreaId
+}
+
+func NewStopAreaMatchController(referential *core.Referential) ControllerInterface {
+	return &StopAreaMatchController{
+		referential: referential,
+	}
+}
+
+func (controller *StopAreaMatchController) serve(response http.ResponseWriter, request *http.Request, requestData *RequestData) {
+	if requestData.Action != "" || (requestData.Id == "" && requestData.Method != "GET") {
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	switch requestData.Method {
+	case "GET":
+		if requestData.Id == "" {
+			controller.index(response)
+			return
+		}
+		controller.show(response, model.StopAreaId(requestData.Id))
+	case "PUT":
+		body := getRequestBody(response, request)
+		if body == nil {
+			return
+		}
+		controller.accept(response, model.StopAreaId(requestData.Id), body)
+	case "DELETE":
+		controller.reject(response, model.StopAreaId(requestData.Id))
+	default:
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+func (controller *StopAreaMatchController) index(response http.ResponseWriter) {
+	logger.Log.Debugf("StopAreaMatches Index")
+
+	jsonBytes, _ := json.Marshal(controller.referential.StopAreaMatcher().Pending())
+	response.Write(jsonBytes)
+}
+
+func (controller *StopAreaMatchController) show(response http.ResponseWriter, id model.StopAreaId) {
+	match, ok := controller.referential.StopAreaMatcher().Find(id)
+	if !ok {
+		http.Error(response, fmt.Sprintf("StopAreaMatch not found: %s", id), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Get StopAreaMatch %s", id)
+
+	jsonBytes, _ := json.Marshal(match)
+	response.Write(jsonBytes)
+}
+
+func (controller *StopAreaMatchController) accept(response http.ResponseWriter, id model.StopAreaId, body []byte) {
+	matcher := controller.referential.StopAreaMatcher()
+	if _, ok := matcher.Find(id); !ok {
+		http.Error(response, fmt.Sprintf("StopAreaMatch not found: %s", id), http.StatusNotFound)
+		return
+	}
+
+	acceptance := &stopAreaMatchAcceptance{}
+	if err := json.Unmarshal(body, acceptance); err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: can't parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	logger.Log.Debugf("Accept StopAreaMatch %s with %s", id, acceptance.CandidateId)
+
+	if err := matcher.Accept(id, acceptance.CandidateId); err != nil {
+		http.Error(response, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+}
+
+func (controller *StopAreaMatchController) reject(response http.ResponseWriter, id model.StopAreaId) {
+	if !controller.referential.StopAreaMatcher().Reject(id) {
+		http.Error(response, fmt.Sprintf("StopAreaMatch not found: %s", id), http.StatusNotFound)
+		return
+	}
+	logger.Log.Debugf("Reject StopAreaMatch %s", id)
+}
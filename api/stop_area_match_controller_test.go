@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func Test_StopAreaMatchController(t *testing.T) {
+	server, referential := createReferential()
+	referential.Settings[core.REFERENTIAL_SETTING_STOP_AREA_MATCHING] = "true"
+
+	existing := referential.Model().StopAreas().New()
+	existing.SetObjectID(model.NewObjectID("internal", "1"))
+	existing.Name = "Gare de Lyon"
+	referential.Model().StopAreas().Save(&existing)
+
+	discovered := referential.Model().StopAreas().New()
+	discovered.SetObjectID(model.NewObjectID("partner", "A"))
+	discovered.Name = "Gare de Lyon"
+	referential.Model().StopAreas().Save(&discovered)
+
+	referential.StopAreaMatcher().Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	request, _ := http.NewRequest("GET", "/default/stop_area_matches", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	var matches []core.StopAreaMatch
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].StopAreaId != discovered.Id() {
+		t.Fatalf("Wrong pending matches: %v", responseRecorder.Body.String())
+	}
+
+	body := fmt.Sprintf(`{"CandidateId":"%v"}`, existing.Id())
+	request, _ = http.NewRequest("PUT", fmt.Sprintf("/default/stop_area_matches/%v", discovered.Id()), strings.NewReader(body))
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+	stopArea, _ := referential.Model().StopAreas().Find(discovered.Id())
+	if stopArea.ReferentId != existing.Id() {
+		t.Errorf("Wrong ReferentId:\n got: %v\n want: %v", stopArea.ReferentId, existing.Id())
+	}
+
+	request, _ = http.NewRequest("DELETE", fmt.Sprintf("/default/stop_area_matches/%v", discovered.Id()), nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder = httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusNotFound {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusNotFound)
+	}
+}
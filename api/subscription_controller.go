@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"bitbucket.org/enroute-mobi/ara/core"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+// Search of the subscriptions of all the partners
+type SubscriptionController struct {
+	referential *core.Referential
+}
+
+type PartnerSubscription struct {
+	Partner      core.PartnerSlug
+	Subscription *core.SubscriptionDiagnostic
+}
+
+func NewSubscriptionController(referential *core.Referential) ControllerInterface {
+	return &SubscriptionController{
+		referential: referential,
+	}
+}
+
+func (controller *SubscriptionController) serve(response http.ResponseWriter, request *http.Request, requestData *RequestData) {
+	if requestData.Method != "GET" || requestData.Id != "" {
+		http.Error(response, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	stopArea := requestData.Filters.Get("stop_area")
+	line := requestData.Filters.Get("line")
+	if stopArea == "" && line == "" {
+		http.Error(response, "Invalid request: stop_area or line parameter is required", http.StatusBadRequest)
+		return
+	}
+	logger.Log.Debugf("Search subscriptions with stop_area '%s' and line '%s'", stopArea, line)
+
+	objectids := []model.ObjectID{}
+	if stopArea != "" {
+		objectid, ok := controller.parseObjectId(stopArea)
+		if !ok {
+			http.Error(response, "Invalid request: stop_area must be formatted as kind:value", http.StatusBadRequest)
+			return
+		}
+		objectids = append(objectids, controller.stopAreaObjectIds(objectid)...)
+	}
+	if line != "" {
+		objectid, ok := controller.parseObjectId(line)
+		if !ok {
+			http.Error(response, "Invalid request: line must be formatted as kind:value", http.StatusBadRequest)
+			return
+		}
+		objectids = append(objectids, controller.lineObjectIds(objectid)...)
+	}
+
+	subscriptions := []*PartnerSubscription{}
+	for _, partner := range controller.referential.Partners().FindAll() {
+		if partner.Subscriptions() == nil {
+			continue
+		}
+		for _, subscription := range partner.Subscriptions().FindAll() {
+			if !subscription.Concerns(objectids) {
+				continue
+			}
+			subscriptions = append(subscriptions, &PartnerSubscription{
+				Partner:      partner.Slug(),
+				Subscription: subscription.Diagnostic(),
+			})
+		}
+	}
+
+	jsonBytes, _ := json.Marshal(subscriptions)
+	response.Write(jsonBytes)
+}
+
+func (controller *SubscriptionController) parseObjectId(value string) (model.ObjectID, bool) {
+	kindValue := strings.SplitN(value, ":", 2)
+	if len(kindValue) != 2 || kindValue[0] == "" || kindValue[1] == "" {
+		return model.ObjectID{}, false
+	}
+	return model.NewObjectID(kindValue[0], kindValue[1]), true
+}
+
+// Returns all the ObjectIDs of the StopArea, to find the subscriptions of each partner
+func (controller *SubscriptionController) stopAreaObjectIds(objectid model.ObjectID) []model.ObjectID {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	stopArea, ok := tx.Model().StopAreas().FindByObjectId(objectid)
+	if !ok {
+		return []model.ObjectID{objectid}
+	}
+	return objectIdsOf(stopArea.ObjectIDs())
+}
+
+// Returns all the ObjectIDs of the Line, to find the subscriptions of each partner
+func (controller *SubscriptionController) lineObjectIds(objectid model.ObjectID) []model.ObjectID {
+	tx := controller.referential.NewTransaction()
+	defer tx.Close()
+
+	line, ok := tx.Model().Lines().FindByObjectId(objectid)
+	if !ok {
+		return []model.ObjectID{objectid}
+	}
+	return objectIdsOf(line.ObjectIDs())
+}
+
+func objectIdsOf(objectids model.ObjectIDs) []model.ObjectID {
+	result := make([]model.ObjectID, 0, len(objectids))
+	for _, objectid := range objectids {
+		result = append(result, objectid)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func Test_SubscriptionController_Search(t *testing.T) {
+	server, referential := createReferential()
+	partner := createPartner(referential)
+
+	tx := referential.NewTransaction()
+	stopArea := tx.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("internal", "stop"))
+	stopArea.SetObjectID(model.NewObjectID("external", "stop"))
+	tx.Model().StopAreas().Save(&stopArea)
+	tx.Commit()
+	tx.Close()
+
+	subscription := partner.Subscriptions().New("StopMonitoringBroadcast")
+	subscription.SetExternalId("externalId")
+	subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("external", "stop")))
+	partner.Subscriptions().New("StopMonitoringBroadcast").CreateAddNewResource(*model.NewReference(model.NewObjectID("external", "other")))
+
+	request, _ := http.NewRequest("GET", "/default/subscriptions?stop_area=internal:stop", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusOK)
+	}
+
+	subscriptions := []*PartnerSubscription{}
+	if err := json.Unmarshal(responseRecorder.Body.Bytes(), &subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	if len(subscriptions) != 1 {
+		t.Fatalf("Search should return one subscription, got: %v", len(subscriptions))
+	}
+	if subscriptions[0].Partner != partner.Slug() || subscriptions[0].Subscription.ExternalId != "externalId" {
+		t.Errorf("Wrong subscription returned: %v %v", subscriptions[0].Partner, subscriptions[0].Subscription.ExternalId)
+	}
+}
+
+func Test_SubscriptionController_Search_WithoutFilter(t *testing.T) {
+	server, _ := createReferential()
+
+	request, _ := http.NewRequest("GET", "/default/subscriptions", nil)
+	request.Header.Set("Authorization", "Token token=testToken")
+	responseRecorder := httptest.NewRecorder()
+	server.HandleFlow(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusBadRequest {
+		t.Errorf("Handler returned wrong status code:\n got %v\n want %v", responseRecorder.Code, http.StatusBadRequest)
+	}
+}
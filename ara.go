@@ -46,6 +46,7 @@ func main() {
 		fmt.Println("\tapi [-listen=<url>]")
 		fmt.Println("\tmigrate [-path=<path>] <up|down>")
 		fmt.Println("\tload <file path> <referential_slug>")
+		fmt.Println("\texport [-model-name=<date>] <referential_slug> <file path>")
 		os.Exit(1)
 	}
 
@@ -188,6 +189,24 @@ func main() {
 		defer model.CloseDB(model.Database)
 
 		err = model.LoadFromCSVFile(loadFlags.Arg(0), loadFlags.Arg(1), *forcePtr)
+	case "export":
+		today := model.NewDate(clock.DefaultClock().Now())
+
+		exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+		modelNamePtr := exportFlags.String("model-name", today.String(), "Specify the model name (date) to export")
+		exportFlags.Parse(flag.Args()[1:])
+
+		if exportFlags.NArg() < 2 {
+			logger.Log.Printf("Incorrect use of command export: not enough aguments")
+			logger.Log.Printf("usage: ara export [-model-name=<date>] <referential slug> <path>")
+			os.Exit(2)
+		}
+
+		// Init Database
+		model.Database = model.InitDB(config.Config.DB)
+		defer model.CloseDB(model.Database)
+
+		err = model.ExportDatabaseToCSVFile(exportFlags.Arg(1), exportFlags.Arg(0), *modelNamePtr)
 	}
 
 	if err != nil {
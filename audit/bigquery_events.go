@@ -72,6 +72,9 @@ type BigQueryPartnerEvent struct {
 	PreviousServiceStartedAt civil.DateTime `bigquery:"previous_service_started_at"`
 	NewStatus                string         `bigquery:"new_status"`
 	NewServiceStartedAt      civil.DateTime `bigquery:"new_service_started_at"`
+
+	PreviousCircuitBreakerState string `bigquery:"previous_circuit_breaker_state"`
+	NewCircuitBreakerState      string `bigquery:"new_circuit_breaker_state"`
 }
 
 func (bq *BigQueryPartnerEvent) EventType() string        { return BQ_PARTNER_EVENT }
@@ -84,6 +87,8 @@ var bqPartnerSchema = bigquery.Schema{
 	{Name: "previous_service_started_at", Required: false, Type: bigquery.DateTimeFieldType},
 	{Name: "new_status", Required: false, Type: bigquery.StringFieldType},
 	{Name: "new_service_started_at", Required: false, Type: bigquery.DateTimeFieldType},
+	{Name: "previous_circuit_breaker_state", Required: false, Type: bigquery.StringFieldType},
+	{Name: "new_circuit_breaker_state", Required: false, Type: bigquery.StringFieldType},
 }
 
 type BigQueryVehicleEvent struct {
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"sort"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+/*
+Builds the FacilityConditions of a FacilityMonitoring delivery.
+
+The Facilities can be filtered by StopPointRefs and FacilityRefs. Facilities
+without ObjectID for the partner or whose validity period is ended are ignored.
+The end of an expired Facility condition is built separately, see
+BuildExpiredFacilityCondition.
+*/
+type BroadcastFacilityMonitoringBuilder struct {
+	clock.ClockConsumer
+
+	partner       *Partner
+	connectorType string
+
+	StopPointRefs []string
+	FacilityRefs  []string
+}
+
+func NewBroadcastFacilityMonitoringBuilder(partner *Partner, connectorType string) *BroadcastFacilityMonitoringBuilder {
+	return &BroadcastFacilityMonitoringBuilder{
+		partner:       partner,
+		connectorType: connectorType,
+	}
+}
+
+func (builder *BroadcastFacilityMonitoringBuilder) remoteObjectIDKind() string {
+	return builder.partner.RemoteObjectIDKind(builder.connectorType)
+}
+
+// Returns the Facilities matching the StopPointRefs and FacilityRefs and the
+// StopPointRefs which can't be found
+func (builder *BroadcastFacilityMonitoringBuilder) Facilities(tx *model.Transaction) (facilities []model.Facility, unknownStopPointRefs []string) {
+	if len(builder.StopPointRefs) == 0 {
+		facilities = tx.Model().Facilities().FindAll()
+	}
+	for _, stopPointRef := range builder.StopPointRefs {
+		stopArea, ok := tx.Model().StopAreas().FindByObjectId(model.NewObjectID(builder.remoteObjectIDKind(), stopPointRef))
+		if !ok {
+			unknownStopPointRefs = append(unknownStopPointRefs, stopPointRef)
+			continue
+		}
+		facilities = append(facilities, tx.Model().Facilities().FindByStopAreaId(stopArea.Id())...)
+	}
+
+	if len(builder.FacilityRefs) == 0 {
+		return
+	}
+
+	filtered := []model.Facility{}
+	for i := range facilities {
+		objectid, ok := facilities[i].ObjectID(builder.remoteObjectIDKind())
+		if ok && builder.requestedFacility(objectid.Value()) {
+			filtered = append(filtered, facilities[i])
+		}
+	}
+	return filtered, unknownStopPointRefs
+}
+
+// Returns true if the Facility matches the StopPointRefs and FacilityRefs
+func (builder *BroadcastFacilityMonitoringBuilder) Match(tx *model.Transaction, facility *model.Facility) bool {
+	if len(builder.StopPointRefs) != 0 {
+		stopArea, ok := tx.Model().StopAreas().Find(facility.StopAreaId)
+		if !ok {
+			return false
+		}
+		objectid, ok := stopArea.ObjectID(builder.remoteObjectIDKind())
+		if !ok || !builder.requestedStopPoint(objectid.Value()) {
+			return false
+		}
+	}
+	if len(builder.FacilityRefs) != 0 {
+		objectid, ok := facility.ObjectID(builder.remoteObjectIDKind())
+		if !ok || !builder.requestedFacility(objectid.Value()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (builder *BroadcastFacilityMonitoringBuilder) requestedStopPoint(stopPointRef string) bool {
+	for _, ref := range builder.StopPointRefs {
+		if ref == stopPointRef {
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *BroadcastFacilityMonitoringBuilder) requestedFacility(facilityRef string) bool {
+	for _, ref := range builder.FacilityRefs {
+		if ref == facilityRef {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the FacilityConditions of the given Facilities, sorted by FacilityRef
+func (builder *BroadcastFacilityMonitoringBuilder) BuildFacilityConditions(tx *model.Transaction, facilities []model.Facility) (conditions []*siri.SIRIFacilityCondition) {
+	for i := range facilities {
+		condition, ok := builder.BuildFacilityCondition(tx, &facilities[i])
+		if !ok {
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+
+	sortFacilityConditions(conditions)
+	return
+}
+
+func sortFacilityConditions(conditions []*siri.SIRIFacilityCondition) {
+	sort.Slice(conditions, func(i, j int) bool {
+		return conditions[i].FacilityRef < conditions[j].FacilityRef
+	})
+}
+
+func (builder *BroadcastFacilityMonitoringBuilder) BuildFacilityCondition(tx *model.Transaction, facility *model.Facility) (*siri.SIRIFacilityCondition, bool) {
+	objectid, ok := facility.ObjectID(builder.remoteObjectIDKind())
+	if !ok || facility.IsExpired(builder.Clock().Now()) {
+		return nil, false
+	}
+
+	condition := &siri.SIRIFacilityCondition{
+		FacilityRef:       objectid.Value(),
+		FacilityClass:     facility.Attributes["FacilityClass"],
+		Name:              facility.Name,
+		Status:            string(facility.Status),
+		Description:       facility.Description,
+		ValidityStartTime: facility.ValidityStartTime,
+		ValidityEndTime:   facility.ValidityEndTime,
+		StopPointRef:      builder.stopPointRef(tx, facility),
+	}
+
+	return condition, true
+}
+
+// Returns the FacilityCondition which ends the condition of an expired
+// Facility: once its validity period is ended, the Facility is available
+func (builder *BroadcastFacilityMonitoringBuilder) BuildExpiredFacilityCondition(tx *model.Transaction, facility *model.Facility) (*siri.SIRIFacilityCondition, bool) {
+	objectid, ok := facility.ObjectID(builder.remoteObjectIDKind())
+	if !ok {
+		return nil, false
+	}
+
+	condition := &siri.SIRIFacilityCondition{
+		FacilityRef:   objectid.Value(),
+		FacilityClass: facility.Attributes["FacilityClass"],
+		Name:          facility.Name,
+		Status:        string(model.FACILITY_STATUS_AVAILABLE),
+		StopPointRef:  builder.stopPointRef(tx, facility),
+	}
+
+	return condition, true
+}
+
+func (builder *BroadcastFacilityMonitoringBuilder) stopPointRef(tx *model.Transaction, facility *model.Facility) string {
+	stopArea, ok := tx.Model().StopAreas().Find(facility.StopAreaId)
+	if !ok {
+		return ""
+	}
+	stopAreaObjectId, ok := stopArea.ReferentOrSelfObjectId(builder.remoteObjectIDKind())
+	if !ok {
+		return ""
+	}
+	return stopAreaObjectId.Value()
+}
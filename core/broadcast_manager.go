@@ -12,6 +12,7 @@ type BroadcastManagerInterface interface {
 
 	GetStopMonitoringBroadcastEventChan() chan model.StopMonitoringBroadcastEvent
 	GetGeneralMessageBroadcastEventChan() chan model.GeneralMessageBroadcastEvent
+	GetFacilityBroadcastEventChan() chan model.FacilityBroadcastEvent
 }
 
 type BroadcastManager struct {
@@ -19,6 +20,7 @@ type BroadcastManager struct {
 
 	smbEventChan chan model.StopMonitoringBroadcastEvent
 	gmbEventChan chan model.GeneralMessageBroadcastEvent
+	fmbEventChan chan model.FacilityBroadcastEvent
 	stop         chan struct{}
 }
 
@@ -27,6 +29,7 @@ func NewBroadcastManager(referential *Referential) *BroadcastManager {
 		Referential:  referential,
 		smbEventChan: make(chan model.StopMonitoringBroadcastEvent, 2000),
 		gmbEventChan: make(chan model.GeneralMessageBroadcastEvent, 2000),
+		fmbEventChan: make(chan model.FacilityBroadcastEvent, 2000),
 	}
 }
 
@@ -38,6 +41,10 @@ func (manager *BroadcastManager) GetGeneralMessageBroadcastEventChan() chan mode
 	return manager.gmbEventChan
 }
 
+func (manager *BroadcastManager) GetFacilityBroadcastEventChan() chan model.FacilityBroadcastEvent {
+	return manager.fmbEventChan
+}
+
 func (manager *BroadcastManager) GetPartnersWithConnector(connectorTypes []string) []*Partner {
 	partners := []*Partner{}
 
@@ -73,6 +80,8 @@ func (manager *BroadcastManager) run() {
 			manager.ettsbEvent_handler(event)
 		case event := <-manager.gmbEventChan:
 			manager.gmsbEvent_handler(event)
+		case event := <-manager.fmbEventChan:
+			manager.fmsbEvent_handler(event)
 		case <-manager.stop:
 			logger.Log.Debugf("BroadcastManager Stop")
 			return
@@ -133,6 +142,16 @@ func (manager *BroadcastManager) gmsbEvent_handler(event model.GeneralMessageBro
 	}
 }
 
+func (manager *BroadcastManager) fmsbEvent_handler(event model.FacilityBroadcastEvent) {
+	connectorTypes := []string{SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER}
+	for _, partner := range manager.GetPartnersWithConnector(connectorTypes) {
+		connector, ok := partner.Connector(SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER)
+		if ok {
+			connector.(*SIRIFacilityMonitoringSubscriptionBroadcaster).HandleFacilityBroadcastEvent(&event)
+		}
+	}
+}
+
 func (manager *BroadcastManager) Stop() {
 	if manager.stop != nil {
 		close(manager.stop)
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"sort"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+/*
+Builds the DatedTimetableVersionFrames of a ProductionTimetable delivery from
+the theoretical (aimed) schedules of the model.
+
+A frame is created for each Line and DirectionRef. A VehicleJourney is included
+when one of its aimed times is in the validity window. A zero StartTime or
+EndTime doesn't limit the window.
+*/
+type BroadcastProductionTimetableBuilder struct {
+	clock.ClockConsumer
+
+	partner       *Partner
+	connectorType string
+
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func NewBroadcastProductionTimetableBuilder(partner *Partner, connectorType string) *BroadcastProductionTimetableBuilder {
+	return &BroadcastProductionTimetableBuilder{
+		partner:       partner,
+		connectorType: connectorType,
+	}
+}
+
+func (builder *BroadcastProductionTimetableBuilder) remoteObjectIDKind() string {
+	return builder.partner.RemoteObjectIDKind(builder.connectorType)
+}
+
+// Returns the frames of the given Line. Returns false when the Line can't be found
+func (builder *BroadcastProductionTimetableBuilder) BuildLine(tx *model.Transaction, lineObjectId model.ObjectID) ([]*siri.SIRIDatedTimetableVersionFrame, bool) {
+	line, ok := tx.Model().Lines().FindByObjectId(lineObjectId)
+	if !ok {
+		return nil, false
+	}
+
+	currentTime := builder.Clock().Now()
+	frames := []*siri.SIRIDatedTimetableVersionFrame{}
+	framesByDirection := make(map[string]*siri.SIRIDatedTimetableVersionFrame)
+
+	for _, vehicleJourney := range tx.Model().VehicleJourneys().FindByLineId(line.Id()) {
+		datedVehicleJourney, ok := builder.buildDatedVehicleJourney(tx, &vehicleJourney, line.Name)
+		if !ok {
+			continue
+		}
+
+		directionRef := vehicleJourney.Attributes["DirectionRef"]
+		frame, ok := framesByDirection[directionRef]
+		if !ok {
+			frame = &siri.SIRIDatedTimetableVersionFrame{
+				RecordedAtTime: currentTime,
+				LineRef:        lineObjectId.Value(),
+				DirectionRef:   directionRef,
+			}
+			framesByDirection[directionRef] = frame
+			frames = append(frames, frame)
+		}
+		frame.DatedVehicleJourneys = append(frame.DatedVehicleJourneys, datedVehicleJourney)
+	}
+
+	return frames, true
+}
+
+func (builder *BroadcastProductionTimetableBuilder) buildDatedVehicleJourney(tx *model.Transaction, vehicleJourney *model.VehicleJourney, lineName string) (*siri.SIRIDatedVehicleJourney, bool) {
+	stopVisits := tx.Model().StopVisits().FindByVehicleJourneyId(vehicleJourney.Id())
+	if !builder.inValidityPeriod(stopVisits) {
+		return nil, false
+	}
+
+	datedVehicleJourneyCode, ok := builder.vehicleJourneyCode(vehicleJourney)
+	if !ok {
+		logger.Log.Debugf("Vehicle journey with id %v does not have a proper objectid", vehicleJourney.Id())
+		return nil, false
+	}
+
+	datedVehicleJourney := &siri.SIRIDatedVehicleJourney{
+		DatedVehicleJourneyCode: datedVehicleJourneyCode,
+		PublishedLineName:       lineName,
+		Attributes:              vehicleJourney.Attributes,
+		References:              builder.vehicleJourneyReferences(tx, vehicleJourney),
+	}
+
+	sort.Slice(stopVisits, func(i, j int) bool {
+		return stopVisits[i].PassageOrder < stopVisits[j].PassageOrder
+	})
+
+	for i := range stopVisits {
+		stopPointName, stopPointRef, ok := builder.stopPointRef(tx, stopVisits[i].StopAreaId)
+		if !ok {
+			logger.Log.Printf("Ignore StopVisit %v without StopArea or with StopArea without correct ObjectID", stopVisits[i].Id())
+			continue
+		}
+
+		aimed := stopVisits[i].Schedules.Schedule("aimed")
+		datedVehicleJourney.DatedCalls = append(datedVehicleJourney.DatedCalls, &siri.SIRIDatedCall{
+			StopPointRef:       stopPointRef,
+			StopPointName:      stopPointName,
+			DestinationDisplay: stopVisits[i].Attributes["DestinationDisplay"],
+			Order:              stopVisits[i].PassageOrder,
+			AimedArrivalTime:   aimed.ArrivalTime(),
+			AimedDepartureTime: aimed.DepartureTime(),
+		})
+	}
+
+	if len(datedVehicleJourney.DatedCalls) == 0 {
+		return nil, false
+	}
+	return datedVehicleJourney, true
+}
+
+// Returns true if one of the aimed times of the StopVisits is in the validity period
+func (builder *BroadcastProductionTimetableBuilder) inValidityPeriod(stopVisits []model.StopVisit) bool {
+	for i := range stopVisits {
+		aimed := stopVisits[i].Schedules.Schedule("aimed")
+		for _, t := range []time.Time{aimed.ArrivalTime(), aimed.DepartureTime()} {
+			if t.IsZero() {
+				continue
+			}
+			if !builder.StartTime.IsZero() && t.Before(builder.StartTime) {
+				continue
+			}
+			if !builder.EndTime.IsZero() && t.After(builder.EndTime) {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *BroadcastProductionTimetableBuilder) vehicleJourneyCode(vehicleJourney *model.VehicleJourney) (string, bool) {
+	vehicleJourneyId, ok := vehicleJourney.ObjectID(builder.remoteObjectIDKind())
+	if ok {
+		return vehicleJourneyId.Value(), true
+	}
+	defaultObjectID, ok := vehicleJourney.ObjectID("_default")
+	if !ok {
+		return "", false
+	}
+	referenceGenerator := builder.partner.IdentifierGenerator(REFERENCE_IDENTIFIER)
+	return referenceGenerator.NewIdentifier(IdentifierAttributes{Type: "VehicleJourney", Default: defaultObjectID.Value()}), true
+}
+
+// Returns the name and the StopPointRef of the StopArea or of its referent
+func (builder *BroadcastProductionTimetableBuilder) stopPointRef(tx *model.Transaction, stopAreaId model.StopAreaId) (string, string, bool) {
+	stopArea, ok := tx.Model().StopAreas().Find(stopAreaId)
+	if !ok {
+		return "", "", false
+	}
+	objectid, ok := stopArea.ObjectID(builder.remoteObjectIDKind())
+	if ok {
+		return stopArea.Name, objectid.Value(), true
+	}
+	referent, ok := stopArea.Referent()
+	if ok {
+		referentObjectId, ok := referent.ObjectID(builder.remoteObjectIDKind())
+		if ok {
+			return referent.Name, referentObjectId.Value(), true
+		}
+	}
+	return "", "", false
+}
+
+func (builder *BroadcastProductionTimetableBuilder) vehicleJourneyReferences(tx *model.Transaction, vehicleJourney *model.VehicleJourney) map[string]string {
+	references := make(map[string]string)
+
+	for _, refType := range []string{"OriginRef", "DestinationRef"} {
+		ref, ok := vehicleJourney.Reference(refType)
+		if !ok || ref == (model.Reference{}) || ref.ObjectId == nil {
+			continue
+		}
+		if foundStopArea, ok := tx.Model().StopAreas().FindByObjectId(*ref.ObjectId); ok {
+			obj, ok := foundStopArea.ReferentOrSelfObjectId(builder.remoteObjectIDKind())
+			if ok {
+				references[refType] = obj.Value()
+				continue
+			}
+		}
+		generator := builder.partner.IdentifierGenerator(REFERENCE_STOP_AREA_IDENTIFIER)
+		references[refType] = generator.NewIdentifier(IdentifierAttributes{Default: ref.GetSha1()})
+	}
+
+	for _, refType := range []string{"RouteRef", "JourneyPatternRef"} {
+		ref, ok := vehicleJourney.Reference(refType)
+		if !ok || ref == (model.Reference{}) || ref.ObjectId == nil {
+			continue
+		}
+		references[refType] = ref.ObjectId.Value()
+	}
+
+	if operatorRef, ok := broadcastedOperatorRef(tx, nil, vehicleJourney, builder.remoteObjectIDKind()); ok {
+		references["OperatorRef"] = operatorRef
+	}
+
+	return references
+}
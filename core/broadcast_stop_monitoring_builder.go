@@ -1,6 +1,7 @@
 package core
 
 import (
+	"sort"
 	"strings"
 
 	"bitbucket.org/enroute-mobi/ara/clock"
@@ -16,6 +17,11 @@ type BroadcastStopMonitoringBuilder struct {
 
 	StopVisitTypes string
 	MonitoringRef  string
+	DetailLevel    string
+
+	// MaximumNumberOfCalls Previous and Onward values, negative when undefined
+	MaximumPreviousCalls int
+	MaximumOnwardCalls   int
 
 	tx                            *model.Transaction
 	referenceGenerator            *IdentifierGenerator
@@ -25,6 +31,8 @@ type BroadcastStopMonitoringBuilder struct {
 	noDestinationRefRewritingFrom []string
 	noDataFrameRefRewritingFrom   []string
 	rewriteJourneyPatternRef      bool
+	fields                        []string
+	elementFilter                 *smElementFilter
 }
 
 func NewBroadcastStopMonitoringBuilder(tx *model.Transaction, partner *Partner, connector string) *BroadcastStopMonitoringBuilder {
@@ -37,6 +45,10 @@ func NewBroadcastStopMonitoringBuilder(tx *model.Transaction, partner *Partner,
 		noDestinationRefRewritingFrom: partner.NoDestinationRefRewritingFrom(),
 		noDataFrameRefRewritingFrom:   partner.NoDataFrameRefRewritingFrom(),
 		rewriteJourneyPatternRef:      partner.RewriteJourneyPatternRef(),
+		DetailLevel:                   partner.StopMonitoringDetailLevel(),
+		MaximumPreviousCalls:          -1,
+		MaximumOnwardCalls:            -1,
+		fields:                        partner.StopMonitoringFields(),
 	}
 }
 
@@ -159,7 +171,9 @@ func (builder *BroadcastStopMonitoringBuilder) BuildMonitoredStopVisit(stopVisit
 
 	builder.resolveVJReferences(vehicleJourneyRefCopy, vehicleJourney.Origin)
 
-	builder.resolveOperator(stopVisitRefCopy)
+	if operatorRef, ok := broadcastedOperatorRef(builder.tx, &stopVisit, &vehicleJourney, builder.remoteObjectidKind); ok {
+		stopVisitRefCopy.SetObjectId("OperatorRef", model.NewObjectID(builder.remoteObjectidKind, operatorRef))
+	}
 
 	monitoredStopVisit.Attributes["StopVisitAttributes"] = stopVisit.Attributes
 	monitoredStopVisit.References["StopVisitReferences"] = stopVisitRefCopy.GetSiriReferences()
@@ -167,9 +181,113 @@ func (builder *BroadcastStopMonitoringBuilder) BuildMonitoredStopVisit(stopVisit
 	monitoredStopVisit.Attributes["VehicleJourneyAttributes"] = vehicleJourney.Attributes
 	monitoredStopVisit.References["VehicleJourney"] = vehicleJourneyRefCopy.GetSiriReferences()
 
+	builder.buildCalls(monitoredStopVisit, &stopVisit)
+
+	builder.filter().apply(monitoredStopVisit)
+
 	return monitoredStopVisit
 }
 
+// Returns the number of calls to include, or a negative value when all calls
+// must be included
+func (builder *BroadcastStopMonitoringBuilder) callsLimit(maximum int) int {
+	if smDetailLevelWithCalls(builder.DetailLevel) {
+		return maximum
+	}
+	if maximum > 0 {
+		return maximum
+	}
+	return 0
+}
+
+// Adds the PreviousCalls and OnwardCalls of the VehicleJourney, ordered by
+// PassageOrder, when the DetailLevel or MaximumNumberOfCalls requires them
+func (builder *BroadcastStopMonitoringBuilder) buildCalls(monitoredStopVisit *siri.SIRIMonitoredStopVisit, stopVisit *model.StopVisit) {
+	previousLimit := builder.callsLimit(builder.MaximumPreviousCalls)
+	onwardLimit := builder.callsLimit(builder.MaximumOnwardCalls)
+	if previousLimit == 0 && onwardLimit == 0 {
+		return
+	}
+
+	stopVisits := builder.tx.Model().StopVisits().FindByVehicleJourneyId(stopVisit.VehicleJourneyId)
+	sort.Slice(stopVisits, func(i, j int) bool {
+		return stopVisits[i].PassageOrder < stopVisits[j].PassageOrder
+	})
+
+	var previous, onward []*model.StopVisit
+	for i := range stopVisits {
+		switch {
+		case stopVisits[i].PassageOrder < stopVisit.PassageOrder:
+			previous = append(previous, &stopVisits[i])
+		case stopVisits[i].PassageOrder > stopVisit.PassageOrder:
+			onward = append(onward, &stopVisits[i])
+		}
+	}
+
+	// The previous calls closest to the monitored call are kept
+	if previousLimit >= 0 && len(previous) > previousLimit {
+		previous = previous[len(previous)-previousLimit:]
+	}
+	if onwardLimit >= 0 && len(onward) > onwardLimit {
+		onward = onward[:onwardLimit]
+	}
+
+	for _, sv := range previous {
+		stopArea, stopPointRef, ok := builder.stopPointRef(sv.StopAreaId)
+		if !ok {
+			continue
+		}
+		call := &siri.SIRIPreviousCall{
+			StopPointRef:  stopPointRef,
+			StopPointName: stopArea.Name,
+			Order:         sv.PassageOrder,
+		}
+		if sv.ArrivalStatus != model.STOP_VISIT_ARRIVAL_CANCELLED {
+			call.AimedArrivalTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_AIMED).ArrivalTime()
+			if monitoredStopVisit.Monitored {
+				call.ActualArrivalTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_ACTUAL).ArrivalTime()
+			}
+		}
+		if sv.DepartureStatus != model.STOP_VISIT_DEPARTURE_CANCELLED {
+			call.AimedDepartureTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_AIMED).DepartureTime()
+			if monitoredStopVisit.Monitored {
+				call.ActualDepartureTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_ACTUAL).DepartureTime()
+			}
+		}
+		monitoredStopVisit.PreviousCalls = append(monitoredStopVisit.PreviousCalls, call)
+	}
+
+	for _, sv := range onward {
+		stopArea, stopPointRef, ok := builder.stopPointRef(sv.StopAreaId)
+		if !ok {
+			continue
+		}
+		call := &siri.SIRIOnwardCall{
+			StopPointRef:    stopPointRef,
+			StopPointName:   stopArea.Name,
+			Order:           sv.PassageOrder,
+			ArrivalStatus:   string(sv.ArrivalStatus),
+			DepartureStatus: string(sv.DepartureStatus),
+		}
+		if sv.ArrivalStatus != model.STOP_VISIT_ARRIVAL_CANCELLED {
+			call.AimedArrivalTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_AIMED).ArrivalTime()
+			call.ExpectedArrivalTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_EXPECTED).ArrivalTime()
+		}
+		if sv.DepartureStatus != model.STOP_VISIT_DEPARTURE_CANCELLED {
+			call.AimedDepartureTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_AIMED).DepartureTime()
+			call.ExpectedDepartureTime = sv.Schedules.Schedule(model.STOP_VISIT_SCHEDULE_EXPECTED).DepartureTime()
+		}
+		monitoredStopVisit.OnwardCalls = append(monitoredStopVisit.OnwardCalls, call)
+	}
+}
+
+func (builder *BroadcastStopMonitoringBuilder) filter() *smElementFilter {
+	if builder.elementFilter == nil {
+		builder.elementFilter = newSMElementFilter(builder.DetailLevel, builder.fields)
+	}
+	return builder.elementFilter
+}
+
 func (builder *BroadcastStopMonitoringBuilder) stopPointRef(stopAreaId model.StopAreaId) (model.StopArea, string, bool) {
 	stopPointRef, ok := builder.tx.Model().StopAreas().Find(stopAreaId)
 	if !ok {
@@ -222,23 +340,6 @@ func (builder *BroadcastStopMonitoringBuilder) dataVehicleJourneyRef(vehicleJour
 	return dataVehicleJourneyRef, true
 }
 
-func (builder *BroadcastStopMonitoringBuilder) resolveOperator(references model.References) {
-	operatorRef, ok := references.Get("OperatorRef")
-	if !ok {
-		return
-	}
-	operator, ok := builder.tx.Model().Operators().FindByObjectId(*operatorRef.ObjectId)
-	if !ok {
-		return
-	}
-	obj, ok := operator.ObjectID(builder.remoteObjectidKind)
-	if !ok {
-		return
-	}
-	ref, _ := references.Get("OperatorRef")
-	ref.ObjectId.SetValue(obj.Value())
-}
-
 func (builder *BroadcastStopMonitoringBuilder) resolveVJReferences(references model.References, origin string) {
 	for _, refType := range []string{"RouteRef", "JourneyPatternRef", "DatedVehicleJourneyRef"} {
 		if refType == "JourneyPatternRef" && !builder.rewriteJourneyPatternRef {
@@ -293,6 +394,14 @@ func (builder *BroadcastStopMonitoringBuilder) dataFrameRef(sv *model.StopVisit,
 	if sv.DataFrameRef != "" && builder.noDataFrameRefRewrite(origin) {
 		return sv.DataFrameRef
 	}
-	modelDate := builder.tx.Model().Date()
+	modelDate := serviceDate(builder.tx.Model(), sv.ServiceDate)
 	return builder.dataFrameGenerator.NewIdentifier(IdentifierAttributes{Id: modelDate.String()})
 }
+
+// Returns the service date of a loaded model, or the model Date
+func serviceDate(m model.Model, date model.Date) model.Date {
+	if date.IsZero() {
+		return m.Date()
+	}
+	return date
+}
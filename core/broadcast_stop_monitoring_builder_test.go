@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+func prepareStopMonitoringCalls(t *testing.T) (*Partner, *Referential, []*model.StopVisit) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "internal"
+
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("internal", "line"))
+	line.Save()
+
+	vehicleJourney := referential.Model().VehicleJourneys().New()
+	vehicleJourney.SetObjectID(model.NewObjectID("internal", "vj"))
+	vehicleJourney.LineId = line.Id()
+	vehicleJourney.Monitored = true
+	vehicleJourney.Save()
+
+	reference := time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC)
+	var stopVisits []*model.StopVisit
+	for order := 1; order <= 5; order++ {
+		stopArea := referential.Model().StopAreas().New()
+		stopArea.SetObjectID(model.NewObjectID("internal", fmt.Sprintf("stop-%d", order)))
+		stopArea.Name = fmt.Sprintf("Stop %d", order)
+		stopArea.Monitored = true
+		stopArea.Save()
+
+		stopVisit := referential.Model().StopVisits().New()
+		stopVisit.SetObjectID(model.NewObjectID("internal", fmt.Sprintf("sv-%d", order)))
+		stopVisit.StopAreaId = stopArea.Id()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.PassageOrder = order
+		stopVisit.Schedules.SetDepartureTime(model.STOP_VISIT_SCHEDULE_AIMED, reference.Add(time.Duration(order)*time.Minute))
+		stopVisit.Schedules.SetDepartureTime(model.STOP_VISIT_SCHEDULE_EXPECTED, reference.Add(time.Duration(order+1)*time.Minute))
+		stopVisit.Save()
+
+		stopVisits = append(stopVisits, &stopVisit)
+	}
+
+	return partner, referential, stopVisits
+}
+
+func Test_BroadcastStopMonitoringBuilder_NoCalls(t *testing.T) {
+	partner, referential, stopVisits := prepareStopMonitoringCalls(t)
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	builder := NewBroadcastStopMonitoringBuilder(tx, partner, SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
+	monitoredStopVisit := &siri.SIRIMonitoredStopVisit{Monitored: true}
+	builder.buildCalls(monitoredStopVisit, stopVisits[2])
+
+	if len(monitoredStopVisit.PreviousCalls) != 0 || len(monitoredStopVisit.OnwardCalls) != 0 {
+		t.Errorf("Calls shouldn't be defined without MaximumNumberOfCalls: %v %v", monitoredStopVisit.PreviousCalls, monitoredStopVisit.OnwardCalls)
+	}
+}
+
+func Test_BroadcastStopMonitoringBuilder_MaximumNumberOfCalls(t *testing.T) {
+	partner, referential, stopVisits := prepareStopMonitoringCalls(t)
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	builder := NewBroadcastStopMonitoringBuilder(tx, partner, SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
+	builder.MaximumPreviousCalls = 1
+	builder.MaximumOnwardCalls = 1
+	monitoredStopVisit := &siri.SIRIMonitoredStopVisit{Monitored: true}
+	builder.buildCalls(monitoredStopVisit, stopVisits[2])
+
+	if len(monitoredStopVisit.PreviousCalls) != 1 || monitoredStopVisit.PreviousCalls[0].StopPointRef != "stop-2" {
+		t.Fatalf("Wrong PreviousCalls: %v", monitoredStopVisit.PreviousCalls)
+	}
+	if len(monitoredStopVisit.OnwardCalls) != 1 {
+		t.Fatalf("Wrong OnwardCalls: %v", monitoredStopVisit.OnwardCalls)
+	}
+
+	onwardCall := monitoredStopVisit.OnwardCalls[0]
+	if onwardCall.StopPointRef != "stop-4" || onwardCall.StopPointName != "Stop 4" || onwardCall.Order != 4 {
+		t.Errorf("Wrong OnwardCall: %v", onwardCall)
+	}
+	if expected := time.Date(2017, time.January, 1, 12, 5, 0, 0, time.UTC); !onwardCall.ExpectedDepartureTime.Equal(expected) {
+		t.Errorf("Wrong OnwardCall ExpectedDepartureTime:\n got: %v\n want: %v", onwardCall.ExpectedDepartureTime, expected)
+	}
+}
+
+func Test_BroadcastStopMonitoringBuilder_CallsDetailLevel(t *testing.T) {
+	partner, referential, stopVisits := prepareStopMonitoringCalls(t)
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	builder := NewBroadcastStopMonitoringBuilder(tx, partner, SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
+	builder.DetailLevel = SM_DETAIL_LEVEL_CALLS
+	builder.MaximumPreviousCalls = 0
+	monitoredStopVisit := &siri.SIRIMonitoredStopVisit{Monitored: true}
+	builder.buildCalls(monitoredStopVisit, stopVisits[1])
+
+	if len(monitoredStopVisit.PreviousCalls) != 0 {
+		t.Errorf("PreviousCalls should be empty: %v", monitoredStopVisit.PreviousCalls)
+	}
+	if len(monitoredStopVisit.OnwardCalls) != 3 {
+		t.Fatalf("All OnwardCalls should be defined: %v", monitoredStopVisit.OnwardCalls)
+	}
+	for i, call := range monitoredStopVisit.OnwardCalls {
+		if call.Order != i+3 {
+			t.Errorf("OnwardCalls should be ordered by PassageOrder: %v", call)
+		}
+	}
+}
+
+func Test_BroadcastStopMonitoringBuilder_DetailLevelCalls(t *testing.T) {
+	partner, referential, stopVisits := prepareStopMonitoringCalls(t)
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	for _, tt := range []struct {
+		detailLevel string
+		calls       int
+	}{
+		{SM_DETAIL_LEVEL_NORMAL, 0},
+		{SM_DETAIL_LEVEL_CALLS, 4},
+		{SM_DETAIL_LEVEL_FULL, 4},
+	} {
+		builder := NewBroadcastStopMonitoringBuilder(tx, partner, SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
+		builder.DetailLevel = tt.detailLevel
+		monitoredStopVisit := &siri.SIRIMonitoredStopVisit{Monitored: true}
+		builder.buildCalls(monitoredStopVisit, stopVisits[1])
+
+		if calls := len(monitoredStopVisit.PreviousCalls) + len(monitoredStopVisit.OnwardCalls); calls != tt.calls {
+			t.Errorf("DetailLevel %v should return %v calls, got: %v", tt.detailLevel, tt.calls, calls)
+		}
+	}
+}
@@ -21,6 +21,8 @@ type CollectManagerInterface interface {
 	UpdateSituation(request *SituationUpdateRequest)
 	HandleSituationUpdateEvent(SituationUpdateSubscriber)
 	BroadcastSituationUpdateEvent(event []*model.SituationUpdateEvent)
+
+	UpdateFacilities()
 }
 
 type CollectManager struct {
@@ -67,6 +69,8 @@ func (manager *TestCollectManager) HandleSituationUpdateEvent(SituationUpdateSub
 func (manager *TestCollectManager) BroadcastSituationUpdateEvent(event []*model.SituationUpdateEvent) {
 }
 
+func (manager *TestCollectManager) UpdateFacilities() {}
+
 // TEST END
 
 func NewCollectManager(referential *Referential) CollectManagerInterface {
@@ -295,3 +299,20 @@ func (manager *CollectManager) requestStopAreaFilteredSituation(requestedId stri
 	}
 	// logger.Log.Debugf("Can't find a partner to request filtered Situations for StopArea %v", requestedId)
 }
+
+// Requests the status of all the Facilities to the partners with a FacilityMonitoring collector
+func (manager *CollectManager) UpdateFacilities() {
+	for _, partner := range manager.referential.Partners().FindAllByCollectPriority() {
+		if partner.PartnerStatus.OperationnalStatus != OPERATIONNAL_STATUS_UP {
+			continue
+		}
+
+		requestConnector := partner.FacilityMonitoringRequestCollector()
+		if requestConnector == nil {
+			continue
+		}
+
+		logger.Log.Debugf("RequestFacilities for Partner %v", partner.Slug())
+		requestConnector.RequestFacilities()
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+// Connection between a feeder and a distributor, used to monitor the
+// guaranteed transfers.
+//
+// The StopAreas and Lines are identified by their ObjectID values with the
+// given ObjectIDKind. Without FeederLine (or DistributorLine), all the Lines
+// of the StopArea are concerned.
+type ConnectionLink struct {
+	Id string
+
+	ObjectIDKind string
+
+	FeederStopArea      string
+	FeederLine          string `json:",omitempty"`
+	DistributorStopArea string
+	DistributorLine     string `json:",omitempty"`
+
+	// Minimum time in seconds to go from the feeder to the distributor
+	TransferTime int `json:",omitempty"`
+	// Maximum time in seconds the distributor departure can be after the
+	// transfer, DEFAULT_MAXIMUM_WAIT_TIME when undefined
+	MaximumWaitTime int `json:",omitempty"`
+}
+
+const DEFAULT_MAXIMUM_WAIT_TIME = 1 * time.Hour
+
+func (link *ConnectionLink) Validate() error {
+	if link.ObjectIDKind == "" {
+		return fmt.Errorf("ObjectIDKind can't be empty")
+	}
+	if link.FeederStopArea == "" {
+		return fmt.Errorf("FeederStopArea can't be empty")
+	}
+	if link.DistributorStopArea == "" {
+		return fmt.Errorf("DistributorStopArea can't be empty")
+	}
+	if link.TransferTime < 0 {
+		return fmt.Errorf("TransferTime can't be negative")
+	}
+	if link.MaximumWaitTime < 0 {
+		return fmt.Errorf("MaximumWaitTime can't be negative")
+	}
+	return nil
+}
+
+func (link *ConnectionLink) TransferDuration() time.Duration {
+	return time.Duration(link.TransferTime) * time.Second
+}
+
+func (link *ConnectionLink) MaximumWaitDuration() time.Duration {
+	if link.MaximumWaitTime == 0 {
+		return DEFAULT_MAXIMUM_WAIT_TIME
+	}
+	return time.Duration(link.MaximumWaitTime) * time.Second
+}
+
+func (link *ConnectionLink) FeederStopAreaObjectID() model.ObjectID {
+	return model.NewObjectID(link.ObjectIDKind, link.FeederStopArea)
+}
+
+func (link *ConnectionLink) DistributorStopAreaObjectID() model.ObjectID {
+	return model.NewObjectID(link.ObjectIDKind, link.DistributorStopArea)
+}
+
+// Returns the ObjectID of the feeder Line, false when all the Lines are concerned
+func (link *ConnectionLink) FeederLineObjectID() (model.ObjectID, bool) {
+	if link.FeederLine == "" {
+		return model.ObjectID{}, false
+	}
+	return model.NewObjectID(link.ObjectIDKind, link.FeederLine), true
+}
+
+// Returns the ObjectID of the distributor Line, false when all the Lines are concerned
+func (link *ConnectionLink) DistributorLineObjectID() (model.ObjectID, bool) {
+	if link.DistributorLine == "" {
+		return model.ObjectID{}, false
+	}
+	return model.NewObjectID(link.ObjectIDKind, link.DistributorLine), true
+}
+
+// ConnectionLinks of a Referential. They are saved in database with the
+// referentials
+type ConnectionLinks struct {
+	uuid.UUIDConsumer
+
+	mutex *sync.RWMutex
+	byId  map[string]*ConnectionLink
+}
+
+func NewConnectionLinks() *ConnectionLinks {
+	return &ConnectionLinks{
+		mutex: &sync.RWMutex{},
+		byId:  make(map[string]*ConnectionLink),
+	}
+}
+
+func (links *ConnectionLinks) Find(id string) (ConnectionLink, bool) {
+	links.mutex.RLock()
+	defer links.mutex.RUnlock()
+
+	link, ok := links.byId[id]
+	if !ok {
+		return ConnectionLink{}, false
+	}
+	return *link, true
+}
+
+// Returns all the ConnectionLinks sorted by Id
+func (links *ConnectionLinks) FindAll() []ConnectionLink {
+	links.mutex.RLock()
+	defer links.mutex.RUnlock()
+
+	result := make([]ConnectionLink, 0, len(links.byId))
+	for _, link := range links.byId {
+		result = append(result, *link)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// Creates or updates the ConnectionLink. An Id is given to a new ConnectionLink
+func (links *ConnectionLinks) Save(link *ConnectionLink) error {
+	if err := link.Validate(); err != nil {
+		return err
+	}
+
+	links.mutex.Lock()
+	defer links.mutex.Unlock()
+
+	if link.Id == "" {
+		link.Id = links.NewUUID()
+	}
+	saved := *link
+	links.byId[link.Id] = &saved
+	return nil
+}
+
+func (links *ConnectionLinks) Delete(id string) bool {
+	links.mutex.Lock()
+	defer links.mutex.Unlock()
+
+	_, ok := links.byId[id]
+	delete(links.byId, id)
+	return ok
+}
+
+func (links *ConnectionLinks) MarshalJSON() ([]byte, error) {
+	return json.Marshal(links.FindAll())
+}
+
+func (links *ConnectionLinks) UnmarshalJSON(data []byte) error {
+	var list []*ConnectionLink
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	byId := make(map[string]*ConnectionLink)
+	for _, link := range list {
+		byId[link.Id] = link
+	}
+
+	links.mutex.Lock()
+	defer links.mutex.Unlock()
+
+	links.byId = byId
+	return nil
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_ConnectionLinks_Save(t *testing.T) {
+	links := NewConnectionLinks()
+	links.SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+
+	invalid := &ConnectionLink{ObjectIDKind: "internal", FeederStopArea: "rail"}
+	if err := links.Save(invalid); err == nil {
+		t.Error("ConnectionLink without DistributorStopArea should be invalid")
+	}
+
+	link := &ConnectionLink{ObjectIDKind: "internal", FeederStopArea: "rail", DistributorStopArea: "bus", TransferTime: 120}
+	if err := links.Save(link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Id == "" {
+		t.Fatal("ConnectionLink should have an Id")
+	}
+
+	found, ok := links.Find(link.Id)
+	if !ok || found != *link {
+		t.Errorf("Wrong saved ConnectionLink:\n got: %v\nwant: %v", found, link)
+	}
+	if _, ok := found.FeederLineObjectID(); ok {
+		t.Error("ConnectionLink without FeederLine should concern all Lines")
+	}
+}
+
+func Test_ConnectionLinks_JSON(t *testing.T) {
+	links := NewConnectionLinks()
+	links.Save(&ConnectionLink{Id: "1", ObjectIDKind: "internal", FeederStopArea: "rail", DistributorStopArea: "bus", DistributorLine: "42"})
+
+	data, err := json.Marshal(links)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewConnectionLinks()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	link, ok := loaded.Find("1")
+	if !ok || link.DistributorLine != "42" {
+		t.Errorf("Wrong loaded ConnectionLink: %v", link)
+	}
+}
@@ -9,34 +9,41 @@ const (
 	SIRI_PARTNER = "siri-partner"
 
 	// Connectors
-	PUSH_COLLECTOR                                    = "push-collector"
-	SIRI_STOP_POINTS_DISCOVERY_REQUEST_COLLECTOR      = "siri-stop-points-discovery-request-collector"
-	SIRI_STOP_POINTS_DISCOVERY_REQUEST_BROADCASTER    = "siri-stop-points-discovery-request-broadcaster"
-	SIRI_LINES_DISCOVERY_REQUEST_BROADCASTER          = "siri-lines-discovery-request-broadcaster"
-	SIRI_SERVICE_REQUEST_BROADCASTER                  = "siri-service-request-broadcaster"
-	SIRI_STOP_MONITORING_REQUEST_COLLECTOR            = "siri-stop-monitoring-request-collector"
-	TEST_STOP_MONITORING_REQUEST_COLLECTOR            = "test-stop-monitoring-request-collector"
-	SIRI_STOP_MONITORING_REQUEST_BROADCASTER          = "siri-stop-monitoring-request-broadcaster"
-	SIRI_STOP_MONITORING_SUBSCRIPTION_COLLECTOR       = "siri-stop-monitoring-subscription-collector"
-	SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER     = "siri-stop-monitoring-subscription-broadcaster"
-	TEST_STOP_MONITORING_SUBSCRIPTION_BROADCASTER     = "siri-stop-monitoring-subscription-broadcaster-test"
-	SIRI_GENERAL_MESSAGE_REQUEST_COLLECTOR            = "siri-general-message-request-collector"
-	SIRI_GENERAL_MESSAGE_REQUEST_BROADCASTER          = "siri-general-message-request-broadcaster"
-	SIRI_GENERAL_MESSAGE_SUBSCRIPTION_COLLECTOR       = "siri-general-message-subscription-collector"
-	SIRI_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER     = "siri-general-message-subscription-broadcaster"
-	TEST_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER     = "siri-general-message-subscription-broadcaster-test"
-	SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER      = "siri-estimated-timetable-request-broadcaster"
-	SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER = "siri-estimated-timetable-subscription-broadcaster"
-	TEST_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER = "siri-estimated-timetable-subscription-broadcaster-test"
-	SIRI_SUBSCRIPTION_REQUEST_DISPATCHER              = "siri-subscription-request-dispatcher"
-	SIRI_CHECK_STATUS_CLIENT_TYPE                     = "siri-check-status-client"
-	TEST_CHECK_STATUS_CLIENT_TYPE                     = "test-check-status-client"
-	SIRI_CHECK_STATUS_SERVER_TYPE                     = "siri-check-status-server"
-	SIRI_LITE_VEHICLE_MONITORING_REQUEST_BROADCASTER  = "siri-lite-vehicle-monitoring-request-broadcaster"
-	TEST_VALIDATION_CONNECTOR                         = "test-validation-connector"
-	TEST_STARTABLE_CONNECTOR                          = "test-startable-connector-connector"
-	GTFS_RT_TRIP_UPDATES_BROADCASTER                  = "gtfs-rt-trip-updates-broadcaster"
-	GTFS_RT_VEHICLE_POSITIONS_BROADCASTER             = "gtfs-rt-vehicle-positions-broadcaster"
+	PUSH_COLLECTOR                                     = "push-collector"
+	SIRI_STOP_POINTS_DISCOVERY_REQUEST_COLLECTOR       = "siri-stop-points-discovery-request-collector"
+	SIRI_STOP_POINTS_DISCOVERY_REQUEST_BROADCASTER     = "siri-stop-points-discovery-request-broadcaster"
+	SIRI_LINES_DISCOVERY_REQUEST_COLLECTOR             = "siri-lines-discovery-request-collector"
+	SIRI_LINES_DISCOVERY_REQUEST_BROADCASTER           = "siri-lines-discovery-request-broadcaster"
+	SIRI_SERVICE_REQUEST_BROADCASTER                   = "siri-service-request-broadcaster"
+	SIRI_STOP_MONITORING_REQUEST_COLLECTOR             = "siri-stop-monitoring-request-collector"
+	TEST_STOP_MONITORING_REQUEST_COLLECTOR             = "test-stop-monitoring-request-collector"
+	SIRI_STOP_MONITORING_REQUEST_BROADCASTER           = "siri-stop-monitoring-request-broadcaster"
+	SIRI_STOP_MONITORING_SUBSCRIPTION_COLLECTOR        = "siri-stop-monitoring-subscription-collector"
+	SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER      = "siri-stop-monitoring-subscription-broadcaster"
+	TEST_STOP_MONITORING_SUBSCRIPTION_BROADCASTER      = "siri-stop-monitoring-subscription-broadcaster-test"
+	SIRI_GENERAL_MESSAGE_REQUEST_COLLECTOR             = "siri-general-message-request-collector"
+	SIRI_GENERAL_MESSAGE_REQUEST_BROADCASTER           = "siri-general-message-request-broadcaster"
+	SIRI_GENERAL_MESSAGE_SUBSCRIPTION_COLLECTOR        = "siri-general-message-subscription-collector"
+	SIRI_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER      = "siri-general-message-subscription-broadcaster"
+	TEST_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER      = "siri-general-message-subscription-broadcaster-test"
+	SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER       = "siri-estimated-timetable-request-broadcaster"
+	SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER  = "siri-estimated-timetable-subscription-broadcaster"
+	TEST_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER  = "siri-estimated-timetable-subscription-broadcaster-test"
+	SIRI_PRODUCTION_TIMETABLE_REQUEST_BROADCASTER      = "siri-production-timetable-request-broadcaster"
+	SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER = "siri-production-timetable-subscription-broadcaster"
+	SIRI_CONNECTION_MONITORING_REQUEST_BROADCASTER     = "siri-connection-monitoring-request-broadcaster"
+	SIRI_FACILITY_MONITORING_REQUEST_COLLECTOR         = "siri-facility-monitoring-request-collector"
+	SIRI_FACILITY_MONITORING_REQUEST_BROADCASTER       = "siri-facility-monitoring-request-broadcaster"
+	SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER  = "siri-facility-monitoring-subscription-broadcaster"
+	SIRI_SUBSCRIPTION_REQUEST_DISPATCHER               = "siri-subscription-request-dispatcher"
+	SIRI_CHECK_STATUS_CLIENT_TYPE                      = "siri-check-status-client"
+	TEST_CHECK_STATUS_CLIENT_TYPE                      = "test-check-status-client"
+	SIRI_CHECK_STATUS_SERVER_TYPE                      = "siri-check-status-server"
+	SIRI_LITE_VEHICLE_MONITORING_REQUEST_BROADCASTER   = "siri-lite-vehicle-monitoring-request-broadcaster"
+	TEST_VALIDATION_CONNECTOR                          = "test-validation-connector"
+	TEST_STARTABLE_CONNECTOR                           = "test-startable-connector-connector"
+	GTFS_RT_TRIP_UPDATES_BROADCASTER                   = "gtfs-rt-trip-updates-broadcaster"
+	GTFS_RT_VEHICLE_POSITIONS_BROADCASTER              = "gtfs-rt-vehicle-positions-broadcaster"
 )
 
 type Connector interface{}
@@ -77,6 +84,8 @@ func NewConnectorFactory(connectorType string) ConnectorFactory {
 		return &SIRIStopPointsDiscoveryRequestCollectorFactory{}
 	case SIRI_STOP_POINTS_DISCOVERY_REQUEST_BROADCASTER:
 		return &SIRIStopPointsDiscoveryRequestBroadcasterFactory{}
+	case SIRI_LINES_DISCOVERY_REQUEST_COLLECTOR:
+		return &SIRILinesDiscoveryRequestCollectorFactory{}
 	case SIRI_LINES_DISCOVERY_REQUEST_BROADCASTER:
 		return &SIRILinesDiscoveryRequestBroadcasterFactory{}
 	case SIRI_SERVICE_REQUEST_BROADCASTER:
@@ -109,6 +118,18 @@ func NewConnectorFactory(connectorType string) ConnectorFactory {
 		return &SIRIEstimatedTimetableSubscriptionBroadcasterFactory{}
 	case TEST_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER:
 		return &TestSIRIETTSubscriptionBroadcasterFactory{}
+	case SIRI_PRODUCTION_TIMETABLE_REQUEST_BROADCASTER:
+		return &SIRIProductionTimetableBroadcasterFactory{}
+	case SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER:
+		return &SIRIProductionTimetableSubscriptionBroadcasterFactory{}
+	case SIRI_CONNECTION_MONITORING_REQUEST_BROADCASTER:
+		return &SIRIConnectionMonitoringBroadcasterFactory{}
+	case SIRI_FACILITY_MONITORING_REQUEST_COLLECTOR:
+		return &SIRIFacilityMonitoringRequestCollectorFactory{}
+	case SIRI_FACILITY_MONITORING_REQUEST_BROADCASTER:
+		return &SIRIFacilityMonitoringBroadcasterFactory{}
+	case SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER:
+		return &SIRIFacilityMonitoringSubscriptionBroadcasterFactory{}
 	case SIRI_CHECK_STATUS_CLIENT_TYPE:
 		return &SIRICheckStatusClientFactory{}
 	case SIRI_SUBSCRIPTION_REQUEST_DISPATCHER:
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"strings"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+)
+
+/*
+Changes applied to the model by a discovery collector.
+
+Created objects are the ones announced for the first time, updated objects
+are the known ones whose announced attributes changed and retired objects
+are the ones previously discovered with the partner but no longer announced.
+*/
+type discoveryDiff struct {
+	Created []string
+	Updated []string
+	Retired []string
+}
+
+func (diff *discoveryDiff) isEmpty() bool {
+	return len(diff.Created) == 0 && len(diff.Updated) == 0 && len(diff.Retired) == 0
+}
+
+func (diff *discoveryDiff) refs() (refs []string) {
+	refs = append(refs, diff.Created...)
+	refs = append(refs, diff.Updated...)
+	refs = append(refs, diff.Retired...)
+	return
+}
+
+// Writes a LogStash and a BigQuery event with the discovery changes, if any.
+// setRefs stores the changed refs in the BigQuery message (StopAreas, Lines)
+func (diff *discoveryDiff) log(partner *Partner, connector, siriType string, setRefs func(*audit.BigQueryMessage, []string)) {
+	if diff.isEmpty() {
+		return
+	}
+
+	logStashEvent := partner.NewLogStashEvent()
+	logStashEvent["connector"] = connector
+	logStashEvent["siriType"] = siriType
+	logStashEvent["created"] = strings.Join(diff.Created, ",")
+	logStashEvent["updated"] = strings.Join(diff.Updated, ",")
+	logStashEvent["retired"] = strings.Join(diff.Retired, ",")
+	audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	message := &audit.BigQueryMessage{
+		Type:      siriType,
+		Protocol:  "siri",
+		Direction: "received",
+		Partner:   string(partner.Slug()),
+		Status:    "OK",
+	}
+	setRefs(message, diff.refs())
+	audit.CurrentBigQuery(string(partner.Referential().Slug())).WriteEvent(message)
+}
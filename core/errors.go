@@ -11,11 +11,12 @@ func (errors Errors) Empty() bool {
 }
 
 const (
-	SETTINGS          = "Settings"
-	ERROR_BLANK       = "Can't be empty"
-	ERROR_SLUG_FORMAT = "Invalid format: only lowercase alphanumeric characters and _"
-	ERROR_ZERO        = "Can't be zero"
-	ERROR_UNIQUE      = "Is already in use"
+	SETTINGS            = "Settings"
+	ERROR_BLANK         = "Can't be empty"
+	ERROR_SLUG_FORMAT   = "Invalid format: only lowercase alphanumeric characters and _"
+	ERROR_ZERO          = "Can't be zero"
+	ERROR_UNIQUE        = "Is already in use"
+	ERROR_UNKNOWN_VALUE = "Unknown value"
 )
 
 func (errors Errors) Get(attribute string) []string {
@@ -77,6 +77,8 @@ func (ett *EstimatedTimeTableBroadcaster) run() {
 
 			ett.prepareSIRIEstimatedTimeTable()
 			ett.prepareNotMonitored()
+			// Redeliver the notifications which failed previously
+			ett.connector.NotificationQueue().Deliver()
 
 			c = ett.Clock().After(5 * time.Second)
 		}
@@ -316,22 +318,9 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) getEstimatedVehi
 		references[refType] = defaultObjectID.Value()
 	}
 
-	// Handle OperatorRef
-	operatorRef, ok := stopVisit.Reference("OperatorRef")
-	if !ok || operatorRef == (model.Reference{}) || operatorRef.ObjectId == nil {
-		return references
+	if operatorRef, ok := broadcastedOperatorRef(tx, stopVisit, vehicleJourney, connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER)); ok {
+		references["OperatorRef"] = operatorRef
 	}
-	operator, ok := tx.Model().Operators().FindByObjectId(*operatorRef.ObjectId)
-	if !ok {
-		references["OperatorRef"] = operatorRef.ObjectId.Value()
-		return references
-	}
-	obj, ok := operator.ObjectID(connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER))
-	if !ok {
-		references["OperatorRef"] = operatorRef.ObjectId.Value()
-		return references
-	}
-	references["OperatorRef"] = obj.Value()
 	return references
 }
 
@@ -345,6 +334,21 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) noDestinationRef
 }
 
 func (ett *ETTBroadcaster) sendDelivery(delivery *siri.SIRINotifyEstimatedTimeTable) {
+	queue := ett.connector.NotificationQueue()
+	queue.Push(&QueuedNotification{
+		Type:                   NOTIFY_ESTIMATED_TIME_TABLE,
+		SubscriptionIdentifier: delivery.SubscriptionIdentifier,
+		EstimatedTimeTable:     delivery,
+	}, ett.connector)
+	queue.Deliver()
+}
+
+func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) deliverNotification(notification *QueuedNotification) error {
+	ett := &ETTBroadcaster{connector: connector}
+	return ett.deliverNotification(notification.EstimatedTimeTable)
+}
+
+func (ett *ETTBroadcaster) deliverNotification(delivery *siri.SIRINotifyEstimatedTimeTable) error {
 	logStashEvent := ett.newLogStashEvent()
 	message := ett.newBQEvent()
 
@@ -362,6 +366,7 @@ func (ett *ETTBroadcaster) sendDelivery(delivery *siri.SIRINotifyEstimatedTimeTa
 	}
 
 	audit.CurrentBigQuery(string(ett.connector.Partner().Referential().Slug())).WriteEvent(message)
+	return err
 }
 
 func (ett *ETTBroadcaster) newBQEvent() *audit.BigQueryMessage {
@@ -76,6 +76,8 @@ func (gmb *GeneralMessageBroadcaster) run() {
 			logger.Log.Debugf("SIRIGeneralMessageBroadcaster visit")
 
 			gmb.prepareSIRIGeneralMessageNotify()
+			// Redeliver the notifications which failed previously
+			gmb.connector.NotificationQueue().Deliver()
 
 			c = gmb.Clock().After(5 * time.Second)
 		}
@@ -143,26 +145,46 @@ func (gmb *GMBroadcaster) prepareSIRIGeneralMessageNotify() {
 			notify.GeneralMessages = append(notify.GeneralMessages, siriGeneralMessage)
 		}
 		if len(notify.GeneralMessages) != 0 {
-			logStashEvent := gmb.newLogStashEvent()
-			message := gmb.newBQEvent()
-
-			logSIRIGeneralMessageNotify(logStashEvent, message, &notify)
-			audit.CurrentLogStash().WriteEvent(logStashEvent)
-			t := gmb.Clock().Now()
-
-			err := gmb.connector.SIRIPartner().SOAPClient().NotifyGeneralMessage(&notify)
-			message.ProcessingTime = gmb.Clock().Since(t).Seconds()
-			if err != nil {
-				event := gmb.newLogStashEvent()
-				logSIRINotifyError(err.Error(), notify.ResponseMessageIdentifier, event)
-				audit.CurrentLogStash().WriteEvent(event)
-			}
-
-			audit.CurrentBigQuery(string(gmb.connector.Partner().Referential().Slug())).WriteEvent(message)
+			gmb.sendNotification(&notify)
 		}
 	}
 }
 
+func (gmb *GMBroadcaster) sendNotification(notify *siri.SIRINotifyGeneralMessage) {
+	queue := gmb.connector.NotificationQueue()
+	queue.Push(&QueuedNotification{
+		Type:                   NOTIFY_GENERAL_MESSAGE,
+		SubscriptionIdentifier: notify.SubscriptionIdentifier,
+		GeneralMessage:         notify,
+	}, gmb.connector)
+	queue.Deliver()
+}
+
+func (connector *SIRIGeneralMessageSubscriptionBroadcaster) deliverNotification(notification *QueuedNotification) error {
+	gmb := &GMBroadcaster{connector: connector}
+	return gmb.deliverNotification(notification.GeneralMessage)
+}
+
+func (gmb *GMBroadcaster) deliverNotification(notify *siri.SIRINotifyGeneralMessage) error {
+	logStashEvent := gmb.newLogStashEvent()
+	message := gmb.newBQEvent()
+
+	logSIRIGeneralMessageNotify(logStashEvent, message, notify)
+	audit.CurrentLogStash().WriteEvent(logStashEvent)
+	t := gmb.Clock().Now()
+
+	err := gmb.connector.SIRIPartner().SOAPClient().NotifyGeneralMessage(notify)
+	message.ProcessingTime = gmb.Clock().Since(t).Seconds()
+	if err != nil {
+		event := gmb.newLogStashEvent()
+		logSIRINotifyError(err.Error(), notify.ResponseMessageIdentifier, event)
+		audit.CurrentLogStash().WriteEvent(event)
+	}
+
+	audit.CurrentBigQuery(string(gmb.connector.Partner().Referential().Slug())).WriteEvent(message)
+	return err
+}
+
 func (gmb *GMBroadcaster) newBQEvent() *audit.BigQueryMessage {
 	return &audit.BigQueryMessage{
 		Type:      "NotifyGeneralMessage",
@@ -0,0 +1,331 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+const (
+	GTFS_ROUTE_TYPE_BUS = "3"
+)
+
+/*
+Builds a GTFS static feed from the current Model.
+
+The feed uses the identifier generators and the objectid kind of the
+TripUpdatesBroadcaster, so route_id, trip_id and stop_id are the same in
+the static and in the realtime feeds.
+*/
+type GtfsStaticFeed struct {
+	connector *TripUpdatesBroadcaster
+
+	objectidKind string
+	location     *time.Location
+
+	agencyId string
+
+	agencies      map[string]string
+	stops         map[model.StopAreaId]string
+	routes        map[model.LineId]string
+	routeAgencies map[model.LineId]string
+	serviceIds    map[string]struct{}
+	trips         []gtfsStaticTrip
+	stopTimes     [][]string
+}
+
+type gtfsStaticTrip struct {
+	routeId   string
+	serviceId string
+	tripId    string
+	headsign  string
+}
+
+func NewGtfsStaticFeed(connector *TripUpdatesBroadcaster) *GtfsStaticFeed {
+	return &GtfsStaticFeed{
+		connector:     connector,
+		objectidKind:  connector.partner.RemoteObjectIDKind(GTFS_RT_TRIP_UPDATES_BROADCASTER),
+		agencyId:      string(connector.partner.Referential().Slug()),
+		agencies:      make(map[string]string),
+		stops:         make(map[model.StopAreaId]string),
+		routes:        make(map[model.LineId]string),
+		routeAgencies: make(map[model.LineId]string),
+		serviceIds:    make(map[string]struct{}),
+	}
+}
+
+func (connector *TripUpdatesBroadcaster) GtfsStaticFeed() ([]byte, error) {
+	return NewGtfsStaticFeed(connector).Build()
+}
+
+// Returns the zip archive of the GTFS feed
+func (feed *GtfsStaticFeed) Build() ([]byte, error) {
+	tx := feed.connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	feed.location = feed.connector.Clock().Now().Location()
+
+	vehicleJourneys := tx.Model().VehicleJourneys().FindAll()
+	sort.Slice(vehicleJourneys, func(i, j int) bool { return vehicleJourneys[i].Id() < vehicleJourneys[j].Id() })
+
+	for i := range vehicleJourneys {
+		feed.handleVehicleJourney(tx, &vehicleJourneys[i])
+	}
+
+	buffer := new(bytes.Buffer)
+	archive := zip.NewWriter(buffer)
+
+	files := []struct {
+		name    string
+		records [][]string
+	}{
+		{"agency.txt", feed.agencyRecords()},
+		{"stops.txt", feed.stopRecords(tx)},
+		{"routes.txt", feed.routeRecords(tx)},
+		{"trips.txt", feed.tripRecords()},
+		{"stop_times.txt", feed.stopTimeRecords()},
+		{"calendar_dates.txt", feed.calendarDateRecords()},
+	}
+	for _, file := range files {
+		if err := writeGtfsFile(archive, file.name, file.records); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("can't close GTFS archive: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func (feed *GtfsStaticFeed) handleVehicleJourney(tx *model.Transaction, vehicleJourney *model.VehicleJourney) {
+	vjId, ok := vehicleJourney.ObjectID(feed.objectidKind)
+	if !ok {
+		return
+	}
+	routeId, ok := feed.routeId(tx, vehicleJourney.LineId)
+	if !ok {
+		return
+	}
+
+	tripId := feed.connector.referenceGenerator.NewIdentifier(IdentifierAttributes{Type: "VehicleJourney", ObjectId: vjId.Value()})
+
+	// Each service date of the model is a GTFS service
+	date := serviceDate(tx.Model(), vehicleJourney.ServiceDate)
+	startOfDay := date.StartOfDay(feed.location)
+	serviceId := startOfDay.Format("20060102")
+
+	stopVisits := tx.Model().StopVisits().FindByVehicleJourneyId(vehicleJourney.Id())
+	sort.Slice(stopVisits, func(i, j int) bool { return stopVisits[i].PassageOrder < stopVisits[j].PassageOrder })
+
+	var stopTimes [][]string
+	for i := range stopVisits {
+		stopId, ok := feed.stopId(tx, stopVisits[i].StopAreaId)
+		if !ok {
+			continue
+		}
+
+		schedule := stopVisits[i].Schedules.Schedule(model.STOP_VISIT_SCHEDULE_AIMED)
+		arrival, departure := schedule.ArrivalTime(), schedule.DepartureTime()
+		if arrival.IsZero() {
+			arrival = departure
+		}
+		if departure.IsZero() {
+			departure = arrival
+		}
+		if arrival.IsZero() {
+			logger.Log.Debugf("Ignore StopVisit %v without aimed times in GTFS static feed", stopVisits[i].Id())
+			continue
+		}
+
+		stopTimes = append(stopTimes, []string{
+			tripId,
+			gtfsTime(startOfDay, arrival),
+			gtfsTime(startOfDay, departure),
+			stopId,
+			strconv.Itoa(stopVisits[i].PassageOrder),
+		})
+	}
+
+	// A GTFS trip needs at least two stop times
+	if len(stopTimes) < 2 {
+		return
+	}
+
+	// A GTFS route belongs to a single agency, the Operator of its first trip
+	if _, ok := feed.routeAgencies[vehicleJourney.LineId]; !ok {
+		feed.routeAgencies[vehicleJourney.LineId] = feed.agency(tx, vehicleJourney)
+	}
+
+	feed.serviceIds[serviceId] = struct{}{}
+	feed.trips = append(feed.trips, gtfsStaticTrip{
+		routeId:   routeId,
+		serviceId: serviceId,
+		tripId:    tripId,
+		headsign:  vehicleJourney.DestinationName,
+	})
+	feed.stopTimes = append(feed.stopTimes, stopTimes...)
+}
+
+func (feed *GtfsStaticFeed) routeId(tx *model.Transaction, lineId model.LineId) (string, bool) {
+	if routeId, ok := feed.routes[lineId]; ok {
+		return routeId, true
+	}
+	line, ok := tx.Model().Lines().Find(lineId)
+	if !ok {
+		return "", false
+	}
+	lineObjectId, ok := line.ObjectID(feed.objectidKind)
+	if !ok {
+		return "", false
+	}
+	routeId := feed.connector.referenceGenerator.NewIdentifier(IdentifierAttributes{Type: "Line", ObjectId: lineObjectId.Value()})
+	feed.routes[lineId] = routeId
+	return routeId, true
+}
+
+// Returns the agency_id of the VehicleJourney Operator. The referential is
+// used as agency when the VehicleJourney has no Operator.
+func (feed *GtfsStaticFeed) agency(tx *model.Transaction, vehicleJourney *model.VehicleJourney) string {
+	reference, ok := operatorReference(nil, vehicleJourney)
+	if !ok {
+		feed.agencies[feed.agencyId] = feed.referentialName()
+		return feed.agencyId
+	}
+
+	agencyId := resolveOperatorRef(tx, reference, feed.objectidKind)
+	if _, ok := feed.agencies[agencyId]; ok {
+		return agencyId
+	}
+	name := agencyId
+	if operator, ok := tx.Model().Operators().FindByObjectId(*reference.ObjectId); ok && operator.Name != "" {
+		name = operator.Name
+	}
+	feed.agencies[agencyId] = name
+	return agencyId
+}
+
+func (feed *GtfsStaticFeed) stopId(tx *model.Transaction, stopAreaId model.StopAreaId) (string, bool) {
+	if stopId, ok := feed.stops[stopAreaId]; ok {
+		return stopId, true
+	}
+	stopArea, ok := tx.Model().StopAreas().Find(stopAreaId)
+	if !ok {
+		return "", false
+	}
+	stopAreaObjectId, ok := stopArea.ObjectID(feed.objectidKind)
+	if !ok {
+		return "", false
+	}
+	stopId := feed.connector.stopAreareferenceGenerator.NewIdentifier(IdentifierAttributes{ObjectId: stopAreaObjectId.Value()})
+	feed.stops[stopAreaId] = stopId
+	return stopId, true
+}
+
+// GTFS times are relative to the service day and can be greater than 24:00:00
+func gtfsTime(startOfDay, t time.Time) string {
+	seconds := int(t.Sub(startOfDay).Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds/60)%60, seconds%60)
+}
+
+func (feed *GtfsStaticFeed) referentialName() string {
+	referential := feed.connector.Partner().Referential()
+	if referential.Name != "" {
+		return referential.Name
+	}
+	return string(referential.Slug())
+}
+
+// A GTFS feed requires at least one agency, the referential is used when the
+// feed has no trip
+func (feed *GtfsStaticFeed) agencyRecords() [][]string {
+	if len(feed.agencies) == 0 {
+		feed.agencies[feed.agencyId] = feed.referentialName()
+	}
+
+	url := feed.connector.Partner().Setting(BROADCAST_GTFS_AGENCY_URL)
+	records := [][]string{{"agency_id", "agency_name", "agency_url", "agency_timezone"}}
+	for agencyId, name := range feed.agencies {
+		records = append(records, []string{agencyId, name, url, feed.location.String()})
+	}
+	sortRecords(records)
+	return records
+}
+
+func (feed *GtfsStaticFeed) stopRecords(tx *model.Transaction) [][]string {
+	records := [][]string{{"stop_id", "stop_name", "stop_lat", "stop_lon"}}
+	for stopAreaId, stopId := range feed.stops {
+		stopArea, _ := tx.Model().StopAreas().Find(stopAreaId)
+		records = append(records, []string{
+			stopId,
+			stopArea.Name,
+			strconv.FormatFloat(stopArea.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(stopArea.Longitude, 'f', -1, 64),
+		})
+	}
+	sortRecords(records)
+	return records
+}
+
+func (feed *GtfsStaticFeed) routeRecords(tx *model.Transaction) [][]string {
+	records := [][]string{{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"}}
+	for lineId, routeId := range feed.routes {
+		// Ignore the Lines without trip in the feed
+		agencyId, ok := feed.routeAgencies[lineId]
+		if !ok {
+			continue
+		}
+		line, _ := tx.Model().Lines().Find(lineId)
+		records = append(records, []string{routeId, agencyId, line.Name, "", GTFS_ROUTE_TYPE_BUS})
+	}
+	sortRecords(records)
+	return records
+}
+
+func (feed *GtfsStaticFeed) tripRecords() [][]string {
+	records := [][]string{{"route_id", "service_id", "trip_id", "trip_headsign"}}
+	for _, trip := range feed.trips {
+		records = append(records, []string{trip.routeId, trip.serviceId, trip.tripId, trip.headsign})
+	}
+	return records
+}
+
+func (feed *GtfsStaticFeed) stopTimeRecords() [][]string {
+	return append([][]string{{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}}, feed.stopTimes...)
+}
+
+func (feed *GtfsStaticFeed) calendarDateRecords() [][]string {
+	records := [][]string{{"service_id", "date", "exception_type"}}
+	for serviceId := range feed.serviceIds {
+		records = append(records, []string{serviceId, serviceId, "1"})
+	}
+	sortRecords(records)
+	return records
+}
+
+// Sorts records by their first column, the header excepted
+func sortRecords(records [][]string) {
+	rows := records[1:]
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+}
+
+func writeGtfsFile(archive *zip.Writer, name string, records [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("can't create %v in GTFS archive: %v", name, err)
+	}
+	if err := csv.NewWriter(file).WriteAll(records); err != nil {
+		return fmt.Errorf("can't write %v in GTFS archive: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func readGtfsFile(t *testing.T, archive *zip.Reader, name string) [][]string {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		records, err := csv.NewReader(reader).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+	t.Fatalf("Can't find %v in GTFS archive", name)
+	return nil
+}
+
+func Test_GtfsStaticFeed_Build(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.reference_identifier"] = "Ara:%{type}::%{objectid}:LOC"
+	partner.Settings["generators.reference_stop_area_identifier"] = "Ara:StopPoint::%{objectid}:LOC"
+	connector := NewTripUpdatesBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	date := referential.Model().Date()
+	startOfDay := time.Date(date.Year, date.Month, date.Day, 0, 0, 0, 0, connector.Clock().Now().Location())
+
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "lId"))
+	line.Name = "Line 1"
+	line.Save()
+
+	vehicleJourney := referential.Model().VehicleJourneys().New()
+	vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", "vjId"))
+	vehicleJourney.LineId = line.Id()
+	vehicleJourney.DestinationName = "Destination"
+	vehicleJourney.Save()
+
+	for i, objectid := range []string{"sa1", "sa2"} {
+		stopArea := referential.Model().StopAreas().New()
+		stopArea.SetObjectID(model.NewObjectID("objectidKind", objectid))
+		stopArea.Name = objectid
+		stopArea.Latitude = 48.85
+		stopArea.Longitude = 2.35
+		stopArea.Save()
+
+		stopVisit := referential.Model().StopVisits().New()
+		stopVisit.StopAreaId = stopArea.Id()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.PassageOrder = i + 1
+		aimed := startOfDay.Add(time.Duration(23+i) * time.Hour)
+		stopVisit.Schedules.SetSchedule(model.STOP_VISIT_SCHEDULE_AIMED, aimed.Add(time.Minute), aimed)
+		stopVisit.Save()
+	}
+
+	// Ignored VehicleJourney without objectid of the partner kind
+	otherVehicleJourney := referential.Model().VehicleJourneys().New()
+	otherVehicleJourney.SetObjectID(model.NewObjectID("other", "vjId"))
+	otherVehicleJourney.LineId = line.Id()
+	otherVehicleJourney.Save()
+
+	data, err := connector.GtfsStaticFeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serviceId := startOfDay.Format("20060102")
+	expected := map[string][][]string{
+		"routes.txt": {
+			{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"},
+			{"Ara:Line::lId:LOC", "referential", "Line 1", "", "3"},
+		},
+		"trips.txt": {
+			{"route_id", "service_id", "trip_id", "trip_headsign"},
+			{"Ara:Line::lId:LOC", serviceId, "Ara:VehicleJourney::vjId:LOC", "Destination"},
+		},
+		"stops.txt": {
+			{"stop_id", "stop_name", "stop_lat", "stop_lon"},
+			{"Ara:StopPoint::sa1:LOC", "sa1", "48.85", "2.35"},
+			{"Ara:StopPoint::sa2:LOC", "sa2", "48.85", "2.35"},
+		},
+		"stop_times.txt": {
+			{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"},
+			{"Ara:VehicleJourney::vjId:LOC", "23:00:00", "23:01:00", "Ara:StopPoint::sa1:LOC", "1"},
+			{"Ara:VehicleJourney::vjId:LOC", "24:00:00", "24:01:00", "Ara:StopPoint::sa2:LOC", "2"},
+		},
+		"calendar_dates.txt": {
+			{"service_id", "date", "exception_type"},
+			{serviceId, serviceId, "1"},
+		},
+	}
+	for name, records := range expected {
+		if got := readGtfsFile(t, archive, name); !reflect.DeepEqual(got, records) {
+			t.Errorf("Wrong %v:\n got: %v\n want: %v", name, got, records)
+		}
+	}
+
+	agency := readGtfsFile(t, archive, "agency.txt")
+	if len(agency) != 2 || agency[1][0] != "referential" {
+		t.Errorf("Wrong agency.txt: %v", agency)
+	}
+}
+
+func Test_GtfsStaticFeed_Build_Operators(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.reference_identifier"] = "%{objectid}"
+	partner.Settings["generators.reference_stop_area_identifier"] = "%{objectid}"
+	connector := NewTripUpdatesBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	date := referential.Model().Date()
+	startOfDay := time.Date(date.Year, date.Month, date.Day, 0, 0, 0, 0, connector.Clock().Now().Location())
+
+	operator := referential.Model().Operators().New()
+	operator.SetObjectID(model.NewObjectID("internal", "op1"))
+	operator.SetObjectID(model.NewObjectID("objectidKind", "OP1"))
+	operator.Name = "Operator 1"
+	operator.Save()
+
+	stopAreaIds := []model.StopAreaId{}
+	for _, objectid := range []string{"sa1", "sa2"} {
+		stopArea := referential.Model().StopAreas().New()
+		stopArea.SetObjectID(model.NewObjectID("objectidKind", objectid))
+		stopArea.Save()
+		stopAreaIds = append(stopAreaIds, stopArea.Id())
+	}
+
+	for _, objectid := range []string{"l1", "l2"} {
+		line := referential.Model().Lines().New()
+		line.SetObjectID(model.NewObjectID("objectidKind", objectid))
+		line.Save()
+
+		vehicleJourney := referential.Model().VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", "vj-"+objectid))
+		vehicleJourney.LineId = line.Id()
+		if objectid == "l1" {
+			vehicleJourney.References.SetObjectId("OperatorRef", model.NewObjectID("internal", "op1"))
+		}
+		vehicleJourney.Save()
+
+		for i, stopAreaId := range stopAreaIds {
+			stopVisit := referential.Model().StopVisits().New()
+			stopVisit.StopAreaId = stopAreaId
+			stopVisit.VehicleJourneyId = vehicleJourney.Id()
+			stopVisit.PassageOrder = i + 1
+			aimed := startOfDay.Add(time.Duration(10+i) * time.Hour)
+			stopVisit.Schedules.SetSchedule(model.STOP_VISIT_SCHEDULE_AIMED, aimed, aimed)
+			stopVisit.Save()
+		}
+	}
+
+	data, err := connector.GtfsStaticFeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := connector.Clock().Now().Location().String()
+	expected := map[string][][]string{
+		"agency.txt": {
+			{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+			{"OP1", "Operator 1", "", location},
+			{"referential", "referential", "", location},
+		},
+		"routes.txt": {
+			{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"},
+			{"l1", "OP1", "", "", "3"},
+			{"l2", "referential", "", "", "3"},
+		},
+	}
+	for name, records := range expected {
+		if got := readGtfsFile(t, archive, name); !reflect.DeepEqual(got, records) {
+			t.Errorf("Wrong %v:\n got: %v\n want: %v", name, got, records)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+const HEARTBEAT_CHECK_INTERVAL = 5 * time.Second
+
+/*
+Sends HeartbeatNotifications to a subscriber, so it can distinguish an
+absence of changes from an unavailable service.
+
+Each broadcast subscription with a HeartbeatInterval has its own schedule. A
+single HeartbeatNotification is sent for all the subscriptions whose interval
+is elapsed, since the notification concerns the producer and not a given
+subscription. Without HeartbeatInterval, no heartbeat is sent.
+*/
+type HeartbeatScheduler struct {
+	clock.ClockConsumer
+	optionParser
+
+	partner *Partner
+	stop    chan struct{}
+
+	// Only used by the scheduler goroutine
+	lastHeartbeatAt map[string]time.Time
+}
+
+func NewHeartbeatScheduler(partner *Partner) *HeartbeatScheduler {
+	return &HeartbeatScheduler{
+		partner:         partner,
+		lastHeartbeatAt: make(map[string]time.Time),
+	}
+}
+
+func (scheduler *HeartbeatScheduler) Start() {
+	logger.Log.Debugf("Start HeartbeatScheduler for partner %v", scheduler.partner.Slug())
+
+	scheduler.stop = make(chan struct{})
+	go scheduler.run()
+}
+
+func (scheduler *HeartbeatScheduler) Stop() {
+	if scheduler.stop != nil {
+		close(scheduler.stop)
+	}
+}
+
+func (scheduler *HeartbeatScheduler) run() {
+	c := scheduler.Clock().After(HEARTBEAT_CHECK_INTERVAL)
+
+	for {
+		select {
+		case <-scheduler.stop:
+			return
+		case <-c:
+			scheduler.heartbeat()
+			c = scheduler.Clock().After(HEARTBEAT_CHECK_INTERVAL)
+		}
+	}
+}
+
+// Returns the identifiers of the broadcast subscriptions whose
+// HeartbeatInterval is elapsed since their last heartbeat
+func (scheduler *HeartbeatScheduler) dueSubscriptions() (subIds []string) {
+	if scheduler.partner.Subscriptions() == nil {
+		return
+	}
+
+	now := scheduler.Clock().Now()
+	lastHeartbeatAt := make(map[string]time.Time)
+	for _, sub := range scheduler.partner.Subscriptions().FindBroadcastSubscriptions() {
+		interval := scheduler.getOptionDuration(sub.SubscriptionOption("HeartbeatInterval"))
+		if interval <= 0 {
+			continue
+		}
+
+		subId := sub.ExternalId()
+		last, ok := scheduler.lastHeartbeatAt[subId]
+		if ok && now.Sub(last) < interval {
+			lastHeartbeatAt[subId] = last
+			continue
+		}
+		lastHeartbeatAt[subId] = now
+		subIds = append(subIds, subId)
+	}
+	// Forget the deleted subscriptions
+	scheduler.lastHeartbeatAt = lastHeartbeatAt
+	return
+}
+
+func (scheduler *HeartbeatScheduler) heartbeat() {
+	subIds := scheduler.dueSubscriptions()
+	if len(subIds) == 0 {
+		return
+	}
+
+	connector, ok := scheduler.partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER)
+	if !ok {
+		logger.Log.Debugf("Can't send HeartbeatNotification to partner %v without SubscriptionRequestDispatcher", scheduler.partner.Slug())
+		return
+	}
+
+	notification := &siri.SIRIHeartbeatNotification{
+		Address:            scheduler.partner.Address(),
+		ProducerRef:        scheduler.partner.ProducerRef(),
+		MessageIdentifier:  scheduler.partner.IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+		RequestTimestamp:   scheduler.Clock().Now(),
+		ServiceStartedTime: scheduler.partner.Referential().StartedAt(),
+		Status:             true,
+	}
+
+	message := &audit.BigQueryMessage{
+		Type:                    "HeartbeatNotification",
+		Protocol:                "siri",
+		Direction:               "sent",
+		Partner:                 string(scheduler.partner.Slug()),
+		Status:                  "OK",
+		RequestIdentifier:       notification.MessageIdentifier,
+		SubscriptionIdentifiers: subIds,
+	}
+	message.RequestRawMessage, _ = notification.BuildXML()
+	message.RequestSize = int64(len(message.RequestRawMessage))
+
+	startTime := scheduler.Clock().Now()
+	err := connector.(*SIRISubscriptionRequestDispatcher).SIRIPartner().SOAPClient().NotifyHeartbeat(notification)
+	message.ProcessingTime = scheduler.Clock().Since(startTime).Seconds()
+	if err != nil {
+		logger.Log.Debugf("HeartbeatNotification to partner %v failed: %v", scheduler.partner.Slug(), err)
+		message.Status = "Error"
+		message.ErrorDetails = fmt.Sprintf("Error during HeartbeatNotification: %v", err)
+	}
+
+	audit.CurrentBigQuery(string(scheduler.partner.Referential().Slug())).WriteEvent(message)
+}
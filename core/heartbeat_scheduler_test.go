@@ -0,0 +1,81 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+func Test_HeartbeatScheduler_Heartbeat(t *testing.T) {
+	heartbeats := 0
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.Contains(string(body), "NotifyHeartbeat") {
+			heartbeats++
+		}
+	}))
+	defer server.Close()
+
+	partners := createTestPartnerManager()
+	partner := partners.New("slug")
+	partner.Settings[REMOTE_URL] = server.URL
+	partners.Save(partner)
+	partner.connectors[SIRI_SUBSCRIPTION_REQUEST_DISPATCHER] = NewSIRISubscriptionRequestDispatcher(partner)
+
+	fakeClock := clock.NewFakeClock()
+	scheduler := NewHeartbeatScheduler(partner)
+	scheduler.SetClock(fakeClock)
+
+	// Without HeartbeatInterval
+	subscription := partner.Subscriptions().New("StopMonitoringBroadcast")
+	subscription.SetExternalId("first")
+	scheduler.heartbeat()
+	if heartbeats != 0 {
+		t.Fatalf("No heartbeat should be sent without HeartbeatInterval, got: %v", heartbeats)
+	}
+
+	subscription = partner.Subscriptions().New("GeneralMessageBroadcast")
+	subscription.SetExternalId("second")
+	subscription.SetSubscriptionOption("HeartbeatInterval", "PT1M")
+
+	scheduler.heartbeat()
+	if heartbeats != 1 {
+		t.Fatalf("A heartbeat should be sent, got: %v", heartbeats)
+	}
+
+	fakeClock.Advance(30 * time.Second)
+	scheduler.heartbeat()
+	if heartbeats != 1 {
+		t.Errorf("No heartbeat should be sent before the HeartbeatInterval, got: %v", heartbeats)
+	}
+
+	fakeClock.Advance(30 * time.Second)
+	scheduler.heartbeat()
+	if heartbeats != 2 {
+		t.Errorf("A heartbeat should be sent after the HeartbeatInterval, got: %v", heartbeats)
+	}
+
+	subscription = partner.Subscriptions().New("EstimatedTimetableBroadcast")
+	subscription.SetExternalId("third")
+	subscription.SetSubscriptionOption("HeartbeatInterval", "PT2M")
+
+	fakeClock.Advance(30 * time.Second)
+	scheduler.heartbeat()
+	if heartbeats != 3 {
+		t.Errorf("A heartbeat should be sent for the new subscription, got: %v", heartbeats)
+	}
+
+	fakeClock.Advance(30 * time.Second)
+	scheduler.heartbeat()
+	if heartbeats != 4 {
+		t.Errorf("A heartbeat should be sent after the first subscription HeartbeatInterval, got: %v", heartbeats)
+	}
+	if last := scheduler.lastHeartbeatAt["third"]; !last.Equal(fakeClock.Now().Add(-30 * time.Second)) {
+		t.Errorf("Subscription with a longer HeartbeatInterval shouldn't be rescheduled, got: %v", last)
+	}
+}
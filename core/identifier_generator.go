@@ -3,6 +3,7 @@ package core
 import (
 	"regexp"
 	"strings"
+	"sync"
 
 	"bitbucket.org/enroute-mobi/ara/uuid"
 )
@@ -16,12 +17,33 @@ var defaultIdentifierGenerators = map[string]string{
 	"subscription_identifier":        "%{id}",
 }
 
+// Builds identifiers from a format string.
+//
+// The format string contains variables (%{id}, %{type}, %{default},
+// %{objectid} and %{uuid}) which can be transformed by a pipeline of functions:
+//
+//	%{objectid|regexp/^STIF:StopPoint:Q:(\d+):$/$1/|lookup:stops|prefix:SNCF:|upcase}
+//
+// Available functions:
+//
+//	upcase, downcase        change the value case
+//	prefix:x, suffix:x      add x before or after the value
+//	lookup:table            replace the value by its mapping in the referential
+//	                        IdentifierMappings table (unchanged if not found)
+//	regexp/pattern/repl/    replace the matches of the pattern (captures can be
+//	                        used with $1, ${name}, ...). The first character
+//	                        after regexp is used as delimiter
+//
+// The legacy %{objectid//pattern/replacement} substitution is still supported.
+//
+// A specific format can be defined for each model type (see
+// IdentifierAttributes.Type) with SetTypeFormat.
 type IdentifierGenerator struct {
 	uuid.UUIDConsumer
 
 	formatString string
-	pattern      string
-	replacement  string
+	typeFormats  map[string]string
+	mappings     *IdentifierMappings
 }
 
 type IdentifierAttributes struct {
@@ -32,19 +54,7 @@ type IdentifierAttributes struct {
 }
 
 func NewIdentifierGenerator(formatString string) *IdentifierGenerator {
-	objectidSubstitutionPattern := regexp.MustCompile(`%{objectid//([^/]+)/([^/]*)}`)
-	matches := objectidSubstitutionPattern.FindStringSubmatch(formatString)
-
-	var pattern, replacement string
-
-	if len(matches) == 3 {
-		pattern = matches[1]
-		replacement = matches[2]
-
-		formatString = objectidSubstitutionPattern.ReplaceAllString(formatString, `%{objectid}`)
-	}
-
-	return &IdentifierGenerator{formatString: formatString, pattern: pattern, replacement: replacement}
+	return &IdentifierGenerator{formatString: formatString}
 }
 
 func NewIdentifierGeneratorWithUUID(formatString string, uuidGenerator uuid.UUIDConsumer) *IdentifierGenerator {
@@ -53,22 +63,172 @@ func NewIdentifierGeneratorWithUUID(formatString string, uuidGenerator uuid.UUID
 	return generator
 }
 
-func (generator *IdentifierGenerator) NewIdentifier(attributes IdentifierAttributes) string {
-	objectidValue := attributes.ObjectId
+func (generator *IdentifierGenerator) SetMappings(mappings *IdentifierMappings) {
+	generator.mappings = mappings
+}
 
-	if len(generator.pattern) > 0 {
-		objectidValue = strings.ReplaceAll(objectidValue, generator.pattern, generator.replacement)
+// Uses the given format string when the IdentifierAttributes Type is modelType
+func (generator *IdentifierGenerator) SetTypeFormat(modelType, formatString string) {
+	if generator.typeFormats == nil {
+		generator.typeFormats = make(map[string]string)
 	}
+	generator.typeFormats[modelType] = formatString
+}
 
-	replacer := strings.NewReplacer("%{id}", attributes.Id, "%{type}", attributes.Type, "%{default}", attributes.Default, "%{objectid}", objectidValue)
-	return generator.handleuuids(replacer.Replace(generator.formatString))
+func (generator *IdentifierGenerator) NewIdentifier(attributes IdentifierAttributes) string {
+	formatString := generator.formatString
+	if typeFormat, ok := generator.typeFormats[attributes.Type]; ok {
+		formatString = typeFormat
+	}
+
+	return generator.expand(formatString, func(name string) (string, bool) {
+		switch name {
+		case "id":
+			return attributes.Id, true
+		case "type":
+			return attributes.Type, true
+		case "default":
+			return attributes.Default, true
+		case "objectid":
+			return attributes.ObjectId, true
+		case "uuid":
+			return generator.NewUUID(), true
+		}
+		return "", false
+	})
 }
 
 func (generator *IdentifierGenerator) NewMessageIdentifier() string {
-	return generator.handleuuids(generator.formatString)
+	return generator.expand(generator.formatString, func(name string) (string, bool) {
+		if name == "uuid" {
+			return generator.NewUUID(), true
+		}
+		return "", false
+	})
+}
+
+// Replaces each %{...} expression of the format string. Expressions with an
+// unknown variable or an invalid syntax are kept unchanged
+func (generator *IdentifierGenerator) expand(formatString string, variable func(string) (string, bool)) string {
+	var builder strings.Builder
+
+	for {
+		start := strings.Index(formatString, "%{")
+		if start < 0 {
+			builder.WriteString(formatString)
+			return builder.String()
+		}
+		builder.WriteString(formatString[:start])
+
+		value, length, ok := generator.evaluate(formatString[start+2:], variable)
+		if !ok {
+			builder.WriteString("%{")
+			formatString = formatString[start+2:]
+			continue
+		}
+		builder.WriteString(value)
+		formatString = formatString[start+2+length:]
+	}
+}
+
+// Evaluates the expression at the beginning of s (after the "%{"). Returns the
+// value and the length of the expression with the closing brace
+func (generator *IdentifierGenerator) evaluate(s string, variable func(string) (string, bool)) (string, int, bool) {
+	end := strings.IndexAny(s, "|/}")
+	if end < 0 {
+		return "", 0, false
+	}
+	value, ok := variable(s[:end])
+	if !ok {
+		return "", 0, false
+	}
+	position := end
+
+	// Legacy %{objectid//pattern/replacement} substitution
+	if strings.HasPrefix(s[position:], "//") {
+		parts := strings.SplitN(s[position+2:], "/", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return "", 0, false
+		}
+		closing := strings.Index(parts[1], "}")
+		if closing < 0 || strings.Contains(parts[1][:closing], "/") {
+			return "", 0, false
+		}
+		value = strings.ReplaceAll(value, parts[0], parts[1][:closing])
+		return value, position + 2 + len(parts[0]) + 1 + closing + 1, true
+	}
+
+	for position < len(s) && s[position] == '|' {
+		position++
+		var length int
+		value, length, ok = generator.apply(s[position:], value)
+		if !ok {
+			return "", 0, false
+		}
+		position += length
+	}
+
+	if position >= len(s) || s[position] != '}' {
+		return "", 0, false
+	}
+	return value, position + 1, true
 }
 
-func (generator *IdentifierGenerator) handleuuids(s string) string {
-	re := regexp.MustCompile("%{uuid}")
-	return re.ReplaceAllStringFunc(s, func(string) string { return generator.NewUUID() })
+// Applies the function at the beginning of s. Returns the transformed value
+// and the length of the function definition
+func (generator *IdentifierGenerator) apply(s, value string) (string, int, bool) {
+	if strings.HasPrefix(s, "regexp") && len(s) > len("regexp") {
+		delimiter := s[len("regexp")]
+		parts := strings.SplitN(s[len("regexp")+1:], string(delimiter), 3)
+		if len(parts) != 3 {
+			return "", 0, false
+		}
+		pattern, err := compileIdentifierPattern(parts[0])
+		if err != nil {
+			return "", 0, false
+		}
+		return pattern.ReplaceAllString(value, parts[1]), len("regexp") + 1 + len(parts[0]) + 1 + len(parts[1]) + 1, true
+	}
+
+	length := strings.IndexAny(s, "|}")
+	if length < 0 {
+		return "", 0, false
+	}
+	name, argument := s[:length], ""
+	if separator := strings.Index(name, ":"); separator >= 0 {
+		name, argument = name[:separator], name[separator+1:]
+	}
+
+	switch name {
+	case "upcase":
+		return strings.ToUpper(value), length, true
+	case "downcase":
+		return strings.ToLower(value), length, true
+	case "prefix":
+		return argument + value, length, true
+	case "suffix":
+		return value + argument, length, true
+	case "lookup":
+		if generator.mappings != nil {
+			if mapped, ok := generator.mappings.Lookup(argument, value); ok {
+				return mapped, length, true
+			}
+		}
+		return value, length, true
+	}
+	return "", 0, false
+}
+
+var identifierPatterns sync.Map
+
+func compileIdentifierPattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, ok := identifierPatterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	identifierPatterns.Store(pattern, compiled)
+	return compiled, nil
 }
@@ -41,3 +41,78 @@ func Test_IdentifierGenerator_NewIdentifier_WithSubstitution(t *testing.T) {
 		t.Errorf("Identifier should be %v, got: %v", expected, identifier)
 	}
 }
+
+func Test_IdentifierGenerator_NewIdentifier_Functions(t *testing.T) {
+	mappings := NewIdentifierMappings()
+	mappings.SetTable("stops", map[string]string{"1234": "A"})
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{"%{objectid|upcase}", "STIF:STOPPOINT:Q:1234:"},
+		{"%{objectid|downcase}", "stif:stoppoint:q:1234:"},
+		{"%{type|prefix:SNCF:|suffix::LOC}", "SNCF:StopArea:LOC"},
+		{`%{objectid|regexp/^STIF:StopPoint:Q:(\d+):$/$1/}`, "1234"},
+		{`%{objectid|regexp#^STIF:(StopPoint|StopArea):Q:(\d{4}):$#${2}-${1}#}`, "1234-StopPoint"},
+		{`%{objectid|regexp/^STIF:StopPoint:Q:(\d+):$/$1/|lookup:stops|prefix:X:}`, "X:A"},
+		{`%{objectid|lookup:stops}`, "STIF:StopPoint:Q:1234:"},
+		{"%{objectid//StopPoint/StopArea}", "STIF:StopArea:Q:1234:"},
+		{"%{unknown|upcase}:%{objectid|unknown}:%{id", "%{unknown|upcase}:%{objectid|unknown}:%{id"},
+	}
+
+	for _, testCase := range testCases {
+		generator := NewIdentifierGenerator(testCase.format)
+		generator.SetMappings(mappings)
+		identifier := generator.NewIdentifier(IdentifierAttributes{Type: "StopArea", ObjectId: "STIF:StopPoint:Q:1234:"})
+		if identifier != testCase.expected {
+			t.Errorf("Wrong identifier for %v:\n got: %v\n want: %v", testCase.format, identifier, testCase.expected)
+		}
+	}
+}
+
+func Test_IdentifierGenerator_NewIdentifier_TypeFormat(t *testing.T) {
+	generator := NewIdentifierGenerator("%{type}:%{default}")
+	generator.SetTypeFormat("Line", "LINE:%{default|upcase}")
+
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "Line", Default: "a"}); identifier != "LINE:A" {
+		t.Errorf("Wrong identifier with type format:\n got: %v\n want: LINE:A", identifier)
+	}
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "StopArea", Default: "a"}); identifier != "StopArea:a" {
+		t.Errorf("Wrong identifier without type format:\n got: %v\n want: StopArea:a", identifier)
+	}
+}
+
+func Test_Partner_IdentifierGenerator_Settings(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	referential.IdentifierMappings().SetTable("lines", map[string]string{"1": "A"})
+
+	partner := referential.Partners().New("partner")
+	partner.Settings["generators.reference_identifier"] = "%{type}:%{default}"
+	partner.Settings["generators.reference_identifier.Line"] = "%{default|lookup:lines}"
+
+	generator := partner.IdentifierGenerator(REFERENCE_IDENTIFIER)
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "Line", Default: "1"}); identifier != "A" {
+		t.Errorf("Wrong Line identifier:\n got: %v\n want: A", identifier)
+	}
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "StopArea", Default: "1"}); identifier != "StopArea:1" {
+		t.Errorf("Wrong StopArea identifier:\n got: %v\n want: StopArea:1", identifier)
+	}
+	// The type formats are parsed again when the settings are defined
+	partner.SetDefinition(&APIPartner{
+		Slug: "partner",
+		Settings: map[string]string{
+			"generators.reference_identifier":          "%{type}:%{default}",
+			"generators.reference_identifier.StopArea": "Stop:%{default}",
+		},
+	})
+
+	generator = partner.IdentifierGenerator(REFERENCE_IDENTIFIER)
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "Line", Default: "1"}); identifier != "Line:1" {
+		t.Errorf("Wrong Line identifier after SetDefinition:\n got: %v\n want: Line:1", identifier)
+	}
+	if identifier := generator.NewIdentifier(IdentifierAttributes{Type: "StopArea", Default: "1"}); identifier != "Stop:1" {
+		t.Errorf("Wrong StopArea identifier after SetDefinition:\n got: %v\n want: Stop:1", identifier)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Named tables associating identifier values, used by the identifier
+// generators with the lookup function to translate the identifiers of a
+// partner into the scheme expected by another one.
+type IdentifierMappings struct {
+	mutex  *sync.RWMutex
+	tables map[string]map[string]string
+}
+
+func NewIdentifierMappings() *IdentifierMappings {
+	return &IdentifierMappings{
+		mutex:  &sync.RWMutex{},
+		tables: make(map[string]map[string]string),
+	}
+}
+
+func (mappings *IdentifierMappings) Lookup(table, value string) (string, bool) {
+	mappings.mutex.RLock()
+	defer mappings.mutex.RUnlock()
+
+	mapped, ok := mappings.tables[table][value]
+	return mapped, ok
+}
+
+// Returns the table names with their number of entries
+func (mappings *IdentifierMappings) Tables() map[string]int {
+	mappings.mutex.RLock()
+	defer mappings.mutex.RUnlock()
+
+	tables := make(map[string]int)
+	for name, table := range mappings.tables {
+		tables[name] = len(table)
+	}
+	return tables
+}
+
+// Returns a copy of the table
+func (mappings *IdentifierMappings) Table(name string) (map[string]string, bool) {
+	mappings.mutex.RLock()
+	defer mappings.mutex.RUnlock()
+
+	table, ok := mappings.tables[name]
+	if !ok {
+		return nil, false
+	}
+	result := make(map[string]string, len(table))
+	for from, to := range table {
+		result[from] = to
+	}
+	return result, true
+}
+
+func (mappings *IdentifierMappings) SetTable(name string, table map[string]string) {
+	mappings.mutex.Lock()
+	defer mappings.mutex.Unlock()
+
+	mappings.tables[name] = table
+}
+
+func (mappings *IdentifierMappings) DeleteTable(name string) bool {
+	mappings.mutex.Lock()
+	defer mappings.mutex.Unlock()
+
+	_, ok := mappings.tables[name]
+	delete(mappings.tables, name)
+	return ok
+}
+
+// Replaces the table with the content of a CSV file. Each line contains the
+// original identifier and the mapped one
+func (mappings *IdentifierMappings) LoadCSV(name string, reader io.Reader) (int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 2
+	csvReader.TrimLeadingSpace = true
+
+	table := make(map[string]string)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		from := strings.TrimSpace(record[0])
+		if from == "" {
+			return 0, fmt.Errorf("empty identifier on line %d", len(table)+1)
+		}
+		table[from] = strings.TrimSpace(record[1])
+	}
+
+	mappings.SetTable(name, table)
+	return len(table), nil
+}
+
+// Writes the table in the CSV format read by LoadCSV
+func (mappings *IdentifierMappings) WriteCSV(name string, writer io.Writer) error {
+	table, ok := mappings.Table(name)
+	if !ok {
+		return fmt.Errorf("unknown table %v", name)
+	}
+
+	froms := make([]string, 0, len(table))
+	for from := range table {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	csvWriter := csv.NewWriter(writer)
+	for _, from := range froms {
+		if err := csvWriter.Write([]string{from, table[from]}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func (mappings *IdentifierMappings) MarshalJSON() ([]byte, error) {
+	mappings.mutex.RLock()
+	defer mappings.mutex.RUnlock()
+
+	return json.Marshal(mappings.tables)
+}
+
+func (mappings *IdentifierMappings) UnmarshalJSON(data []byte) error {
+	tables := make(map[string]map[string]string)
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return err
+	}
+
+	mappings.mutex.Lock()
+	defer mappings.mutex.Unlock()
+
+	mappings.tables = tables
+	return nil
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_IdentifierMappings_LoadCSV(t *testing.T) {
+	mappings := NewIdentifierMappings()
+
+	count, err := mappings.LoadCSV("stops", strings.NewReader("STIF:1, SNCF:A\nSTIF:2,SNCF:B\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Wrong loaded mappings count:\n got: %v\n want: 2", count)
+	}
+	if mapped, ok := mappings.Lookup("stops", "STIF:1"); !ok || mapped != "SNCF:A" {
+		t.Errorf("Wrong mapping: %v %v", mapped, ok)
+	}
+	if _, ok := mappings.Lookup("stops", "STIF:3"); ok {
+		t.Errorf("Unknown value should not be mapped")
+	}
+
+	if _, err := mappings.LoadCSV("stops", strings.NewReader("STIF:1\n")); err == nil {
+		t.Errorf("Invalid CSV should be refused")
+	}
+	if _, ok := mappings.Lookup("stops", "STIF:1"); !ok {
+		t.Errorf("Table should be unchanged after an invalid import")
+	}
+}
+
+func Test_IdentifierMappings_JSON(t *testing.T) {
+	mappings := NewIdentifierMappings()
+	mappings.SetTable("lines", map[string]string{"1": "A"})
+
+	data, err := json.Marshal(mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewIdentifierMappings()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if mapped, ok := loaded.Lookup("lines", "1"); !ok || mapped != "A" {
+		t.Errorf("Wrong mapping after JSON round trip: %v %v", mapped, ok)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
 )
 
 type lastState interface {
@@ -189,6 +190,58 @@ func (sglc *generalMessageLastChange) Haschanged(situation *model.Situation) boo
 	return !situation.RecordedAt.Equal(sglc.recordedAt)
 }
 
+type facilityMonitoringLastChange struct {
+	subscription *Subscription
+
+	status            model.FacilityStatus
+	description       string
+	validityStartTime time.Time
+	validityEndTime   time.Time
+
+	// Last FacilityCondition sent, to notify the end of the condition when
+	// the Facility is deleted
+	condition *siri.SIRIFacilityCondition
+	// The end of the condition has been sent
+	ended bool
+}
+
+func (fmlc *facilityMonitoringLastChange) InitState(facility *model.Facility, sub *Subscription) {
+	fmlc.SetSubscription(sub)
+	fmlc.UpdateState(facility)
+}
+
+func (fmlc *facilityMonitoringLastChange) SetSubscription(sub *Subscription) {
+	fmlc.subscription = sub
+}
+
+func (fmlc *facilityMonitoringLastChange) UpdateState(facility *model.Facility) bool {
+	fmlc.status = facility.Status
+	fmlc.description = facility.Description
+	fmlc.validityStartTime = facility.ValidityStartTime
+	fmlc.validityEndTime = facility.ValidityEndTime
+	fmlc.ended = false
+	return true
+}
+
+// Returns the FacilityCondition sent when the Facility is deleted: its status
+// isn't known anymore
+func (fmlc *facilityMonitoringLastChange) DeletedCondition() *siri.SIRIFacilityCondition {
+	return &siri.SIRIFacilityCondition{
+		FacilityRef:   fmlc.condition.FacilityRef,
+		FacilityClass: fmlc.condition.FacilityClass,
+		Name:          fmlc.condition.Name,
+		StopPointRef:  fmlc.condition.StopPointRef,
+		Status:        string(model.FACILITY_STATUS_UNKNOWN),
+	}
+}
+
+func (fmlc *facilityMonitoringLastChange) Haschanged(facility *model.Facility) bool {
+	return facility.Status != fmlc.status ||
+		facility.Description != fmlc.description ||
+		!facility.ValidityStartTime.Equal(fmlc.validityStartTime) ||
+		!facility.ValidityEndTime.Equal(fmlc.validityEndTime)
+}
+
 type schedulesHandler struct{}
 
 func (sh *schedulesHandler) handleArrivalTime(sc, lssc *model.StopVisitSchedule, duration time.Duration) bool {
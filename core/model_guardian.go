@@ -16,6 +16,7 @@ type ModelGuardian struct {
 	uuid.UUIDConsumer
 
 	gmTimer     time.Time
+	fmTimer     time.Time
 	stop        chan struct{}
 	referential *Referential
 }
@@ -41,6 +42,7 @@ func (guardian *ModelGuardian) Stop() {
 func (guardian *ModelGuardian) Run() {
 	c := guardian.Clock().After(10 * time.Second)
 	guardian.gmTimer = guardian.Clock().Now()
+	guardian.fmTimer = guardian.Clock().Now()
 
 	for {
 		select {
@@ -58,6 +60,7 @@ func (guardian *ModelGuardian) Run() {
 			guardian.refreshLines()
 			guardian.simulateActualAttributes()
 			guardian.requestSituations()
+			guardian.requestFacilities()
 
 			c = guardian.Clock().After(10 * time.Second)
 		}
@@ -170,6 +173,18 @@ func (guardian *ModelGuardian) requestSituations() {
 	guardian.referential.CollectManager().UpdateSituation(situationUpdateRequest)
 }
 
+func (guardian *ModelGuardian) requestFacilities() {
+	defer monitoring.HandlePanic()
+
+	if guardian.Clock().Now().Before(guardian.fmTimer.Add(1 * time.Minute)) {
+		return
+	}
+
+	guardian.fmTimer = guardian.fmTimer.Add(1 * time.Minute)
+
+	guardian.referential.CollectManager().UpdateFacilities()
+}
+
 func (guardian *ModelGuardian) simulateActualAttributes() {
 	defer monitoring.HandlePanic()
 
@@ -0,0 +1,612 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+const (
+	NOTIFICATION_QUEUE = "notification-queue"
+
+	NOTIFY_STOP_MONITORING      = "NotifyStopMonitoring"
+	NOTIFY_GENERAL_MESSAGE      = "NotifyGeneralMessage"
+	NOTIFY_ESTIMATED_TIME_TABLE = "NotifyEstimatedTimetable"
+	NOTIFY_PRODUCTION_TIMETABLE = "NotifyProductionTimetable"
+	NOTIFY_FACILITY_MONITORING  = "NotifyFacilityMonitoring"
+
+	DEFAULT_NOTIFICATIONS_MAX_AGE  = 5 * time.Minute
+	DEFAULT_NOTIFICATIONS_MAX_SIZE = 1000
+
+	// Notifications are sent to the subscriber
+	DELIVERY_MODE_DIRECT = "direct"
+	// Subscriber is notified with a DataReady and fetches the notifications
+	// with a DataSupplyRequest
+	DELIVERY_MODE_FETCHED = "fetched"
+
+	// A new DataReady is sent when the notifications aren't fetched after this delay
+	DATA_READY_INTERVAL = 1 * time.Minute
+)
+
+type QueuedNotification struct {
+	Type                   string
+	SubscriptionIdentifier string
+	CreatedAt              time.Time
+	Attempts               int
+
+	StopMonitoring      *siri.SIRINotifyStopMonitoring      `json:",omitempty"`
+	GeneralMessage      *siri.SIRINotifyGeneralMessage      `json:",omitempty"`
+	EstimatedTimeTable  *siri.SIRINotifyEstimatedTimeTable  `json:",omitempty"`
+	ProductionTimetable *siri.SIRINotifyProductionTimetable `json:",omitempty"`
+	FacilityMonitoring  *siri.SIRINotifyFacilityMonitoring  `json:",omitempty"`
+}
+
+// Implemented by the subscription broadcasters to send a queued notification
+type notificationSender interface {
+	deliverNotification(notification *QueuedNotification) error
+}
+
+type NotificationQueueStatus struct {
+	Pending     int
+	Spilled     int
+	Delivered   int
+	Redelivered int
+	Failed      int
+	Expired     int
+	Dropped     int
+
+	OldestPendingAt time.Time `json:",omitempty"`
+}
+
+/*
+Outbound queue of the notifications sent to a Partner.
+
+Notifications are sent in the order they have been pushed. When a delivery
+fails, the other notifications of the same subscription are kept and the failed
+notification is sent again at the next Deliver call, so the notifications of a
+subscription are never reordered. The notifications of the other subscriptions
+are still delivered.
+
+With the fetched delivery mode (broadcast.delivery_mode), the notifications
+aren't sent but a DataReady is sent to the subscriber for each subscription
+with pending notifications. The subscriber fetches the notifications of a
+subscription with a DataSupplyRequest referencing its DataReady, or the
+notifications of all the subscriptions without NotificationRef. The DataReady
+is sent again every DATA_READY_INTERVAL while the notifications aren't fetched.
+
+Notifications older than broadcast.notifications.max_age are expired. When the
+queue contains more than broadcast.notifications.max_size notifications, the
+new ones are written in broadcast.notifications.spill_directory if defined,
+otherwise the oldest ones are dropped.
+*/
+type NotificationQueue struct {
+	clock.ClockConsumer
+
+	partner *Partner
+
+	mutex         *sync.Mutex
+	deliveryMutex *sync.Mutex
+
+	notifications []*QueuedNotification
+	senders       map[string]notificationSender
+	status        NotificationQueueStatus
+
+	// Last DataReady sent for each subscription
+	dataReadies        map[string]*dataReady
+	dataReadyScheduled bool
+}
+
+// DataReady sent for the pending notifications of a subscription
+type dataReady struct {
+	messageIdentifier string
+	// Zero when the notifications have been fetched since the DataReady
+	sentAt time.Time
+}
+
+func NewNotificationQueue(partner *Partner) *NotificationQueue {
+	return &NotificationQueue{
+		partner:       partner,
+		mutex:         &sync.Mutex{},
+		deliveryMutex: &sync.Mutex{},
+		senders:       make(map[string]notificationSender),
+		dataReadies:   make(map[string]*dataReady),
+	}
+}
+
+func (connector *siriConnector) NotificationQueue() *NotificationQueue {
+	if !connector.Partner().Context().IsDefined(NOTIFICATION_QUEUE) {
+		connector.Partner().Context().SetValue(NOTIFICATION_QUEUE, NewNotificationQueue(connector.Partner()))
+	}
+	return connector.Partner().Context().Value(NOTIFICATION_QUEUE).(*NotificationQueue)
+}
+
+func (queue *NotificationQueue) maxAge() time.Duration {
+	maxAge, _ := time.ParseDuration(queue.partner.Setting(BROADCAST_NOTIFICATIONS_MAX_AGE))
+	if maxAge <= 0 {
+		return DEFAULT_NOTIFICATIONS_MAX_AGE
+	}
+	return maxAge
+}
+
+func (queue *NotificationQueue) maxSize() int {
+	maxSize, _ := strconv.Atoi(queue.partner.Setting(BROADCAST_NOTIFICATIONS_MAX_SIZE))
+	if maxSize <= 0 {
+		return DEFAULT_NOTIFICATIONS_MAX_SIZE
+	}
+	return maxSize
+}
+
+func (queue *NotificationQueue) spillFile() string {
+	directory := queue.partner.Setting(BROADCAST_NOTIFICATIONS_SPILL_DIRECTORY)
+	if directory == "" {
+		return ""
+	}
+	return filepath.Join(directory, fmt.Sprintf("notifications-%v.jsonl", queue.partner.Id()))
+}
+
+func (queue *NotificationQueue) Push(notification *QueuedNotification, sender notificationSender) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	notification.CreatedAt = queue.Clock().Now()
+	queue.senders[notification.Type] = sender
+
+	// When notifications are already spilled, the new ones must follow them
+	if len(queue.notifications) < queue.maxSize() && queue.status.Spilled == 0 {
+		queue.notifications = append(queue.notifications, notification)
+		return
+	}
+
+	if spillFile := queue.spillFile(); spillFile != "" {
+		err := queue.spill(spillFile, []*QueuedNotification{notification})
+		if err == nil {
+			queue.status.Spilled++
+			return
+		}
+		logger.Log.Printf("Can't spill notification of partner %v: %v", queue.partner.Slug(), err)
+	}
+
+	queue.notifications = append(queue.notifications, notification)
+	for len(queue.notifications) > queue.maxSize() {
+		logger.Log.Printf("Drop %v notification of partner %v, queue is full", queue.notifications[0].Type, queue.partner.Slug())
+		queue.notifications = queue.notifications[1:]
+		queue.status.Dropped++
+	}
+}
+
+// Sends the pending notifications until the queue is empty. When a delivery
+// fails, the next notifications of the same subscription are kept in the queue
+func (queue *NotificationQueue) Deliver() {
+	queue.deliveryMutex.Lock()
+	defer queue.deliveryMutex.Unlock()
+
+	if queue.fetched() {
+		queue.notifyDataReady()
+		return
+	}
+
+	failedSubscriptions := make(map[string]struct{})
+	for {
+		notification, sender := queue.next(func(notification *QueuedNotification) bool {
+			_, failed := failedSubscriptions[notification.SubscriptionIdentifier]
+			return failed
+		})
+		if notification == nil {
+			return
+		}
+
+		err := sender.deliverNotification(notification)
+		queue.recordDelivery(notification, err)
+
+		queue.mutex.Lock()
+		notification.Attempts++
+		if err != nil {
+			queue.status.Failed++
+			queue.mutex.Unlock()
+			failedSubscriptions[notification.SubscriptionIdentifier] = struct{}{}
+			logger.Log.Debugf("Delivery of %v notification to partner %v failed (attempt %v): %v", notification.Type, queue.partner.Slug(), notification.Attempts, err)
+			continue
+		}
+
+		// The notification can have been dropped during the delivery
+		queue.remove(notification)
+		queue.status.Delivered++
+		if notification.Attempts > 1 {
+			queue.status.Redelivered++
+		}
+		queue.mutex.Unlock()
+	}
+}
+
+func (queue *NotificationQueue) fetched() bool {
+	return queue.partner.Setting(BROADCAST_DELIVERY_MODE) == DELIVERY_MODE_FETCHED
+}
+
+func (queue *NotificationQueue) maxFetchedNotifications() int {
+	max, _ := strconv.Atoi(queue.partner.Setting(BROADCAST_DATA_SUPPLY_MAX_NOTIFICATIONS))
+	return max
+}
+
+// Sends a DataReady for each subscription with pending notifications, unless
+// its last DataReady has been sent less than DATA_READY_INTERVAL ago and hasn't
+// been followed by a DataSupplyRequest
+func (queue *NotificationQueue) notifyDataReady() {
+	subscriptions := queue.pendingSubscriptions()
+	if len(subscriptions) == 0 {
+		return
+	}
+	defer queue.scheduleDataReady()
+
+	connector, ok := queue.partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER)
+	if !ok {
+		logger.Log.Debugf("Can't send DataReady to partner %v without subscription request dispatcher", queue.partner.Slug())
+		return
+	}
+	siriPartner := connector.(*SIRISubscriptionRequestDispatcher).SIRIPartner()
+
+	for _, subscriptionIdentifier := range subscriptions {
+		queue.mutex.Lock()
+		last, ok := queue.dataReadies[subscriptionIdentifier]
+		queue.mutex.Unlock()
+		if ok && !last.sentAt.IsZero() && queue.Clock().Since(last.sentAt) < DATA_READY_INTERVAL {
+			continue
+		}
+
+		notification := &siri.SIRIDataReadyNotification{
+			Address:           queue.partner.Address(),
+			ProducerRef:       queue.partner.ProducerRef(),
+			MessageIdentifier: queue.partner.IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+			RequestTimestamp:  queue.Clock().Now(),
+		}
+		if err := siriPartner.SOAPClient().DataReady(notification); err != nil {
+			logger.Log.Debugf("DataReady to partner %v failed: %v", queue.partner.Slug(), err)
+			return
+		}
+
+		queue.mutex.Lock()
+		queue.dataReadies[subscriptionIdentifier] = &dataReady{
+			messageIdentifier: notification.MessageIdentifier,
+			sentAt:            queue.Clock().Now(),
+		}
+		queue.mutex.Unlock()
+	}
+}
+
+// Returns the identifiers of the subscriptions with pending notifications
+func (queue *NotificationQueue) pendingSubscriptions() (subscriptions []string) {
+	found := make(map[string]struct{})
+	for {
+		notification, _ := queue.next(func(notification *QueuedNotification) bool {
+			_, ok := found[notification.SubscriptionIdentifier]
+			return ok
+		})
+		if notification == nil {
+			return
+		}
+		found[notification.SubscriptionIdentifier] = struct{}{}
+		subscriptions = append(subscriptions, notification.SubscriptionIdentifier)
+	}
+}
+
+// Calls Deliver after DATA_READY_INTERVAL, to send again the DataReady of the
+// notifications which haven't been fetched
+func (queue *NotificationQueue) scheduleDataReady() {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	if queue.dataReadyScheduled {
+		return
+	}
+	queue.dataReadyScheduled = true
+
+	after := queue.Clock().After(DATA_READY_INTERVAL)
+	go func() {
+		<-after
+
+		queue.mutex.Lock()
+		queue.dataReadyScheduled = false
+		queue.mutex.Unlock()
+
+		queue.Deliver()
+	}()
+}
+
+// Returns the identifier of the subscription notified by the DataReady with
+// the given MessageIdentifier
+func (queue *NotificationQueue) DataReadySubscription(ref string) (string, bool) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	for subscriptionIdentifier, dataReady := range queue.dataReadies {
+		if dataReady.messageIdentifier == ref {
+			return subscriptionIdentifier, true
+		}
+	}
+	return "", false
+}
+
+// Gives the pending notifications of the subscription, or of all the
+// subscriptions when subscriptionIdentifier is empty, in the order they have
+// been pushed, to deliver. With a positive max, gives at most max notifications
+// and true if other notifications are pending. The notifications are removed
+// from the queue only when deliver succeeds, otherwise they are fetched again
+// by the next call.
+func (queue *NotificationQueue) Fetch(subscriptionIdentifier string, max int, deliver func(notifications []*QueuedNotification, more bool) error) error {
+	queue.deliveryMutex.Lock()
+	defer queue.deliveryMutex.Unlock()
+
+	if max <= 0 {
+		max = queue.maxFetchedNotifications()
+	}
+
+	var notifications []*QueuedNotification
+	more := false
+	for {
+		notification, _ := queue.next(func(notification *QueuedNotification) bool {
+			return subscriptionIdentifier != "" && notification.SubscriptionIdentifier != subscriptionIdentifier
+		})
+		if notification == nil {
+			break
+		}
+		if max > 0 && len(notifications) >= max {
+			more = true
+			break
+		}
+
+		queue.mutex.Lock()
+		queue.remove(notification)
+		queue.mutex.Unlock()
+
+		notifications = append(notifications, notification)
+	}
+
+	err := deliver(notifications, more)
+
+	queue.mutex.Lock()
+	for _, notification := range notifications {
+		notification.Attempts++
+	}
+	if err != nil {
+		queue.notifications = append(notifications, queue.notifications...)
+		queue.status.Failed += len(notifications)
+		queue.mutex.Unlock()
+
+		for _, notification := range notifications {
+			queue.recordDelivery(notification, err)
+		}
+		logger.Log.Debugf("Fetch of %v notifications by partner %v failed: %v", len(notifications), queue.partner.Slug(), err)
+		return err
+	}
+
+	for _, notification := range notifications {
+		queue.status.Delivered++
+		if notification.Attempts > 1 {
+			queue.status.Redelivered++
+		}
+	}
+	for identifier, dataReady := range queue.dataReadies {
+		if subscriptionIdentifier == "" || identifier == subscriptionIdentifier {
+			dataReady.sentAt = time.Time{}
+		}
+	}
+	queue.mutex.Unlock()
+
+	for _, notification := range notifications {
+		queue.recordDelivery(notification, nil)
+	}
+	return nil
+}
+
+// Returns the XML of the deliveries of the notification
+func (notification *QueuedNotification) DeliveriesXML() ([]string, error) {
+	switch {
+	case notification.StopMonitoring != nil:
+		deliveries := []string{}
+		for _, delivery := range notification.StopMonitoring.Deliveries {
+			xml, err := delivery.BuildNotifyStopMonitoringDeliveryXML()
+			if err != nil {
+				return nil, err
+			}
+			deliveries = append(deliveries, xml)
+		}
+		return deliveries, nil
+	case notification.GeneralMessage != nil:
+		xml, err := notification.GeneralMessage.BuildNotifyGeneralMessageDeliveryXML()
+		if err != nil {
+			return nil, err
+		}
+		return []string{xml}, nil
+	case notification.EstimatedTimeTable != nil:
+		xml, err := notification.EstimatedTimeTable.BuildNotifyEstimatedTimetableDeliveryXML()
+		if err != nil {
+			return nil, err
+		}
+		return []string{xml}, nil
+	case notification.ProductionTimetable != nil:
+		xml, err := notification.ProductionTimetable.BuildNotifyProductionTimetableDeliveryXML()
+		if err != nil {
+			return nil, err
+		}
+		return []string{xml}, nil
+	case notification.FacilityMonitoring != nil:
+		xml, err := notification.FacilityMonitoring.BuildNotifyFacilityMonitoringDeliveryXML()
+		if err != nil {
+			return nil, err
+		}
+		return []string{xml}, nil
+	}
+	return nil, nil
+}
+
+// Saves the delivery result in the notification subscription, for diagnostics
+func (queue *NotificationQueue) recordDelivery(notification *QueuedNotification, err error) {
+	if queue.partner.Subscriptions() == nil {
+		return
+	}
+	subscription, ok := queue.partner.Subscriptions().FindByExternalId(notification.SubscriptionIdentifier)
+	if !ok {
+		return
+	}
+	if err != nil {
+		subscription.NotificationFailed()
+		return
+	}
+	subscription.NotificationSent(queue.Clock().Now())
+}
+
+// Returns the first notification to be delivered which isn't skipped, after
+// expiring the old ones
+func (queue *NotificationQueue) next(skip func(*QueuedNotification) bool) (*QueuedNotification, notificationSender) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	for i := 0; ; {
+		if i == len(queue.notifications) {
+			if queue.status.Spilled == 0 || len(queue.notifications) >= queue.maxSize() {
+				return nil, nil
+			}
+			queue.unspill()
+			if i == len(queue.notifications) {
+				return nil, nil
+			}
+		}
+
+		notification := queue.notifications[i]
+		if queue.Clock().Since(notification.CreatedAt) > queue.maxAge() {
+			logger.Log.Printf("Expire %v notification of partner %v created at %v", notification.Type, queue.partner.Slug(), notification.CreatedAt)
+			queue.removeAt(i)
+			queue.status.Expired++
+			continue
+		}
+
+		sender, ok := queue.senders[notification.Type]
+		if !ok {
+			logger.Log.Printf("Drop %v notification of partner %v without sender", notification.Type, queue.partner.Slug())
+			queue.removeAt(i)
+			queue.status.Dropped++
+			continue
+		}
+
+		if skip != nil && skip(notification) {
+			i++
+			continue
+		}
+		return notification, sender
+	}
+}
+
+// Removes the given notification from the queue. Must be called with the lock
+func (queue *NotificationQueue) remove(notification *QueuedNotification) {
+	for i := range queue.notifications {
+		if queue.notifications[i] == notification {
+			queue.removeAt(i)
+			return
+		}
+	}
+}
+
+func (queue *NotificationQueue) removeAt(i int) {
+	if i == 0 {
+		queue.notifications = queue.notifications[1:]
+		return
+	}
+	queue.notifications = append(queue.notifications[:i], queue.notifications[i+1:]...)
+}
+
+func (queue *NotificationQueue) spill(spillFile string, notifications []*QueuedNotification) error {
+	file, err := os.OpenFile(spillFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, notification := range notifications {
+		if err := encoder.Encode(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Loads the spilled notifications in memory, after the pending ones. Must be
+// called with the lock
+func (queue *NotificationQueue) unspill() {
+	spillFile := queue.spillFile()
+	queue.status.Spilled = 0
+	if spillFile == "" {
+		return
+	}
+
+	file, err := os.Open(spillFile)
+	if err != nil {
+		logger.Log.Printf("Can't read spilled notifications of partner %v: %v", queue.partner.Slug(), err)
+		return
+	}
+
+	var notifications []*QueuedNotification
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		notification := &QueuedNotification{}
+		if err := json.Unmarshal(scanner.Bytes(), notification); err != nil {
+			logger.Log.Printf("Ignore invalid spilled notification of partner %v: %v", queue.partner.Slug(), err)
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Log.Printf("Can't read spilled notifications of partner %v: %v", queue.partner.Slug(), err)
+	}
+	file.Close()
+	os.Remove(spillFile)
+
+	// The spilled notifications follow the ones still in memory
+	available := queue.maxSize() - len(queue.notifications)
+	if len(notifications) <= available {
+		queue.notifications = append(queue.notifications, notifications...)
+		return
+	}
+
+	queue.notifications = append(queue.notifications, notifications[:available]...)
+	remaining := notifications[available:]
+	if err := queue.spill(spillFile, remaining); err != nil {
+		logger.Log.Printf("Can't spill notifications of partner %v: %v", queue.partner.Slug(), err)
+		queue.status.Dropped += len(remaining)
+		return
+	}
+	queue.status.Spilled = len(remaining)
+}
+
+// Removes all the pending notifications
+func (queue *NotificationQueue) Clear() {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	queue.notifications = nil
+	if queue.status.Spilled != 0 {
+		os.Remove(queue.spillFile())
+		queue.status.Spilled = 0
+	}
+}
+
+func (queue *NotificationQueue) Status() NotificationQueueStatus {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	status := queue.status
+	status.Pending = len(queue.notifications) + queue.status.Spilled
+	if len(queue.notifications) != 0 {
+		status.OldestPendingAt = queue.notifications[0].CreatedAt
+	}
+	return status
+}
@@ -0,0 +1,335 @@
+package core
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type fakeNotificationSender struct {
+	fail                bool
+	failedSubscriptions map[string]bool
+	delivered           []string
+}
+
+func (sender *fakeNotificationSender) deliverNotification(notification *QueuedNotification) error {
+	if sender.fail || sender.failedSubscriptions[notification.SubscriptionIdentifier] {
+		return errors.New("delivery failed")
+	}
+	sender.delivered = append(sender.delivered, notification.GeneralMessage.ResponseMessageIdentifier)
+	return nil
+}
+
+func newTestQueuedNotification(identifier string) *QueuedNotification {
+	return &QueuedNotification{
+		Type:                   NOTIFY_GENERAL_MESSAGE,
+		SubscriptionIdentifier: "subscription",
+		GeneralMessage:         &siri.SIRINotifyGeneralMessage{ResponseMessageIdentifier: identifier},
+	}
+}
+
+func checkDelivered(t *testing.T, sender *fakeNotificationSender, expected ...string) {
+	t.Helper()
+	if len(sender.delivered) != len(expected) {
+		t.Fatalf("Wrong delivered notifications:\n got: %v\n want: %v", sender.delivered, expected)
+	}
+	for i := range expected {
+		if sender.delivered[i] != expected[i] {
+			t.Errorf("Wrong delivered notifications:\n got: %v\n want: %v", sender.delivered, expected)
+		}
+	}
+}
+
+func Test_NotificationQueue_Redelivery(t *testing.T) {
+	queue := NewNotificationQueue(NewPartner())
+	sender := &fakeNotificationSender{fail: true}
+
+	queue.Push(newTestQueuedNotification("1"), sender)
+	queue.Deliver()
+	queue.Push(newTestQueuedNotification("2"), sender)
+	queue.Deliver()
+
+	if status := queue.Status(); status.Pending != 2 || status.Failed != 2 {
+		t.Errorf("Wrong queue status after failures: %#v", status)
+	}
+	checkDelivered(t, sender)
+
+	sender.fail = false
+	queue.Deliver()
+
+	checkDelivered(t, sender, "1", "2")
+	if status := queue.Status(); status.Pending != 0 || status.Delivered != 2 || status.Redelivered != 1 {
+		t.Errorf("Wrong queue status after redelivery: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_FailedSubscription(t *testing.T) {
+	queue := NewNotificationQueue(NewPartner())
+	sender := &fakeNotificationSender{failedSubscriptions: map[string]bool{"failed": true}}
+
+	for _, identifier := range []string{"1", "2", "3", "4"} {
+		notification := newTestQueuedNotification(identifier)
+		if identifier == "1" || identifier == "3" {
+			notification.SubscriptionIdentifier = "failed"
+		}
+		queue.Push(notification, sender)
+	}
+	queue.Deliver()
+
+	checkDelivered(t, sender, "2", "4")
+	if status := queue.Status(); status.Pending != 2 || status.Failed != 1 {
+		t.Errorf("Wrong queue status after failure: %#v", status)
+	}
+
+	sender.failedSubscriptions = nil
+	queue.Deliver()
+
+	checkDelivered(t, sender, "2", "4", "1", "3")
+}
+
+func Test_NotificationQueue_PartnerStop(t *testing.T) {
+	partner := createTestPartnerManager().New("partner")
+	queue := NewNotificationQueue(partner)
+	partner.Context().SetValue(NOTIFICATION_QUEUE, queue)
+
+	queue.Push(newTestQueuedNotification("1"), &fakeNotificationSender{fail: true})
+	partner.Stop()
+
+	if status := queue.Status(); status.Pending != 1 {
+		t.Errorf("Pending notifications should be kept when the partner is stopped: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_Expiry(t *testing.T) {
+	fakeClock := clock.NewFakeClock()
+	partner := NewPartner()
+	partner.Settings[BROADCAST_NOTIFICATIONS_MAX_AGE] = "1m"
+
+	queue := NewNotificationQueue(partner)
+	queue.SetClock(fakeClock)
+	sender := &fakeNotificationSender{fail: true}
+
+	queue.Push(newTestQueuedNotification("1"), sender)
+	fakeClock.Advance(2 * time.Minute)
+	queue.Push(newTestQueuedNotification("2"), sender)
+
+	sender.fail = false
+	queue.Deliver()
+
+	checkDelivered(t, sender, "2")
+	if status := queue.Status(); status.Expired != 1 {
+		t.Errorf("Wrong queue status after expiry: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_Drop(t *testing.T) {
+	partner := NewPartner()
+	partner.Settings[BROADCAST_NOTIFICATIONS_MAX_SIZE] = "2"
+
+	queue := NewNotificationQueue(partner)
+	sender := &fakeNotificationSender{}
+
+	for _, identifier := range []string{"1", "2", "3"} {
+		queue.Push(newTestQueuedNotification(identifier), sender)
+	}
+	queue.Deliver()
+
+	checkDelivered(t, sender, "2", "3")
+	if status := queue.Status(); status.Dropped != 1 {
+		t.Errorf("Wrong queue status after drop: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_Spill(t *testing.T) {
+	directory, err := ioutil.TempDir("", "notifications")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	partner := NewPartner()
+	partner.Settings[BROADCAST_NOTIFICATIONS_MAX_SIZE] = "2"
+	partner.Settings[BROADCAST_NOTIFICATIONS_SPILL_DIRECTORY] = directory
+
+	queue := NewNotificationQueue(partner)
+	sender := &fakeNotificationSender{}
+
+	for _, identifier := range []string{"1", "2", "3", "4", "5"} {
+		queue.Push(newTestQueuedNotification(identifier), sender)
+	}
+	if status := queue.Status(); status.Pending != 5 || status.Spilled != 3 {
+		t.Errorf("Wrong queue status after spill: %#v", status)
+	}
+
+	queue.Deliver()
+
+	checkDelivered(t, sender, "1", "2", "3", "4", "5")
+	if status := queue.Status(); status.Pending != 0 || status.Spilled != 0 || status.Dropped != 0 {
+		t.Errorf("Wrong queue status after delivery: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_Fetched(t *testing.T) {
+	dataReadyCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.Contains(string(body), "DataReady") {
+			dataReadyCount++
+		}
+	}))
+	defer server.Close()
+
+	partner := NewPartner()
+	partner.Settings[REMOTE_URL] = server.URL
+	partner.Settings[BROADCAST_DELIVERY_MODE] = DELIVERY_MODE_FETCHED
+	partner.Settings[BROADCAST_DATA_SUPPLY_MAX_NOTIFICATIONS] = "2"
+	partner.CreateSubscriptionRequestDispatcher()
+
+	fakeClock := clock.NewFakeClock()
+	queue := NewNotificationQueue(partner)
+	queue.SetClock(fakeClock)
+	sender := &fakeNotificationSender{}
+
+	queue.Push(newTestQueuedNotification("1"), sender)
+	queue.Push(newTestQueuedNotification("2"), sender)
+	queue.Push(newTestQueuedNotification("3"), sender)
+	queue.Deliver()
+	queue.Deliver()
+
+	checkDelivered(t, sender)
+	if dataReadyCount != 1 {
+		t.Errorf("One DataReady should be sent, got: %v", dataReadyCount)
+	}
+
+	var notifications []*QueuedNotification
+	var more bool
+	fetch := func(notificationsFetched []*QueuedNotification, moreData bool) error {
+		notifications, more = notificationsFetched, moreData
+		return nil
+	}
+
+	queue.Fetch("", 0, fetch)
+	if len(notifications) != 2 || !more {
+		t.Errorf("Fetch should return 2 notifications and more data, got: %v %v", len(notifications), more)
+	}
+	if notifications[0].GeneralMessage.ResponseMessageIdentifier != "1" {
+		t.Errorf("Notifications should be fetched in order")
+	}
+
+	// A new DataReady is sent after the fetch
+	queue.Deliver()
+	if dataReadyCount != 2 {
+		t.Errorf("A new DataReady should be sent after a fetch, got: %v", dataReadyCount)
+	}
+
+	queue.Fetch("", 0, fetch)
+	if len(notifications) != 1 || more {
+		t.Errorf("Fetch should return the last notification, got: %v %v", len(notifications), more)
+	}
+	if status := queue.Status(); status.Pending != 0 || status.Delivered != 3 {
+		t.Errorf("Wrong queue status after fetch: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_Fetched_WriteError(t *testing.T) {
+	partner := NewPartner()
+	partner.Settings[BROADCAST_DELIVERY_MODE] = DELIVERY_MODE_FETCHED
+
+	queue := NewNotificationQueue(partner)
+	queue.SetClock(clock.NewFakeClock())
+	sender := &fakeNotificationSender{}
+
+	queue.Push(newTestQueuedNotification("1"), sender)
+	queue.Push(newTestQueuedNotification("2"), sender)
+
+	err := queue.Fetch("", 0, func(notifications []*QueuedNotification, more bool) error {
+		return errors.New("write failed")
+	})
+	if err == nil {
+		t.Errorf("Fetch should return the write error")
+	}
+	if status := queue.Status(); status.Pending != 2 || status.Delivered != 0 || status.Failed != 2 {
+		t.Errorf("Notifications should be kept after a write error: %#v", status)
+	}
+
+	var identifiers []string
+	queue.Fetch("", 0, func(notifications []*QueuedNotification, more bool) error {
+		for _, notification := range notifications {
+			identifiers = append(identifiers, notification.GeneralMessage.ResponseMessageIdentifier)
+		}
+		return nil
+	})
+	if strings.Join(identifiers, ",") != "1,2" {
+		t.Errorf("Notifications should be fetched again in order, got: %v", identifiers)
+	}
+	if status := queue.Status(); status.Pending != 0 || status.Delivered != 2 || status.Redelivered != 2 {
+		t.Errorf("Wrong queue status after fetch: %#v", status)
+	}
+}
+
+func Test_NotificationQueue_FetchedSubscriptions(t *testing.T) {
+	var dataReadyCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.Contains(string(body), "DataReady") {
+			atomic.AddInt32(&dataReadyCount, 1)
+		}
+	}))
+	defer server.Close()
+
+	partner := NewPartner()
+	partner.Settings[REMOTE_URL] = server.URL
+	partner.Settings[BROADCAST_DELIVERY_MODE] = DELIVERY_MODE_FETCHED
+	partner.CreateSubscriptionRequestDispatcher()
+
+	fakeClock := clock.NewFakeClock()
+	queue := NewNotificationQueue(partner)
+	queue.SetClock(fakeClock)
+	sender := &fakeNotificationSender{}
+
+	for _, identifier := range []string{"a1", "b1", "a2"} {
+		notification := newTestQueuedNotification(identifier)
+		notification.SubscriptionIdentifier = identifier[:1]
+		queue.Push(notification, sender)
+	}
+	queue.Deliver()
+
+	if count := atomic.LoadInt32(&dataReadyCount); count != 2 {
+		t.Errorf("A DataReady should be sent for each subscription, got: %v", count)
+	}
+
+	subscriptionIdentifier, ok := queue.DataReadySubscription(queue.dataReadies["a"].messageIdentifier)
+	if !ok || subscriptionIdentifier != "a" {
+		t.Fatalf("DataReady should reference its subscription, got: %v %v", subscriptionIdentifier, ok)
+	}
+
+	var identifiers []string
+	queue.Fetch("a", 0, func(notifications []*QueuedNotification, more bool) error {
+		for _, notification := range notifications {
+			identifiers = append(identifiers, notification.GeneralMessage.ResponseMessageIdentifier)
+		}
+		return nil
+	})
+	if strings.Join(identifiers, ",") != "a1,a2" {
+		t.Errorf("Only the subscription notifications should be fetched, got: %v", identifiers)
+	}
+
+	// The DataReady of the notifications not fetched is sent again
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(DATA_READY_INTERVAL)
+	for i := 0; i < 100 && atomic.LoadInt32(&dataReadyCount) < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count := atomic.LoadInt32(&dataReadyCount); count != 3 {
+		t.Errorf("DataReady should be sent again for the pending subscription, got: %v", count)
+	}
+}
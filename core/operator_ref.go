@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+// Returns the Operator reference of the StopVisit, or the one of its
+// VehicleJourney when the StopVisit has none
+func operatorReference(stopVisit *model.StopVisit, vehicleJourney *model.VehicleJourney) (model.Reference, bool) {
+	if stopVisit != nil {
+		if ref, ok := stopVisit.Reference("OperatorRef"); ok && ref.ObjectId != nil {
+			return ref, true
+		}
+	}
+	if vehicleJourney != nil {
+		if ref, ok := vehicleJourney.Reference("OperatorRef"); ok && ref.ObjectId != nil {
+			return ref, true
+		}
+	}
+	return model.Reference{}, false
+}
+
+// Returns the OperatorRef to broadcast for the given Operator reference. The
+// Operator ObjectID with the given kind is used when defined, the collected
+// value otherwise.
+func resolveOperatorRef(tx *model.Transaction, reference model.Reference, objectidKind string) string {
+	operator, ok := tx.Model().Operators().FindByObjectId(*reference.ObjectId)
+	if !ok {
+		return reference.ObjectId.Value()
+	}
+	obj, ok := operator.ObjectID(objectidKind)
+	if !ok {
+		return reference.ObjectId.Value()
+	}
+	return obj.Value()
+}
+
+// Returns the broadcasted OperatorRef of the StopVisit or of its VehicleJourney
+func broadcastedOperatorRef(tx *model.Transaction, stopVisit *model.StopVisit, vehicleJourney *model.VehicleJourney, objectidKind string) (string, bool) {
+	reference, ok := operatorReference(stopVisit, vehicleJourney)
+	if !ok {
+		return "", false
+	}
+	return resolveOperatorRef(tx, reference, objectidKind), true
+}
@@ -9,12 +9,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"bitbucket.org/enroute-mobi/ara/audit"
 	"bitbucket.org/enroute-mobi/ara/cache"
 	"bitbucket.org/enroute-mobi/ara/logger"
 	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
 	"bitbucket.org/enroute-mobi/ara/state"
 	"bitbucket.org/enroute-mobi/ara/uuid"
 )
@@ -38,15 +40,21 @@ const (
 	NOTIFICATIONS_REMOTE_URL     = "notifications.remote_url"
 	SUBSCRIPTIONS_REMOTE_URL     = "subscriptions.remote_url"
 
-	COLLECT_PRIORITY                 = "collect.priority"
-	COLLECT_INCLUDE_LINES            = "collect.include_lines"
-	COLLECT_INCLUDE_STOP_AREAS       = "collect.include_stop_areas"
-	COLLECT_EXCLUDE_STOP_AREAS       = "collect.exclude_stop_areas"
-	COLLECT_USE_DISCOVERED_SA        = "collect.use_discovered_stop_areas"
-	COLLECT_SUBSCRIPTIONS_PERSISTENT = "collect.subscriptions.persistent"
-	COLLECT_FILTER_GENERAL_MESSAGES  = "collect.filter_general_messages"
-
-	DISCOVERY_INTERVAL = "discovery_interval"
+	COLLECT_PRIORITY                        = "collect.priority"
+	COLLECT_INCLUDE_LINES                   = "collect.include_lines"
+	COLLECT_INCLUDE_STOP_AREAS              = "collect.include_stop_areas"
+	COLLECT_EXCLUDE_STOP_AREAS              = "collect.exclude_stop_areas"
+	COLLECT_INCLUDE_OPERATORS               = "collect.include_operators"
+	COLLECT_EXCLUDE_OPERATORS               = "collect.exclude_operators"
+	COLLECT_USE_DISCOVERED_SA               = "collect.use_discovered_stop_areas"
+	COLLECT_SUBSCRIPTIONS_PERSISTENT        = "collect.subscriptions.persistent"
+	COLLECT_SUBSCRIPTIONS_RENEWAL_MARGIN    = "collect.subscriptions.renewal_margin"
+	COLLECT_SUBSCRIPTIONS_RESUBSCRIBE_AFTER = "collect.subscriptions.resubscribe_after"
+	COLLECT_FILTER_GENERAL_MESSAGES         = "collect.filter_general_messages"
+
+	DISCOVERY_INTERVAL             = "discovery_interval"
+	DISCOVERY_INTERVAL_LINES       = "discovery_interval.lines"
+	DISCOVERY_INTERVAL_STOP_POINTS = "discovery_interval.stop_points"
 
 	BROADCAST_SUBSCRIPTIONS_PERSISTENT         = "broadcast.subscriptions.persistent"
 	BROADCAST_REWRITE_JOURNEY_PATTERN_REF      = "broadcast.rewrite_journey_pattern_ref"
@@ -54,6 +62,16 @@ const (
 	BROADCAST_NO_DATAFRAMEREF_REWRITING_FROM   = "broadcast.no_dataframeref_rewriting_from"
 	BROADCAST_GZIP_GTFS                        = "broadcast.gzip_gtfs"
 	BROADCAST_GTFS_CACHE_TIMEOUT               = "broadcast.gtfs.cache_timeout"
+	BROADCAST_GTFS_AGENCY_URL                  = "broadcast.gtfs.agency_url"
+	BROADCAST_NOTIFICATIONS_MAX_AGE            = "broadcast.notifications.max_age"
+	BROADCAST_NOTIFICATIONS_MAX_SIZE           = "broadcast.notifications.max_size"
+	BROADCAST_NOTIFICATIONS_SPILL_DIRECTORY    = "broadcast.notifications.spill_directory"
+	BROADCAST_DELIVERY_MODE                    = "broadcast.delivery_mode"
+	BROADCAST_DATA_SUPPLY_MAX_NOTIFICATIONS    = "broadcast.data_supply.max_notifications"
+	BROADCAST_SUBSCRIPTIONS_MAXIMUM_DURATION   = "broadcast.subscriptions.maximum_duration"
+	BROADCAST_SUBSCRIPTIONS_MAXIMUM_COUNT      = "broadcast.subscriptions.maximum_count"
+	BROADCAST_STOP_MONITORING_DETAIL_LEVEL     = "broadcast.stop_monitoring.detail_level"
+	BROADCAST_STOP_MONITORING_FIELDS           = "broadcast.stop_monitoring.fields"
 
 	IGNORE_STOP_WITHOUT_LINE        = "ignore_stop_without_line"
 	GENEREAL_MESSAGE_REQUEST_2      = "generalMessageRequest.version2.2"
@@ -64,6 +82,34 @@ const (
 
 	CACHE_TIMEOUT = "cache_timeout"
 
+	RATE_LIMIT_PER_MINUTE  = "rate_limit.per_minute"
+	RATE_LIMIT_BURST       = "rate_limit.burst"
+	RATE_LIMIT_DAILY_QUOTA = "rate_limit.daily_quota"
+
+	SIRI_TIMEOUT                   = "siri.timeout"
+	SIRI_SUBSCRIPTION_TIMEOUT      = "siri.subscription_timeout"
+	SIRI_CHECK_STATUS_TIMEOUT      = "siri.check_status_timeout"
+	SIRI_NOTIFICATION_TIMEOUT      = "siri.notification_timeout"
+	SIRI_RETRIES                   = "siri.retries"
+	SIRI_RETRY_BACKOFF             = "siri.retry_backoff"
+	SIRI_CIRCUIT_BREAKER_THRESHOLD = "siri.circuit_breaker.threshold"
+	SIRI_CIRCUIT_BREAKER_TIMEOUT   = "siri.circuit_breaker.timeout"
+
+	TLS_CLIENT_CERTIFICATE = "tls.client_certificate"
+	TLS_CLIENT_KEY         = "tls.client_key"
+	TLS_CA_BUNDLE          = "tls.ca_bundle"
+	TLS_SERVER_NAME        = "tls.server_name"
+
+	REMOTE_AUTHENTICATION_TYPE          = "remote_authentication.type"
+	REMOTE_AUTHENTICATION_USERNAME      = "remote_authentication.username"
+	REMOTE_AUTHENTICATION_PASSWORD      = "remote_authentication.password"
+	REMOTE_AUTHENTICATION_HEADER_NAME   = "remote_authentication.header_name"
+	REMOTE_AUTHENTICATION_HEADER_VALUE  = "remote_authentication.header_value"
+	REMOTE_AUTHENTICATION_TOKEN_URL     = "remote_authentication.token_url"
+	REMOTE_AUTHENTICATION_CLIENT_ID     = "remote_authentication.client_id"
+	REMOTE_AUTHENTICATION_CLIENT_SECRET = "remote_authentication.client_secret"
+	REMOTE_AUTHENTICATION_SCOPE         = "remote_authentication.scope"
+
 	// Generators
 	MESSAGE_IDENTIFIER             = "message_identifier"
 	RESPONSE_MESSAGE_IDENTIFIER    = "response_message_identifier"
@@ -102,6 +148,8 @@ type Partners interface {
 type PartnerStatus struct {
 	OperationnalStatus OperationnalStatus
 	ServiceStartedAt   time.Time
+
+	CertificateExpiresAt *time.Time `json:",omitempty"`
 }
 
 type Partner struct {
@@ -117,16 +165,25 @@ type Partner struct {
 	ConnectorTypes []string
 	Settings       map[string]string
 
-	connectors          map[string]Connector
-	discoveredStopAreas map[string]struct{}
-	startedAt           time.Time
-	lastDiscovery       time.Time
-	lastPush            time.Time
-	context             Context
-	subscriptionManager Subscriptions
-	manager             Partners
+	connectors              map[string]Connector
+	discoveredStopAreas     map[string]struct{}
+	announcedStopPoints     map[string]struct{}
+	announcedLines          map[string]struct{}
+	startedAt               time.Time
+	lastLinesDiscovery      time.Time
+	lastStopPointsDiscovery time.Time
+	lastPush                time.Time
+	context                 Context
+	heartbeatScheduler      *HeartbeatScheduler
+	subscriptionManager     Subscriptions
+	manager                 Partners
 
-	gtfsCache *cache.CacheTable
+	gtfsCache   *cache.CacheTable
+	rateLimiter *RateLimiter
+
+	// generators.<name>.<Type> settings, parsed once when the settings are
+	// defined
+	generatorTypeFormats atomic.Value
 }
 
 type ByPriority []*Partner
@@ -178,6 +235,12 @@ func (partner *APIPartner) Validate() bool {
 		factory.Validate(partner)
 	}
 
+	partner.validateTLSSettings()
+	partner.validateRemoteAuthentication()
+	partner.validateDeliveryMode()
+	partner.validateRateLimit()
+	partner.validateStopMonitoringDetailLevel()
+
 	// Check Slug uniqueness
 	for _, existingPartner := range partner.manager.FindAll() {
 		if existingPartner.id != partner.Id && existingPartner.slug == partner.Slug {
@@ -198,6 +261,69 @@ func (partner *APIPartner) Validate() bool {
 	return len(partner.Errors) == 0
 }
 
+// Checks the TLS files can be loaded
+func (partner *APIPartner) validateTLSSettings() {
+	certificate := siri.TLSSettings{
+		ClientCertificate: partner.Settings[TLS_CLIENT_CERTIFICATE],
+		ClientKey:         partner.Settings[TLS_CLIENT_KEY],
+	}
+	if !certificate.IsZero() {
+		if _, _, err := certificate.Config(); err != nil {
+			partner.Errors.AddSettingError(TLS_CLIENT_CERTIFICATE, err.Error())
+		}
+	}
+
+	if bundle := partner.Settings[TLS_CA_BUNDLE]; bundle != "" {
+		if _, _, err := (siri.TLSSettings{CABundle: bundle}).Config(); err != nil {
+			partner.Errors.AddSettingError(TLS_CA_BUNDLE, err.Error())
+		}
+	}
+}
+
+func (partner *APIPartner) validateRemoteAuthentication() {
+	switch partner.Settings[REMOTE_AUTHENTICATION_TYPE] {
+	case "":
+	case siri.AUTHENTICATION_BASIC:
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_USERNAME)
+	case siri.AUTHENTICATION_HEADER:
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_HEADER_NAME)
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_HEADER_VALUE)
+	case siri.AUTHENTICATION_OAUTH2:
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_TOKEN_URL)
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_CLIENT_ID)
+		partner.ValidatePresenceOfSetting(REMOTE_AUTHENTICATION_CLIENT_SECRET)
+	default:
+		partner.Errors.AddSettingError(REMOTE_AUTHENTICATION_TYPE, ERROR_UNKNOWN_VALUE)
+	}
+}
+
+func (partner *APIPartner) validateDeliveryMode() {
+	switch partner.Settings[BROADCAST_DELIVERY_MODE] {
+	case "", DELIVERY_MODE_DIRECT, DELIVERY_MODE_FETCHED:
+	default:
+		partner.Errors.AddSettingError(BROADCAST_DELIVERY_MODE, ERROR_UNKNOWN_VALUE)
+	}
+}
+
+func (partner *APIPartner) validateRateLimit() {
+	for _, setting := range []string{RATE_LIMIT_PER_MINUTE, RATE_LIMIT_BURST, RATE_LIMIT_DAILY_QUOTA} {
+		value, ok := partner.Settings[setting]
+		if !ok {
+			continue
+		}
+		if i, err := strconv.Atoi(value); err != nil || i <= 0 {
+			partner.Errors.AddSettingError(setting, ERROR_UNKNOWN_VALUE)
+		}
+	}
+}
+
+func (partner *APIPartner) validateStopMonitoringDetailLevel() {
+	detailLevel, ok := partner.Settings[BROADCAST_STOP_MONITORING_DETAIL_LEVEL]
+	if ok && !ValidSMDetailLevel(detailLevel) {
+		partner.Errors.AddSettingError(BROADCAST_STOP_MONITORING_DETAIL_LEVEL, ERROR_UNKNOWN_VALUE)
+	}
+}
+
 func (partner *APIPartner) credentials() string {
 	return fmt.Sprintf("%v,%v", partner.Settings[LOCAL_CREDENTIAL], partner.Settings[LOCAL_CREDENTIALS])
 }
@@ -277,11 +403,15 @@ func NewPartner() *Partner {
 		gtfsCache: cache.NewCacheTable(),
 	}
 	partner.subscriptionManager = NewMemorySubscriptions(partner)
+	partner.rateLimiter = NewRateLimiter(partner)
 
 	return partner
 }
 
 func (partner *Partner) Referential() *Referential {
+	if partner.manager == nil {
+		return nil
+	}
 	return partner.manager.Referential()
 }
 
@@ -313,6 +443,11 @@ func (partner *Partner) GtfsCache() *cache.CacheTable {
 	return partner.gtfsCache
 }
 
+// Limits the requests received from the partner
+func (partner *Partner) RateLimiter() *RateLimiter {
+	return partner.rateLimiter
+}
+
 func (partner *Partner) Credentials() string {
 	_, ok := partner.Settings[LOCAL_CREDENTIAL]
 	_, ok2 := partner.Settings[LOCAL_CREDENTIALS]
@@ -323,19 +458,63 @@ func (partner *Partner) Credentials() string {
 }
 
 func (partner *Partner) IdentifierGenerator(generatorName string) *IdentifierGenerator {
-	formatString := partner.Setting(fmt.Sprintf("generators.%v", generatorName))
-	if formatString == "" {
-		formatString = defaultIdentifierGenerators[generatorName]
-	}
-	return NewIdentifierGeneratorWithUUID(formatString, partner.UUIDConsumer)
+	return partner.IdentifierGeneratorWithDefault(generatorName, defaultIdentifierGenerators[generatorName])
 }
 
+// The generator uses the setting generators.<name> as format string, and
+// generators.<name>.<Type> for the identifiers of the given model type
 func (partner *Partner) IdentifierGeneratorWithDefault(generatorName, defaultFormat string) *IdentifierGenerator {
 	formatString := partner.Setting(fmt.Sprintf("generators.%v", generatorName))
 	if formatString == "" {
 		formatString = defaultFormat
 	}
-	return NewIdentifierGeneratorWithUUID(formatString, partner.UUIDConsumer)
+	generator := NewIdentifierGeneratorWithUUID(formatString, partner.UUIDConsumer)
+
+	for modelType, typeFormat := range partner.identifierGeneratorTypeFormats()[generatorName] {
+		generator.SetTypeFormat(modelType, typeFormat)
+	}
+
+	if partner.manager != nil {
+		if referential := partner.manager.Referential(); referential != nil {
+			generator.SetMappings(referential.IdentifierMappings())
+		}
+	}
+
+	return generator
+}
+
+// Type formats of the identifier generators, by generator name and model type
+type identifierGeneratorTypeFormats map[string]map[string]string
+
+// Returns the generators.<name>.<Type> settings. The Settings are only parsed
+// on the first call after they have been defined.
+func (partner *Partner) identifierGeneratorTypeFormats() identifierGeneratorTypeFormats {
+	if typeFormats, _ := partner.generatorTypeFormats.Load().(identifierGeneratorTypeFormats); typeFormats != nil {
+		return typeFormats
+	}
+
+	typeFormats := make(identifierGeneratorTypeFormats)
+	for key, value := range partner.Settings {
+		if value == "" || !strings.HasPrefix(key, "generators.") {
+			continue
+		}
+		names := strings.SplitN(strings.TrimPrefix(key, "generators."), ".", 2)
+		if len(names) != 2 {
+			continue
+		}
+		if typeFormats[names[0]] == nil {
+			typeFormats[names[0]] = make(map[string]string)
+		}
+		typeFormats[names[0]][names[1]] = value
+	}
+
+	partner.generatorTypeFormats.Store(typeFormats)
+	return typeFormats
+}
+
+// Drops the values parsed from the previous Settings
+func (partner *Partner) resetSettings() {
+	partner.generatorTypeFormats.Store(identifierGeneratorTypeFormats(nil))
 }
 
 func (partner *Partner) RemoteObjectIDKind(connectorName string) string {
@@ -362,6 +541,36 @@ func (partner *Partner) GtfsCacheTimeout() (t time.Duration) {
 	return
 }
 
+// Collect subscriptions are renewed when they end before this margin
+func (partner *Partner) SubscriptionsRenewalMargin() (d time.Duration) {
+	d, _ = time.ParseDuration(partner.Setting(COLLECT_SUBSCRIPTIONS_RENEWAL_MARGIN))
+	if d <= 0 {
+		d = DEFAULT_SUBSCRIPTIONS_RENEWAL_MARGIN
+	}
+	return
+}
+
+// Collect subscriptions are sent again when the partner stays silent during
+// this duration. Disabled when zero
+func (partner *Partner) SubscriptionsResubscribeAfter() (d time.Duration) {
+	d, _ = time.ParseDuration(partner.Setting(COLLECT_SUBSCRIPTIONS_RESUBSCRIBE_AFTER))
+	return
+}
+
+// Broadcast subscriptions can't be requested for a longer duration.
+// Unlimited when zero
+func (partner *Partner) BroadcastSubscriptionsMaximumDuration() (d time.Duration) {
+	d, _ = time.ParseDuration(partner.Setting(BROADCAST_SUBSCRIPTIONS_MAXIMUM_DURATION))
+	return
+}
+
+// Maximum number of broadcast subscriptions the partner can create.
+// Unlimited when zero
+func (partner *Partner) BroadcastSubscriptionsMaximumCount() (count int) {
+	count, _ = strconv.Atoi(partner.Setting(BROADCAST_SUBSCRIPTIONS_MAXIMUM_COUNT))
+	return
+}
+
 func (partner *Partner) CacheTimeout(connectorName string) (t time.Duration) {
 	t, _ = time.ParseDuration(partner.Setting(fmt.Sprintf("%s.%s", connectorName, CACHE_TIMEOUT)))
 	return
@@ -400,16 +609,39 @@ func (partner *Partner) Save() (ok bool) {
 func (partner *Partner) MarshalJSON() ([]byte, error) {
 	type Alias Partner
 	return json.Marshal(&struct {
-		Id   PartnerId
-		Slug PartnerSlug
+		Id                PartnerId
+		Slug              PartnerSlug
+		CircuitBreaker    *siri.CircuitBreakerStatus `json:",omitempty"`
+		NotificationQueue *NotificationQueueStatus   `json:",omitempty"`
 		*Alias
 	}{
-		Id:    partner.id,
-		Slug:  partner.slug,
-		Alias: (*Alias)(partner),
+		Id:                partner.id,
+		Slug:              partner.slug,
+		CircuitBreaker:    partner.CircuitBreakerStatus(),
+		NotificationQueue: partner.NotificationQueueStatus(),
+		Alias:             (*Alias)(partner),
 	})
 }
 
+// Returns the status of the outbound notification queue, nil when the partner doesn't send notifications
+func (partner *Partner) NotificationQueueStatus() *NotificationQueueStatus {
+	queue, ok := partner.context.Value(NOTIFICATION_QUEUE).(*NotificationQueue)
+	if !ok {
+		return nil
+	}
+	status := queue.Status()
+	return &status
+}
+
+// Returns the status of the SOAPClient circuit breaker, nil when the partner doesn't use SIRI
+func (partner *Partner) CircuitBreakerStatus() *siri.CircuitBreakerStatus {
+	siriPartner, ok := partner.context.Value(SIRI_PARTNER).(*SIRIPartner)
+	if !ok {
+		return nil
+	}
+	return siriPartner.CircuitBreakerStatus()
+}
+
 func (partner *Partner) Definition() *APIPartner {
 	return &APIPartner{
 		Id:             partner.id,
@@ -430,13 +662,19 @@ func (partner *Partner) Stop() {
 			c.Stop()
 		}
 	}
+	if partner.heartbeatScheduler != nil {
+		partner.heartbeatScheduler.Stop()
+		partner.heartbeatScheduler = nil
+	}
 	partner.CancelSubscriptions()
 	partner.gtfsCache.Clear()
+	// The pending notifications are kept and delivered when the partner is
+	// started again
 }
 
 func (partner *Partner) Start() {
 	partner.startedAt = partner.manager.Referential().Clock().Now()
-	partner.lastDiscovery = time.Time{}
+	partner.ResetDiscoveries()
 	partner.lastPush = time.Time{}
 
 	for _, connector := range partner.connectors {
@@ -446,10 +684,16 @@ func (partner *Partner) Start() {
 		}
 	}
 
+	if _, ok := partner.connectors[SIRI_SUBSCRIPTION_REQUEST_DISPATCHER]; ok && partner.heartbeatScheduler == nil {
+		partner.heartbeatScheduler = NewHeartbeatScheduler(partner)
+		partner.heartbeatScheduler.Start()
+	}
+
 	to := partner.GtfsCacheTimeout()
 	partner.gtfsCache.Add("trip-updates", to, nil)
 	partner.gtfsCache.Add("vehicle-positions", to, nil)
 	partner.gtfsCache.Add("trip-updates,vehicle-position", to, nil)
+	partner.gtfsCache.Add("static", to, nil)
 }
 
 func (partner *Partner) CollectPriority() int {
@@ -480,6 +724,31 @@ func (partner *Partner) CanCollectLine(lineObjectId model.ObjectID) bool {
 	return false
 }
 
+// Returns false when the OperatorRef is excluded, or when included
+// Operators are defined and the OperatorRef isn't one of them
+func (partner *Partner) CanCollectOperator(operatorRef string) bool {
+	if partner.operatorInSetting(operatorRef, COLLECT_EXCLUDE_OPERATORS) {
+		return false
+	}
+	if partner.Setting(COLLECT_INCLUDE_OPERATORS) == "" {
+		return true
+	}
+	return partner.operatorInSetting(operatorRef, COLLECT_INCLUDE_OPERATORS)
+}
+
+func (partner *Partner) operatorInSetting(operatorRef string, setting string) bool {
+	if partner.Setting(setting) == "" {
+		return false
+	}
+	operators := strings.Split(partner.Settings[setting], ",")
+	for _, operator := range operators {
+		if strings.TrimSpace(operator) == operatorRef {
+			return true
+		}
+	}
+	return false
+}
+
 func (partner *Partner) checkDiscovered(stopAreaObjectId model.ObjectID) (ok bool) {
 	_, ok = partner.discoveredStopAreas[stopAreaObjectId.Value()]
 	return
@@ -533,6 +802,22 @@ func (partner *Partner) NoDataFrameRefRewritingFrom() []string {
 	return strings.Split(partner.Settings[BROADCAST_NO_DATAFRAMEREF_REWRITING_FROM], ",")
 }
 
+// Returns the DetailLevel used when a StopMonitoring request doesn't define it
+func (partner *Partner) StopMonitoringDetailLevel() string {
+	if detailLevel := partner.Setting(BROADCAST_STOP_MONITORING_DETAIL_LEVEL); detailLevel != "" {
+		return detailLevel
+	}
+	return SM_DETAIL_LEVEL_NORMAL
+}
+
+func (partner *Partner) StopMonitoringFields() []string {
+	fields := partner.Setting(BROADCAST_STOP_MONITORING_FIELDS)
+	if fields == "" {
+		return []string{}
+	}
+	return strings.Split(fields, ",")
+}
+
 func (partner *Partner) RewriteJourneyPatternRef() (r bool) {
 	r, _ = strconv.ParseBool(partner.Settings[BROADCAST_REWRITE_JOURNEY_PATTERN_REF])
 	return
@@ -559,6 +844,7 @@ func (partner *Partner) SetDefinition(apiPartner *APIPartner) {
 	partner.slug = apiPartner.Slug
 	partner.Name = apiPartner.Name
 	partner.Settings = apiPartner.Settings
+	partner.resetSettings()
 	partner.ConnectorTypes = apiPartner.ConnectorTypes
 	partner.PartnerStatus.OperationnalStatus = OPERATIONNAL_STATUS_UNKNOWN
 
@@ -612,6 +898,14 @@ func (partner *Partner) hasSubscribers() bool {
 		return true
 	}
 	_, ok = partner.connectors[SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER]
+	if ok {
+		return true
+	}
+	_, ok = partner.connectors[SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER]
+	if ok {
+		return true
+	}
+	_, ok = partner.connectors[SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER]
 	return ok
 }
 
@@ -620,6 +914,44 @@ func (partner *Partner) Connector(connectorType string) (Connector, bool) {
 	return connector, ok
 }
 
+// Implemented by the subscription broadcasters able to send again the
+// complete state of a Subscription
+type SubscriptionResyncer interface {
+	ResyncSubscription(sub *Subscription)
+}
+
+var broadcasterBySubscriptionKind = map[string]string{
+	"StopMonitoringBroadcast":      SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER,
+	"GeneralMessageBroadcast":      SIRI_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER,
+	"EstimatedTimeTableBroadcast":  SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER,
+	"ProductionTimetableBroadcast": SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER,
+	"FacilityMonitoringBroadcast":  SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER,
+}
+
+// Forces a complete synchronization of the Subscription: the complete state
+// is sent again to the subscriber for a broadcast Subscription, and a new
+// subscription request is sent to the partner for a collect Subscription
+func (partner *Partner) ResyncSubscription(sub *Subscription) error {
+	if sub.ExternalId() == "" {
+		for _, resource := range sub.ResourcesByObjectIDCopy() {
+			resource.Resubscribe()
+		}
+		sub.SetHealth(SUBSCRIPTION_HEALTH_PENDING)
+		return nil
+	}
+
+	connector, ok := partner.Connector(broadcasterBySubscriptionKind[sub.Kind()])
+	if !ok {
+		return fmt.Errorf("no broadcaster for subscription kind %v", sub.Kind())
+	}
+	resyncer, ok := connector.(SubscriptionResyncer)
+	if !ok {
+		return fmt.Errorf("broadcaster of subscription kind %v can't resync", sub.Kind())
+	}
+	resyncer.ResyncSubscription(sub)
+	return nil
+}
+
 func (partner *Partner) CreateSubscriptionRequestDispatcher() {
 	partner.connectors[SIRI_SUBSCRIPTION_REQUEST_DISPATCHER] = NewSIRISubscriptionRequestDispatcher(partner)
 }
@@ -660,6 +992,14 @@ func (partner *Partner) GeneralMessageRequestCollector() GeneralMessageRequestCo
 	return nil
 }
 
+func (partner *Partner) FacilityMonitoringRequestCollector() FacilityMonitoringRequestCollector {
+	client, ok := partner.connectors[SIRI_FACILITY_MONITORING_REQUEST_COLLECTOR]
+	if ok {
+		return client.(FacilityMonitoringRequestCollector)
+	}
+	return nil
+}
+
 func (partner *Partner) GeneralMessageSubscriptionCollector() GeneralMessageSubscriptionCollector {
 	// WIP
 	client, ok := partner.connectors[SIRI_GENERAL_MESSAGE_SUBSCRIPTION_COLLECTOR]
@@ -713,10 +1053,28 @@ func (partner *Partner) CheckStatus() (PartnerStatus, error) {
 	if err != nil {
 		logger.Log.Printf("Error while checking status: %v", err)
 	}
+	partnerStatus.CertificateExpiresAt = partner.certificateExpiresAt()
 	logger.Log.Debugf("Partner %v status is %v", partner.slug, partnerStatus.OperationnalStatus)
 	return partnerStatus, nil
 }
 
+// Returns the expiry date of the client certificate used for mutual TLS
+func (partner *Partner) certificateExpiresAt() *time.Time {
+	siriPartner, ok := partner.context.Value(SIRI_PARTNER).(*SIRIPartner)
+	if !ok {
+		return nil
+	}
+	certificate := siriPartner.SOAPClient().ClientCertificate()
+	if certificate == nil {
+		return nil
+	}
+	if partner.manager.Referential().Clock().Now().After(certificate.NotAfter) {
+		logger.Log.Printf("Client certificate of partner %v expired at %v", partner.slug, certificate.NotAfter)
+	}
+	expiresAt := certificate.NotAfter
+	return &expiresAt
+}
+
 func (partner *Partner) checkPushStatus() (partnerStatus PartnerStatus, _ error) {
 	logger.Log.Debugf("Checking %v partner status with PushNotifications", partner.slug)
 	if partner.lastPush.Before(partner.manager.Referential().Clock().Now().Add(-5 * time.Minute)) {
@@ -747,22 +1105,50 @@ func (partner *Partner) NewLogStashEvent() audit.LogStashEvent {
 	return logStashEvent
 }
 
-func (partner *Partner) LastDiscovery() time.Time {
-	return partner.lastDiscovery
-}
-
-func (partner *Partner) DiscoveryInterval() time.Duration {
-	d, _ := time.ParseDuration(partner.Settings[DISCOVERY_INTERVAL])
-	if d == 0 {
+// Returns the interval between two discoveries defined by the given setting.
+// The discovery_interval setting is used when not defined, one hour by default.
+func (partner *Partner) DiscoveryInterval(setting string) time.Duration {
+	d, _ := time.ParseDuration(partner.Settings[setting])
+	if d <= 0 {
+		d, _ = time.ParseDuration(partner.Settings[DISCOVERY_INTERVAL])
+	}
+	if d <= 0 {
 		d = 1 * time.Hour
 	}
-	return -1 * time.Hour
+	return d
 }
 
+func (partner *Partner) ResetDiscoveries() {
+	partner.lastLinesDiscovery = time.Time{}
+	partner.lastStopPointsDiscovery = time.Time{}
+}
+
+// Requests the Lines and the StopPoints of the partner when their discovery
+// interval is elapsed. Lines are discovered first.
 func (partner *Partner) Discover() {
-	partner.lastDiscovery = partner.manager.Referential().Clock().Now()
-	// partner.LineDiscovery()
-	partner.stopDiscovery()
+	now := partner.manager.Referential().Clock().Now()
+
+	if partner.lastLinesDiscovery.IsZero() || !now.Before(partner.lastLinesDiscovery.Add(partner.DiscoveryInterval(DISCOVERY_INTERVAL_LINES))) {
+		partner.lastLinesDiscovery = now
+		partner.linesDiscovery()
+	}
+
+	if partner.lastStopPointsDiscovery.IsZero() || !now.Before(partner.lastStopPointsDiscovery.Add(partner.DiscoveryInterval(DISCOVERY_INTERVAL_STOP_POINTS))) {
+		partner.lastStopPointsDiscovery = now
+		partner.stopDiscovery()
+	}
+}
+
+func (partner *Partner) linesDiscovery() {
+	logger.Log.Debugf("LinesDiscovery for partner '%s'", partner.slug)
+
+	c, ok := partner.connectors[SIRI_LINES_DISCOVERY_REQUEST_COLLECTOR]
+	if !ok {
+		logger.Log.Debugf("No SiriLinesDiscoveryRequestCollector found for partner '%s'", partner.slug)
+		return
+	}
+
+	c.(LinesDiscoveryRequestCollector).RequestLines()
 }
 
 func (partner *Partner) stopDiscovery() {
@@ -781,6 +1167,7 @@ func (partner *Partner) Pushed() {
 	partner.lastPush = partner.manager.Referential().Clock().Now()
 }
 
+// Replaces the discovered StopAreas by the given ones
 func (partner *Partner) RegisterDiscoveredStopAreas(stops []string) {
 	if partner.Setting(COLLECT_USE_DISCOVERED_SA) == "" {
 		return
@@ -788,6 +1175,7 @@ func (partner *Partner) RegisterDiscoveredStopAreas(stops []string) {
 
 	partner.mutex.Lock()
 
+	partner.discoveredStopAreas = make(map[string]struct{})
 	for i := range stops {
 		partner.discoveredStopAreas[stops[i]] = struct{}{}
 	}
@@ -795,6 +1183,56 @@ func (partner *Partner) RegisterDiscoveredStopAreas(stops []string) {
 	partner.mutex.Unlock()
 }
 
+// Replaces the StopPointRefs announced by the last StopPointsDiscovery and
+// returns the previous ones
+func (partner *Partner) replaceAnnouncedStopPoints(stopPointRefs map[string]struct{}) (previous map[string]struct{}) {
+	partner.mutex.Lock()
+	defer partner.mutex.Unlock()
+
+	previous = partner.announcedStopPoints
+	partner.announcedStopPoints = stopPointRefs
+	return
+}
+
+// Replaces the LineRefs announced by the last LinesDiscovery and returns the
+// previous ones
+func (partner *Partner) replaceAnnouncedLines(lineRefs map[string]struct{}) (previous map[string]struct{}) {
+	partner.mutex.Lock()
+	defer partner.mutex.Unlock()
+
+	previous = partner.announcedLines
+	partner.announcedLines = lineRefs
+	return
+}
+
+// Returns true if the StopArea is announced by the last StopPointsDiscovery
+func (partner *Partner) announcesStopArea(stopArea *model.StopArea) bool {
+	objectid, ok := stopArea.ObjectID(partner.RemoteObjectIDKind(SIRI_STOP_POINTS_DISCOVERY_REQUEST_COLLECTOR))
+	if !ok {
+		return false
+	}
+
+	partner.mutex.RLock()
+	defer partner.mutex.RUnlock()
+
+	_, ok = partner.announcedStopPoints[objectid.Value()]
+	return ok
+}
+
+// Returns true if the Line is announced by the last LinesDiscovery
+func (partner *Partner) announcesLine(line *model.Line) bool {
+	objectid, ok := line.ObjectID(partner.RemoteObjectIDKind(SIRI_LINES_DISCOVERY_REQUEST_COLLECTOR))
+	if !ok {
+		return false
+	}
+
+	partner.mutex.RLock()
+	defer partner.mutex.RUnlock()
+
+	_, ok = partner.announcedLines[objectid.Value()]
+	return ok
+}
+
 func NewPartnerManager(referential *Referential) *PartnerManager {
 	manager := &PartnerManager{
 		mutex:                 &sync.RWMutex{},
@@ -844,6 +1282,7 @@ func (manager *PartnerManager) New(slug PartnerSlug) *Partner {
 		gtfsCache:      cache.NewCacheTable(),
 	}
 	partner.subscriptionManager = NewMemorySubscriptions(partner)
+	partner.rateLimiter = NewRateLimiter(partner)
 	return partner
 }
 
@@ -935,6 +1374,10 @@ func (manager *PartnerManager) Delete(partner *Partner) bool {
 	delete(manager.byId, partner.id)
 	manager.mutex.Unlock()
 
+	if queue, ok := partner.context.Value(NOTIFICATION_QUEUE).(*NotificationQueue); ok {
+		queue.Clear()
+	}
+
 	return true
 }
 
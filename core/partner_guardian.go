@@ -67,6 +67,7 @@ func (guardian *PartnersGuardian) routineWork(partner *Partner) {
 	s := guardian.checkPartnerStatus(partner)
 	if s {
 		guardian.checkSubscriptionsTerminatedTime(partner)
+		guardian.checkCollectSubscriptions(partner)
 	}
 
 	guardian.checkPartnerDiscovery(partner)
@@ -79,6 +80,7 @@ func (guardian *PartnersGuardian) checkPartnerStatus(partner *Partner) bool {
 		}
 	}()
 
+	previousCircuitBreakerStatus := partner.CircuitBreakerStatus()
 	partnerStatus, _ := partner.CheckStatus()
 
 	if partnerStatus.OperationnalStatus != partner.PartnerStatus.OperationnalStatus {
@@ -92,6 +94,12 @@ func (guardian *PartnersGuardian) checkPartnerStatus(partner *Partner) bool {
 			NewStatus:                string(partnerStatus.OperationnalStatus),
 			NewServiceStartedAt:      civil.DateTimeOf(partnerStatus.ServiceStartedAt),
 		}
+		if previousCircuitBreakerStatus != nil {
+			partnerEvent.PreviousCircuitBreakerState = string(previousCircuitBreakerStatus.State)
+		}
+		if status := partner.CircuitBreakerStatus(); status != nil {
+			partnerEvent.NewCircuitBreakerState = string(status.State)
+		}
 
 		audit.CurrentBigQuery(string(guardian.referential.Slug())).WriteEvent(partnerEvent)
 	}
@@ -99,13 +107,14 @@ func (guardian *PartnersGuardian) checkPartnerStatus(partner *Partner) bool {
 	if partnerStatus.OperationnalStatus == OPERATIONNAL_STATUS_UP && partnerStatus.ServiceStartedAt != partner.PartnerStatus.ServiceStartedAt {
 		partner.PartnerStatus = partnerStatus
 		partner.Subscriptions().CancelSubscriptions()
-		partner.lastDiscovery = time.Time{} // Reset discoveries if distant partner reloaded
+		partner.ResetDiscoveries() // Reset discoveries if distant partner reloaded
 		return false
 	}
 
 	if partnerStatus.OperationnalStatus == OPERATIONNAL_STATUS_UNKNOWN || partnerStatus.OperationnalStatus == OPERATIONNAL_STATUS_DOWN {
 		partner.PartnerStatus.OperationnalStatus = partnerStatus.OperationnalStatus
-		partner.lastDiscovery = time.Time{} // Reset discoveries if distant partner is down
+		partner.PartnerStatus.CertificateExpiresAt = partnerStatus.CertificateExpiresAt
+		partner.ResetDiscoveries() // Reset discoveries if distant partner is down
 
 		collectPersistent, _ := strconv.ParseBool(partner.Setting(COLLECT_SUBSCRIPTIONS_PERSISTENT))
 		if !collectPersistent {
@@ -143,12 +152,27 @@ func (guardian *PartnersGuardian) checkSubscriptionsTerminatedTime(partner *Part
 	}
 }
 
+// Renews the collect subscriptions before their end, resubscribes when the
+// partner stopped sending notifications and heartbeats and updates the
+// subscriptions health
+func (guardian *PartnersGuardian) checkCollectSubscriptions(partner *Partner) {
+	if partner.Subscriptions() == nil {
+		return
+	}
+
+	now := guardian.Clock().Now()
+	renewalMargin := partner.SubscriptionsRenewalMargin()
+	resubscribeAfter := partner.SubscriptionsResubscribeAfter()
+
+	for _, sub := range partner.Subscriptions().FindCollectSubscriptions() {
+		sub.CheckCollectHealth(now, renewalMargin, resubscribeAfter)
+	}
+}
+
 func (guardian *PartnersGuardian) checkPartnerDiscovery(partner *Partner) {
 	if partner.OperationnalStatus() != OPERATIONNAL_STATUS_UP {
 		return
 	}
 
-	if partner.LastDiscovery().IsZero() || partner.LastDiscovery().Before(guardian.Clock().Now().Add(partner.DiscoveryInterval())) {
-		partner.Discover()
-	}
+	partner.Discover()
 }
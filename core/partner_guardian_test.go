@@ -1,11 +1,16 @@
 package core
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"bitbucket.org/enroute-mobi/ara/audit"
 	"bitbucket.org/enroute-mobi/ara/cache"
 	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
 )
 
 func Test_PartnerGuardian_Run(t *testing.T) {
@@ -52,3 +57,180 @@ func Test_PartnerGuardian_Run(t *testing.T) {
 		t.Errorf("Guardian CheckPartnerStatus with TestCheckStatusClient timed out")
 	}
 }
+
+func Test_PartnerGuardian_CheckCollectSubscriptions_Renewal(t *testing.T) {
+	partners := createTestPartnerManager()
+	partner := partners.New("slug")
+	partner.Settings[COLLECT_SUBSCRIPTIONS_RENEWAL_MARGIN] = "2h"
+	partners.Save(partner)
+
+	fakeClock := clock.NewFakeClock()
+	guardian := partners.Guardian()
+	guardian.SetClock(fakeClock)
+
+	subscription := partner.Subscriptions().New("StopMonitoringCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("test", "value")))
+	resource.SubscribedAt = fakeClock.Now()
+	resource.TerminationTime = fakeClock.Now().Add(3 * time.Hour)
+
+	guardian.checkCollectSubscriptions(partner)
+	if resource.SubscribedAt.IsZero() {
+		t.Errorf("Resource shouldn't be renewed before the renewal margin")
+	}
+	if subscription.Health() != SUBSCRIPTION_HEALTH_ACTIVE {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_ACTIVE)
+	}
+
+	fakeClock.Advance(90 * time.Minute)
+	guardian.checkCollectSubscriptions(partner)
+	if !resource.SubscribedAt.IsZero() || !resource.TerminationTime.IsZero() {
+		t.Errorf("Resource should be renewed in the renewal margin")
+	}
+}
+
+func Test_PartnerGuardian_CheckCollectSubscriptions_Resubscribe(t *testing.T) {
+	partners := createTestPartnerManager()
+	partner := partners.New("slug")
+	partner.Settings[COLLECT_SUBSCRIPTIONS_RESUBSCRIBE_AFTER] = "10m"
+	partners.Save(partner)
+
+	fakeClock := clock.NewFakeClock()
+	guardian := partners.Guardian()
+	guardian.SetClock(fakeClock)
+
+	subscription := partner.Subscriptions().New("StopMonitoringCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("test", "value")))
+	resource.SubscribedAt = fakeClock.Now()
+	resource.TerminationTime = fakeClock.Now().Add(COLLECT_SUBSCRIPTION_DURATION)
+
+	fakeClock.Advance(8 * time.Minute)
+	subscription.SetLastNotificationAt(fakeClock.Now())
+	fakeClock.Advance(8 * time.Minute)
+
+	guardian.checkCollectSubscriptions(partner)
+	if resource.SubscribedAt.IsZero() {
+		t.Errorf("Resource shouldn't be resubscribed after a recent notification")
+	}
+
+	fakeClock.Advance(5 * time.Minute)
+	guardian.checkCollectSubscriptions(partner)
+	if !resource.SubscribedAt.IsZero() {
+		t.Errorf("Resource should be resubscribed without notification")
+	}
+	if subscription.Health() != SUBSCRIPTION_HEALTH_SILENT {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_SILENT)
+	}
+
+	guardian.checkCollectSubscriptions(partner)
+	if subscription.Health() != SUBSCRIPTION_HEALTH_PENDING {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_PENDING)
+	}
+}
+
+func Test_PartnerGuardian_CheckCollectSubscriptions_Heartbeat(t *testing.T) {
+	partners := createTestPartnerManager()
+	partner := partners.New("slug")
+	partner.Settings[COLLECT_SUBSCRIPTIONS_RESUBSCRIBE_AFTER] = "10m"
+	partners.Save(partner)
+
+	fakeClock := clock.NewFakeClock()
+	guardian := partners.Guardian()
+	guardian.SetClock(fakeClock)
+
+	subscription := partner.Subscriptions().New("StopMonitoringCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("test", "value")))
+	resource.SubscribedAt = fakeClock.Now()
+	resource.TerminationTime = fakeClock.Now().Add(COLLECT_SUBSCRIPTION_DURATION)
+
+	broadcast := partner.Subscriptions().New("StopMonitoringBroadcast")
+	broadcast.SetExternalId("external")
+
+	fakeClock.Advance(8 * time.Minute)
+	connector := NewSIRICheckStatusServer(partner)
+	connector.SetClock(fakeClock)
+	request, err := siri.NewXMLCheckStatusRequestFromContent([]byte(`<ns7:CheckStatus xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>CheckStatus:Test:0</ns2:MessageIdentifier>
+  </Request>
+</ns7:CheckStatus>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector.CheckStatus(request, &audit.BigQueryMessage{})
+
+	if !broadcast.LastHeartbeatAt().IsZero() {
+		t.Errorf("CheckStatus shouldn't be recorded on broadcast subscriptions")
+	}
+
+	fakeClock.Advance(8 * time.Minute)
+	guardian.checkCollectSubscriptions(partner)
+	if resource.SubscribedAt.IsZero() {
+		t.Errorf("Resource shouldn't be resubscribed after a recent CheckStatus")
+	}
+	if subscription.Health() != SUBSCRIPTION_HEALTH_ACTIVE {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_ACTIVE)
+	}
+
+	fakeClock.Advance(5 * time.Minute)
+	guardian.checkCollectSubscriptions(partner)
+	if !resource.SubscribedAt.IsZero() {
+		t.Errorf("Resource should be resubscribed without notification nor heartbeat")
+	}
+	if subscription.Health() != SUBSCRIPTION_HEALTH_SILENT {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_SILENT)
+	}
+}
+
+func Test_PartnerGuardian_CheckCollectSubscriptions_Failed(t *testing.T) {
+	partners := createTestPartnerManager()
+	partner := partners.New("slug")
+	partners.Save(partner)
+
+	guardian := partners.Guardian()
+	guardian.SetClock(clock.NewFakeClock())
+
+	subscription := partner.Subscriptions().New("GeneralMessageCollect")
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("test", "value")))
+	resource.RetryCount = SUBSCRIPTION_MAXIMUM_RETRY_COUNT + 1
+
+	guardian.checkCollectSubscriptions(partner)
+	if subscription.Health() != SUBSCRIPTION_HEALTH_FAILED {
+		t.Errorf("Wrong subscription health:\n got: %v\n want: %v", subscription.Health(), SUBSCRIPTION_HEALTH_FAILED)
+	}
+}
+
+func Test_PartnerGuardian_CheckPartnerStatus_CircuitBreakerState(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	bigQuery := audit.NewFakeBigQuery()
+	audit.SetCurrentBigQuery("referential", bigQuery)
+	defer audit.SetCurrentBigQuery("referential", audit.NewNullBigQuery())
+
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings[SIRI_CIRCUIT_BREAKER_THRESHOLD] = "1"
+	partner.ConnectorTypes = []string{SIRI_CHECK_STATUS_CLIENT_TYPE}
+	partner.RefreshConnectors()
+	partner.CheckStatusClient().(*SIRICheckStatusClient).SIRIPartner().SOAPClient()
+	partner.PartnerStatus.OperationnalStatus = OPERATIONNAL_STATUS_UP
+
+	NewPartnersGuardian(referential).checkPartnerStatus(partner)
+
+	var statusEvent *audit.BigQueryPartnerEvent
+	for _, event := range bigQuery.PartnerEvents() {
+		if event.NewStatus != event.PreviousStatus {
+			statusEvent = event
+		}
+	}
+	if statusEvent == nil {
+		t.Fatalf("A partner event should be written for the status change: %v", bigQuery.PartnerEvents())
+	}
+	if statusEvent.PreviousCircuitBreakerState != "closed" || statusEvent.NewCircuitBreakerState != "open" {
+		t.Errorf("Wrong circuit breaker states:\n got: %v -> %v\n want: closed -> open", statusEvent.PreviousCircuitBreakerState, statusEvent.NewCircuitBreakerState)
+	}
+}
@@ -358,6 +358,26 @@ func Test_Partner_CanCollectTrueSPDButExcluded(t *testing.T) {
 	}
 }
 
+func Test_Partner_CanCollectOperator(t *testing.T) {
+	partner := &Partner{}
+	partner.Settings = make(map[string]string)
+
+	if !partner.CanCollectOperator("OP1") {
+		t.Errorf("Partner can collect operator should return true without settings")
+	}
+
+	partner.Settings[COLLECT_EXCLUDE_OPERATORS] = "OP1"
+	if partner.CanCollectOperator("OP1") || !partner.CanCollectOperator("OP2") {
+		t.Errorf("Partner can collect operator should return false only for excluded operators")
+	}
+
+	partner.Settings[COLLECT_EXCLUDE_OPERATORS] = ""
+	partner.Settings[COLLECT_INCLUDE_OPERATORS] = "OP1, OP2"
+	if !partner.CanCollectOperator("OP2") || partner.CanCollectOperator("OP3") || partner.CanCollectOperator("") {
+		t.Errorf("Partner can collect operator should return true only for included operators")
+	}
+}
+
 func Test_Partners_FindAllByCollectPriority(t *testing.T) {
 	partners := createTestPartnerManager()
 	partner1 := Partner{}
@@ -509,6 +529,39 @@ func Test_APIPartner_Validate(t *testing.T) {
 	}
 }
 
+func Test_APIPartner_Validate_RemoteAuthentication(t *testing.T) {
+	partners := createTestPartnerManager()
+
+	apiPartner := &APIPartner{
+		Slug:     "slug",
+		Settings: map[string]string{REMOTE_AUTHENTICATION_TYPE: "dummy"},
+		manager:  partners,
+	}
+	if apiPartner.Validate() {
+		t.Errorf("Validate should return false")
+	}
+	if errors := apiPartner.Errors.GetSettingError(REMOTE_AUTHENTICATION_TYPE); len(errors) != 1 || errors[0] != ERROR_UNKNOWN_VALUE {
+		t.Errorf("apiPartner should have Error for %v, got %v", REMOTE_AUTHENTICATION_TYPE, apiPartner.Errors)
+	}
+
+	apiPartner.Settings = map[string]string{
+		REMOTE_AUTHENTICATION_TYPE:      "oauth2",
+		REMOTE_AUTHENTICATION_TOKEN_URL: "https://auth.example.com/token",
+	}
+	if apiPartner.Validate() {
+		t.Errorf("Validate should return false")
+	}
+	if len(apiPartner.Errors.getSettings()) != 2 {
+		t.Errorf("apiPartner should have Errors for client id and secret, got %v", apiPartner.Errors)
+	}
+
+	apiPartner.Settings[REMOTE_AUTHENTICATION_CLIENT_ID] = "ara"
+	apiPartner.Settings[REMOTE_AUTHENTICATION_CLIENT_SECRET] = "secret"
+	if !apiPartner.Validate() {
+		t.Errorf("Validate should return true, got %v", apiPartner.Errors)
+	}
+}
+
 func Test_NewPartnerManager(t *testing.T) {
 	partners := createTestPartnerManager()
 
@@ -79,6 +79,7 @@ func (pc *PushCollector) HandlePushNotification(model *external_models.ExternalC
 func (pc *PushCollector) handleStopAreas(sas []*external_models.ExternalStopArea) (stopAreas []string) {
 	partner := string(pc.Partner().Slug())
 	id_kind := pc.Partner().Setting(REMOTE_OBJECTID_KIND)
+	objectids := []model.ObjectID{}
 
 	for i := range sas {
 		sa := sas[i]
@@ -92,9 +93,11 @@ func (pc *PushCollector) handleStopAreas(sas []*external_models.ExternalStopArea
 		event.Latitude = sa.GetLatitude()
 
 		stopAreas = append(stopAreas, sa.GetObjectid())
+		objectids = append(objectids, event.ObjectId)
 
 		pc.broadcastUpdateEvent(event)
 	}
+	pc.Partner().Referential().StopAreaMatcher().Match(partner, objectids)
 	return
 }
 
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+// Returned by RateLimiter.Allow when a request must be refused
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (err *RateLimitError) Error() string {
+	return err.Reason
+}
+
+// Limits the requests received from a Partner.
+//
+// A token bucket refilled with RATE_LIMIT_PER_MINUTE tokens every minute (up
+// to RATE_LIMIT_BURST tokens) limits the request rate. RATE_LIMIT_DAILY_QUOTA
+// limits the number of requests accepted each day. The limits are read in the
+// Partner settings at each request, and are disabled when not defined.
+type RateLimiter struct {
+	clock.ClockConsumer
+
+	mutex   *sync.Mutex
+	partner *Partner
+
+	tokens     float64
+	refilledAt time.Time
+
+	day        string
+	dailyCount int
+}
+
+func NewRateLimiter(partner *Partner) *RateLimiter {
+	return &RateLimiter{
+		mutex:   &sync.Mutex{},
+		partner: partner,
+		tokens:  -1,
+	}
+}
+
+func (limiter *RateLimiter) Allow() *RateLimitError {
+	perMinute, _ := strconv.Atoi(limiter.partner.Setting(RATE_LIMIT_PER_MINUTE))
+	quota, _ := strconv.Atoi(limiter.partner.Setting(RATE_LIMIT_DAILY_QUOTA))
+	if perMinute <= 0 && quota <= 0 {
+		return nil
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.Clock().Now()
+
+	if quota > 0 {
+		if day := now.Format("2006-01-02"); day != limiter.day {
+			limiter.day = day
+			limiter.dailyCount = 0
+		}
+		if limiter.dailyCount >= quota {
+			year, month, day := now.Date()
+			tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+			return &RateLimitError{
+				Reason:     fmt.Sprintf("Daily quota of %d requests exceeded", quota),
+				RetryAfter: tomorrow.Sub(now),
+			}
+		}
+	}
+
+	if perMinute > 0 {
+		burst, _ := strconv.Atoi(limiter.partner.Setting(RATE_LIMIT_BURST))
+		if burst <= 0 {
+			burst = perMinute
+		}
+
+		if limiter.tokens < 0 {
+			limiter.tokens = float64(burst)
+		} else {
+			limiter.tokens += now.Sub(limiter.refilledAt).Minutes() * float64(perMinute)
+		}
+		if limiter.tokens > float64(burst) {
+			limiter.tokens = float64(burst)
+		}
+		limiter.refilledAt = now
+
+		if limiter.tokens < 1 {
+			return &RateLimitError{
+				Reason:     fmt.Sprintf("Rate limit of %d requests per minute exceeded", perMinute),
+				RetryAfter: time.Duration((1 - limiter.tokens) / float64(perMinute) * float64(time.Minute)),
+			}
+		}
+		limiter.tokens--
+	}
+
+	limiter.dailyCount++
+	return nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+func Test_RateLimiter_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(NewPartner())
+
+	for i := 0; i < 100; i++ {
+		if err := limiter.Allow(); err != nil {
+			t.Fatalf("Requests should not be limited without settings: %v", err)
+		}
+	}
+}
+
+func Test_RateLimiter_PerMinute(t *testing.T) {
+	partner := NewPartner()
+	partner.Settings[RATE_LIMIT_PER_MINUTE] = "2"
+	partner.Settings[RATE_LIMIT_BURST] = "3"
+
+	fakeClock := clock.NewFakeClock()
+	limiter := NewRateLimiter(partner)
+	limiter.SetClock(fakeClock)
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow(); err != nil {
+			t.Fatalf("Request %d should be accepted within the burst: %v", i, err)
+		}
+	}
+
+	err := limiter.Allow()
+	if err == nil {
+		t.Fatalf("Request should be refused after the burst")
+	}
+	if err.RetryAfter != 30*time.Second {
+		t.Errorf("Wrong RetryAfter:\n got: %v\n want: %v", err.RetryAfter, 30*time.Second)
+	}
+
+	fakeClock.Advance(30 * time.Second)
+	if err := limiter.Allow(); err != nil {
+		t.Errorf("Request should be accepted after the refill: %v", err)
+	}
+	if err := limiter.Allow(); err == nil {
+		t.Errorf("Request should be refused when the bucket is empty")
+	}
+}
+
+func Test_RateLimiter_DailyQuota(t *testing.T) {
+	partner := NewPartner()
+	partner.Settings[RATE_LIMIT_DAILY_QUOTA] = "2"
+
+	fakeClock := clock.NewFakeClockAt(time.Date(2017, time.January, 1, 23, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(partner)
+	limiter.SetClock(fakeClock)
+
+	limiter.Allow()
+	limiter.Allow()
+
+	err := limiter.Allow()
+	if err == nil {
+		t.Fatalf("Request should be refused when the quota is exceeded")
+	}
+	if err.RetryAfter != time.Hour {
+		t.Errorf("Wrong RetryAfter:\n got: %v\n want: %v", err.RetryAfter, time.Hour)
+	}
+
+	fakeClock.Advance(time.Hour)
+	if err := limiter.Allow(); err != nil {
+		t.Errorf("Quota should be reset the next day: %v", err)
+	}
+}
@@ -22,7 +22,16 @@ type ReferentialId string
 type ReferentialSlug string
 
 const (
-	REFERENTIAL_SETTING_MODEL_RELOAD_AT = "model.reload_at"
+	REFERENTIAL_SETTING_MODEL_RELOAD_AT          = "model.reload_at"
+	REFERENTIAL_SETTING_MODEL_INCREMENTAL_RELOAD = "model.incremental_reload"
+	REFERENTIAL_SETTING_MODEL_PREVIOUS_DAYS      = "model.previous_service_days"
+	REFERENTIAL_SETTING_MODEL_NEXT_DAYS          = "model.next_service_days"
+
+	REFERENTIAL_SETTING_STOP_AREA_MATCHING                    = "stop_areas.matching"
+	REFERENTIAL_SETTING_STOP_AREA_MATCHING_MODE               = "stop_areas.matching.mode"
+	REFERENTIAL_SETTING_STOP_AREA_MATCHING_THRESHOLD          = "stop_areas.matching.threshold"
+	REFERENTIAL_SETTING_STOP_AREA_MATCHING_MINIMUM_CONFIDENCE = "stop_areas.matching.minimum_confidence"
+	REFERENTIAL_SETTING_STOP_AREA_MATCHING_MAXIMUM_DISTANCE   = "stop_areas.matching.maximum_distance"
 )
 
 // Validation
@@ -38,15 +47,18 @@ type Referential struct {
 	Settings       map[string]string `json:"Settings,omitempty"`
 	OrganisationId string            `json:",omitempty"`
 
-	collectManager    CollectManagerInterface
-	broacasterManager BroadcastManagerInterface
-	manager           Referentials
-	model             *model.MemoryModel
-	modelGuardian     *ModelGuardian
-	partners          Partners
-	startedAt         time.Time
-	nextReloadAt      time.Time
-	Tokens            []string `json:",omitempty"`
+	collectManager     CollectManagerInterface
+	broacasterManager  BroadcastManagerInterface
+	connectionLinks    *ConnectionLinks
+	identifierMappings *IdentifierMappings
+	manager            Referentials
+	model              *model.MemoryModel
+	modelGuardian      *ModelGuardian
+	partners           Partners
+	stopAreaMatcher    *StopAreaMatcher
+	startedAt          time.Time
+	nextReloadAt       time.Time
+	Tokens             []string `json:",omitempty"`
 }
 
 type Referentials interface {
@@ -129,6 +141,18 @@ func (referential *Referential) Model() model.Model {
 	return referential.model
 }
 
+func (referential *Referential) ConnectionLinks() *ConnectionLinks {
+	return referential.connectionLinks
+}
+
+func (referential *Referential) IdentifierMappings() *IdentifierMappings {
+	return referential.identifierMappings
+}
+
+func (referential *Referential) StopAreaMatcher() *StopAreaMatcher {
+	return referential.stopAreaMatcher
+}
+
 func (referential *Referential) ModelGuardian() *ModelGuardian {
 	return referential.modelGuardian
 }
@@ -163,6 +187,7 @@ func (referential *Referential) Start() {
 	referential.broacasterManager = NewBroadcastManager(referential)
 	referential.model.SetBroadcastSMChan(referential.broacasterManager.GetStopMonitoringBroadcastEventChan())
 	referential.model.SetBroadcastGMChan(referential.broacasterManager.GetGeneralMessageBroadcastEventChan())
+	referential.model.SetBroadcastFMChan(referential.broacasterManager.GetFacilityBroadcastEventChan())
 
 	referential.broacasterManager.Start()
 
@@ -245,13 +270,52 @@ func (referential *Referential) NextReloadAt() time.Time {
 }
 
 func (referential *Referential) ReloadModel() {
+	referential.stopAreaMatcher.Reset()
+
+	if referential.incrementalReload() {
+		referential.reloadModelIncrementally()
+		return
+	}
+
 	logger.Log.Printf("Reset Model for referential %v", referential.slug)
 	referential.Stop()
-	referential.model = referential.model.Reload(string(referential.Slug()))
+	referential.setServiceDays()
+	referential.model = referential.model.Reload(string(referential.Slug()), referential.Clock())
 	referential.setNextReloadAt()
 	referential.Start()
 }
 
+func (referential *Referential) incrementalReload() bool {
+	incremental, _ := strconv.ParseBool(referential.Setting(REFERENTIAL_SETTING_MODEL_INCREMENTAL_RELOAD))
+	return incremental
+}
+
+// Applies the database changes to the live model, without stopping partners and subscriptions
+func (referential *Referential) reloadModelIncrementally() {
+	logger.Log.Printf("Incremental reload of Model for referential %v", referential.slug)
+	referential.setServiceDays()
+	result, err := referential.model.IncrementalReload(string(referential.Slug()), referential.Clock())
+	if err != nil {
+		logger.Log.Printf("Error during incremental reload of referential %v: %v", referential.slug, err)
+	} else {
+		logger.Log.Printf("%v", result)
+	}
+	referential.setNextReloadAt()
+}
+
+// Defines the service dates loaded around the model date, to handle services after midnight
+func (referential *Referential) setServiceDays() {
+	previous, _ := strconv.Atoi(referential.Setting(REFERENTIAL_SETTING_MODEL_PREVIOUS_DAYS))
+	next, _ := strconv.Atoi(referential.Setting(REFERENTIAL_SETTING_MODEL_NEXT_DAYS))
+	if previous < 0 {
+		previous = 0
+	}
+	if next < 0 {
+		next = 0
+	}
+	referential.model.SetServiceDays(previous, next)
+}
+
 func (referential *Referential) setNextReloadAt() {
 	reloadHour := referential.Setting(REFERENTIAL_SETTING_MODEL_RELOAD_AT)
 	hour, minute := 4, 0
@@ -274,6 +338,7 @@ func (referential *Referential) setNextReloadAt() {
 
 func (referential *Referential) Load() {
 	referential.Partners().Load()
+	referential.setServiceDays()
 	referential.model.Load(string(referential.slug))
 }
 
@@ -297,10 +362,12 @@ func (manager *MemoryReferentials) New(slug ReferentialSlug) *Referential {
 	model := model.NewMemoryModel(string(slug))
 
 	referential := &Referential{
-		manager:  manager,
-		model:    model,
-		slug:     slug,
-		Settings: make(map[string]string),
+		manager:            manager,
+		model:              model,
+		slug:               slug,
+		Settings:           make(map[string]string),
+		identifierMappings: NewIdentifierMappings(),
+		connectionLinks:    NewConnectionLinks(),
 	}
 
 	referential.partners = NewPartnerManager(referential)
@@ -309,8 +376,10 @@ func (manager *MemoryReferentials) New(slug ReferentialSlug) *Referential {
 
 	referential.model.SetBroadcastSMChan(referential.broacasterManager.GetStopMonitoringBroadcastEventChan())
 	referential.model.SetBroadcastGMChan(referential.broacasterManager.GetGeneralMessageBroadcastEventChan())
+	referential.model.SetBroadcastFMChan(referential.broacasterManager.GetFacilityBroadcastEventChan())
 
 	referential.modelGuardian = NewModelGuardian(referential)
+	referential.stopAreaMatcher = NewStopAreaMatcher(referential)
 	referential.setNextReloadAt()
 
 	return referential
@@ -388,6 +457,18 @@ func (manager *MemoryReferentials) Load() error {
 			}
 		}
 
+		if r.IdentifierMappings.Valid && len(r.IdentifierMappings.String) > 0 {
+			if err = json.Unmarshal([]byte(r.IdentifierMappings.String), referential.identifierMappings); err != nil {
+				return err
+			}
+		}
+
+		if r.ConnectionLinks.Valid && len(r.ConnectionLinks.String) > 0 {
+			if err = json.Unmarshal([]byte(r.ConnectionLinks.String), referential.connectionLinks); err != nil {
+				return err
+			}
+		}
+
 		referential.setNextReloadAt()
 		manager.Save(referential)
 		referential.Load()
@@ -450,13 +531,23 @@ func (manager *MemoryReferentials) newDbReferential(referential *Referential) (*
 	if err != nil {
 		return nil, err
 	}
+	identifierMappings, err := json.Marshal(referential.identifierMappings)
+	if err != nil {
+		return nil, err
+	}
+	connectionLinks, err := json.Marshal(referential.connectionLinks)
+	if err != nil {
+		return nil, err
+	}
 	return &model.DatabaseReferential{
-		ReferentialId:  string(referential.id),
-		OrganisationId: referential.DatabaseOrganisationId(),
-		Slug:           string(referential.slug),
-		Name:           referential.Name,
-		Settings:       string(settings),
-		Tokens:         string(tokens),
+		ReferentialId:      string(referential.id),
+		OrganisationId:     referential.DatabaseOrganisationId(),
+		Slug:               string(referential.slug),
+		Name:               referential.Name,
+		Settings:           string(settings),
+		Tokens:             string(tokens),
+		IdentifierMappings: string(identifierMappings),
+		ConnectionLinks:    string(connectionLinks),
 	}, nil
 }
 
@@ -46,6 +46,11 @@ func (connector *SIRICheckStatusServer) CheckStatus(request *siri.XMLCheckStatus
 	message.RequestIdentifier = request.MessageIdentifier()
 	message.ResponseIdentifier = response.ResponseMessageIdentifier
 
+	// The CheckStatus requests of the partner are the heartbeats of its collect subscriptions
+	for _, sub := range connector.Partner().Subscriptions().FindCollectSubscriptions() {
+		sub.SetLastHeartbeatAt(connector.Clock().Now())
+	}
+
 	logSIRICheckStatusResponse(logStashEvent, response)
 
 	return response, nil
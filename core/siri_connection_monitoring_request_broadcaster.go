@@ -0,0 +1,340 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type ConnectionMonitoringBroadcaster interface {
+	RequestConnectionMonitoring(*siri.XMLGetConnectionMonitoring, *audit.BigQueryMessage) *siri.SIRIConnectionMonitoringResponse
+}
+
+/*
+Broadcasts the feeder arrivals of the referential ConnectionLinks.
+
+The arrivals of the feeder StopArea (and feeder Line when defined) are returned
+as MonitoredFeederArrivals, or MonitoredFeederArrivalCancellations when the
+arrival is cancelled. The arrivals of the feeder StopArea descendants and
+referents are included. An arrival is ignored when no departure of the
+distributor StopArea (and distributor Line) can be reached with the transfer
+time, or when the reachable departures leave after the maximum wait time.
+*/
+type SIRIConnectionMonitoringBroadcaster struct {
+	clock.ClockConsumer
+
+	siriConnector
+}
+
+type SIRIConnectionMonitoringBroadcasterFactory struct{}
+
+func NewSIRIConnectionMonitoringBroadcaster(partner *Partner) *SIRIConnectionMonitoringBroadcaster {
+	broadcaster := &SIRIConnectionMonitoringBroadcaster{}
+	broadcaster.partner = partner
+	return broadcaster
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) RequestConnectionMonitoring(request *siri.XMLGetConnectionMonitoring, message *audit.BigQueryMessage) *siri.SIRIConnectionMonitoringResponse {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	logStashEvent := connector.newLogStashEvent()
+	defer audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	logXMLConnectionMonitoringRequest(logStashEvent, &request.XMLConnectionMonitoringRequest)
+	logStashEvent["requestorRef"] = request.RequestorRef()
+
+	response := &siri.SIRIConnectionMonitoringResponse{
+		Address:                   connector.Partner().Address(),
+		ProducerRef:               connector.Partner().ProducerRef(),
+		ResponseMessageIdentifier: connector.Partner().IdentifierGenerator(RESPONSE_MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+	}
+
+	response.SIRIConnectionMonitoringFeederDelivery = connector.getConnectionMonitoringFeederDelivery(tx, &request.XMLConnectionMonitoringRequest)
+
+	if !response.Status {
+		message.Status = "Error"
+		message.ErrorDetails = response.ErrorString()
+	}
+	message.RequestIdentifier = request.MessageIdentifier()
+	message.ResponseIdentifier = response.ResponseMessageIdentifier
+
+	logSIRIConnectionMonitoringResponse(logStashEvent, response)
+
+	return response
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) getConnectionMonitoringFeederDelivery(tx *model.Transaction, request *siri.XMLConnectionMonitoringRequest) siri.SIRIConnectionMonitoringFeederDelivery {
+	delivery := siri.SIRIConnectionMonitoringFeederDelivery{
+		RequestMessageRef: request.MessageIdentifier(),
+		ResponseTimestamp: connector.Clock().Now(),
+		Status:            true,
+	}
+
+	links, unknownLinks := connector.connectionLinks(request.ConnectionLinkRefs())
+	if len(unknownLinks) != 0 {
+		delivery.Status = false
+		delivery.ErrorType = "InvalidDataReferencesError"
+		delivery.ErrorText = fmt.Sprintf("Unknown ConnectionLink(s) %v", strings.Join(unknownLinks, ","))
+		return delivery
+	}
+
+	startTime := request.StartTime()
+	if startTime.IsZero() {
+		startTime = connector.Clock().Now()
+	}
+	var endTime time.Time
+	if request.PreviewInterval() != 0 {
+		endTime = startTime.Add(request.PreviewInterval())
+	}
+
+	for i := range links {
+		connector.addFeederArrivals(tx, &delivery, &links[i], startTime, endTime)
+	}
+
+	sort.SliceStable(delivery.MonitoredFeederArrivals, func(i, j int) bool {
+		return delivery.MonitoredFeederArrivals[i].AimedArrivalTime.Before(delivery.MonitoredFeederArrivals[j].AimedArrivalTime)
+	})
+
+	return delivery
+}
+
+// Returns the requested ConnectionLinks, or all of them without requested ref
+func (connector *SIRIConnectionMonitoringBroadcaster) connectionLinks(refs []string) (links []ConnectionLink, unknownRefs []string) {
+	connectionLinks := connector.Partner().Referential().ConnectionLinks()
+	if len(refs) == 0 {
+		return connectionLinks.FindAll(), nil
+	}
+
+	for _, ref := range refs {
+		link, ok := connectionLinks.Find(ref)
+		if !ok {
+			unknownRefs = append(unknownRefs, ref)
+			continue
+		}
+		links = append(links, link)
+	}
+	return links, unknownRefs
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) addFeederArrivals(tx *model.Transaction, delivery *siri.SIRIConnectionMonitoringFeederDelivery, link *ConnectionLink, startTime, endTime time.Time) {
+	feederStopArea, ok := tx.Model().StopAreas().FindByObjectId(link.FeederStopAreaObjectID())
+	if !ok {
+		logger.Log.Debugf("Cannot find feeder StopArea %v of ConnectionLink %v", link.FeederStopArea, link.Id)
+		return
+	}
+	feederLineId, ok := connector.lineId(tx, link.FeederLineObjectID)
+	if !ok {
+		return
+	}
+
+	distributorDepartures, ok := connector.distributorDepartures(tx, link)
+	if !ok {
+		return
+	}
+
+	stopAreaRef := link.FeederStopArea
+	if objectid, ok := feederStopArea.ReferentOrSelfObjectId(connector.remoteObjectIDKind()); ok {
+		stopAreaRef = objectid.Value()
+	}
+
+	stopVisits := []model.StopVisit{}
+	for _, stopAreaId := range tx.Model().StopAreas().FindFamily(feederStopArea.Id()) {
+		stopVisits = append(stopVisits, tx.Model().StopVisits().FindByStopAreaId(stopAreaId)...)
+	}
+	for i := range stopVisits {
+		stopVisit := &stopVisits[i]
+		aimedArrivalTime := stopVisit.Schedules.Schedule("aimed").ArrivalTime()
+		if aimedArrivalTime.IsZero() {
+			continue
+		}
+		arrivalTime := stopVisit.Schedules.Schedule("expected").ArrivalTime()
+		if arrivalTime.IsZero() {
+			arrivalTime = aimedArrivalTime
+		}
+		if arrivalTime.Before(startTime) || (!endTime.IsZero() && arrivalTime.After(endTime)) {
+			continue
+		}
+		if !connector.reachDistributor(distributorDepartures, aimedArrivalTime.Add(link.TransferDuration()), link.MaximumWaitDuration()) {
+			continue
+		}
+
+		vehicleJourney, ok := tx.Model().VehicleJourneys().Find(stopVisit.VehicleJourneyId)
+		if !ok || (feederLineId != "" && vehicleJourney.LineId != feederLineId) {
+			continue
+		}
+		line, ok := tx.Model().Lines().Find(vehicleJourney.LineId)
+		if !ok {
+			continue
+		}
+		lineObjectId, ok := line.ObjectID(connector.remoteObjectIDKind())
+		if !ok {
+			continue
+		}
+		datedVehicleJourneyRef, ok := connector.datedVehicleJourneyRef(&vehicleJourney)
+		if !ok {
+			continue
+		}
+		modelDate := serviceDate(tx.Model(), vehicleJourney.ServiceDate)
+		dataFrameRef := connector.Partner().IdentifierGenerator(DATA_FRAME_IDENTIFIER).NewIdentifier(IdentifierAttributes{Id: modelDate.String()})
+
+		if stopVisit.ArrivalStatus == model.STOP_VISIT_ARRIVAL_CANCELLED {
+			delivery.MonitoredFeederArrivalCancellations = append(delivery.MonitoredFeederArrivalCancellations, &siri.SIRIMonitoredFeederArrivalCancellation{
+				RecordedAtTime:         stopVisit.RecordedAt,
+				ItemRef:                fmt.Sprintf("%v:%v", link.Id, datedVehicleJourneyRef),
+				ConnectionLinkRef:      link.Id,
+				StopAreaRef:            stopAreaRef,
+				LineRef:                lineObjectId.Value(),
+				DirectionRef:           vehicleJourney.Attributes["DirectionRef"],
+				DataFrameRef:           dataFrameRef,
+				DatedVehicleJourneyRef: datedVehicleJourneyRef,
+			})
+			continue
+		}
+
+		delivery.MonitoredFeederArrivals = append(delivery.MonitoredFeederArrivals, &siri.SIRIMonitoredFeederArrival{
+			RecordedAtTime:         stopVisit.RecordedAt,
+			ItemIdentifier:         fmt.Sprintf("%v:%v", link.Id, datedVehicleJourneyRef),
+			ConnectionLinkRef:      link.Id,
+			StopAreaRef:            stopAreaRef,
+			LineRef:                lineObjectId.Value(),
+			DirectionRef:           vehicleJourney.Attributes["DirectionRef"],
+			DataFrameRef:           dataFrameRef,
+			DatedVehicleJourneyRef: datedVehicleJourneyRef,
+			PublishedLineName:      line.Name,
+			VehicleAtStop:          stopVisit.VehicleAtStop,
+			AimedArrivalTime:       aimedArrivalTime,
+			ExpectedArrivalTime:    stopVisit.Schedules.Schedule("expected").ArrivalTime(),
+		})
+	}
+}
+
+// Returns the aimed departure times of the distributor StopVisits
+func (connector *SIRIConnectionMonitoringBroadcaster) distributorDepartures(tx *model.Transaction, link *ConnectionLink) ([]time.Time, bool) {
+	distributorStopArea, ok := tx.Model().StopAreas().FindByObjectId(link.DistributorStopAreaObjectID())
+	if !ok {
+		logger.Log.Debugf("Cannot find distributor StopArea %v of ConnectionLink %v", link.DistributorStopArea, link.Id)
+		return nil, false
+	}
+	distributorLineId, ok := connector.lineId(tx, link.DistributorLineObjectID)
+	if !ok {
+		return nil, false
+	}
+
+	departures := []time.Time{}
+	stopVisits := tx.Model().StopVisits().FindByStopAreaId(distributorStopArea.Id())
+	for i := range stopVisits {
+		stopVisit := &stopVisits[i]
+		if stopVisit.DepartureStatus == model.STOP_VISIT_DEPARTURE_CANCELLED {
+			continue
+		}
+		if distributorLineId != "" {
+			vehicleJourney, ok := tx.Model().VehicleJourneys().Find(stopVisit.VehicleJourneyId)
+			if !ok || vehicleJourney.LineId != distributorLineId {
+				continue
+			}
+		}
+		departure := stopVisit.Schedules.Schedule("aimed").DepartureTime()
+		if !departure.IsZero() {
+			departures = append(departures, departure)
+		}
+	}
+	return departures, true
+}
+
+// Returns true if a distributor departure leaves between the arrival and the
+// end of the maximum wait
+func (connector *SIRIConnectionMonitoringBroadcaster) reachDistributor(departures []time.Time, arrival time.Time, maximumWait time.Duration) bool {
+	latestDeparture := arrival.Add(maximumWait)
+	for _, departure := range departures {
+		if !departure.Before(arrival) && !departure.After(latestDeparture) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the Line id of the ConnectionLink Line, an empty id when no Line is
+// defined, and false when the Line can't be found
+func (connector *SIRIConnectionMonitoringBroadcaster) lineId(tx *model.Transaction, lineObjectId func() (model.ObjectID, bool)) (model.LineId, bool) {
+	objectid, ok := lineObjectId()
+	if !ok {
+		return "", true
+	}
+	line, ok := tx.Model().Lines().FindByObjectId(objectid)
+	if !ok {
+		logger.Log.Debugf("Cannot find ConnectionLink Line %v", objectid.String())
+		return "", false
+	}
+	return line.Id(), true
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) datedVehicleJourneyRef(vehicleJourney *model.VehicleJourney) (string, bool) {
+	vehicleJourneyId, ok := vehicleJourney.ObjectID(connector.remoteObjectIDKind())
+	if ok {
+		return vehicleJourneyId.Value(), true
+	}
+	defaultObjectID, ok := vehicleJourney.ObjectID("_default")
+	if !ok {
+		return "", false
+	}
+	referenceGenerator := connector.Partner().IdentifierGenerator(REFERENCE_IDENTIFIER)
+	return referenceGenerator.NewIdentifier(IdentifierAttributes{Type: "VehicleJourney", Default: defaultObjectID.Value()}), true
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) remoteObjectIDKind() string {
+	return connector.Partner().RemoteObjectIDKind(SIRI_CONNECTION_MONITORING_REQUEST_BROADCASTER)
+}
+
+func (connector *SIRIConnectionMonitoringBroadcaster) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "ConnectionMonitoringRequestBroadcaster"
+	return event
+}
+
+func (factory *SIRIConnectionMonitoringBroadcasterFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfLocalCredentials()
+}
+
+func (factory *SIRIConnectionMonitoringBroadcasterFactory) CreateConnector(partner *Partner) Connector {
+	return NewSIRIConnectionMonitoringBroadcaster(partner)
+}
+
+func logXMLConnectionMonitoringRequest(logStashEvent audit.LogStashEvent, request *siri.XMLConnectionMonitoringRequest) {
+	logStashEvent["siriType"] = "ConnectionMonitoringResponse"
+	logStashEvent["messageIdentifier"] = request.MessageIdentifier()
+	logStashEvent["requestTimestamp"] = request.RequestTimestamp().String()
+	logStashEvent["connectionLinkRefs"] = strings.Join(request.ConnectionLinkRefs(), ",")
+	logStashEvent["requestXML"] = request.RawXML()
+}
+
+func logSIRIConnectionMonitoringResponse(logStashEvent audit.LogStashEvent, response *siri.SIRIConnectionMonitoringResponse) {
+	logStashEvent["address"] = response.Address
+	logStashEvent["producerRef"] = response.ProducerRef
+	logStashEvent["responseMessageIdentifier"] = response.ResponseMessageIdentifier
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp.String()
+	logStashEvent["status"] = strconv.FormatBool(response.Status)
+	if !response.Status {
+		logStashEvent["errorType"] = response.ErrorType
+		if response.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber)
+		}
+		logStashEvent["errorText"] = response.ErrorText
+	}
+	xml, err := response.BuildXML()
+	if err != nil {
+		logStashEvent["responseXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["responseXML"] = xml
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_SIRIConnectionMonitoringBroadcaster_RequestConnectionMonitoring(t *testing.T) {
+	fakeClock := clock.NewFakeClock()
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "internal"
+	partner.SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+
+	connector := NewSIRIConnectionMonitoringBroadcaster(partner)
+	connector.SetClock(fakeClock)
+
+	newStopArea := func(code string) model.StopAreaId {
+		stopArea := referential.Model().StopAreas().New()
+		stopArea.SetObjectID(model.NewObjectID("internal", code))
+		stopArea.Save()
+		return stopArea.Id()
+	}
+	newLine := func(code string) model.LineId {
+		line := referential.Model().Lines().New()
+		line.SetObjectID(model.NewObjectID("internal", code))
+		line.Name = code
+		line.Save()
+		return line.Id()
+	}
+	newStopVisit := func(code string, lineId model.LineId, stopAreaId model.StopAreaId, arrival, departure time.Duration) *model.StopVisit {
+		vehicleJourney := referential.Model().VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("internal", code))
+		vehicleJourney.LineId = lineId
+		vehicleJourney.Save()
+
+		stopVisit := referential.Model().StopVisits().New()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.StopAreaId = stopAreaId
+		if arrival != 0 {
+			stopVisit.Schedules.SetArrivalTime("aimed", fakeClock.Now().Add(arrival))
+		}
+		if departure != 0 {
+			stopVisit.Schedules.SetDepartureTime("aimed", fakeClock.Now().Add(departure))
+		}
+		stopVisit.Save()
+		return &stopVisit
+	}
+
+	rail := newStopArea("rail")
+	bus := newStopArea("bus")
+	rer := newLine("RER")
+	tram := newLine("TRAM")
+	busLine := newLine("42")
+
+	// Feeder arrivals
+	newStopVisit("rer-1", rer, rail, 10*time.Minute, 0)
+	cancelled := newStopVisit("rer-2", rer, rail, 20*time.Minute, 0)
+	cancelled.ArrivalStatus = model.STOP_VISIT_ARRIVAL_CANCELLED
+	cancelled.Save()
+	newStopVisit("tram-1", tram, rail, 15*time.Minute, 0)  // Not the feeder Line
+	newStopVisit("rer-3", rer, rail, 50*time.Minute, 0)    // No distributor departure after the transfer
+	newStopVisit("rer-4", rer, rail, 3*time.Hour, 0)       // After the preview interval
+	newStopVisit("rer-6", rer, rail, 1*time.Minute, 0)     // Distributor departure after the maximum wait
+	newStopVisit("bus-1", busLine, bus, 0, 45*time.Minute) // Distributor departure
+
+	// Feeder arrival on a child StopArea of the feeder StopArea
+	platform := referential.Model().StopAreas().New()
+	platform.SetObjectID(model.NewObjectID("internal", "platform"))
+	platform.ParentId = rail
+	platform.Save()
+	newStopVisit("rer-5", rer, platform.Id(), 30*time.Minute, 0)
+
+	referential.ConnectionLinks().Save(&ConnectionLink{
+		Id:                  "link",
+		ObjectIDKind:        "internal",
+		FeederStopArea:      "rail",
+		FeederLine:          "RER",
+		DistributorStopArea: "bus",
+		DistributorLine:     "42",
+		TransferTime:        300,
+		MaximumWaitTime:     2100,
+	})
+
+	request, err := siri.NewXMLGetConnectionMonitoringFromContent([]byte(`<ns7:GetConnectionMonitoring xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>ConnectionMonitoring:Test:0</ns2:MessageIdentifier>
+    <ns2:PreviewInterval>PT2H</ns2:PreviewInterval>
+    <ns2:ConnectionLinkRef>link</ns2:ConnectionLinkRef>
+  </Request>
+</ns7:GetConnectionMonitoring>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestConnectionMonitoring(request, &audit.BigQueryMessage{})
+
+	if !response.Status {
+		t.Fatalf("Response has wrong status: %v", response.ErrorString())
+	}
+	if len(response.MonitoredFeederArrivals) != 2 {
+		t.Fatalf("Response should have 2 MonitoredFeederArrivals, got: %v", len(response.MonitoredFeederArrivals))
+	}
+	if arrival := response.MonitoredFeederArrivals[1]; arrival.DatedVehicleJourneyRef != "rer-5" {
+		t.Errorf("Arrival of the feeder StopArea descendant should be monitored, got: %v", arrival)
+	}
+	arrival := response.MonitoredFeederArrivals[0]
+	if arrival.DatedVehicleJourneyRef != "rer-1" || arrival.LineRef != "RER" || arrival.StopAreaRef != "rail" || arrival.ConnectionLinkRef != "link" {
+		t.Errorf("Wrong MonitoredFeederArrival: %v", arrival)
+	}
+	if expected := fakeClock.Now().Add(10 * time.Minute); !arrival.AimedArrivalTime.Equal(expected) {
+		t.Errorf("Wrong AimedArrivalTime:\n got: %v\nwant: %v", arrival.AimedArrivalTime, expected)
+	}
+
+	if len(response.MonitoredFeederArrivalCancellations) != 1 {
+		t.Fatalf("Response should have 1 MonitoredFeederArrivalCancellation, got: %v", len(response.MonitoredFeederArrivalCancellations))
+	}
+	if cancellation := response.MonitoredFeederArrivalCancellations[0]; cancellation.DatedVehicleJourneyRef != "rer-2" {
+		t.Errorf("Wrong MonitoredFeederArrivalCancellation: %v", cancellation)
+	}
+}
+
+func Test_SIRIConnectionMonitoringBroadcaster_UnknownConnectionLink(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "internal"
+
+	connector := NewSIRIConnectionMonitoringBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	request, err := siri.NewXMLGetConnectionMonitoringFromContent([]byte(`<ns7:GetConnectionMonitoring xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:ConnectionLinkRef>unknown</ns2:ConnectionLinkRef>
+  </Request>
+</ns7:GetConnectionMonitoring>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := &audit.BigQueryMessage{}
+	response := connector.RequestConnectionMonitoring(request, message)
+
+	if response.Status || response.ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Response should have an InvalidDataReferencesError, got: %v %v", response.Status, response.ErrorType)
+	}
+	if message.Status != "Error" {
+		t.Errorf("BigQuery message should have an Error status, got: %v", message.Status)
+	}
+}
@@ -72,6 +72,16 @@ func (connector *SIRIEstimatedTimetableBroadcaster) getEstimatedTimetableDeliver
 		Status:            true,
 	}
 
+	if errorType, errorText := invalidEstimatedTimetableRequest(request); errorType != "" {
+		delivery.Status = false
+		delivery.ErrorType = errorType
+		delivery.ErrorText = errorText
+		logSIRIEstimatedTimetableDelivery(logStashEvent, delivery, monitoringRefs, lineRefs)
+		return delivery
+	}
+
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER)
+
 	selectors := []model.StopVisitSelector{}
 
 	if request.PreviewInterval() != 0 {
@@ -81,17 +91,76 @@ func (connector *SIRIEstimatedTimetableBroadcaster) getEstimatedTimetableDeliver
 			now = request.StartTime()
 		}
 		selectors = append(selectors, model.StopVisitSelectorByTime(now, now.Add(duration)))
+	} else if !request.StartTime().IsZero() {
+		selectors = append(selectors, model.StopVisitSelectorAfterTime(request.StartTime()))
+	}
+	if len(request.OperatorRefs()) != 0 {
+		selectors = append(selectors, connector.operatorSelector(request.OperatorRefs()))
 	}
 	selector := model.CompositeStopVisitSelector(selectors)
 
+	// DirectionRefs by requested LineRef. A Line requested without
+	// DirectionRef is broadcasted in all directions
+	directionRefs := make(map[string]map[string]struct{})
+	allDirections := make(map[string]struct{})
+	for _, lineDirection := range request.LineDirections() {
+		if lineDirection.DirectionRef() == "" {
+			allDirections[lineDirection.LineRef()] = struct{}{}
+			continue
+		}
+		if directionRefs[lineDirection.LineRef()] == nil {
+			directionRefs[lineDirection.LineRef()] = make(map[string]struct{})
+		}
+		directionRefs[lineDirection.LineRef()][lineDirection.DirectionRef()] = struct{}{}
+	}
+	for lineRef := range allDirections {
+		delete(directionRefs, lineRef)
+	}
+
+	// The same LineRef can be requested in several LineDirections
+	requestedLines := []string{}
+	for _, lineRef := range request.Lines() {
+		requestedLines = appendLineRef(requestedLines, lineRef)
+	}
+
+	// Selected VehicleJourneys when VehicleJourneyRefs are requested
+	var vehicleJourneyIds map[model.VehicleJourneyId]struct{}
+	unknownVehicleJourneys := []string{}
+	if len(request.VehicleJourneyRefs()) != 0 {
+		vehicleJourneyIds = make(map[model.VehicleJourneyId]struct{})
+		for _, vehicleJourneyRef := range request.VehicleJourneyRefs() {
+			vehicleJourney, ok := tx.Model().VehicleJourneys().FindByObjectId(model.NewObjectID(objectidKind, vehicleJourneyRef))
+			if !ok {
+				unknownVehicleJourneys = append(unknownVehicleJourneys, vehicleJourneyRef)
+				continue
+			}
+			vehicleJourneyIds[vehicleJourney.Id()] = struct{}{}
+
+			// Without requested Lines, the Lines of the VehicleJourneys are used
+			if len(request.Lines()) == 0 {
+				requestedLines = appendVehicleJourneyLineRef(tx, requestedLines, &vehicleJourney, objectidKind)
+			}
+		}
+		if len(unknownVehicleJourneys) == len(request.VehicleJourneyRefs()) {
+			delivery.Status = false
+			delivery.ErrorType = "InvalidDataReferencesError"
+			delivery.ErrorText = fmt.Sprintf("Unknown VehicleJourney(s) %v", strings.Join(unknownVehicleJourneys, ","))
+			logSIRIEstimatedTimetableDelivery(logStashEvent, delivery, monitoringRefs, lineRefs)
+			return delivery
+		}
+	}
+
 	// SIRIEstimatedJourneyVersionFrame
-	for _, lineId := range request.Lines() {
-		lineObjectId := model.NewObjectID(connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER), lineId)
+	unknownLines := []string{}
+	for _, lineId := range requestedLines {
+		lineObjectId := model.NewObjectID(objectidKind, lineId)
 		line, ok := tx.Model().Lines().FindByObjectId(lineObjectId)
 		if !ok {
 			logger.Log.Debugf("Cannot find requested line Estimated Time Table with id %v at %v", lineObjectId.String(), connector.Clock().Now())
+			unknownLines = append(unknownLines, lineId)
 			continue
 		}
+		directions := directionRefs[lineId]
 
 		journeyFrame := &siri.SIRIEstimatedJourneyVersionFrame{
 			RecordedAtTime: currentTime,
@@ -99,8 +168,19 @@ func (connector *SIRIEstimatedTimetableBroadcaster) getEstimatedTimetableDeliver
 
 		// SIRIEstimatedVehicleJourney
 		for _, vehicleJourney := range tx.Model().VehicleJourneys().FindByLineId(line.Id()) {
+			if vehicleJourneyIds != nil {
+				if _, ok := vehicleJourneyIds[vehicleJourney.Id()]; !ok {
+					continue
+				}
+			}
+			if directions != nil {
+				if _, ok := directions[vehicleJourney.Attributes["DirectionRef"]]; !ok {
+					continue
+				}
+			}
+
 			// Handle vehicleJourney Objectid
-			vehicleJourneyId, ok := vehicleJourney.ObjectID(connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER))
+			vehicleJourneyId, ok := vehicleJourney.ObjectID(objectidKind)
 			var datedVehicleJourneyRef string
 			if ok {
 				datedVehicleJourneyRef = vehicleJourneyId.Value()
@@ -137,7 +217,7 @@ func (connector *SIRIEstimatedTimetableBroadcaster) getEstimatedTimetableDeliver
 					continue
 				}
 
-				connector.resolveOperatorRef(estimatedVehicleJourney.References, stopVisit, tx)
+				connector.resolveOperatorRef(estimatedVehicleJourney.References, &stopVisit, &vehicleJourney, tx)
 
 				monitoringRefs = append(monitoringRefs, stopAreaId)
 				estimatedCall := &siri.SIRIEstimatedCall{
@@ -173,11 +253,70 @@ func (connector *SIRIEstimatedTimetableBroadcaster) getEstimatedTimetableDeliver
 		}
 	}
 
+	if len(unknownLines) != 0 && len(unknownLines) == len(requestedLines) {
+		delivery.Status = false
+		delivery.ErrorType = "InvalidDataReferencesError"
+		delivery.ErrorText = fmt.Sprintf("Unknown Line(s) %v", strings.Join(unknownLines, ","))
+	}
+
 	logSIRIEstimatedTimetableDelivery(logStashEvent, delivery, monitoringRefs, lineRefs)
 
 	return delivery
 }
 
+// Appends the LineRef of the VehicleJourney Line when it isn't already present
+func appendVehicleJourneyLineRef(tx *model.Transaction, lineRefs []string, vehicleJourney *model.VehicleJourney, objectidKind string) []string {
+	line, ok := tx.Model().Lines().Find(vehicleJourney.LineId)
+	if !ok {
+		return lineRefs
+	}
+	lineObjectId, ok := line.ObjectID(objectidKind)
+	if !ok {
+		return lineRefs
+	}
+	return appendLineRef(lineRefs, lineObjectId.Value())
+}
+
+// Appends the LineRef when it isn't already present
+func appendLineRef(lineRefs []string, lineRef string) []string {
+	for _, existing := range lineRefs {
+		if existing == lineRef {
+			return lineRefs
+		}
+	}
+	return append(lineRefs, lineRef)
+}
+
+// Returns the error type and text when the request parameters are invalid
+func invalidEstimatedTimetableRequest(request *siri.XMLEstimatedTimetableRequest) (string, string) {
+	if request.PreviewInterval() < 0 {
+		return "ParametersIgnoredError", fmt.Sprintf("Invalid negative PreviewInterval %v", request.PreviewInterval())
+	}
+	for _, lineDirection := range request.LineDirections() {
+		if lineDirection.LineRef() == "" && lineDirection.DirectionRef() != "" {
+			return "InvalidDataReferencesError", fmt.Sprintf("DirectionRef %v without LineRef", lineDirection.DirectionRef())
+		}
+	}
+	return "", ""
+}
+
+// Selects the StopVisits operated by one of the requested Operators
+func (connector *SIRIEstimatedTimetableBroadcaster) operatorSelector(operatorRefs []string) model.StopVisitSelector {
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER)
+	operatorSelectors := make([]model.StopVisitSelector, 0, len(operatorRefs))
+	for _, operatorRef := range operatorRefs {
+		operatorSelectors = append(operatorSelectors, model.StopVisitSelectorByOperator(model.NewObjectID(objectidKind, operatorRef)))
+	}
+	return func(stopVisit model.StopVisit) bool {
+		for _, operatorSelector := range operatorSelectors {
+			if operatorSelector(stopVisit) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func (connector *SIRIEstimatedTimetableBroadcaster) stopPointRef(stopAreaId model.StopAreaId, tx *model.Transaction) (model.StopArea, string, bool) {
 	stopPointRef, ok := tx.Model().StopAreas().Find(stopAreaId)
 	if !ok {
@@ -233,26 +372,14 @@ func (connector *SIRIEstimatedTimetableBroadcaster) noDestinationRefRewrite(orig
 	return false
 }
 
-func (connector *SIRIEstimatedTimetableBroadcaster) resolveOperatorRef(refs map[string]string, stopVisit model.StopVisit, tx *model.Transaction) {
+func (connector *SIRIEstimatedTimetableBroadcaster) resolveOperatorRef(refs map[string]string, stopVisit *model.StopVisit, vehicleJourney *model.VehicleJourney, tx *model.Transaction) {
 	if _, ok := refs["OperatorRef"]; ok {
 		return
 	}
 
-	operatorRef, ok := stopVisit.Reference("OperatorRef")
-	if !ok || operatorRef == (model.Reference{}) || operatorRef.ObjectId == nil {
-		return
-	}
-	operator, ok := tx.Model().Operators().FindByObjectId(*operatorRef.ObjectId)
-	if !ok {
-		refs["OperatorRef"] = operatorRef.ObjectId.Value()
-		return
-	}
-	obj, ok := operator.ObjectID(connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER))
-	if !ok {
-		refs["OperatorRef"] = operatorRef.ObjectId.Value()
-		return
+	if operatorRef, ok := broadcastedOperatorRef(tx, stopVisit, vehicleJourney, connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_REQUEST_BROADCASTER)); ok {
+		refs["OperatorRef"] = operatorRef
 	}
-	refs["OperatorRef"] = obj.Value()
 }
 
 func (connector *SIRIEstimatedTimetableBroadcaster) newLogStashEvent() audit.LogStashEvent {
@@ -275,6 +402,10 @@ func logXMLEstimatedTimetableRequest(logStashEvent audit.LogStashEvent, request
 	logStashEvent["messageIdentifier"] = request.MessageIdentifier()
 	logStashEvent["requestTimestamp"] = request.RequestTimestamp().String()
 	logStashEvent["requestedLines"] = strings.Join(request.Lines(), ",")
+	logStashEvent["requestedOperators"] = strings.Join(request.OperatorRefs(), ",")
+	logStashEvent["requestedVehicleJourneys"] = strings.Join(request.VehicleJourneyRefs(), ",")
+	logStashEvent["startTime"] = request.StartTime().String()
+	logStashEvent["previewInterval"] = request.PreviewInterval().String()
 	logStashEvent["requestXML"] = request.RawXML()
 }
 
@@ -1,8 +1,11 @@
 package core
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -295,6 +298,76 @@ func Test_SIRIEstimatedTimetableBroadcaster_RequestStopAreaWithReferent(t *testi
 	}
 }
 
+func Test_SIRIEstimatedTimetableBroadcaster_RequestOperatorSelector(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	connector := NewSIRIEstimatedTimetableBroadcaster(partner)
+	fakeClock := clock.NewFakeClock()
+	clock.SetDefaultClock(fakeClock)
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Monitored = true
+	stopArea.Save()
+
+	line := referential.model.Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:2:LOC"))
+	line.Save()
+
+	operator := referential.Model().Operators().New()
+	operator.SetObjectID(model.NewObjectID("internal", "op1"))
+	operator.SetObjectID(model.NewObjectID("objectidKind", "OP1"))
+	operator.Save()
+
+	for _, operatorRef := range []string{"op1", "op2"} {
+		vehicleJourney := referential.model.VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", "vehicleJourney-"+operatorRef))
+		vehicleJourney.LineId = line.Id()
+		vehicleJourney.References.SetObjectId("OperatorRef", model.NewObjectID("internal", operatorRef))
+		vehicleJourney.Save()
+
+		stopVisit := referential.model.StopVisits().New()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.StopAreaId = stopArea.Id()
+		stopVisit.PassageOrder = 1
+		stopVisit.Schedules.SetArrivalTime("aimed", connector.Clock().Now().Add(1*time.Minute))
+		stopVisit.Save()
+	}
+
+	file, err := os.Open("testdata/estimated_timetable_request_operator.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := siri.NewXMLGetEstimatedTimetableFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestLine(request, &audit.BigQueryMessage{})
+
+	if len(response.EstimatedJourneyVersionFrames) != 1 {
+		t.Fatalf("Response should have 1 EstimatedJourneyVersionFrame, got: %v", len(response.EstimatedJourneyVersionFrames))
+	}
+	vehicleJourneys := response.EstimatedJourneyVersionFrames[0].EstimatedVehicleJourneys
+	if len(vehicleJourneys) != 1 {
+		t.Fatalf("Response should have 1 EstimatedVehicleJourney, got: %v", len(vehicleJourneys))
+	}
+	if vehicleJourneys[0].DatedVehicleJourneyRef != "vehicleJourney-op1" {
+		t.Errorf("Wrong DatedVehicleJourneyRef:\n got: %v\n want: vehicleJourney-op1", vehicleJourneys[0].DatedVehicleJourneyRef)
+	}
+	if operatorRef := vehicleJourneys[0].References["OperatorRef"]; operatorRef != "OP1" {
+		t.Errorf("Wrong OperatorRef:\n got: %v\n want: OP1", operatorRef)
+	}
+}
+
 func Test_SIRIEstimatedTimetableBroadcasterFactory_Validate(t *testing.T) {
 	partner := &Partner{
 		slug:           "partner",
@@ -415,3 +488,136 @@ func Test_SIRIEstimatedTimetableBroadcaster_RemoteObjectIDKindAbsent(t *testing.
 		t.Errorf("RemoteObjectIDKind should be egals to Kind2")
 	}
 }
+
+func estimatedTimetableFiltersRequest(t *testing.T, filters string) *siri.XMLGetEstimatedTimetable {
+	content := `<ns7:GetEstimatedTimetable xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <ServiceRequestInfo>
+    <ns2:RequestTimestamp>2016-09-07T09:11:25.174Z</ns2:RequestTimestamp>
+    <ns2:RequestorRef>test</ns2:RequestorRef>
+    <ns2:MessageIdentifier>EstimatedTimetable:Test:0</ns2:MessageIdentifier>
+  </ServiceRequestInfo>
+  <Request version="2.0:FR-IDF-2.4">
+    <ns2:RequestTimestamp>2016-09-07T09:11:25.174Z</ns2:RequestTimestamp>
+    <ns2:MessageIdentifier>EstimatedTimetable:Test:0</ns2:MessageIdentifier>
+    ` + filters + `
+  </Request>
+  <RequestExtension />
+</ns7:GetEstimatedTimetable>`
+	request, err := siri.NewXMLGetEstimatedTimetableFromContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func Test_SIRIEstimatedTimetableBroadcaster_RequestFilters(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	connector := NewSIRIEstimatedTimetableBroadcaster(partner)
+	fakeClock := clock.NewFakeClock()
+	clock.SetDefaultClock(fakeClock)
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Monitored = true
+	stopArea.Save()
+
+	line := referential.model.Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "line"))
+	line.Save()
+
+	// vj1 leaves in 10 minutes, vj2 in 2 hours in the opposite direction
+	for i, direction := range []string{"Aller", "Retour"} {
+		vehicleJourney := referential.model.VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", fmt.Sprintf("vj%v", i+1)))
+		vehicleJourney.LineId = line.Id()
+		vehicleJourney.Attributes.Set("DirectionRef", direction)
+		vehicleJourney.Save()
+
+		stopVisit := referential.model.StopVisits().New()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.StopAreaId = stopArea.Id()
+		stopVisit.PassageOrder = 1
+		stopVisit.Schedules.SetArrivalTime("aimed", fakeClock.Now().Add(10*time.Minute+time.Duration(i)*110*time.Minute))
+		stopVisit.Save()
+	}
+
+	startTime := fakeClock.Now().Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		filters         string
+		vehicleJourneys []string
+		errorType       string
+		errorText       string
+	}{
+		{
+			filters:         `<ns2:Lines><ns2:LineRef>line</ns2:LineRef></ns2:Lines>`,
+			vehicleJourneys: []string{"vj1", "vj2"},
+		},
+		{
+			filters:         `<ns2:PreviewInterval>PT1H</ns2:PreviewInterval><ns2:Lines><ns2:LineRef>line</ns2:LineRef></ns2:Lines>`,
+			vehicleJourneys: []string{"vj1"},
+		},
+		{
+			filters:         `<ns2:StartTime>` + startTime + `</ns2:StartTime><ns2:Lines><ns2:LineRef>line</ns2:LineRef></ns2:Lines>`,
+			vehicleJourneys: []string{"vj2"},
+		},
+		{
+			filters:         `<ns2:Lines><ns2:LineDirection><ns2:LineRef>line</ns2:LineRef><ns2:DirectionRef>Retour</ns2:DirectionRef></ns2:LineDirection></ns2:Lines>`,
+			vehicleJourneys: []string{"vj2"},
+		},
+		{
+			filters:         `<ns2:Lines><ns2:LineDirection><ns2:LineRef>line</ns2:LineRef><ns2:DirectionRef>Aller</ns2:DirectionRef></ns2:LineDirection><ns2:LineDirection><ns2:LineRef>line</ns2:LineRef><ns2:DirectionRef>Retour</ns2:DirectionRef></ns2:LineDirection></ns2:Lines>`,
+			vehicleJourneys: []string{"vj1", "vj2"},
+		},
+		{
+			filters:         `<ns2:VehicleJourneyRef>vj1</ns2:VehicleJourneyRef>`,
+			vehicleJourneys: []string{"vj1"},
+		},
+		{
+			filters:   `<ns2:PreviewInterval>-PT1H</ns2:PreviewInterval><ns2:Lines><ns2:LineRef>line</ns2:LineRef></ns2:Lines>`,
+			errorType: "ParametersIgnoredError",
+			errorText: "Invalid negative PreviewInterval -1h0m0s",
+		},
+		{
+			filters:   `<ns2:Lines><ns2:LineDirection><ns2:DirectionRef>Aller</ns2:DirectionRef></ns2:LineDirection></ns2:Lines>`,
+			errorType: "InvalidDataReferencesError",
+			errorText: "DirectionRef Aller without LineRef",
+		},
+		{
+			filters:   `<ns2:VehicleJourneyRef>unknown</ns2:VehicleJourneyRef>`,
+			errorType: "InvalidDataReferencesError",
+			errorText: "Unknown VehicleJourney(s) unknown",
+		},
+		{
+			filters:   `<ns2:Lines><ns2:LineRef>unknown</ns2:LineRef></ns2:Lines>`,
+			errorType: "InvalidDataReferencesError",
+			errorText: "Unknown Line(s) unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		response := connector.RequestLine(estimatedTimetableFiltersRequest(t, tt.filters), &audit.BigQueryMessage{})
+
+		if tt.errorText != "" {
+			if response.Status || response.ErrorType != tt.errorType || response.ErrorText != tt.errorText {
+				t.Errorf("Wrong error for %v:\n got: %v %v %v\n want: %v %v", tt.filters, response.Status, response.ErrorType, response.ErrorText, tt.errorType, tt.errorText)
+			}
+			continue
+		}
+
+		vehicleJourneys := []string{}
+		for _, frame := range response.EstimatedJourneyVersionFrames {
+			for _, vehicleJourney := range frame.EstimatedVehicleJourneys {
+				vehicleJourneys = append(vehicleJourneys, vehicleJourney.DatedVehicleJourneyRef)
+			}
+		}
+		sort.Strings(vehicleJourneys)
+		if !reflect.DeepEqual(vehicleJourneys, tt.vehicleJourneys) {
+			t.Errorf("Wrong VehicleJourneys for %v:\n got: %v\n want: %v", tt.filters, vehicleJourneys, tt.vehicleJourneys)
+		}
+	}
+}
@@ -59,6 +59,8 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) HandleSubscripti
 
 	var lineIds, subIds []string
 
+	validator := newSubscriptionRequestValidator(connector.Partner(), connector.Clock().Now())
+
 	for _, ett := range request.XMLSubscriptionETTEntries() {
 		logStashEvent := connector.newLogStashEvent()
 		logSIRIEstimatedTimeTableSubscriptionEntry(logStashEvent, ett)
@@ -77,9 +79,8 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) HandleSubscripti
 			logger.Log.Debugf("EstimatedTimeTable subscription request Could not find line(s) with id : %v", strings.Join(lineIds, ","))
 			rs.ErrorType = "InvalidDataReferencesError"
 			rs.ErrorText = fmt.Sprintf("Unknown Line(s) %v", strings.Join(lineIds, ","))
-		} else {
+		} else if validator.validate(&rs, "EstimatedTimeTableBroadcast", ett.InitialTerminationTime()) {
 			rs.Status = true
-			rs.ValidUntil = ett.InitialTerminationTime()
 		}
 
 		resps = append(resps, rs)
@@ -87,7 +88,8 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) HandleSubscripti
 		logSIRIEstimatedTimeTableSubscriptionResponseEntry(logStashEvent, &rs)
 		audit.CurrentLogStash().WriteEvent(logStashEvent)
 
-		if len(lineIds) != 0 {
+		if !rs.Status {
+			message.Status = "Error"
 			continue
 		}
 
@@ -101,6 +103,8 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) HandleSubscripti
 		}
 
 		for _, r := range resources {
+			r.SubscribedUntil = rs.ValidUntil
+
 			line, ok := connector.Partner().Model().Lines().FindByObjectId(*r.Reference.ObjectId)
 			if !ok {
 				continue
@@ -135,6 +139,22 @@ func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) addLineStopVisit
 	}
 }
 
+// Sends again all the StopVisits of the Subscription Lines
+func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) ResyncSubscription(sub *Subscription) {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	for _, resource := range sub.ResourcesByObjectIDCopy() {
+		line, ok := tx.Model().Lines().FindByObjectId(*resource.Reference.ObjectId)
+		if !ok {
+			continue
+		}
+
+		resource.ClearLastStates()
+		connector.addLineStopVisits(sub, resource, line.Id())
+	}
+}
+
 func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) checkLines(ett *siri.XMLEstimatedTimetableSubscriptionRequestEntry) (resources []SubscribedResource, lineIds []string) {
 	for _, lineId := range ett.Lines() {
 		lineObjectId := model.NewObjectID(connector.partner.RemoteObjectIDKind(SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER), lineId)
@@ -173,6 +193,7 @@ func (ettb *SIRIEstimatedTimeTableSubscriptionBroadcaster) fillOptions(s *Subscr
 	}
 	s.SetSubscriptionOption("ChangeBeforeUpdates", changeBeforeUpdates)
 	s.SetSubscriptionOption("MessageIdentifier", request.MessageIdentifier())
+	s.SetSubscriptionOption("HeartbeatInterval", request.HeartbeatInterval())
 }
 
 func (connector *SIRIEstimatedTimeTableSubscriptionBroadcaster) HandleBroadcastEvent(event *model.StopMonitoringBroadcastEvent) {
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type FacilityMonitoringBroadcaster interface {
+	RequestFacilities(*siri.XMLGetFacilityMonitoring, *audit.BigQueryMessage) *siri.SIRIFacilityMonitoringResponse
+}
+
+type SIRIFacilityMonitoringBroadcaster struct {
+	clock.ClockConsumer
+
+	siriConnector
+}
+
+type SIRIFacilityMonitoringBroadcasterFactory struct{}
+
+func NewSIRIFacilityMonitoringBroadcaster(partner *Partner) *SIRIFacilityMonitoringBroadcaster {
+	broadcaster := &SIRIFacilityMonitoringBroadcaster{}
+	broadcaster.partner = partner
+	return broadcaster
+}
+
+func (connector *SIRIFacilityMonitoringBroadcaster) RequestFacilities(request *siri.XMLGetFacilityMonitoring, message *audit.BigQueryMessage) *siri.SIRIFacilityMonitoringResponse {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	logStashEvent := connector.newLogStashEvent()
+	defer audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	logXMLFacilityMonitoringRequest(logStashEvent, &request.XMLFacilityMonitoringRequest)
+	logStashEvent["requestorRef"] = request.RequestorRef()
+
+	response := &siri.SIRIFacilityMonitoringResponse{
+		Address:                   connector.Partner().Address(),
+		ProducerRef:               connector.Partner().ProducerRef(),
+		ResponseMessageIdentifier: connector.Partner().IdentifierGenerator(RESPONSE_MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+	}
+
+	response.SIRIFacilityMonitoringDelivery = connector.getFacilityMonitoringDelivery(tx, &request.XMLFacilityMonitoringRequest, logStashEvent)
+
+	if !response.SIRIFacilityMonitoringDelivery.Status {
+		message.Status = "Error"
+		message.ErrorDetails = response.SIRIFacilityMonitoringDelivery.ErrorString()
+	}
+	message.StopAreas = request.StopPointRefs()
+	message.RequestIdentifier = request.MessageIdentifier()
+	message.ResponseIdentifier = response.ResponseMessageIdentifier
+
+	logSIRIFacilityMonitoringResponse(logStashEvent, response)
+
+	return response
+}
+
+func (connector *SIRIFacilityMonitoringBroadcaster) getFacilityMonitoringDelivery(tx *model.Transaction, request *siri.XMLFacilityMonitoringRequest, logStashEvent audit.LogStashEvent) siri.SIRIFacilityMonitoringDelivery {
+	delivery := siri.SIRIFacilityMonitoringDelivery{
+		RequestMessageRef: request.MessageIdentifier(),
+		ResponseTimestamp: connector.Clock().Now(),
+		Status:            true,
+	}
+
+	builder := NewBroadcastFacilityMonitoringBuilder(connector.Partner(), SIRI_FACILITY_MONITORING_REQUEST_BROADCASTER)
+	builder.SetClock(connector.Clock())
+	builder.StopPointRefs = request.StopPointRefs()
+	builder.FacilityRefs = request.FacilityRefs()
+
+	facilities, unknownStopPointRefs := builder.Facilities(tx)
+	if len(unknownStopPointRefs) != 0 && len(unknownStopPointRefs) == len(request.StopPointRefs()) {
+		delivery.Status = false
+		delivery.ErrorType = "InvalidDataReferencesError"
+		delivery.ErrorText = fmt.Sprintf("Unknown StopPointRef(s) %v", strings.Join(unknownStopPointRefs, ","))
+	} else {
+		delivery.FacilityConditions = builder.BuildFacilityConditions(tx, facilities)
+	}
+
+	logSIRIFacilityMonitoringDelivery(logStashEvent, delivery)
+
+	return delivery
+}
+
+func (connector *SIRIFacilityMonitoringBroadcaster) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "FacilityMonitoringRequestBroadcaster"
+	return event
+}
+
+func (factory *SIRIFacilityMonitoringBroadcasterFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfLocalCredentials()
+}
+
+func (factory *SIRIFacilityMonitoringBroadcasterFactory) CreateConnector(partner *Partner) Connector {
+	return NewSIRIFacilityMonitoringBroadcaster(partner)
+}
+
+func logXMLFacilityMonitoringRequest(logStashEvent audit.LogStashEvent, request *siri.XMLFacilityMonitoringRequest) {
+	logStashEvent["siriType"] = "FacilityMonitoringResponse"
+	logStashEvent["messageIdentifier"] = request.MessageIdentifier()
+	logStashEvent["requestTimestamp"] = request.RequestTimestamp().String()
+	logStashEvent["stopPointRefs"] = strings.Join(request.StopPointRefs(), ",")
+	logStashEvent["facilityRefs"] = strings.Join(request.FacilityRefs(), ",")
+	logStashEvent["requestXML"] = request.RawXML()
+}
+
+func logSIRIFacilityMonitoringDelivery(logStashEvent audit.LogStashEvent, delivery siri.SIRIFacilityMonitoringDelivery) {
+	logStashEvent["requestMessageRef"] = delivery.RequestMessageRef
+	logStashEvent["responseTimestamp"] = delivery.ResponseTimestamp.String()
+	logStashEvent["status"] = strconv.FormatBool(delivery.Status)
+	if !delivery.Status {
+		logStashEvent["errorType"] = delivery.ErrorType
+		if delivery.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(delivery.ErrorNumber)
+		}
+		logStashEvent["errorText"] = delivery.ErrorText
+	}
+}
+
+func logSIRIFacilityMonitoringResponse(logStashEvent audit.LogStashEvent, response *siri.SIRIFacilityMonitoringResponse) {
+	logStashEvent["address"] = response.Address
+	logStashEvent["producerRef"] = response.ProducerRef
+	logStashEvent["responseMessageIdentifier"] = response.ResponseMessageIdentifier
+	xml, err := response.BuildXML()
+	if err != nil {
+		logStashEvent["responseXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["responseXML"] = xml
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_SIRIFacilityMonitoringBroadcaster_RequestFacilities(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIFacilityMonitoringBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Save()
+
+	stopArea2 := referential.Model().StopAreas().New()
+	stopArea2.SetObjectID(model.NewObjectID("objectidKind", "stopArea2"))
+	stopArea2.Save()
+
+	lift := referential.Model().Facilities().New()
+	lift.SetObjectID(model.NewObjectID("objectidKind", "lift1"))
+	lift.StopAreaId = stopArea.Id()
+	lift.Name = "Lift 1"
+	lift.Status = model.FACILITY_STATUS_NOT_AVAILABLE
+	lift.Attributes.Set("FacilityClass", "lift")
+	lift.Save()
+
+	escalator := referential.Model().Facilities().New()
+	escalator.SetObjectID(model.NewObjectID("objectidKind", "escalator1"))
+	escalator.StopAreaId = stopArea.Id()
+	escalator.Status = model.FACILITY_STATUS_AVAILABLE
+	escalator.Save()
+
+	expired := referential.Model().Facilities().New()
+	expired.SetObjectID(model.NewObjectID("objectidKind", "expired"))
+	expired.StopAreaId = stopArea2.Id()
+	expired.ValidityEndTime = fakeClock.Now().Add(-time.Minute)
+	expired.Save()
+
+	request, err := siri.NewXMLGetFacilityMonitoringFromContent([]byte(`<ns7:GetFacilityMonitoring xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>FacilityMonitoring:Test:0</ns2:MessageIdentifier>
+    <ns2:StopPointRef>stopArea1</ns2:StopPointRef>
+    <ns2:StopPointRef>stopArea2</ns2:StopPointRef>
+  </Request>
+</ns7:GetFacilityMonitoring>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestFacilities(request, &audit.BigQueryMessage{})
+
+	if response.Address != "http://ara" {
+		t.Errorf("Response has wrong adress:\n got: %v\n want: http://ara", response.Address)
+	}
+	if !response.Status {
+		t.Errorf("Response has wrong status: %v", response.ErrorString())
+	}
+	if expected := "FacilityMonitoring:Test:0"; response.RequestMessageRef != expected {
+		t.Errorf("Wrong RequestMessageRef:\n got: %v\nwant: %v", response.RequestMessageRef, expected)
+	}
+
+	conditions := response.FacilityConditions
+	if len(conditions) != 2 {
+		t.Fatalf("Response should contain the 2 valid Facilities, got: %v", len(conditions))
+	}
+	if conditions[0].FacilityRef != "escalator1" || conditions[0].Status != "available" {
+		t.Errorf("Wrong first FacilityCondition: %v", conditions[0])
+	}
+	if conditions[1].FacilityRef != "lift1" || conditions[1].Status != "notAvailable" || conditions[1].FacilityClass != "lift" || conditions[1].StopPointRef != "stopArea1" {
+		t.Errorf("Wrong second FacilityCondition: %v", conditions[1])
+	}
+}
+
+func Test_SIRIFacilityMonitoringBroadcaster_RequestFacilities_FacilityRef(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIFacilityMonitoringBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Save()
+
+	stopArea2 := referential.Model().StopAreas().New()
+	stopArea2.SetObjectID(model.NewObjectID("objectidKind", "stopArea2"))
+	stopArea2.Save()
+
+	lift := referential.Model().Facilities().New()
+	lift.SetObjectID(model.NewObjectID("objectidKind", "lift1"))
+	lift.StopAreaId = stopArea.Id()
+	lift.Name = "Lift 1"
+	lift.Status = model.FACILITY_STATUS_NOT_AVAILABLE
+	lift.Attributes.Set("FacilityClass", "lift")
+	lift.Save()
+
+	escalator := referential.Model().Facilities().New()
+	escalator.SetObjectID(model.NewObjectID("objectidKind", "escalator1"))
+	escalator.StopAreaId = stopArea.Id()
+	escalator.Status = model.FACILITY_STATUS_AVAILABLE
+	escalator.Save()
+
+	expired := referential.Model().Facilities().New()
+	expired.SetObjectID(model.NewObjectID("objectidKind", "expired"))
+	expired.StopAreaId = stopArea2.Id()
+	expired.ValidityEndTime = fakeClock.Now().Add(-time.Minute)
+	expired.Save()
+
+	request, err := siri.NewXMLGetFacilityMonitoringFromContent([]byte(`<ns7:GetFacilityMonitoring xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>FacilityMonitoring:Test:0</ns2:MessageIdentifier>
+    <ns2:FacilityRef>lift1</ns2:FacilityRef>
+  </Request>
+</ns7:GetFacilityMonitoring>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestFacilities(request, &audit.BigQueryMessage{})
+
+	if len(response.FacilityConditions) != 1 || response.FacilityConditions[0].FacilityRef != "lift1" {
+		t.Errorf("Response should only contain the requested Facility: %v", response.FacilityConditions)
+	}
+}
+
+func Test_SIRIFacilityMonitoringBroadcaster_RequestFacilities_UnknownStopPoint(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIFacilityMonitoringBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	connector.SetClock(clock.NewFakeClock())
+
+	request, err := siri.NewXMLGetFacilityMonitoringFromContent([]byte(`<ns7:GetFacilityMonitoring xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>FacilityMonitoring:Test:0</ns2:MessageIdentifier>
+    <ns2:StopPointRef>unknown</ns2:StopPointRef>
+  </Request>
+</ns7:GetFacilityMonitoring>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := &audit.BigQueryMessage{}
+	response := connector.RequestFacilities(request, message)
+
+	if response.Status {
+		t.Errorf("Response should have a false status")
+	}
+	if response.ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Wrong ErrorType: %v", response.ErrorType)
+	}
+	if message.Status != "Error" {
+		t.Errorf("BigQuery message should have an Error status, got: %v", message.Status)
+	}
+}
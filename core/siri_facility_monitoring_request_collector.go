@@ -0,0 +1,208 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type FacilityMonitoringRequestCollector interface {
+	RequestFacilities()
+}
+
+type SIRIFacilityMonitoringRequestCollectorFactory struct{}
+
+type SIRIFacilityMonitoringRequestCollector struct {
+	clock.ClockConsumer
+
+	siriConnector
+
+	updateSubscriber UpdateSubscriber
+}
+
+func NewSIRIFacilityMonitoringRequestCollector(partner *Partner) *SIRIFacilityMonitoringRequestCollector {
+	connector := &SIRIFacilityMonitoringRequestCollector{}
+	connector.partner = partner
+	manager := partner.Referential().CollectManager()
+	connector.updateSubscriber = manager.BroadcastUpdateEvent
+
+	return connector
+}
+
+func (connector *SIRIFacilityMonitoringRequestCollector) SetUpdateSubscriber(updateSubscriber UpdateSubscriber) {
+	connector.updateSubscriber = updateSubscriber
+}
+
+// Requests the status of all the Facilities of the partner
+func (connector *SIRIFacilityMonitoringRequestCollector) RequestFacilities() {
+	logStashEvent := connector.newLogStashEvent()
+	defer audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	message := connector.newBQEvent()
+	defer audit.CurrentBigQuery(string(connector.Partner().Referential().Slug())).WriteEvent(message)
+
+	startTime := connector.Clock().Now()
+
+	request := &siri.SIRIGetFacilityMonitoringRequest{
+		RequestorRef: connector.SIRIPartner().RequestorRef(),
+	}
+	request.MessageIdentifier = connector.Partner().IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier()
+	request.RequestTimestamp = connector.Clock().Now()
+
+	logSIRIFacilityMonitoringRequest(logStashEvent, message, request)
+
+	xmlResponse, err := connector.SIRIPartner().SOAPClient().FacilityMonitoring(request)
+	logStashEvent["responseTime"] = connector.Clock().Since(startTime).String()
+	message.ProcessingTime = connector.Clock().Since(startTime).Seconds()
+	if err != nil {
+		e := fmt.Sprintf("Error during GetFacilityMonitoring: %v", err)
+		logStashEvent["status"] = "false"
+		logStashEvent["errorDescription"] = e
+
+		message.Status = "Error"
+		message.ErrorDetails = e
+		return
+	}
+
+	logXMLFacilityMonitoringResponse(logStashEvent, message, xmlResponse)
+	if !xmlResponse.Status() {
+		return
+	}
+
+	events := connector.facilityUpdateEvents(xmlResponse)
+	for _, event := range events {
+		connector.broadcastUpdateEvent(event)
+	}
+	connector.deleteMissingFacilities(events)
+}
+
+// Deletes the Facilities collected from the partner which aren't returned
+// anymore
+func (connector *SIRIFacilityMonitoringRequestCollector) deleteMissingFacilities(events []*model.FacilityUpdateEvent) {
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_FACILITY_MONITORING_REQUEST_COLLECTOR)
+
+	returned := make(map[string]struct{})
+	for _, event := range events {
+		returned[event.ObjectId.Value()] = struct{}{}
+	}
+
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	facilities := tx.Model().Facilities().FindAll()
+	for i := range facilities {
+		if facilities[i].Origin != string(connector.partner.Slug()) {
+			continue
+		}
+		objectid, ok := facilities[i].ObjectID(objectidKind)
+		if !ok {
+			continue
+		}
+		if _, ok := returned[objectid.Value()]; ok {
+			continue
+		}
+		tx.Model().Facilities().Delete(&facilities[i])
+	}
+	tx.Commit()
+}
+
+func (connector *SIRIFacilityMonitoringRequestCollector) facilityUpdateEvents(xmlResponse *siri.XMLFacilityMonitoringResponse) (events []*model.FacilityUpdateEvent) {
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_FACILITY_MONITORING_REQUEST_COLLECTOR)
+
+	for _, condition := range xmlResponse.XMLFacilityConditions() {
+		if condition.FacilityRef() == "" {
+			continue
+		}
+
+		event := model.NewFacilityUpdateEvent()
+		event.Origin = string(connector.partner.Slug())
+		event.ObjectId = model.NewObjectID(objectidKind, condition.FacilityRef())
+		event.StopAreaObjectId = model.NewObjectID(objectidKind, condition.StopPointRef())
+		event.Name = condition.Name()
+		event.FacilityClass = condition.FacilityClass()
+		event.Status = model.NormalizeFacilityStatus(condition.Status())
+		event.Description = condition.Description()
+		event.ValidityStartTime = condition.ValidityStartTime()
+		event.ValidityEndTime = condition.ValidityEndTime()
+
+		events = append(events, event)
+	}
+	return
+}
+
+func (connector *SIRIFacilityMonitoringRequestCollector) broadcastUpdateEvent(event model.UpdateEvent) {
+	if connector.updateSubscriber != nil {
+		connector.updateSubscriber(event)
+	}
+}
+
+func (connector *SIRIFacilityMonitoringRequestCollector) newBQEvent() *audit.BigQueryMessage {
+	return &audit.BigQueryMessage{
+		Type:      "FacilityMonitoringRequest",
+		Protocol:  "siri",
+		Direction: "sent",
+		Partner:   string(connector.partner.Slug()),
+		Status:    "OK",
+	}
+}
+
+func (connector *SIRIFacilityMonitoringRequestCollector) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "FacilityMonitoringRequestCollector"
+	return event
+}
+
+func (factory *SIRIFacilityMonitoringRequestCollectorFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_URL)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_CREDENTIAL)
+}
+
+func (factory *SIRIFacilityMonitoringRequestCollectorFactory) CreateConnector(partner *Partner) Connector {
+	return NewSIRIFacilityMonitoringRequestCollector(partner)
+}
+
+func logSIRIFacilityMonitoringRequest(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, request *siri.SIRIGetFacilityMonitoringRequest) {
+	logStashEvent["siriType"] = "FacilityMonitoringRequest"
+	logStashEvent["messageIdentifier"] = request.MessageIdentifier
+	logStashEvent["requestorRef"] = request.RequestorRef
+	logStashEvent["requestTimestamp"] = request.RequestTimestamp.String()
+	xml, err := request.BuildXML()
+	if err != nil {
+		logStashEvent["requestXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["requestXML"] = xml
+
+	message.RequestIdentifier = request.MessageIdentifier
+	message.RequestRawMessage = xml
+	message.RequestSize = int64(len(xml))
+}
+
+func logXMLFacilityMonitoringResponse(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, response *siri.XMLFacilityMonitoringResponse) {
+	message.ResponseIdentifier = response.ResponseMessageIdentifier()
+
+	logStashEvent["address"] = response.Address()
+	logStashEvent["producerRef"] = response.ProducerRef()
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef()
+	logStashEvent["responseMessageIdentifier"] = response.ResponseMessageIdentifier()
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp().String()
+	logStashEvent["status"] = strconv.FormatBool(response.Status())
+	if !response.Status() {
+		message.Status = "Error"
+		logStashEvent["errorType"] = response.ErrorType()
+		if response.ErrorType() == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber())
+		}
+		logStashEvent["errorText"] = response.ErrorText()
+		logStashEvent["errorDescription"] = response.ErrorDescription()
+		message.ErrorDetails = response.ErrorString()
+	}
+	logStashEvent["responseXML"] = response.RawXML()
+	message.ResponseRawMessage = response.RawXML()
+	message.ResponseSize = int64(len(message.ResponseRawMessage))
+}
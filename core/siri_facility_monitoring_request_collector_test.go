@@ -0,0 +1,115 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func Test_SIRIFacilityMonitoringRequestCollector_RequestFacilities(t *testing.T) {
+	audit.SetCurrentLogstash(audit.NewFakeLogStash())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := os.Open("testdata/facilitymonitoring-response-soap.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		io.Copy(w, file)
+	}))
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+
+	connector := NewSIRIFacilityMonitoringRequestCollector(partner)
+
+	var events []*model.FacilityUpdateEvent
+	connector.SetUpdateSubscriber(func(event model.UpdateEvent) {
+		events = append(events, event.(*model.FacilityUpdateEvent))
+	})
+
+	connector.RequestFacilities()
+
+	if len(events) != 2 {
+		t.Fatalf("Collector should broadcast an event by FacilityCondition, got: %v", len(events))
+	}
+
+	event := events[0]
+	if expected := model.NewObjectID("objectidKind", "NINOXE:Facility:Lift:1:LOC"); event.ObjectId != expected {
+		t.Errorf("Wrong ObjectId:\n got: %v\nwant: %v", event.ObjectId, expected)
+	}
+	if expected := model.NewObjectID("objectidKind", "NINOXE:StopPoint:SP:24:LOC"); event.StopAreaObjectId != expected {
+		t.Errorf("Wrong StopAreaObjectId:\n got: %v\nwant: %v", event.StopAreaObjectId, expected)
+	}
+	if event.Origin != "partner" {
+		t.Errorf("Wrong Origin: %v", event.Origin)
+	}
+	if event.Status != model.FACILITY_STATUS_NOT_AVAILABLE || event.Description != "Under maintenance" || event.FacilityClass != "lift" {
+		t.Errorf("Wrong event attributes: %v", event)
+	}
+	if expected := time.Date(2017, time.January, 1, 18, 0, 0, 0, time.UTC); !event.ValidityEndTime.Equal(expected) {
+		t.Errorf("Wrong ValidityEndTime:\n got: %v\nwant: %v", event.ValidityEndTime, expected)
+	}
+
+	if events[1].Status != model.FACILITY_STATUS_AVAILABLE {
+		t.Errorf("Wrong second event Status: %v", events[1].Status)
+	}
+}
+
+func Test_SIRIFacilityMonitoringRequestCollector_DeleteMissingFacilities(t *testing.T) {
+	audit.SetCurrentLogstash(audit.NewFakeLogStash())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := os.Open("testdata/facilitymonitoring-response-soap.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		io.Copy(w, file)
+	}))
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+
+	returned := referential.Model().Facilities().New()
+	returned.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Facility:Lift:1:LOC"))
+	returned.Origin = "partner"
+	returned.Save()
+
+	missing := referential.Model().Facilities().New()
+	missing.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Facility:Lift:2:LOC"))
+	missing.Origin = "partner"
+	missing.Save()
+
+	other := referential.Model().Facilities().New()
+	other.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Facility:Lift:3:LOC"))
+	other.Origin = "other"
+	other.Save()
+
+	connector := NewSIRIFacilityMonitoringRequestCollector(partner)
+	connector.SetUpdateSubscriber(func(event model.UpdateEvent) {})
+
+	connector.RequestFacilities()
+
+	if _, ok := referential.Model().Facilities().Find(returned.Id()); !ok {
+		t.Errorf("Facility returned by the partner should be kept")
+	}
+	if _, ok := referential.Model().Facilities().Find(missing.Id()); ok {
+		t.Errorf("Facility not returned anymore by the partner should be deleted")
+	}
+	if _, ok := referential.Model().Facilities().Find(other.Id()); !ok {
+		t.Errorf("Facility of another partner should be kept")
+	}
+}
@@ -0,0 +1,471 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+/*
+Broadcasts the status changes of the Facilities.
+
+A Facility is notified when it is saved in the model and its status,
+description or validity period changed since the last notification. All the
+current Facilities are sent when the Subscription is created or resynced.
+
+When a notified Facility expires, it is notified once as available. When it is
+deleted, it is notified once with an unknown status.
+*/
+type SIRIFacilityMonitoringSubscriptionBroadcaster struct {
+	clock.ClockConsumer
+
+	siriConnector
+
+	toBroadcast map[SubscriptionId][]model.FacilityId
+	mutex       *sync.Mutex //protect the map
+
+	stop chan struct{}
+}
+
+type SIRIFacilityMonitoringSubscriptionBroadcasterFactory struct{}
+
+func (factory *SIRIFacilityMonitoringSubscriptionBroadcasterFactory) CreateConnector(partner *Partner) Connector {
+	if _, ok := partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER); !ok {
+		partner.CreateSubscriptionRequestDispatcher()
+	}
+	return newSIRIFacilityMonitoringSubscriptionBroadcaster(partner)
+}
+
+func (factory *SIRIFacilityMonitoringSubscriptionBroadcasterFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_URL)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_CREDENTIAL)
+	apiPartner.ValidatePresenceOfSetting(LOCAL_CREDENTIAL)
+}
+
+func newSIRIFacilityMonitoringSubscriptionBroadcaster(partner *Partner) *SIRIFacilityMonitoringSubscriptionBroadcaster {
+	connector := &SIRIFacilityMonitoringSubscriptionBroadcaster{}
+	connector.partner = partner
+	connector.mutex = &sync.Mutex{}
+	connector.toBroadcast = make(map[SubscriptionId][]model.FacilityId)
+	return connector
+}
+
+func facilityResourceObjectID() model.ObjectID {
+	return model.NewObjectID("FacilityResource", "Facility")
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) HandleSubscriptionRequest(request *siri.XMLSubscriptionRequest, message *audit.BigQueryMessage) (resps []siri.SIRIResponseStatus) {
+	var stopPointRefs, subIds []string
+
+	validator := newSubscriptionRequestValidator(connector.Partner(), connector.Clock().Now())
+
+	for _, fm := range request.XMLSubscriptionFMEntries() {
+		logStashEvent := connector.newLogStashEvent()
+		logSIRIFacilityMonitoringSubscriptionEntry(logStashEvent, fm)
+
+		rs := siri.SIRIResponseStatus{
+			RequestMessageRef: fm.MessageIdentifier(),
+			SubscriberRef:     fm.SubscriberRef(),
+			SubscriptionRef:   fm.SubscriptionIdentifier(),
+			ResponseTimestamp: connector.Clock().Now(),
+		}
+
+		stopPointRefs = append(stopPointRefs, fm.StopPointRefs()...)
+
+		if unknownRefs := connector.unknownStopPointRefs(fm); len(unknownRefs) != 0 {
+			logger.Log.Debugf("FacilityMonitoring subscription request Could not find StopPointRef(s) : %v", strings.Join(unknownRefs, ","))
+			rs.ErrorType = "InvalidDataReferencesError"
+			rs.ErrorText = fmt.Sprintf("Unknown StopPointRef(s) %v", strings.Join(unknownRefs, ","))
+		} else if validator.validate(&rs, "FacilityMonitoringBroadcast", fm.InitialTerminationTime()) {
+			rs.Status = true
+		}
+
+		resps = append(resps, rs)
+
+		logSIRIFacilityMonitoringSubscriptionResponseEntry(logStashEvent, &rs)
+		audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+		if !rs.Status {
+			message.Status = "Error"
+			continue
+		}
+
+		subIds = append(subIds, fm.SubscriptionIdentifier())
+
+		sub, ok := connector.Partner().Subscriptions().FindByExternalId(fm.SubscriptionIdentifier())
+		if !ok {
+			sub = connector.Partner().Subscriptions().New("FacilityMonitoringBroadcast")
+			sub.SetExternalId(fm.SubscriptionIdentifier())
+		}
+
+		sub.SetSubscriptionOption("StopPointRef", strings.Join(fm.StopPointRefs(), ","))
+		sub.SetSubscriptionOption("FacilityRef", strings.Join(fm.FacilityRefs(), ","))
+		sub.SetSubscriptionOption("MessageIdentifier", fm.MessageIdentifier())
+		sub.SetSubscriptionOption("HeartbeatInterval", request.HeartbeatInterval())
+
+		obj := facilityResourceObjectID()
+		r := sub.Resource(obj)
+		if r == nil {
+			ref := model.Reference{
+				ObjectId: &obj,
+				Type:     "Facility",
+			}
+			r = sub.CreateAddNewResource(ref)
+			r.SubscribedAt = connector.Clock().Now()
+		}
+		r.SubscribedUntil = rs.ValidUntil
+
+		sub.Save()
+
+		connector.addFacilities(sub)
+	}
+	message.Type = "FacilityMonitoringSubscriptionRequest"
+	message.SubscriptionIdentifiers = subIds
+	message.StopAreas = stopPointRefs
+
+	return resps
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) unknownStopPointRefs(fm *siri.XMLFacilityMonitoringSubscriptionRequestEntry) (refs []string) {
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER)
+
+	for _, stopPointRef := range fm.StopPointRefs() {
+		if _, ok := connector.partner.Model().StopAreas().FindByObjectId(model.NewObjectID(objectidKind, stopPointRef)); !ok {
+			refs = append(refs, stopPointRef)
+		}
+	}
+	return
+}
+
+// Sends again all the current Facilities
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) ResyncSubscription(sub *Subscription) {
+	for _, resource := range sub.ResourcesByObjectIDCopy() {
+		resource.ClearLastStates()
+	}
+	connector.addFacilities(sub)
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) HandleFacilityBroadcastEvent(event *model.FacilityBroadcastEvent) {
+	connector.checkEvent(event.FacilityId)
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) checkEvent(facilityId model.FacilityId) {
+	facility, ok := connector.Partner().Model().Facilities().Find(facilityId)
+	if !ok || facility.Origin == string(connector.partner.Slug()) {
+		return
+	}
+
+	for _, sub := range connector.Partner().Subscriptions().FindSubscriptionsByKind("FacilityMonitoringBroadcast") {
+		resource := sub.Resource(facilityResourceObjectID())
+		if resource == nil || resource.SubscribedUntil.Before(connector.Clock().Now()) {
+			continue
+		}
+		connector.addFacility(sub.Id(), facilityId)
+	}
+}
+
+// Adds all the current Facilities of the Subscription to the next notification
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) addFacilities(sub *Subscription) {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	facilities, _ := connector.newBuilder(sub).Facilities(tx)
+	for i := range facilities {
+		connector.addFacility(sub.Id(), facilities[i].Id())
+	}
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) addFacility(subId SubscriptionId, facilityId model.FacilityId) {
+	connector.mutex.Lock()
+	connector.toBroadcast[subId] = append(connector.toBroadcast[subId], facilityId)
+	connector.mutex.Unlock()
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) Start() {
+	logger.Log.Debugf("Start FacilityMonitoringSubscriptionBroadcaster")
+
+	connector.stop = make(chan struct{})
+	go connector.run()
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) Stop() {
+	if connector.stop != nil {
+		close(connector.stop)
+	}
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) run() {
+	c := connector.Clock().After(5 * time.Second)
+
+	for {
+		select {
+		case <-connector.stop:
+			logger.Log.Debugf("facility monitoring broadcaster routine stop")
+			return
+		case <-c:
+			connector.prepareNotifications()
+			// Redeliver the notifications which failed previously
+			connector.NotificationQueue().Deliver()
+
+			c = connector.Clock().After(5 * time.Second)
+		}
+	}
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) prepareNotifications() {
+	connector.mutex.Lock()
+
+	events := connector.toBroadcast
+	connector.toBroadcast = make(map[SubscriptionId][]model.FacilityId)
+
+	connector.mutex.Unlock()
+
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	for _, sub := range connector.Partner().Subscriptions().FindSubscriptionsByKind("FacilityMonitoringBroadcast") {
+		resource := sub.Resource(facilityResourceObjectID())
+		if resource == nil || resource.SubscribedUntil.Before(connector.Clock().Now()) {
+			continue
+		}
+
+		delivery := connector.buildNotification(tx, sub, resource, events[sub.Id()])
+		if len(delivery.FacilityConditions) == 0 {
+			continue
+		}
+		connector.sendDelivery(delivery)
+	}
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) newBuilder(sub *Subscription) *BroadcastFacilityMonitoringBuilder {
+	builder := NewBroadcastFacilityMonitoringBuilder(connector.Partner(), SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER)
+	builder.SetClock(connector.Clock())
+	if sub.SubscriptionOption("StopPointRef") != "" {
+		builder.StopPointRefs = strings.Split(sub.SubscriptionOption("StopPointRef"), ",")
+	}
+	if sub.SubscriptionOption("FacilityRef") != "" {
+		builder.FacilityRefs = strings.Split(sub.SubscriptionOption("FacilityRef"), ",")
+	}
+	return builder
+}
+
+// Builds the notification of the given changed Facilities and of the notified
+// Facilities which expired or have been deleted since. The last states are
+// identified by the Facility ObjectID, which is kept across model reloads.
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) buildNotification(tx *model.Transaction, sub *Subscription, resource *SubscribedResource, facilityIds []model.FacilityId) *siri.SIRINotifyFacilityMonitoring {
+	delivery := &siri.SIRINotifyFacilityMonitoring{
+		Address:                   connector.Partner().Address(),
+		ProducerRef:               connector.Partner().ProducerRef(),
+		ResponseMessageIdentifier: connector.Partner().IdentifierGenerator(RESPONSE_MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+		SubscriberRef:             connector.SIRIPartner().SubscriberRef(),
+		SubscriptionIdentifier:    sub.ExternalId(),
+		ResponseTimestamp:         connector.Clock().Now(),
+		Status:                    true,
+		RequestMessageRef:         sub.SubscriptionOption("MessageIdentifier"),
+	}
+
+	builder := connector.newBuilder(sub)
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER)
+
+	notified := make(map[model.FacilityId]struct{})
+	for _, facilityId := range facilityIds {
+		if _, ok := notified[facilityId]; ok {
+			continue
+		}
+		notified[facilityId] = struct{}{}
+
+		facility, ok := tx.Model().Facilities().Find(facilityId)
+		if !ok || facility.Origin == string(connector.partner.Slug()) || !builder.Match(tx, &facility) {
+			continue
+		}
+		objectid, ok := facility.ObjectID(objectidKind)
+		if !ok {
+			continue
+		}
+
+		var fmlc *facilityMonitoringLastChange
+		if lastState, ok := resource.LastState(objectid.Value()); ok {
+			fmlc = lastState.(*facilityMonitoringLastChange)
+		}
+		if fmlc != nil && !fmlc.Haschanged(&facility) {
+			continue
+		}
+		// Expired Facilities are ended below
+		condition, ok := builder.BuildFacilityCondition(tx, &facility)
+		if !ok {
+			continue
+		}
+		if fmlc == nil {
+			fmlc = &facilityMonitoringLastChange{}
+			fmlc.InitState(&facility, sub)
+			resource.SetLastState(objectid.Value(), fmlc)
+		} else {
+			fmlc.UpdateState(&facility)
+		}
+		fmlc.condition = condition
+		delivery.FacilityConditions = append(delivery.FacilityConditions, condition)
+	}
+
+	// Notified Facilities which expired or have been deleted
+	for facilityRef := range resource.LastStateKinds() {
+		lastState, _ := resource.LastState(facilityRef)
+		fmlc, ok := lastState.(*facilityMonitoringLastChange)
+		if !ok {
+			continue
+		}
+
+		facility, ok := tx.Model().Facilities().FindByObjectId(model.NewObjectID(objectidKind, facilityRef))
+		if !ok {
+			if !fmlc.ended {
+				delivery.FacilityConditions = append(delivery.FacilityConditions, fmlc.DeletedCondition())
+			}
+			resource.DeleteLastState(facilityRef)
+			continue
+		}
+
+		if fmlc.ended || !facility.IsExpired(connector.Clock().Now()) {
+			continue
+		}
+		if condition, ok := builder.BuildExpiredFacilityCondition(tx, &facility); ok {
+			delivery.FacilityConditions = append(delivery.FacilityConditions, condition)
+		}
+		fmlc.ended = true
+	}
+
+	sortFacilityConditions(delivery.FacilityConditions)
+	return delivery
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) sendDelivery(delivery *siri.SIRINotifyFacilityMonitoring) {
+	queue := connector.NotificationQueue()
+	queue.Push(&QueuedNotification{
+		Type:                   NOTIFY_FACILITY_MONITORING,
+		SubscriptionIdentifier: delivery.SubscriptionIdentifier,
+		FacilityMonitoring:     delivery,
+	}, connector)
+	queue.Deliver()
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) deliverNotification(notification *QueuedNotification) error {
+	delivery := notification.FacilityMonitoring
+
+	logStashEvent := connector.newLogStashEvent()
+	message := connector.newBQEvent()
+
+	logSIRIFacilityMonitoringNotify(logStashEvent, message, delivery)
+	audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	t := connector.Clock().Now()
+
+	err := connector.SIRIPartner().SOAPClient().NotifyFacilityMonitoring(delivery)
+	message.ProcessingTime = connector.Clock().Since(t).Seconds()
+	if err != nil {
+		event := connector.newLogStashEvent()
+		logSIRINotifyError(err.Error(), delivery.ResponseMessageIdentifier, event)
+		audit.CurrentLogStash().WriteEvent(event)
+	}
+
+	audit.CurrentBigQuery(string(connector.Partner().Referential().Slug())).WriteEvent(message)
+	return err
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) newBQEvent() *audit.BigQueryMessage {
+	return &audit.BigQueryMessage{
+		Type:      "NotifyFacilityMonitoring",
+		Protocol:  "siri",
+		Direction: "sent",
+		Partner:   string(connector.partner.Slug()),
+		Status:    "OK",
+	}
+}
+
+func (connector *SIRIFacilityMonitoringSubscriptionBroadcaster) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "FacilityMonitoringSubscriptionBroadcaster"
+	return event
+}
+
+func logSIRIFacilityMonitoringSubscriptionEntry(logStashEvent audit.LogStashEvent, fmEntry *siri.XMLFacilityMonitoringSubscriptionRequestEntry) {
+	logStashEvent["siriType"] = "FacilityMonitoringSubscriptionEntry"
+	logStashEvent["stopPointRefs"] = strings.Join(fmEntry.StopPointRefs(), ",")
+	logStashEvent["facilityRefs"] = strings.Join(fmEntry.FacilityRefs(), ",")
+	logStashEvent["messageIdentifier"] = fmEntry.MessageIdentifier()
+	logStashEvent["subscriberRef"] = fmEntry.SubscriberRef()
+	logStashEvent["subscriptionIdentifier"] = fmEntry.SubscriptionIdentifier()
+	logStashEvent["initialTerminationTime"] = fmEntry.InitialTerminationTime().String()
+	logStashEvent["requestTimestamp"] = fmEntry.RequestTimestamp().String()
+	logStashEvent["requestXML"] = fmEntry.RawXML()
+}
+
+func logSIRIFacilityMonitoringSubscriptionResponseEntry(logStashEvent audit.LogStashEvent, response *siri.SIRIResponseStatus) {
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef
+	logStashEvent["subscriptionRef"] = response.SubscriptionRef
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp.String()
+	logStashEvent["validUntil"] = response.ValidUntil.String()
+	logStashEvent["status"] = strconv.FormatBool(response.Status)
+	if !response.Status {
+		logStashEvent["errorType"] = response.ErrorType
+		if response.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber)
+		}
+		logStashEvent["errorText"] = response.ErrorText
+	}
+}
+
+func logSIRIFacilityMonitoringNotify(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, response *siri.SIRINotifyFacilityMonitoring) {
+	facilityRefs := []string{}
+	mr := make(map[string]struct{})
+	for _, condition := range response.FacilityConditions {
+		facilityRefs = append(facilityRefs, condition.FacilityRef)
+		if condition.StopPointRef != "" {
+			mr[condition.StopPointRef] = struct{}{}
+		}
+	}
+	monitoringRefs := []string{}
+	for k := range mr {
+		monitoringRefs = append(monitoringRefs, k)
+	}
+
+	message.RequestIdentifier = response.RequestMessageRef
+	message.ResponseIdentifier = response.ResponseMessageIdentifier
+	message.StopAreas = monitoringRefs
+	message.SubscriptionIdentifiers = []string{response.SubscriptionIdentifier}
+
+	logStashEvent["siriType"] = "NotifyFacilityMonitoring"
+	logStashEvent["producerRef"] = response.ProducerRef
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef
+	logStashEvent["responseMessageIdentifier"] = response.ResponseMessageIdentifier
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp.String()
+	logStashEvent["subscriberRef"] = response.SubscriberRef
+	logStashEvent["subscriptionIdentifier"] = response.SubscriptionIdentifier
+	logStashEvent["facilityRefs"] = strings.Join(facilityRefs, ",")
+	logStashEvent["monitoringRefs"] = strings.Join(monitoringRefs, ",")
+	logStashEvent["status"] = strconv.FormatBool(response.Status)
+
+	if !response.Status {
+		message.Status = "Error"
+		logStashEvent["errorType"] = response.ErrorType
+		if response.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber)
+		}
+		logStashEvent["errorText"] = response.ErrorText
+		message.ErrorDetails = response.ErrorString()
+	}
+	xml, err := response.BuildXML()
+	if err != nil {
+		logStashEvent["responseXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["responseXML"] = xml
+	message.ResponseRawMessage = xml
+	message.ResponseSize = int64(len(xml))
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_SIRIFacilityMonitoringSubscriptionBroadcaster_HandleSubscriptionRequest(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := newSIRIFacilityMonitoringSubscriptionBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Save()
+
+	lift := referential.Model().Facilities().New()
+	lift.SetObjectID(model.NewObjectID("objectidKind", "lift1"))
+	lift.StopAreaId = stopArea.Id()
+	lift.Status = model.FACILITY_STATUS_NOT_AVAILABLE
+	lift.Save()
+
+	escalator := referential.Model().Facilities().New()
+	escalator.SetObjectID(model.NewObjectID("objectidKind", "escalator1"))
+	escalator.StopAreaId = stopArea.Id()
+	escalator.Status = model.FACILITY_STATUS_AVAILABLE
+	escalator.Save()
+
+	request, err := siri.NewXMLSubscriptionRequestFromContent([]byte(`<ws:Subscribe xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<SubscriptionRequestInfo>
+		<siri:MessageIdentifier>Subscription:Test:0</siri:MessageIdentifier>
+	</SubscriptionRequestInfo>
+	<Request>
+		<siri:FacilityMonitoringSubscriptionRequest>
+			<siri:SubscriberRef>subscriber</siri:SubscriberRef>
+			<siri:SubscriptionIdentifier>FM:Subscription:1</siri:SubscriptionIdentifier>
+			<siri:InitialTerminationTime>` + fakeClock.Now().Add(24*time.Hour).Format(time.RFC3339) + `</siri:InitialTerminationTime>
+			<siri:FacilityMonitoringRequest>
+				<siri:MessageIdentifier>FacilityMonitoring:Test:1</siri:MessageIdentifier>
+				<siri:StopPointRef>stopArea1</siri:StopPointRef>
+			</siri:FacilityMonitoringRequest>
+		</siri:FacilityMonitoringSubscriptionRequest>
+	</Request>
+</ws:Subscribe>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses := connector.HandleSubscriptionRequest(request, &audit.BigQueryMessage{})
+
+	if len(responses) != 1 || !responses[0].Status {
+		t.Fatalf("Subscription should be accepted: %v", responses)
+	}
+
+	sub, ok := partner.Subscriptions().FindByExternalId("FM:Subscription:1")
+	if !ok {
+		t.Fatal("Subscription should be created")
+	}
+	if sub.Kind() != "FacilityMonitoringBroadcast" {
+		t.Errorf("Wrong Subscription kind: %v", sub.Kind())
+	}
+	resource := sub.Resource(facilityResourceObjectID())
+	if resource == nil {
+		t.Fatal("Subscription should have a Facility resource")
+	}
+
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	notification := connector.buildNotification(tx, sub, resource, connector.toBroadcast[sub.Id()])
+	if notification.SubscriptionIdentifier != "FM:Subscription:1" {
+		t.Errorf("Wrong SubscriptionIdentifier: %v", notification.SubscriptionIdentifier)
+	}
+	if len(notification.FacilityConditions) != 2 {
+		t.Fatalf("First notification should contain all the Facilities of the StopArea, got: %v", len(notification.FacilityConditions))
+	}
+
+	notification = connector.buildNotification(tx, sub, resource, connector.toBroadcast[sub.Id()])
+	if len(notification.FacilityConditions) != 0 {
+		t.Fatalf("Notification shouldn't contain unchanged Facilities, got: %v", len(notification.FacilityConditions))
+	}
+
+	lift.Status = model.FACILITY_STATUS_AVAILABLE
+	lift.Save()
+	connector.HandleFacilityBroadcastEvent(&model.FacilityBroadcastEvent{FacilityId: lift.Id()})
+
+	if ids := connector.toBroadcast[sub.Id()]; ids[len(ids)-1] != lift.Id() {
+		t.Errorf("Saved Facility should be added to the next notification: %v", ids)
+	}
+
+	notification = connector.buildNotification(tx, sub, resource, []model.FacilityId{lift.Id()})
+	if len(notification.FacilityConditions) != 1 || notification.FacilityConditions[0].FacilityRef != "lift1" {
+		t.Fatalf("Notification should only contain the changed Facility: %v", notification.FacilityConditions)
+	}
+
+	connector.ResyncSubscription(sub)
+	notification = connector.buildNotification(tx, sub, resource, connector.toBroadcast[sub.Id()])
+	if len(notification.FacilityConditions) != 2 {
+		t.Errorf("Notification should contain all the Facilities after a resync, got: %v", len(notification.FacilityConditions))
+	}
+}
+
+func Test_SIRIFacilityMonitoringSubscriptionBroadcaster_HandleSubscriptionRequest_UnknownStopPoint(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	connector := newSIRIFacilityMonitoringSubscriptionBroadcaster(partner)
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	request, err := siri.NewXMLSubscriptionRequestFromContent([]byte(`<ws:Subscribe xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<SubscriptionRequestInfo>
+		<siri:MessageIdentifier>Subscription:Test:0</siri:MessageIdentifier>
+	</SubscriptionRequestInfo>
+	<Request>
+		<siri:FacilityMonitoringSubscriptionRequest>
+			<siri:SubscriberRef>subscriber</siri:SubscriberRef>
+			<siri:SubscriptionIdentifier>FM:Subscription:1</siri:SubscriptionIdentifier>
+			<siri:InitialTerminationTime>` + fakeClock.Now().Add(24*time.Hour).Format(time.RFC3339) + `</siri:InitialTerminationTime>
+			<siri:FacilityMonitoringRequest>
+				<siri:MessageIdentifier>FacilityMonitoring:Test:1</siri:MessageIdentifier>
+				<siri:StopPointRef>unknown</siri:StopPointRef>
+			</siri:FacilityMonitoringRequest>
+		</siri:FacilityMonitoringSubscriptionRequest>
+	</Request>
+</ws:Subscribe>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := &audit.BigQueryMessage{}
+	responses := connector.HandleSubscriptionRequest(request, message)
+
+	if len(responses) != 1 || responses[0].Status {
+		t.Fatalf("Subscription should be refused: %v", responses)
+	}
+	if responses[0].ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Wrong ErrorType: %v", responses[0].ErrorType)
+	}
+	if _, ok := partner.Subscriptions().FindByExternalId("FM:Subscription:1"); ok {
+		t.Error("Subscription shouldn't be created")
+	}
+}
+
+func Test_SIRIFacilityMonitoringSubscriptionBroadcaster_ExpiredAndDeletedFacilities(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := newSIRIFacilityMonitoringSubscriptionBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Save()
+
+	lift := referential.Model().Facilities().New()
+	lift.SetObjectID(model.NewObjectID("objectidKind", "lift1"))
+	lift.StopAreaId = stopArea.Id()
+	lift.Status = model.FACILITY_STATUS_NOT_AVAILABLE
+	lift.Save()
+
+	escalator := referential.Model().Facilities().New()
+	escalator.SetObjectID(model.NewObjectID("objectidKind", "escalator1"))
+	escalator.StopAreaId = stopArea.Id()
+	escalator.Status = model.FACILITY_STATUS_AVAILABLE
+	escalator.Save()
+
+	sub := partner.Subscriptions().New("FacilityMonitoringBroadcast")
+	sub.SetExternalId("FM:Subscription:1")
+	obj := facilityResourceObjectID()
+	resource := sub.CreateAddNewResource(model.Reference{ObjectId: &obj, Type: "Facility"})
+	resource.SubscribedUntil = fakeClock.Now().Add(24 * time.Hour)
+	sub.Save()
+
+	tx := referential.NewTransaction()
+	defer tx.Close()
+
+	connector.buildNotification(tx, sub, resource, []model.FacilityId{lift.Id(), escalator.Id()})
+
+	lift.ValidityEndTime = fakeClock.Now().Add(time.Minute)
+	lift.Save()
+	connector.buildNotification(tx, sub, resource, []model.FacilityId{lift.Id()})
+
+	fakeClock.Advance(2 * time.Minute)
+
+	notification := connector.buildNotification(tx, sub, resource, nil)
+	if len(notification.FacilityConditions) != 1 {
+		t.Fatalf("Notification should contain the expired Facility, got: %v", notification.FacilityConditions)
+	}
+	condition := notification.FacilityConditions[0]
+	if condition.FacilityRef != "lift1" || condition.Status != string(model.FACILITY_STATUS_AVAILABLE) || !condition.ValidityEndTime.IsZero() {
+		t.Errorf("Expired Facility should be notified as available: %v", condition)
+	}
+
+	notification = connector.buildNotification(tx, sub, resource, nil)
+	if len(notification.FacilityConditions) != 0 {
+		t.Errorf("Expired Facility should be notified once, got: %v", notification.FacilityConditions)
+	}
+
+	referential.Model().Facilities().Delete(&escalator)
+
+	notification = connector.buildNotification(tx, sub, resource, nil)
+	if len(notification.FacilityConditions) != 1 {
+		t.Fatalf("Notification should contain the deleted Facility, got: %v", notification.FacilityConditions)
+	}
+	condition = notification.FacilityConditions[0]
+	if condition.FacilityRef != "escalator1" || condition.Status != string(model.FACILITY_STATUS_UNKNOWN) || condition.StopPointRef != "stopArea1" {
+		t.Errorf("Deleted Facility should be notified with an unknown status: %v", condition)
+	}
+
+	notification = connector.buildNotification(tx, sub, resource, nil)
+	if len(notification.FacilityConditions) != 0 {
+		t.Errorf("Deleted Facility should be notified once, got: %v", notification.FacilityConditions)
+	}
+}
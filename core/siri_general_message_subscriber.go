@@ -103,7 +103,7 @@ func (subscriber *GMSubscriber) prepareSIRIGeneralMessageSubscriptionRequest() {
 	resourcesToRequest := make(map[string]*resourceToRequest)
 	for _, subscription := range subscriptions {
 		for _, resource := range subscription.ResourcesByObjectIDCopy() {
-			if resource.SubscribedAt.IsZero() && resource.RetryCount <= 10 {
+			if resource.SubscribedAt.IsZero() && resource.RetryCount <= SUBSCRIPTION_MAXIMUM_RETRY_COUNT {
 				messageIdentifier := subscriber.connector.Partner().IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier()
 				logger.Log.Debugf("send request for subscription with id : %v", subscription.id)
 				resourcesToRequest[messageIdentifier] = &resourceToRequest{
@@ -136,7 +136,7 @@ func (subscriber *GMSubscriber) prepareSIRIGeneralMessageSubscriptionRequest() {
 		entry := &siri.SIRIGeneralMessageSubscriptionRequestEntry{
 			SubscriberRef:          subscriber.connector.SIRIPartner().RequestorRef(),
 			SubscriptionIdentifier: string(requestedResource.subId),
-			InitialTerminationTime: subscriber.Clock().Now().Add(48 * time.Hour),
+			InitialTerminationTime: subscriber.Clock().Now().Add(COLLECT_SUBSCRIPTION_DURATION),
 		}
 		entry.MessageIdentifier = messageIdentifier
 		entry.RequestTimestamp = subscriber.Clock().Now()
@@ -212,6 +212,7 @@ func (subscriber *GMSubscriber) prepareSIRIGeneralMessageSubscriptionRequest() {
 			continue
 		}
 		resource.SubscribedAt = subscriber.Clock().Now()
+		resource.TerminationTime = resource.SubscribedAt.Add(COLLECT_SUBSCRIPTION_DURATION)
 		resource.RetryCount = 0
 	}
 	// Should not happen but see #4691
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -111,6 +112,8 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) HandleSubscriptionRe
 
 	var subIds []string
 
+	validator := newSubscriptionRequestValidator(connector.Partner(), connector.Clock().Now())
+
 	for _, gm := range request.XMLSubscriptionGMEntries() {
 		logStashEvent := connector.newLogStashEvent()
 		logXMLGeneralMessageSubscriptionEntry(logStashEvent, gm)
@@ -119,11 +122,25 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) HandleSubscriptionRe
 			RequestMessageRef: gm.MessageIdentifier(),
 			SubscriberRef:     gm.SubscriberRef(),
 			SubscriptionRef:   gm.SubscriptionIdentifier(),
-			Status:            true,
 			ResponseTimestamp: connector.Clock().Now(),
-			ValidUntil:        gm.InitialTerminationTime(),
 		}
 
+		if unknownRefs := connector.unknownReferences(gm); len(unknownRefs) != 0 {
+			rs.ErrorType = "InvalidDataReferencesError"
+			rs.ErrorText = fmt.Sprintf("Unknown reference(s) %v", strings.Join(unknownRefs, ","))
+		}
+
+		if rs.ErrorType != "" || !validator.validate(&rs, "GeneralMessageBroadcast", gm.InitialTerminationTime()) {
+			resps = append(resps, rs)
+
+			logSIRIGeneralMessageSubscriptionResponseEntry(logStashEvent, &rs)
+			audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+			message.Status = "Error"
+			continue
+		}
+		rs.Status = true
+
 		subIds = append(subIds, gm.SubscriptionIdentifier())
 
 		sub, ok := connector.Partner().Subscriptions().FindByExternalId(gm.SubscriptionIdentifier())
@@ -136,6 +153,7 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) HandleSubscriptionRe
 		sub.SetSubscriptionOption("LineRef", strings.Join(gm.LineRef(), ","))
 		sub.SetSubscriptionOption("StopPointRef", strings.Join(gm.StopPointRef(), ","))
 		sub.SetSubscriptionOption("MessageIdentifier", gm.MessageIdentifier())
+		sub.SetSubscriptionOption("HeartbeatInterval", request.HeartbeatInterval())
 
 		obj := model.NewObjectID("SituationResource", "Situation")
 		r := sub.Resource(obj)
@@ -146,8 +164,8 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) HandleSubscriptionRe
 			}
 			r = sub.CreateAddNewResource(ref)
 			r.SubscribedAt = connector.Clock().Now()
-			r.SubscribedUntil = gm.InitialTerminationTime()
 		}
+		r.SubscribedUntil = rs.ValidUntil
 
 		sub.Save()
 
@@ -164,6 +182,23 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) HandleSubscriptionRe
 	return resps
 }
 
+// Returns the LineRefs and StopPointRefs which can't be found in the model
+func (connector *SIRIGeneralMessageSubscriptionBroadcaster) unknownReferences(gm *siri.XMLGeneralMessageSubscriptionRequestEntry) (refs []string) {
+	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER)
+
+	for _, lineRef := range gm.LineRef() {
+		if _, ok := connector.partner.Model().Lines().FindByObjectId(model.NewObjectID(objectidKind, lineRef)); !ok {
+			refs = append(refs, lineRef)
+		}
+	}
+	for _, stopPointRef := range gm.StopPointRef() {
+		if _, ok := connector.partner.Model().StopAreas().FindByObjectId(model.NewObjectID(objectidKind, stopPointRef)); !ok {
+			refs = append(refs, stopPointRef)
+		}
+	}
+	return
+}
+
 func (connector *SIRIGeneralMessageSubscriptionBroadcaster) addSituations(sub *Subscription, r *SubscribedResource) {
 	for _, situation := range connector.partner.Model().Situations().FindAll() {
 		if situation.ValidUntil.Before(connector.Clock().Now()) {
@@ -177,6 +212,14 @@ func (connector *SIRIGeneralMessageSubscriptionBroadcaster) addSituations(sub *S
 	}
 }
 
+// Sends again all the current Situations
+func (connector *SIRIGeneralMessageSubscriptionBroadcaster) ResyncSubscription(sub *Subscription) {
+	for _, resource := range sub.ResourcesByObjectIDCopy() {
+		resource.ClearLastStates()
+		connector.addSituations(sub, resource)
+	}
+}
+
 func (connector *SIRIGeneralMessageSubscriptionBroadcaster) newLogStashEvent() audit.LogStashEvent {
 	event := connector.partner.NewLogStashEvent()
 	event["connector"] = "GeneralMessageSubscriptionBroadcaster"
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type LinesDiscoveryRequestCollector interface {
+	RequestLines()
+}
+
+/*
+Requests the Lines of the partner and reconciles the model with the response.
+
+Unknown Lines are created, the name of the known ones is updated and the Lines
+discovered with the partner which are no longer announced by any partner are
+retired: their GeneralMessages are no longer collected.
+*/
+type SIRILinesDiscoveryRequestCollector struct {
+	clock.ClockConsumer
+
+	siriConnector
+
+	lineUpdateSubscriber UpdateSubscriber
+}
+
+type SIRILinesDiscoveryRequestCollectorFactory struct{}
+
+func (factory *SIRILinesDiscoveryRequestCollectorFactory) CreateConnector(partner *Partner) Connector {
+	return NewSIRILinesDiscoveryRequestCollector(partner)
+}
+
+func (factory *SIRILinesDiscoveryRequestCollectorFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_URL)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_CREDENTIAL)
+}
+
+func NewSIRILinesDiscoveryRequestCollector(partner *Partner) *SIRILinesDiscoveryRequestCollector {
+	connector := &SIRILinesDiscoveryRequestCollector{}
+	connector.partner = partner
+	manager := partner.Referential().CollectManager()
+	connector.lineUpdateSubscriber = manager.BroadcastUpdateEvent
+
+	return connector
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) SetSubscriber(subscriber UpdateSubscriber) {
+	connector.lineUpdateSubscriber = subscriber
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) broadcastUpdateEvent(event model.UpdateEvent) {
+	if connector.lineUpdateSubscriber != nil {
+		connector.lineUpdateSubscriber(event)
+	}
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) RequestLines() {
+	logStashEvent := connector.newLogStashEvent()
+	defer audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	message := connector.newBQEvent()
+	defer audit.CurrentBigQuery(string(connector.Partner().Referential().Slug())).WriteEvent(message)
+
+	startTime := connector.Clock().Now()
+
+	request := &siri.SIRILinesDiscoveryRequest{
+		MessageIdentifier: connector.Partner().IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+		RequestorRef:      connector.SIRIPartner().RequestorRef(),
+		RequestTimestamp:  startTime,
+	}
+
+	logSIRILinesDiscoveryRequest(logStashEvent, message, request)
+
+	response, err := connector.SIRIPartner().SOAPClient().LinesDiscovery(request)
+	logStashEvent["responseTime"] = connector.Clock().Since(startTime).String()
+	message.ProcessingTime = connector.Clock().Since(startTime).Seconds()
+	if err != nil {
+		e := fmt.Sprintf("Error during LinesDiscovery: %v", err)
+		logStashEvent["status"] = "false"
+		logStashEvent["errorDescription"] = e
+
+		message.Status = "Error"
+		message.ErrorDetails = e
+		return
+	}
+
+	logXMLLinesDiscoveryResponse(logStashEvent, message, response)
+
+	if !response.Status() {
+		return
+	}
+
+	diff := connector.reconcile(response)
+	diff.log(connector.Partner(), "LinesDiscoveryRequestCollector", "LinesDiscoveryReconciliation", func(message *audit.BigQueryMessage, refs []string) {
+		message.Lines = refs
+	})
+
+	lineRefs := []string{}
+	for _, annotatedLine := range response.AnnotatedLineRefs() {
+		lineRefs = append(lineRefs, annotatedLine.LineRef())
+	}
+	message.Lines = lineRefs
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) reconcile(response *siri.XMLLinesDiscoveryResponse) *discoveryDiff {
+	diff := &discoveryDiff{}
+	idKind := connector.partner.RemoteObjectIDKind(SIRI_LINES_DISCOVERY_REQUEST_COLLECTOR)
+	partner := string(connector.Partner().Slug())
+	announced := make(map[string]struct{})
+
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	for _, annotatedLine := range response.AnnotatedLineRefs() {
+		lineRef := annotatedLine.LineRef()
+		announced[lineRef] = struct{}{}
+
+		objectid := model.NewObjectID(idKind, lineRef)
+		line, ok := tx.Model().Lines().FindByObjectId(objectid)
+		if !ok {
+			event := model.NewLineUpdateEvent()
+			event.Origin = partner
+			event.ObjectId = objectid
+			event.Name = annotatedLine.LineName()
+
+			connector.broadcastUpdateEvent(event)
+			diff.Created = append(diff.Created, lineRef)
+			continue
+		}
+
+		// Only the Lines created by the discovery are collected
+		changed := false
+		if line.Origin() == partner && !line.CollectGeneralMessages {
+			line.CollectGeneralMessages = true
+			changed = true
+		}
+		if name := annotatedLine.LineName(); name != "" && name != line.Name {
+			line.Name = name
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		tx.Model().Lines().Save(&line)
+		diff.Updated = append(diff.Updated, lineRef)
+	}
+
+	// Only the Lines announced by the previous discovery can be retired
+	for lineRef := range connector.Partner().replaceAnnouncedLines(announced) {
+		if _, ok := announced[lineRef]; ok {
+			continue
+		}
+		line, ok := tx.Model().Lines().FindByObjectId(model.NewObjectID(idKind, lineRef))
+		if !ok || line.Origin() != partner || !line.CollectGeneralMessages || connector.announcedByOtherPartner(&line) {
+			continue
+		}
+
+		line.CollectGeneralMessages = false
+		tx.Model().Lines().Save(&line)
+		diff.Retired = append(diff.Retired, lineRef)
+	}
+
+	tx.Commit()
+	return diff
+}
+
+// Returns true if the last discovery of another partner still announces the
+// Line
+func (connector *SIRILinesDiscoveryRequestCollector) announcedByOtherPartner(line *model.Line) bool {
+	for _, partner := range connector.Partner().Referential().Partners().FindAll() {
+		if partner != connector.Partner() && partner.announcesLine(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) newBQEvent() *audit.BigQueryMessage {
+	return &audit.BigQueryMessage{
+		Type:      "LinesDiscoveryRequest",
+		Protocol:  "siri",
+		Direction: "sent",
+		Partner:   string(connector.partner.Slug()),
+		Status:    "OK",
+	}
+}
+
+func (connector *SIRILinesDiscoveryRequestCollector) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "LinesDiscoveryRequestCollector"
+	return event
+}
+
+func logSIRILinesDiscoveryRequest(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, request *siri.SIRILinesDiscoveryRequest) {
+	logStashEvent["siriType"] = "LinesDiscoveryRequest"
+	logStashEvent["messageIdentifier"] = request.MessageIdentifier
+	logStashEvent["requestorRef"] = request.RequestorRef
+	logStashEvent["requestTimestamp"] = request.RequestTimestamp.String()
+	xml, err := request.BuildXML()
+	if err != nil {
+		logStashEvent["requestXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["requestXML"] = xml
+
+	message.RequestIdentifier = request.MessageIdentifier
+	message.RequestRawMessage = xml
+	message.RequestSize = int64(len(xml))
+}
+
+func logXMLLinesDiscoveryResponse(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, response *siri.XMLLinesDiscoveryResponse) {
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp().String()
+	logStashEvent["responseXML"] = response.RawXML()
+	logStashEvent["status"] = strconv.FormatBool(response.Status())
+	if !response.Status() {
+		message.Status = "Error"
+		logStashEvent["errorType"] = response.ErrorType()
+		if response.ErrorType() == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber())
+		}
+		logStashEvent["errorText"] = response.ErrorText()
+		logStashEvent["errorDescription"] = response.ErrorDescription()
+		message.ErrorDetails = response.ErrorString()
+	}
+
+	message.ResponseRawMessage = response.RawXML()
+	message.ResponseSize = int64(len(message.ResponseRawMessage))
+}
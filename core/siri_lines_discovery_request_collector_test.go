@@ -0,0 +1,176 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func discoveryTestServer(t *testing.T, responseFilePath string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := os.Open(responseFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		io.Copy(w, file)
+	}))
+}
+
+func Test_SIRILinesDiscoveryRequestCollector_RequestLines(t *testing.T) {
+	logStash := audit.NewFakeLogStash()
+	audit.SetCurrentLogstash(logStash)
+
+	ts := discoveryTestServer(t, "testdata/linesdiscovery-response-soap.xml")
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:1:LOC"))
+	line.SetOrigin("partner")
+	line.Name = "Old name"
+	line.CollectGeneralMessages = true
+	line.Save()
+
+	retiredLine := referential.Model().Lines().New()
+	retiredLine.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:3:LOC"))
+	retiredLine.SetOrigin("partner")
+	retiredLine.CollectGeneralMessages = true
+	retiredLine.Save()
+
+	otherLine := referential.Model().Lines().New()
+	otherLine.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:4:LOC"))
+	otherLine.SetOrigin("other")
+	otherLine.CollectGeneralMessages = true
+	otherLine.Save()
+
+	// Line collected with the partner but never discovered
+	collectedLine := referential.Model().Lines().New()
+	collectedLine.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:5:LOC"))
+	collectedLine.SetOrigin("partner")
+	collectedLine.CollectGeneralMessages = true
+	collectedLine.Save()
+
+	// Previous discovery
+	partner.replaceAnnouncedLines(map[string]struct{}{"NINOXE:Line:1:LOC": {}, "NINOXE:Line:3:LOC": {}})
+
+	connector := NewSIRILinesDiscoveryRequestCollector(partner)
+	connector.SetSubscriber(model.NewUpdateManager(referential))
+	connector.RequestLines()
+
+	line, _ = referential.Model().Lines().Find(line.Id())
+	if line.Name != "Line 1" {
+		t.Errorf("Known Line name should be updated, got: %v", line.Name)
+	}
+
+	createdLine, ok := referential.Model().Lines().FindByObjectId(model.NewObjectID("objectidKind", "NINOXE:Line:2:LOC"))
+	if !ok {
+		t.Fatal("Unknown Line should be created")
+	}
+	if createdLine.Name != "Line 2" || createdLine.Origin() != "partner" {
+		t.Errorf("Wrong created Line: %v %v", createdLine.Name, createdLine.Origin())
+	}
+
+	retiredLine, _ = referential.Model().Lines().Find(retiredLine.Id())
+	if retiredLine.CollectGeneralMessages {
+		t.Errorf("Line no longer announced should be retired")
+	}
+	otherLine, _ = referential.Model().Lines().Find(otherLine.Id())
+	if !otherLine.CollectGeneralMessages {
+		t.Errorf("Line discovered with another partner shouldn't be retired")
+	}
+	collectedLine, _ = referential.Model().Lines().Find(collectedLine.Id())
+	if !collectedLine.CollectGeneralMessages {
+		t.Errorf("Line never discovered shouldn't be retired")
+	}
+
+	var diffEvent audit.LogStashEvent
+	for _, event := range logStash.Events() {
+		if event["siriType"] == "LinesDiscoveryReconciliation" {
+			diffEvent = event
+		}
+	}
+	if diffEvent == nil {
+		t.Fatal("A LogStash event should report the discovery changes")
+	}
+	if diffEvent["created"] != "NINOXE:Line:2:LOC" || diffEvent["updated"] != "NINOXE:Line:1:LOC" || diffEvent["retired"] != "NINOXE:Line:3:LOC" {
+		t.Errorf("Wrong discovery changes: %v", diffEvent)
+	}
+}
+
+func Test_SIRILinesDiscoveryRequestCollector_RequestLines_OtherPartners(t *testing.T) {
+	audit.SetCurrentLogstash(audit.NewFakeLogStash())
+	bigQuery := audit.NewFakeBigQuery()
+	audit.SetCurrentBigQuery("referential", bigQuery)
+	defer audit.SetCurrentBigQuery("referential", audit.NewNullBigQuery())
+
+	ts := discoveryTestServer(t, "testdata/linesdiscovery-response-soap.xml")
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Save()
+
+	otherPartner := referential.Partners().New("other")
+	otherPartner.Settings["remote_objectid_kind"] = "objectidKind"
+	otherPartner.Save()
+
+	// Line created by another partner
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:1:LOC"))
+	line.SetOrigin("other")
+	line.Save()
+
+	announcedLine := referential.Model().Lines().New()
+	announcedLine.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:3:LOC"))
+	announcedLine.SetOrigin("partner")
+	announcedLine.CollectGeneralMessages = true
+	announcedLine.Save()
+
+	// Previous discoveries
+	partner.replaceAnnouncedLines(map[string]struct{}{"NINOXE:Line:3:LOC": {}})
+	otherPartner.replaceAnnouncedLines(map[string]struct{}{"NINOXE:Line:3:LOC": {}})
+
+	connector := NewSIRILinesDiscoveryRequestCollector(partner)
+	connector.SetSubscriber(model.NewUpdateManager(referential))
+	connector.RequestLines()
+
+	line, _ = referential.Model().Lines().Find(line.Id())
+	if line.Name != "Line 1" {
+		t.Errorf("Known Line name should be updated, got: %v", line.Name)
+	}
+	if line.CollectGeneralMessages {
+		t.Errorf("Line created by another partner shouldn't be collected")
+	}
+
+	announcedLine, _ = referential.Model().Lines().Find(announcedLine.Id())
+	if !announcedLine.CollectGeneralMessages {
+		t.Errorf("Line still announced by another partner shouldn't be retired")
+	}
+
+	var diffMessage *audit.BigQueryMessage
+	for _, message := range bigQuery.Messages() {
+		if message.Type == "LinesDiscoveryReconciliation" {
+			diffMessage = message
+		}
+	}
+	if diffMessage == nil {
+		t.Fatal("A BigQuery message should report the discovery changes")
+	}
+	if len(diffMessage.Lines) != 2 || diffMessage.Lines[0] != "NINOXE:Line:2:LOC" || diffMessage.Lines[1] != "NINOXE:Line:1:LOC" {
+		t.Errorf("Wrong discovery changes: %v", diffMessage.Lines)
+	}
+}
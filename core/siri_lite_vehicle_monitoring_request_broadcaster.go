@@ -108,8 +108,11 @@ func (connector *SIRILiteVehicleMonitoringRequestBroadcaster) RequestVehicles(ur
 		refs := vj.References.Copy()
 		activity.MonitoredVehicleJourney.OriginRef = connector.handleRef(tx, "OriginRef", vj.Origin, refs)
 		activity.MonitoredVehicleJourney.DestinationRef = connector.handleRef(tx, "DestinationRef", vj.Origin, refs)
+		if operatorRef, ok := broadcastedOperatorRef(tx, nil, vj, connector.remoteObjectidKind); ok {
+			activity.MonitoredVehicleJourney.OperatorRef = operatorRef
+		}
 
-		modelDate := tx.Model().Date()
+		modelDate := serviceDate(tx.Model(), vj.ServiceDate)
 		activity.MonitoredVehicleJourney.FramedVehicleJourneyRef.DataFrameRef =
 			connector.Partner().IdentifierGenerator(DATA_FRAME_IDENTIFIER).NewIdentifier(IdentifierAttributes{Id: modelDate.String()})
 		activity.MonitoredVehicleJourney.FramedVehicleJourneyRef.DatedVehicleJourneyRef = dvj
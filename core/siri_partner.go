@@ -1,8 +1,13 @@
 package core
 
 import (
+	"strconv"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
 	"bitbucket.org/enroute-mobi/ara/logger"
 	"bitbucket.org/enroute-mobi/ara/siri"
+	"cloud.google.com/go/civil"
 )
 
 type SIRIPartner struct {
@@ -21,13 +26,94 @@ func (siriPartner *SIRIPartner) SOAPClient() *siri.SOAPClient {
 		SubscriptionsUrl: siriPartner.partner.Setting(SUBSCRIPTIONS_REMOTE_URL),
 		NotificationsUrl: siriPartner.partner.Setting(NOTIFICATIONS_REMOTE_URL),
 	}
-	if siriPartner.soapClient == nil || siriPartner.soapClient.SOAPClientUrls != urls {
+	settings := siriPartner.soapClientSettings()
+	if siriPartner.soapClient == nil || siriPartner.soapClient.SOAPClientUrls != urls || siriPartner.soapClient.SOAPClientSettings != settings {
 		logger.Log.Debugf("Create SIRI SOAPClient to %s", urls.Url)
-		siriPartner.soapClient = siri.NewSOAPClient(urls)
+		siriPartner.soapClient = siri.NewSOAPClientWithSettings(urls, settings)
+		siriPartner.soapClient.CircuitBreaker().OnStateChange(siriPartner.circuitBreakerStateChanged)
+		if referential := siriPartner.partner.Referential(); referential != nil {
+			siriPartner.soapClient.SetClock(referential.Clock())
+			siriPartner.soapClient.CircuitBreaker().SetClock(referential.Clock())
+		}
+		if err := siriPartner.soapClient.SettingsError(); err != nil {
+			logger.Log.Printf("Invalid SOAPClient settings for partner %v: %v", siriPartner.partner.Slug(), err)
+		}
 	}
 	return siriPartner.soapClient
 }
 
+// Returns the status of the circuit breaker, or nil when no SOAPClient is used
+func (siriPartner *SIRIPartner) CircuitBreakerStatus() *siri.CircuitBreakerStatus {
+	if siriPartner.soapClient == nil {
+		return nil
+	}
+	status := siriPartner.soapClient.CircuitBreaker().Status()
+	return &status
+}
+
+func (siriPartner *SIRIPartner) soapClientSettings() siri.SOAPClientSettings {
+	settings := siri.DefaultSOAPClientSettings()
+
+	durations := map[string]*time.Duration{
+		SIRI_TIMEOUT:                 &settings.Timeout,
+		SIRI_SUBSCRIPTION_TIMEOUT:    &settings.SubscriptionTimeout,
+		SIRI_CHECK_STATUS_TIMEOUT:    &settings.CheckStatusTimeout,
+		SIRI_NOTIFICATION_TIMEOUT:    &settings.NotificationTimeout,
+		SIRI_RETRY_BACKOFF:           &settings.RetryBackoff,
+		SIRI_CIRCUIT_BREAKER_TIMEOUT: &settings.CircuitBreakerTimeout,
+	}
+	for setting, duration := range durations {
+		if d, err := time.ParseDuration(siriPartner.partner.Setting(setting)); err == nil && d > 0 {
+			*duration = d
+		}
+	}
+
+	settings.Retries, _ = strconv.Atoi(siriPartner.partner.Setting(SIRI_RETRIES))
+	settings.CircuitBreakerThreshold, _ = strconv.Atoi(siriPartner.partner.Setting(SIRI_CIRCUIT_BREAKER_THRESHOLD))
+
+	settings.TLS = siri.TLSSettings{
+		ClientCertificate: siriPartner.partner.Setting(TLS_CLIENT_CERTIFICATE),
+		ClientKey:         siriPartner.partner.Setting(TLS_CLIENT_KEY),
+		CABundle:          siriPartner.partner.Setting(TLS_CA_BUNDLE),
+		ServerName:        siriPartner.partner.Setting(TLS_SERVER_NAME),
+	}
+
+	settings.Authentication = siri.AuthenticationSettings{
+		Type:         siriPartner.partner.Setting(REMOTE_AUTHENTICATION_TYPE),
+		Username:     siriPartner.partner.Setting(REMOTE_AUTHENTICATION_USERNAME),
+		Password:     siriPartner.partner.Setting(REMOTE_AUTHENTICATION_PASSWORD),
+		HeaderName:   siriPartner.partner.Setting(REMOTE_AUTHENTICATION_HEADER_NAME),
+		HeaderValue:  siriPartner.partner.Setting(REMOTE_AUTHENTICATION_HEADER_VALUE),
+		TokenUrl:     siriPartner.partner.Setting(REMOTE_AUTHENTICATION_TOKEN_URL),
+		ClientId:     siriPartner.partner.Setting(REMOTE_AUTHENTICATION_CLIENT_ID),
+		ClientSecret: siriPartner.partner.Setting(REMOTE_AUTHENTICATION_CLIENT_SECRET),
+		Scope:        siriPartner.partner.Setting(REMOTE_AUTHENTICATION_SCOPE),
+	}
+
+	return settings
+}
+
+func (siriPartner *SIRIPartner) circuitBreakerStateChanged(previous, state siri.CircuitBreakerState) {
+	partner := siriPartner.partner
+	logger.Log.Printf("Partner %v circuit breaker is now %v (was %v)", partner.Slug(), state, previous)
+
+	referential := partner.Referential()
+	if referential == nil {
+		return
+	}
+	partnerEvent := &audit.BigQueryPartnerEvent{
+		Timestamp:                   referential.Clock().Now(),
+		Slug:                        string(partner.Slug()),
+		PreviousStatus:              string(partner.PartnerStatus.OperationnalStatus),
+		PreviousServiceStartedAt:    civil.DateTimeOf(partner.PartnerStatus.ServiceStartedAt),
+		NewStatus:                   string(partner.PartnerStatus.OperationnalStatus),
+		NewServiceStartedAt:         civil.DateTimeOf(partner.PartnerStatus.ServiceStartedAt),
+		PreviousCircuitBreakerState: string(previous),
+		NewCircuitBreakerState:      string(state),
+	}
+	audit.CurrentBigQuery(string(referential.Slug())).WriteEvent(partnerEvent)
+}
+
 func (siriPartner *SIRIPartner) RequestorRef() string {
 	return siriPartner.partner.ProducerRef()
 }
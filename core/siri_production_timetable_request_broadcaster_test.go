@@ -0,0 +1,163 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_SIRIProductionTimetableBroadcaster_RequestLine(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIProductionTimetableBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Name = "Stop Area 1"
+	stopArea.Save()
+
+	stopArea2 := referential.Model().StopAreas().New()
+	stopArea2.SetObjectID(model.NewObjectID("objectidKind", "stopArea2"))
+	stopArea2.Name = "Stop Area 2"
+	stopArea2.Save()
+
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:2:LOC"))
+	line.Name = "lineName"
+	line.Save()
+
+	// Two VehicleJourneys in each direction, the last one leaves in 3 hours
+	for i, departure := range []time.Duration{10 * time.Minute, 20 * time.Minute, 3 * time.Hour} {
+		vehicleJourney := referential.Model().VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", fmt.Sprintf("vehicleJourney%d", i+1)))
+		vehicleJourney.LineId = line.Id()
+		if i == 1 {
+			vehicleJourney.Attributes.Set("DirectionRef", "Retour")
+		} else {
+			vehicleJourney.Attributes.Set("DirectionRef", "Aller")
+		}
+		vehicleJourney.Save()
+
+		// Saved in the reverse order to check the Calls order
+		for j, stopAreaId := range []model.StopAreaId{stopArea2.Id(), stopArea.Id()} {
+			stopVisit := referential.Model().StopVisits().New()
+			stopVisit.VehicleJourneyId = vehicleJourney.Id()
+			stopVisit.StopAreaId = stopAreaId
+			stopVisit.PassageOrder = 2 - j
+			stopVisit.Schedules.SetDepartureTime("aimed", fakeClock.Now().Add(departure+time.Duration(2-j)*time.Minute))
+			stopVisit.Save()
+		}
+	}
+
+	request, err := siri.NewXMLGetProductionTimetableFromContent([]byte(fmt.Sprintf(`<ns7:GetProductionTimetable xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>ProductionTimetable:Test:0</ns2:MessageIdentifier>
+    <ns2:ValidityPeriod>
+      <ns2:StartTime>%s</ns2:StartTime>
+      <ns2:EndTime>%s</ns2:EndTime>
+    </ns2:ValidityPeriod>
+    <ns2:Lines>
+      <ns2:LineRef>NINOXE:Line:2:LOC</ns2:LineRef>
+    </ns2:Lines>
+  </Request>
+</ns7:GetProductionTimetable>`, fakeClock.Now().Format(time.RFC3339), fakeClock.Now().Add(time.Hour).Format(time.RFC3339))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestLine(request, &audit.BigQueryMessage{})
+
+	if response.Address != "http://ara" {
+		t.Errorf("Response has wrong adress:\n got: %v\n want: http://ara", response.Address)
+	}
+	if !response.Status {
+		t.Errorf("Response has wrong status: %v", response.ErrorString())
+	}
+	if expected := "ProductionTimetable:Test:0"; response.RequestMessageRef != expected {
+		t.Errorf("Wrong RequestMessageRef:\n got: %v\nwant: %v", response.RequestMessageRef, expected)
+	}
+
+	frames := response.DatedTimetableVersionFrames
+	if len(frames) != 2 {
+		t.Fatalf("Response should have a frame by direction, got: %v", len(frames))
+	}
+
+	vehicleJourneys := make(map[string][]*siri.SIRIDatedVehicleJourney)
+	for _, frame := range frames {
+		if frame.LineRef != "NINOXE:Line:2:LOC" {
+			t.Errorf("Wrong frame LineRef: %v", frame.LineRef)
+		}
+		vehicleJourneys[frame.DirectionRef] = frame.DatedVehicleJourneys
+	}
+
+	if len(vehicleJourneys["Aller"]) != 1 || vehicleJourneys["Aller"][0].DatedVehicleJourneyCode != "vehicleJourney1" {
+		t.Errorf("Aller frame should only contain the vehicleJourney1 in the validity period: %v", vehicleJourneys["Aller"])
+	}
+	if len(vehicleJourneys["Retour"]) != 1 || vehicleJourneys["Retour"][0].DatedVehicleJourneyCode != "vehicleJourney2" {
+		t.Fatalf("Retour frame should contain the vehicleJourney2: %v", vehicleJourneys["Retour"])
+	}
+
+	calls := vehicleJourneys["Retour"][0].DatedCalls
+	if len(calls) != 2 {
+		t.Fatalf("DatedVehicleJourney should have 2 calls, got: %v", len(calls))
+	}
+	if calls[0].StopPointRef != "stopArea1" || calls[0].Order != 1 || calls[0].StopPointName != "Stop Area 1" {
+		t.Errorf("Wrong first call: %v", calls[0])
+	}
+	if expected := fakeClock.Now().Add(21 * time.Minute); !calls[0].AimedDepartureTime.Equal(expected) {
+		t.Errorf("Wrong first call AimedDepartureTime:\n got: %v\nwant: %v", calls[0].AimedDepartureTime, expected)
+	}
+	if calls[1].StopPointRef != "stopArea2" || calls[1].Order != 2 {
+		t.Errorf("Wrong second call: %v", calls[1])
+	}
+}
+
+func Test_SIRIProductionTimetableBroadcaster_RequestLine_UnknownLine(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIProductionTimetableBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	connector.SetClock(clock.NewFakeClock())
+
+	request, err := siri.NewXMLGetProductionTimetableFromContent([]byte(`<ns7:GetProductionTimetable xmlns:ns2="http://www.siri.org.uk/siri" xmlns:ns7="http://wsdl.siri.org.uk">
+  <Request>
+    <ns2:MessageIdentifier>ProductionTimetable:Test:0</ns2:MessageIdentifier>
+    <ns2:Lines>
+      <ns2:LineRef>NINOXE:Line:unknown:LOC</ns2:LineRef>
+    </ns2:Lines>
+  </Request>
+</ns7:GetProductionTimetable>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := &audit.BigQueryMessage{}
+	response := connector.RequestLine(request, message)
+
+	if response.Status {
+		t.Errorf("Response should have a false status")
+	}
+	if response.ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Wrong ErrorType: %v", response.ErrorType)
+	}
+	if message.Status != "Error" {
+		t.Errorf("BigQuery message should have an Error status, got: %v", message.Status)
+	}
+}
@@ -0,0 +1,414 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+/*
+Broadcasts the theoretical timetable of the subscribed Lines.
+
+The complete timetable is sent when the Subscription is created or resynced,
+and again each time the service dates of the VehicleJourneys change, when the
+model is reloaded for a new day.
+*/
+type SIRIProductionTimetableSubscriptionBroadcaster struct {
+	clock.ClockConsumer
+
+	siriConnector
+
+	toBroadcast  map[SubscriptionId]struct{}
+	serviceDates string
+
+	mutex *sync.Mutex //protect the map
+	stop  chan struct{}
+}
+
+type SIRIProductionTimetableSubscriptionBroadcasterFactory struct{}
+
+func (factory *SIRIProductionTimetableSubscriptionBroadcasterFactory) CreateConnector(partner *Partner) Connector {
+	if _, ok := partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER); !ok {
+		partner.CreateSubscriptionRequestDispatcher()
+	}
+	return newSIRIProductionTimetableSubscriptionBroadcaster(partner)
+}
+
+func (factory *SIRIProductionTimetableSubscriptionBroadcasterFactory) Validate(apiPartner *APIPartner) {
+	apiPartner.ValidatePresenceOfSetting(REMOTE_OBJECTID_KIND)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_URL)
+	apiPartner.ValidatePresenceOfSetting(REMOTE_CREDENTIAL)
+	apiPartner.ValidatePresenceOfSetting(LOCAL_CREDENTIAL)
+}
+
+func newSIRIProductionTimetableSubscriptionBroadcaster(partner *Partner) *SIRIProductionTimetableSubscriptionBroadcaster {
+	connector := &SIRIProductionTimetableSubscriptionBroadcaster{}
+	connector.partner = partner
+	connector.mutex = &sync.Mutex{}
+	connector.toBroadcast = make(map[SubscriptionId]struct{})
+	return connector
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) HandleSubscriptionRequest(request *siri.XMLSubscriptionRequest, message *audit.BigQueryMessage) (resps []siri.SIRIResponseStatus) {
+	var lineIds, subIds []string
+
+	validator := newSubscriptionRequestValidator(connector.Partner(), connector.Clock().Now())
+
+	for _, pt := range request.XMLSubscriptionPTEntries() {
+		logStashEvent := connector.newLogStashEvent()
+		logSIRIProductionTimetableSubscriptionEntry(logStashEvent, pt)
+
+		rs := siri.SIRIResponseStatus{
+			RequestMessageRef: pt.MessageIdentifier(),
+			SubscriberRef:     pt.SubscriberRef(),
+			SubscriptionRef:   pt.SubscriptionIdentifier(),
+			ResponseTimestamp: connector.Clock().Now(),
+		}
+
+		lineIds = append(lineIds, pt.Lines()...)
+
+		references, unknownLineIds := connector.checkLines(pt)
+		if len(unknownLineIds) != 0 {
+			logger.Log.Debugf("ProductionTimetable subscription request Could not find line(s) with id : %v", strings.Join(unknownLineIds, ","))
+			rs.ErrorType = "InvalidDataReferencesError"
+			rs.ErrorText = fmt.Sprintf("Unknown Line(s) %v", strings.Join(unknownLineIds, ","))
+		} else if validator.validate(&rs, "ProductionTimetableBroadcast", pt.InitialTerminationTime()) {
+			rs.Status = true
+		}
+
+		resps = append(resps, rs)
+
+		logSIRIProductionTimetableSubscriptionResponseEntry(logStashEvent, &rs)
+		audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+		if !rs.Status {
+			message.Status = "Error"
+			continue
+		}
+
+		subIds = append(subIds, pt.SubscriptionIdentifier())
+
+		sub, ok := connector.Partner().Subscriptions().FindByExternalId(pt.SubscriptionIdentifier())
+		if !ok {
+			sub = connector.Partner().Subscriptions().New("ProductionTimetableBroadcast")
+			sub.SetExternalId(pt.SubscriptionIdentifier())
+			connector.fillOptions(sub, request, pt)
+		}
+
+		for _, reference := range references {
+			r := sub.CreateAddNewResource(reference)
+			r.SubscribedAt = connector.Clock().Now()
+			r.SubscribedUntil = rs.ValidUntil
+		}
+		sub.Save()
+
+		connector.addSubscription(sub.Id())
+	}
+	message.Type = "ProductionTimetableSubscriptionRequest"
+	message.SubscriptionIdentifiers = subIds
+	message.Lines = lineIds
+
+	return resps
+}
+
+// Sends again the complete timetable of the Subscription Lines
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) ResyncSubscription(sub *Subscription) {
+	connector.addSubscription(sub.Id())
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) checkLines(pt *siri.XMLProductionTimetableSubscriptionRequestEntry) (references []model.Reference, lineIds []string) {
+	for _, lineId := range pt.Lines() {
+		lineObjectId := model.NewObjectID(connector.partner.RemoteObjectIDKind(SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER), lineId)
+		_, ok := connector.Partner().Model().Lines().FindByObjectId(lineObjectId)
+
+		if !ok {
+			lineIds = append(lineIds, lineId)
+			continue
+		}
+
+		references = append(references, model.Reference{
+			ObjectId: &lineObjectId,
+			Type:     "Line",
+		})
+	}
+	return references, lineIds
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) fillOptions(s *Subscription, request *siri.XMLSubscriptionRequest, pt *siri.XMLProductionTimetableSubscriptionRequestEntry) {
+	s.SetSubscriptionOption("MessageIdentifier", request.MessageIdentifier())
+	s.SetSubscriptionOption("HeartbeatInterval", request.HeartbeatInterval())
+	if !pt.StartTime().IsZero() {
+		s.SetSubscriptionOption("StartTime", pt.StartTime().Format(time.RFC3339))
+	}
+	if !pt.EndTime().IsZero() {
+		s.SetSubscriptionOption("EndTime", pt.EndTime().Format(time.RFC3339))
+	}
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) addSubscription(subId SubscriptionId) {
+	connector.mutex.Lock()
+	connector.toBroadcast[subId] = struct{}{}
+	connector.mutex.Unlock()
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) Start() {
+	logger.Log.Debugf("Start ProductionTimetableSubscriptionBroadcaster")
+
+	connector.serviceDates = connector.currentServiceDates()
+	connector.stop = make(chan struct{})
+	go connector.run()
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) Stop() {
+	if connector.stop != nil {
+		close(connector.stop)
+	}
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) run() {
+	c := connector.Clock().After(5 * time.Second)
+
+	for {
+		select {
+		case <-connector.stop:
+			logger.Log.Debugf("production timetable broadcaster routine stop")
+			return
+		case <-c:
+			connector.checkServiceDates()
+			connector.prepareNotifications()
+			// Redeliver the notifications which failed previously
+			connector.NotificationQueue().Deliver()
+
+			c = connector.Clock().After(5 * time.Second)
+		}
+	}
+}
+
+// Returns the sorted service dates of the VehicleJourneys in the model
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) currentServiceDates() string {
+	m := connector.Partner().Model()
+
+	dates := make(map[string]struct{})
+	for _, vehicleJourney := range m.VehicleJourneys().FindAll() {
+		date := serviceDate(m, vehicleJourney.ServiceDate)
+		dates[date.String()] = struct{}{}
+	}
+
+	serviceDates := make([]string, 0, len(dates))
+	for date := range dates {
+		serviceDates = append(serviceDates, date)
+	}
+	sort.Strings(serviceDates)
+	return strings.Join(serviceDates, ",")
+}
+
+// Broadcasts all the Subscriptions when the service dates of the
+// VehicleJourneys change
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) checkServiceDates() {
+	serviceDates := connector.currentServiceDates()
+	if serviceDates == connector.serviceDates {
+		return
+	}
+	connector.serviceDates = serviceDates
+
+	for _, sub := range connector.Partner().Subscriptions().FindSubscriptionsByKind("ProductionTimetableBroadcast") {
+		connector.addSubscription(sub.Id())
+	}
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) prepareNotifications() {
+	connector.mutex.Lock()
+
+	subIds := connector.toBroadcast
+	connector.toBroadcast = make(map[SubscriptionId]struct{})
+
+	connector.mutex.Unlock()
+
+	for subId := range subIds {
+		sub, ok := connector.Partner().Subscriptions().Find(subId)
+		if !ok {
+			logger.Log.Debugf("PT subscriptionBroadcast Could not find sub with id : %v", subId)
+			continue
+		}
+
+		delivery := connector.buildNotification(sub)
+		if len(delivery.DatedTimetableVersionFrames) == 0 {
+			continue
+		}
+		connector.sendDelivery(delivery)
+	}
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) buildNotification(sub *Subscription) *siri.SIRINotifyProductionTimetable {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	delivery := &siri.SIRINotifyProductionTimetable{
+		Address:                   connector.Partner().Address(),
+		ProducerRef:               connector.Partner().ProducerRef(),
+		ResponseMessageIdentifier: connector.Partner().IdentifierGenerator(RESPONSE_MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+		SubscriberRef:             connector.SIRIPartner().SubscriberRef(),
+		SubscriptionIdentifier:    sub.ExternalId(),
+		ResponseTimestamp:         connector.Clock().Now(),
+		Status:                    true,
+		RequestMessageRef:         sub.SubscriptionOption("MessageIdentifier"),
+	}
+
+	builder := NewBroadcastProductionTimetableBuilder(connector.Partner(), SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER)
+	builder.SetClock(connector.Clock())
+	if startTime, err := time.Parse(time.RFC3339, sub.SubscriptionOption("StartTime")); err == nil {
+		builder.StartTime = startTime
+	}
+	if endTime, err := time.Parse(time.RFC3339, sub.SubscriptionOption("EndTime")); err == nil {
+		builder.EndTime = endTime
+	}
+
+	for _, resource := range sub.ResourcesByObjectIDCopy() {
+		if resource.SubscribedUntil.Before(connector.Clock().Now()) {
+			continue
+		}
+		frames, ok := builder.BuildLine(tx, *resource.Reference.ObjectId)
+		if !ok {
+			continue
+		}
+		delivery.DatedTimetableVersionFrames = append(delivery.DatedTimetableVersionFrames, frames...)
+	}
+
+	return delivery
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) sendDelivery(delivery *siri.SIRINotifyProductionTimetable) {
+	queue := connector.NotificationQueue()
+	queue.Push(&QueuedNotification{
+		Type:                   NOTIFY_PRODUCTION_TIMETABLE,
+		SubscriptionIdentifier: delivery.SubscriptionIdentifier,
+		ProductionTimetable:    delivery,
+	}, connector)
+	queue.Deliver()
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) deliverNotification(notification *QueuedNotification) error {
+	delivery := notification.ProductionTimetable
+
+	logStashEvent := connector.newLogStashEvent()
+	message := connector.newBQEvent()
+
+	logSIRIProductionTimetableNotify(logStashEvent, message, delivery)
+	audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	t := connector.Clock().Now()
+
+	err := connector.SIRIPartner().SOAPClient().NotifyProductionTimetable(delivery)
+	message.ProcessingTime = connector.Clock().Since(t).Seconds()
+	if err != nil {
+		event := connector.newLogStashEvent()
+		logSIRINotifyError(err.Error(), delivery.ResponseMessageIdentifier, event)
+		audit.CurrentLogStash().WriteEvent(event)
+	}
+
+	audit.CurrentBigQuery(string(connector.Partner().Referential().Slug())).WriteEvent(message)
+	return err
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) newBQEvent() *audit.BigQueryMessage {
+	return &audit.BigQueryMessage{
+		Type:      "NotifyProductionTimetable",
+		Protocol:  "siri",
+		Direction: "sent",
+		Partner:   string(connector.partner.Slug()),
+		Status:    "OK",
+	}
+}
+
+func (connector *SIRIProductionTimetableSubscriptionBroadcaster) newLogStashEvent() audit.LogStashEvent {
+	event := connector.partner.NewLogStashEvent()
+	event["connector"] = "ProductionTimetableSubscriptionBroadcaster"
+	return event
+}
+
+func logSIRIProductionTimetableSubscriptionEntry(logStashEvent audit.LogStashEvent, ptEntry *siri.XMLProductionTimetableSubscriptionRequestEntry) {
+	logStashEvent["siriType"] = "ProductionTimetableSubscriptionEntry"
+	logStashEvent["lineRefs"] = strings.Join(ptEntry.Lines(), ",")
+	logStashEvent["messageIdentifier"] = ptEntry.MessageIdentifier()
+	logStashEvent["subscriberRef"] = ptEntry.SubscriberRef()
+	logStashEvent["subscriptionIdentifier"] = ptEntry.SubscriptionIdentifier()
+	logStashEvent["initialTerminationTime"] = ptEntry.InitialTerminationTime().String()
+	logStashEvent["requestTimestamp"] = ptEntry.RequestTimestamp().String()
+	logStashEvent["requestXML"] = ptEntry.RawXML()
+}
+
+func logSIRIProductionTimetableSubscriptionResponseEntry(logStashEvent audit.LogStashEvent, response *siri.SIRIResponseStatus) {
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef
+	logStashEvent["subscriptionRef"] = response.SubscriptionRef
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp.String()
+	logStashEvent["validUntil"] = response.ValidUntil.String()
+	logStashEvent["status"] = strconv.FormatBool(response.Status)
+	if !response.Status {
+		logStashEvent["errorType"] = response.ErrorType
+		if response.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber)
+		}
+		logStashEvent["errorText"] = response.ErrorText
+	}
+}
+
+func logSIRIProductionTimetableNotify(logStashEvent audit.LogStashEvent, message *audit.BigQueryMessage, response *siri.SIRINotifyProductionTimetable) {
+	lineRefs := []string{}
+	mr := make(map[string]struct{})
+	for _, frame := range response.DatedTimetableVersionFrames {
+		lineRefs = append(lineRefs, frame.LineRef)
+		for _, vj := range frame.DatedVehicleJourneys {
+			for _, call := range vj.DatedCalls {
+				mr[call.StopPointRef] = struct{}{}
+			}
+		}
+	}
+	monitoringRefs := []string{}
+	for k := range mr {
+		monitoringRefs = append(monitoringRefs, k)
+	}
+
+	message.RequestIdentifier = response.RequestMessageRef
+	message.ResponseIdentifier = response.ResponseMessageIdentifier
+	message.Lines = lineRefs
+	message.StopAreas = monitoringRefs
+	message.SubscriptionIdentifiers = []string{response.SubscriptionIdentifier}
+
+	logStashEvent["siriType"] = "NotifyProductionTimetable"
+	logStashEvent["producerRef"] = response.ProducerRef
+	logStashEvent["requestMessageRef"] = response.RequestMessageRef
+	logStashEvent["responseMessageIdentifier"] = response.ResponseMessageIdentifier
+	logStashEvent["responseTimestamp"] = response.ResponseTimestamp.String()
+	logStashEvent["subscriberRef"] = response.SubscriberRef
+	logStashEvent["subscriptionIdentifier"] = response.SubscriptionIdentifier
+	logStashEvent["lineRefs"] = strings.Join(lineRefs, ",")
+	logStashEvent["monitoringRefs"] = strings.Join(monitoringRefs, ",")
+	logStashEvent["status"] = strconv.FormatBool(response.Status)
+
+	if !response.Status {
+		message.Status = "Error"
+		logStashEvent["errorType"] = response.ErrorType
+		if response.ErrorType == "OtherError" {
+			logStashEvent["errorNumber"] = strconv.Itoa(response.ErrorNumber)
+		}
+		logStashEvent["errorText"] = response.ErrorText
+		message.ErrorDetails = response.ErrorString()
+	}
+	xml, err := response.BuildXML()
+	if err != nil {
+		logStashEvent["responseXML"] = fmt.Sprintf("%v", err)
+		return
+	}
+	logStashEvent["responseXML"] = xml
+	message.ResponseRawMessage = xml
+	message.ResponseSize = int64(len(xml))
+}
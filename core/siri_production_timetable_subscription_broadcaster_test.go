@@ -0,0 +1,186 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
+	"bitbucket.org/enroute-mobi/ara/siri"
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+func Test_SIRIProductionTimetableSubscriptionBroadcaster_HandleSubscriptionRequest(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	partner.Settings["remote_url"] = "http://remote"
+	connector := newSIRIProductionTimetableSubscriptionBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "stopArea1"))
+	stopArea.Save()
+
+	line := referential.Model().Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:Line:2:LOC"))
+	line.Name = "lineName"
+	line.Save()
+
+	vehicleJourney := referential.Model().VehicleJourneys().New()
+	vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", "vehicleJourney1"))
+	vehicleJourney.LineId = line.Id()
+	vehicleJourney.Attributes.Set("DirectionRef", "Aller")
+	vehicleJourney.Save()
+
+	stopVisit := referential.Model().StopVisits().New()
+	stopVisit.VehicleJourneyId = vehicleJourney.Id()
+	stopVisit.StopAreaId = stopArea.Id()
+	stopVisit.PassageOrder = 1
+	stopVisit.Schedules.SetDepartureTime("aimed", fakeClock.Now().Add(10*time.Minute))
+	stopVisit.Save()
+
+	vehicleJourney2 := referential.Model().VehicleJourneys().New()
+	vehicleJourney2.SetObjectID(model.NewObjectID("objectidKind", "vehicleJourney2"))
+	vehicleJourney2.LineId = line.Id()
+	vehicleJourney2.Attributes.Set("DirectionRef", "Retour")
+	vehicleJourney2.Save()
+
+	stopVisit2 := referential.Model().StopVisits().New()
+	stopVisit2.VehicleJourneyId = vehicleJourney2.Id()
+	stopVisit2.StopAreaId = stopArea.Id()
+	stopVisit2.PassageOrder = 1
+	stopVisit2.Schedules.SetDepartureTime("aimed", fakeClock.Now().Add(20*time.Minute))
+	stopVisit2.Save()
+
+	request, err := siri.NewXMLSubscriptionRequestFromContent([]byte(`<ws:Subscribe xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<SubscriptionRequestInfo>
+		<siri:MessageIdentifier>Subscription:Test:0</siri:MessageIdentifier>
+	</SubscriptionRequestInfo>
+	<Request>
+		<siri:ProductionTimetableSubscriptionRequest>
+			<siri:SubscriberRef>subscriber</siri:SubscriberRef>
+			<siri:SubscriptionIdentifier>PT:Subscription:1</siri:SubscriptionIdentifier>
+			<siri:InitialTerminationTime>` + fakeClock.Now().Add(24*time.Hour).Format(time.RFC3339) + `</siri:InitialTerminationTime>
+			<siri:ProductionTimetableRequest>
+				<siri:MessageIdentifier>ProductionTimetable:Test:1</siri:MessageIdentifier>
+				<siri:Lines>
+					<siri:LineRef>NINOXE:Line:2:LOC</siri:LineRef>
+				</siri:Lines>
+			</siri:ProductionTimetableRequest>
+		</siri:ProductionTimetableSubscriptionRequest>
+	</Request>
+</ws:Subscribe>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := connector.HandleSubscriptionRequest(request, &audit.BigQueryMessage{})
+
+	if len(responses) != 1 || !responses[0].Status {
+		t.Fatalf("Subscription should be accepted: %v", responses)
+	}
+
+	sub, ok := partner.Subscriptions().FindByExternalId("PT:Subscription:1")
+	if !ok {
+		t.Fatal("Subscription should be created")
+	}
+	if sub.Kind() != "ProductionTimetableBroadcast" {
+		t.Errorf("Wrong Subscription kind: %v", sub.Kind())
+	}
+	if _, ok := connector.toBroadcast[sub.Id()]; !ok {
+		t.Error("Subscription should be broadcasted")
+	}
+
+	notification := connector.buildNotification(sub)
+	if notification.SubscriptionIdentifier != "PT:Subscription:1" {
+		t.Errorf("Wrong SubscriptionIdentifier: %v", notification.SubscriptionIdentifier)
+	}
+	if len(notification.DatedTimetableVersionFrames) != 2 {
+		t.Errorf("Notification should contain a frame by direction, got: %v", len(notification.DatedTimetableVersionFrames))
+	}
+}
+
+func Test_SIRIProductionTimetableSubscriptionBroadcaster_HandleSubscriptionRequest_UnknownLine(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := newSIRIProductionTimetableSubscriptionBroadcaster(partner)
+	fakeClock := clock.NewFakeClock()
+	connector.SetClock(fakeClock)
+
+	request, err := siri.NewXMLSubscriptionRequestFromContent([]byte(`<ws:Subscribe xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<SubscriptionRequestInfo>
+		<siri:MessageIdentifier>Subscription:Test:0</siri:MessageIdentifier>
+	</SubscriptionRequestInfo>
+	<Request>
+		<siri:ProductionTimetableSubscriptionRequest>
+			<siri:SubscriberRef>subscriber</siri:SubscriberRef>
+			<siri:SubscriptionIdentifier>PT:Subscription:1</siri:SubscriptionIdentifier>
+			<siri:InitialTerminationTime>` + fakeClock.Now().Add(24*time.Hour).Format(time.RFC3339) + `</siri:InitialTerminationTime>
+			<siri:ProductionTimetableRequest>
+				<siri:MessageIdentifier>ProductionTimetable:Test:1</siri:MessageIdentifier>
+				<siri:Lines>
+					<siri:LineRef>NINOXE:Line:unknown:LOC</siri:LineRef>
+				</siri:Lines>
+			</siri:ProductionTimetableRequest>
+		</siri:ProductionTimetableSubscriptionRequest>
+	</Request>
+</ws:Subscribe>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := &audit.BigQueryMessage{}
+	responses := connector.HandleSubscriptionRequest(request, message)
+
+	if len(responses) != 1 || responses[0].Status {
+		t.Fatalf("Subscription should be refused: %v", responses)
+	}
+	if responses[0].ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Wrong ErrorType: %v", responses[0].ErrorType)
+	}
+	if _, ok := partner.Subscriptions().FindByExternalId("PT:Subscription:1"); ok {
+		t.Error("Subscription shouldn't be created")
+	}
+}
+
+func Test_SIRIProductionTimetableSubscriptionBroadcaster_checkServiceDates(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	connector := newSIRIProductionTimetableSubscriptionBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	sub := partner.Subscriptions().New("ProductionTimetableBroadcast")
+	sub.Save()
+
+	vehicleJourney := referential.Model().VehicleJourneys().New()
+	vehicleJourney.ServiceDate = model.NewDate(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC))
+	vehicleJourney.Save()
+
+	connector.serviceDates = connector.currentServiceDates()
+	if connector.serviceDates != "2017-01-01" {
+		t.Errorf("Wrong service dates: %v", connector.serviceDates)
+	}
+	connector.checkServiceDates()
+	if len(connector.toBroadcast) != 0 {
+		t.Fatal("Subscription shouldn't be broadcasted when the service dates don't change")
+	}
+
+	vehicleJourney = referential.Model().VehicleJourneys().New()
+	vehicleJourney.ServiceDate = model.NewDate(time.Date(2017, time.January, 2, 0, 0, 0, 0, time.UTC))
+	vehicleJourney.Save()
+
+	connector.checkServiceDates()
+	if _, ok := connector.toBroadcast[sub.Id()]; !ok {
+		t.Error("Subscription should be broadcasted when the service dates change")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"bitbucket.org/enroute-mobi/ara/audit"
 	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/model"
 	"bitbucket.org/enroute-mobi/ara/siri"
 )
 
@@ -42,7 +43,7 @@ func (connector *SIRIStopPointsDiscoveryRequestBroadcaster) StopAreas(request *s
 	annotedStopPointMap := make(map[string]struct{})
 
 	objectIDKind := connector.partner.RemoteObjectIDKind(SIRI_STOP_POINTS_DISCOVERY_REQUEST_BROADCASTER)
-	for _, stopArea := range tx.Model().StopAreas().FindAll() {
+	for _, stopArea := range connector.requestedStopAreas(tx, request) {
 		if stopArea.Name == "" || !stopArea.CollectedAlways {
 			continue
 		}
@@ -89,6 +90,21 @@ func (connector *SIRIStopPointsDiscoveryRequestBroadcaster) StopAreas(request *s
 	return response, nil
 }
 
+// Returns the StopAreas matching the request Circle or BoundingBox, or all the StopAreas
+func (connector *SIRIStopPointsDiscoveryRequestBroadcaster) requestedStopAreas(tx *model.Transaction, request *siri.XMLStopPointsDiscoveryRequest) []model.StopArea {
+	if circle := request.Circle(); circle != nil && circle.Radius() > 0 {
+		return tx.Model().StopAreas().FindNear(circle.Latitude(), circle.Longitude(), circle.Radius())
+	}
+
+	upperLeft, lowerRight := request.UpperLeft(), request.LowerRight()
+	if upperLeft != nil && lowerRight != nil {
+		box := model.NewBoundingBox(upperLeft.Latitude(), upperLeft.Longitude(), lowerRight.Latitude(), lowerRight.Longitude())
+		return tx.Model().StopAreas().FindInBoundingBox(box)
+	}
+
+	return tx.Model().StopAreas().FindAll()
+}
+
 func (connector *SIRIStopPointsDiscoveryRequestBroadcaster) ignoreStopWithoutLine() bool {
 	return connector.partner.Setting(IGNORE_STOP_WITHOUT_LINE) != "false"
 }
@@ -189,3 +189,54 @@ func Test_SIRIStopPointDiscoveryRequestBroadcaster_StopAreasWithParent(t *testin
 		t.Errorf("AnnotatedStopPoints StopPointRef 2 is wrong:\n got: %v\n want: %v", response.AnnotatedStopPoints[1].StopPointRef, secondObjectID.Value())
 	}
 }
+
+func Test_SIRIStopPointDiscoveryRequestBroadcaster_StopAreasWithCircle(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "test"
+	partner.Settings["ignore_stop_without_line"] = "false"
+	connector := NewSIRIStopDiscoveryRequestBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	nearStopArea := referential.Model().StopAreas().New()
+	nearObjectID := model.NewObjectID("test", "NINOXE:StopPoint:SP:1:LOC")
+	nearStopArea.SetObjectID(nearObjectID)
+	nearStopArea.Name = "Near"
+	nearStopArea.Latitude = 48.851
+	nearStopArea.Longitude = 2.351
+	nearStopArea.Save()
+
+	farStopArea := referential.Model().StopAreas().New()
+	farStopArea.SetObjectID(model.NewObjectID("test", "NINOXE:StopPoint:SP:2:LOC"))
+	farStopArea.Name = "Far"
+	farStopArea.Latitude = 48.9
+	farStopArea.Longitude = 2.4
+	farStopArea.Save()
+
+	file, err := os.Open("testdata/stoppointdiscovery-request-circle-soap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := siri.NewXMLStopPointsDiscoveryRequestFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := connector.StopAreas(request, &audit.BigQueryMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.AnnotatedStopPoints) != 1 {
+		t.Fatalf("AnnotatedStopPoints lenght is wrong:\n got: %v\n want: 1", len(response.AnnotatedStopPoints))
+	}
+	if response.AnnotatedStopPoints[0].StopPointRef != nearObjectID.Value() {
+		t.Errorf("AnnotatedStopPoints StopPointRef is wrong:\n got: %v\n want: %v", response.AnnotatedStopPoints[0].StopPointRef, nearObjectID.Value())
+	}
+}
@@ -32,6 +32,17 @@ func NewSIRIStopMonitoringRequestBroadcaster(partner *Partner) *SIRIStopMonitori
 }
 
 func (connector *SIRIStopMonitoringRequestBroadcaster) getStopMonitoringDelivery(tx *model.Transaction, logStashEvent audit.LogStashEvent, request *siri.XMLStopMonitoringRequest) siri.SIRIStopMonitoringDelivery {
+	if detailLevel := request.DetailLevel(); detailLevel != "" && !ValidSMDetailLevel(detailLevel) {
+		return siri.SIRIStopMonitoringDelivery{
+			RequestMessageRef: request.MessageIdentifier(),
+			Status:            false,
+			ResponseTimestamp: connector.Clock().Now(),
+			ErrorType:         "InvalidDataReferencesError",
+			ErrorText:         fmt.Sprintf("Invalid DetailLevel '%s'", detailLevel),
+			MonitoringRef:     request.MonitoringRef(),
+		}
+	}
+
 	objectidKind := connector.partner.RemoteObjectIDKind(SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
 	objectid := model.NewObjectID(objectidKind, request.MonitoringRef())
 	stopArea, ok := tx.Model().StopAreas().FindByObjectId(objectid)
@@ -71,6 +82,10 @@ func (connector *SIRIStopMonitoringRequestBroadcaster) getStopMonitoringDelivery
 		lineSelectorObjectid := model.NewObjectID(objectidKind, request.LineRef())
 		selectors = append(selectors, model.StopVisitSelectorByLine(lineSelectorObjectid))
 	}
+	if request.OperatorRef() != "" {
+		operatorSelectorObjectid := model.NewObjectID(objectidKind, request.OperatorRef())
+		selectors = append(selectors, model.StopVisitSelectorByOperator(operatorSelectorObjectid))
+	}
 	if request.PreviewInterval() != 0 {
 		duration := request.PreviewInterval()
 		now := connector.Clock().Now()
@@ -88,6 +103,10 @@ func (connector *SIRIStopMonitoringRequestBroadcaster) getStopMonitoringDelivery
 	stopMonitoringBuilder := NewBroadcastStopMonitoringBuilder(tx, connector.Partner(), SIRI_STOP_MONITORING_REQUEST_BROADCASTER)
 	stopMonitoringBuilder.StopVisitTypes = request.StopVisitTypes()
 	stopMonitoringBuilder.MonitoringRef = request.MonitoringRef()
+	if detailLevel := request.DetailLevel(); detailLevel != "" {
+		stopMonitoringBuilder.DetailLevel = detailLevel
+	}
+	stopMonitoringBuilder.MaximumPreviousCalls, stopMonitoringBuilder.MaximumOnwardCalls = request.MaximumNumberOfCalls()
 
 	// Find Descendants
 	stopAreas := tx.Model().StopAreas().FindFamily(stopArea.Id())
@@ -171,6 +190,7 @@ func logXMLStopMonitoringRequest(logStashEvent audit.LogStashEvent, request *sir
 	logStashEvent["monitoringRef"] = request.MonitoringRef()
 	logStashEvent["stopVisitTypes"] = request.StopVisitTypes()
 	logStashEvent["lineRef"] = request.LineRef()
+	logStashEvent["operatorRef"] = request.OperatorRef()
 	logStashEvent["maximumStopVisits"] = strconv.Itoa(request.MaximumStopVisits())
 	logStashEvent["requestTimestamp"] = request.RequestTimestamp().String()
 	logStashEvent["startTime"] = request.StartTime().String()
@@ -3,6 +3,7 @@ package core
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -302,6 +303,71 @@ func Test_SIRIStopMonitoringRequestBroadcaster_RequestStopAreaLineSelector(t *te
 	}
 }
 
+func Test_SIRIStopMonitoringRequestBroadcaster_RequestStopAreaOperatorSelector(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["local_url"] = "http://ara"
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings["generators.response_message_identifier"] = "Ara:ResponseMessage::%{uuid}:LOC"
+	connector := NewSIRIStopMonitoringRequestBroadcaster(partner)
+	connector.Partner().SetUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	fakeClock := clock.NewFakeClock()
+	clock.SetDefaultClock(fakeClock)
+	connector.SetClock(fakeClock)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:StopPoint:SP:24:LOC"))
+	stopArea.Save()
+
+	operator := referential.Model().Operators().New()
+	operator.SetObjectID(model.NewObjectID("internal", "op1"))
+	operator.SetObjectID(model.NewObjectID("objectidKind", "OP1"))
+	operator.Save()
+
+	line := referential.model.Lines().New()
+	line.SetObjectID(model.NewObjectID("objectidKind", "line"))
+	line.Save()
+
+	for i, operatorRef := range []string{"op1", "op2"} {
+		vehicleJourney := referential.model.VehicleJourneys().New()
+		vehicleJourney.SetObjectID(model.NewObjectID("objectidKind", "vehicleJourney-"+operatorRef))
+		vehicleJourney.LineId = line.Id()
+		vehicleJourney.References.SetObjectId("OperatorRef", model.NewObjectID("internal", operatorRef))
+		vehicleJourney.Save()
+
+		stopVisit := referential.model.StopVisits().New()
+		stopVisit.SetObjectID(model.NewObjectID("objectidKind", "stopVisit-"+operatorRef))
+		stopVisit.StopAreaId = stopArea.Id()
+		stopVisit.VehicleJourneyId = vehicleJourney.Id()
+		stopVisit.Schedules.SetArrivalTime("actual", connector.Clock().Now().Add(time.Duration(10+i)*time.Minute))
+		stopVisit.Save()
+	}
+
+	file, err := os.Open("testdata/stopmonitoring-request-operator-selector-soap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := siri.NewXMLGetStopMonitoringFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestStopArea(request, &audit.BigQueryMessage{})
+
+	if len(response.MonitoredStopVisits) != 1 {
+		t.Fatalf("Response.MonitoredStopVisits should be 1 is %v", len(response.MonitoredStopVisits))
+	}
+	if ref := response.MonitoredStopVisits[0].References["StopVisitReferences"]["OperatorRef"]; ref != "OP1" {
+		t.Errorf("Wrong OperatorRef:\n got: %v\n want: OP1", ref)
+	}
+}
+
 func Test_SIRIStopMonitoringRequestBroadcaster_RequestStopAreaTimeSelector(t *testing.T) {
 	referentials := NewMemoryReferentials()
 	referential := referentials.New("referential")
@@ -539,3 +605,28 @@ func Test_SIRIStopMonitoringRequestBroadcaster_RemoteObjectIDKindAbsent(t *testi
 		t.Errorf("RemoteObjectIDKind should be egals to Kind2")
 	}
 }
+
+func Test_SIRIStopMonitoringRequestBroadcaster_RequestStopAreaInvalidDetailLevel(t *testing.T) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	connector := NewSIRIStopMonitoringRequestBroadcaster(partner)
+	connector.SetClock(clock.NewFakeClock())
+
+	content, err := ioutil.ReadFile("testdata/stopmonitoring-request-soap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = []byte(strings.Replace(string(content), "<ns2:MonitoringRef>", "<ns2:DetailLevel>unknown</ns2:DetailLevel><ns2:MonitoringRef>", 1))
+	request, err := siri.NewXMLGetStopMonitoringFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := connector.RequestStopArea(request, &audit.BigQueryMessage{})
+
+	if response.Status || response.ErrorType != "InvalidDataReferencesError" {
+		t.Errorf("Unknown DetailLevel should be refused, got: %v %v", response.Status, response.ErrorType)
+	}
+}
@@ -100,7 +100,7 @@ func (subscriber *SMSubscriber) prepareSIRIStopMonitoringSubscriptionRequest() {
 	stopAreasToRequest := make(map[string]*saToRequest)
 	for _, subscription := range subscriptions {
 		for _, resource := range subscription.ResourcesByObjectIDCopy() {
-			if resource.SubscribedAt.IsZero() && resource.RetryCount <= 10 {
+			if resource.SubscribedAt.IsZero() && resource.RetryCount <= SUBSCRIPTION_MAXIMUM_RETRY_COUNT {
 				messageIdentifier := subscriber.connector.Partner().IdentifierGenerator(MESSAGE_IDENTIFIER).NewMessageIdentifier()
 				stopAreasToRequest[messageIdentifier] = &saToRequest{
 					subId: subscription.id,
@@ -132,7 +132,7 @@ func (subscriber *SMSubscriber) prepareSIRIStopMonitoringSubscriptionRequest() {
 		entry := &siri.SIRIStopMonitoringSubscriptionRequestEntry{
 			SubscriberRef:          subscriber.connector.SIRIPartner().RequestorRef(),
 			SubscriptionIdentifier: string(requestedSa.subId),
-			InitialTerminationTime: subscriber.Clock().Now().Add(48 * time.Hour),
+			InitialTerminationTime: subscriber.Clock().Now().Add(COLLECT_SUBSCRIPTION_DURATION),
 		}
 		entry.MessageIdentifier = messageIdentifier
 		entry.RequestTimestamp = subscriber.Clock().Now()
@@ -199,6 +199,7 @@ func (subscriber *SMSubscriber) prepareSIRIStopMonitoringSubscriptionRequest() {
 			continue
 		}
 		resource.SubscribedAt = subscriber.Clock().Now()
+		resource.TerminationTime = resource.SubscribedAt.Add(COLLECT_SUBSCRIPTION_DURATION)
 		resource.RetryCount = 0
 	}
 	// Should not happen but see #4691
@@ -187,6 +187,8 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 
 	var monitoringRefs, subIds []string
 
+	validator := newSubscriptionRequestValidator(connector.Partner(), connector.Clock().Now())
+
 	for _, sm := range request.XMLSubscriptionSMEntries() {
 		logStashEvent := connector.newLogStashEvent()
 		logXMLStopMonitoringSubscriptionEntry(logStashEvent, sm)
@@ -214,6 +216,28 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 			continue
 		}
 
+		if sm.LineRef() != "" {
+			lineObjectId := model.NewObjectID(connector.partner.RemoteObjectIDKind(SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER), sm.LineRef())
+			if _, ok := tx.Model().Lines().FindByObjectId(lineObjectId); !ok {
+				rs.ErrorType = "InvalidDataReferencesError"
+				rs.ErrorText = fmt.Sprintf("Line not found: '%s'", lineObjectId.Value())
+			}
+		}
+		if detailLevel := sm.DetailLevel(); detailLevel != "" && !ValidSMDetailLevel(detailLevel) {
+			rs.ErrorType = "InvalidDataReferencesError"
+			rs.ErrorText = fmt.Sprintf("Invalid DetailLevel '%s'", detailLevel)
+		}
+
+		if rs.ErrorType != "" || !validator.validate(&rs, "StopMonitoringBroadcast", sm.InitialTerminationTime()) {
+			resps = append(resps, rs)
+
+			logSIRIStopMonitoringSubscriptionResponseEntry(logStashEvent, &rs)
+			audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+			message.Status = "Error"
+			continue
+		}
+
 		subIds = append(subIds, sm.SubscriptionIdentifier())
 
 		sub, ok := connector.Partner().Subscriptions().FindByExternalId(sm.SubscriptionIdentifier())
@@ -229,7 +253,7 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 
 		r := sub.CreateAddNewResource(ref)
 		r.SubscribedAt = connector.Clock().Now()
-		r.SubscribedUntil = sm.InitialTerminationTime()
+		r.SubscribedUntil = rs.ValidUntil
 
 		connector.fillOptions(sub, r, request, sm)
 		if sm.LineRef() != "" {
@@ -237,7 +261,6 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 		}
 
 		rs.Status = true
-		rs.ValidUntil = sm.InitialTerminationTime()
 		resps = append(resps, rs)
 
 		logSIRIStopMonitoringSubscriptionResponseEntry(logStashEvent, &rs)
@@ -248,7 +271,7 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 		salc.InitState(&sa, sub)
 		r.SetLastState(string(sa.Id()), salc)
 		// Init StopVisits LastChange
-		connector.addStopAreaStopVisits(sa, sub, r)
+		connector.addStopAreaStopVisits(&sa, sub, r)
 	}
 
 	message.Type = "StopMonitoringSubscriptionRequest"
@@ -258,7 +281,7 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) HandleSubscriptionRe
 	return
 }
 
-func (connector *SIRIStopMonitoringSubscriptionBroadcaster) addStopAreaStopVisits(sa model.StopArea, sub *Subscription, res *SubscribedResource) {
+func (connector *SIRIStopMonitoringSubscriptionBroadcaster) addStopAreaStopVisits(sa *model.StopArea, sub *Subscription, res *SubscribedResource) {
 	tx := connector.Partner().Referential().NewTransaction()
 	defer tx.Close()
 
@@ -282,6 +305,25 @@ func (connector *SIRIStopMonitoringSubscriptionBroadcaster) addStopAreaStopVisit
 	}
 }
 
+// Sends again all the StopVisits of the Subscription StopAreas
+func (connector *SIRIStopMonitoringSubscriptionBroadcaster) ResyncSubscription(sub *Subscription) {
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	for _, resource := range sub.ResourcesByObjectIDCopy() {
+		sa, ok := tx.Model().StopAreas().FindByObjectId(*resource.Reference.ObjectId)
+		if !ok {
+			continue
+		}
+
+		resource.ClearLastStates()
+		salc := &stopAreaLastChange{}
+		salc.InitState(&sa, sub)
+		resource.SetLastState(string(sa.Id()), salc)
+		connector.addStopAreaStopVisits(&sa, sub, resource)
+	}
+}
+
 // WIP Need to do something about this method Refs #6338
 func (smsb *SIRIStopMonitoringSubscriptionBroadcaster) fillOptions(s *Subscription, r *SubscribedResource, request *siri.XMLSubscriptionRequest, sm *siri.XMLStopMonitoringSubscriptionRequestEntry) {
 	changeBeforeUpdates := request.ChangeBeforeUpdates()
@@ -290,10 +332,15 @@ func (smsb *SIRIStopMonitoringSubscriptionBroadcaster) fillOptions(s *Subscripti
 	}
 
 	s.SetSubscriptionOption("StopVisitTypes", sm.StopVisitTypes())
+	s.SetSubscriptionOption("DetailLevel", sm.DetailLevel())
+	previousCalls, onwardCalls := sm.MaximumNumberOfCalls()
+	s.SetSubscriptionOption("MaximumPreviousCalls", strconv.Itoa(previousCalls))
+	s.SetSubscriptionOption("MaximumOnwardCalls", strconv.Itoa(onwardCalls))
 	s.SetSubscriptionOption("IncrementalUpdates", request.IncrementalUpdates())
 	s.SetSubscriptionOption("MaximumStopVisits", strconv.Itoa(sm.MaximumStopVisits()))
 	s.SetSubscriptionOption("ChangeBeforeUpdates", changeBeforeUpdates)
 	s.SetSubscriptionOption("MessageIdentifier", request.MessageIdentifier())
+	s.SetSubscriptionOption("HeartbeatInterval", request.HeartbeatInterval())
 }
 
 // Returns the LineId of the line defined in the LineRef subscription option
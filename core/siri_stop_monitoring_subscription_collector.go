@@ -141,6 +141,7 @@ func (connector *SIRIStopMonitoringSubscriptionCollector) HandleNotifyStopMonito
 			subscriptionErrors[subscriptionId] = "Subscription of id %s is not a subscription of kind StopMonitoringCollect"
 			continue
 		}
+		subscription.SetLastNotificationAt(connector.Clock().Now())
 
 		originStopAreaObjectId := model.ObjectID{}
 		resource := subscription.UniqueResource()
@@ -92,20 +92,15 @@ func (connector *SIRIStopPointsDiscoveryRequestCollector) RequestStopPoints() {
 		return
 	}
 
-	stopPointRefs := []string{}
-	idKind := connector.partner.Setting(REMOTE_OBJECTID_KIND)
-	partner := string(connector.Partner().Slug())
+	diff, objectids := connector.reconcile(response)
+	diff.log(connector.Partner(), "StopPointsDiscoveryRequestCollector", "StopPointsDiscoveryReconciliation", func(message *audit.BigQueryMessage, refs []string) {
+		message.StopAreas = refs
+	})
+	connector.Partner().Referential().StopAreaMatcher().Match(string(connector.Partner().Slug()), objectids)
 
+	stopPointRefs := []string{}
 	for _, annotatedStopPoint := range response.AnnotatedStopPointRefs() {
 		stopPointRefs = append(stopPointRefs, annotatedStopPoint.StopPointRef())
-		event := model.NewStopAreaUpdateEvent()
-
-		event.Origin = partner
-		event.ObjectId = model.NewObjectID(idKind, annotatedStopPoint.StopPointRef())
-		event.Name = annotatedStopPoint.StopName()
-		event.CollectedAlways = true
-
-		connector.broadcastUpdateEvent(event)
 	}
 
 	connector.partner.RegisterDiscoveredStopAreas(stopPointRefs)
@@ -113,6 +108,98 @@ func (connector *SIRIStopPointsDiscoveryRequestCollector) RequestStopPoints() {
 	message.StopAreas = stopPointRefs
 }
 
+// Creates the unknown StopAreas, updates the name and the coordinates of the
+// known ones and retires the StopAreas announced by the previous discovery
+// which are no longer announced by any partner: they are no longer always
+// collected.
+func (connector *SIRIStopPointsDiscoveryRequestCollector) reconcile(response *siri.XMLStopPointsDiscoveryResponse) (*discoveryDiff, []model.ObjectID) {
+	diff := &discoveryDiff{}
+	objectids := []model.ObjectID{}
+	idKind := connector.partner.RemoteObjectIDKind(SIRI_STOP_POINTS_DISCOVERY_REQUEST_COLLECTOR)
+	partner := string(connector.Partner().Slug())
+	announced := make(map[string]struct{})
+
+	tx := connector.Partner().Referential().NewTransaction()
+	defer tx.Close()
+
+	for _, annotatedStopPoint := range response.AnnotatedStopPointRefs() {
+		stopPointRef := annotatedStopPoint.StopPointRef()
+		announced[stopPointRef] = struct{}{}
+
+		objectid := model.NewObjectID(idKind, stopPointRef)
+		objectids = append(objectids, objectid)
+
+		stopArea, ok := tx.Model().StopAreas().FindByObjectId(objectid)
+		if !ok {
+			event := model.NewStopAreaUpdateEvent()
+			event.Origin = partner
+			event.ObjectId = objectid
+			event.Name = annotatedStopPoint.StopName()
+			event.Longitude = annotatedStopPoint.Longitude()
+			event.Latitude = annotatedStopPoint.Latitude()
+			event.CollectedAlways = true
+
+			connector.broadcastUpdateEvent(event)
+			diff.Created = append(diff.Created, stopPointRef)
+			continue
+		}
+
+		if !updateDiscoveredStopArea(&stopArea, annotatedStopPoint) {
+			continue
+		}
+
+		tx.Model().StopAreas().Save(&stopArea)
+		diff.Updated = append(diff.Updated, stopPointRef)
+	}
+
+	// Only the StopAreas announced by the previous discovery can be retired
+	for stopPointRef := range connector.Partner().replaceAnnouncedStopPoints(announced) {
+		if _, ok := announced[stopPointRef]; ok {
+			continue
+		}
+		stopArea, ok := tx.Model().StopAreas().FindByObjectId(model.NewObjectID(idKind, stopPointRef))
+		if !ok || !stopArea.CollectedAlways || connector.announcedByOtherPartner(&stopArea) {
+			continue
+		}
+
+		stopArea.CollectedAlways = false
+		tx.Model().StopAreas().Save(&stopArea)
+		diff.Retired = append(diff.Retired, stopPointRef)
+	}
+
+	tx.Commit()
+	return diff, objectids
+}
+
+// Returns true if the last discovery of another partner still announces the
+// StopArea
+func (connector *SIRIStopPointsDiscoveryRequestCollector) announcedByOtherPartner(stopArea *model.StopArea) bool {
+	for _, partner := range connector.Partner().Referential().Partners().FindAll() {
+		if partner != connector.Partner() && partner.announcesStopArea(stopArea) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the StopArea is modified by the announced StopPoint
+func updateDiscoveredStopArea(stopArea *model.StopArea, annotatedStopPoint *siri.XMLAnnotatedStopPointRef) (changed bool) {
+	if !stopArea.CollectedAlways {
+		stopArea.CollectedAlways = true
+		changed = true
+	}
+	if name := annotatedStopPoint.StopName(); name != "" && name != stopArea.Name {
+		stopArea.Name = name
+		changed = true
+	}
+	if longitude, latitude := annotatedStopPoint.Longitude(), annotatedStopPoint.Latitude(); (longitude != 0 || latitude != 0) && (longitude != stopArea.Longitude || latitude != stopArea.Latitude) {
+		stopArea.Longitude = longitude
+		stopArea.Latitude = latitude
+		changed = true
+	}
+	return
+}
+
 func (connector *SIRIStopPointsDiscoveryRequestCollector) newBQEvent() *audit.BigQueryMessage {
 	return &audit.BigQueryMessage{
 		Type:      "StopDiscoveryRequest",
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/audit"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func Test_SIRIStopPointsDiscoveryRequestCollector_RequestStopPoints(t *testing.T) {
+	audit.SetCurrentLogstash(audit.NewFakeLogStash())
+
+	ts := discoveryTestServer(t, "testdata/stoppointsdiscovery-response-soap.xml")
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Settings[COLLECT_USE_DISCOVERED_SA] = "true"
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:StopPoint:BP:6:LOC"))
+	stopArea.Name = "Old name"
+	stopArea.CollectedAlways = false
+	stopArea.SetPartnerStatus("partner", true)
+	stopArea.Save()
+
+	retiredStopArea := referential.Model().StopAreas().New()
+	retiredStopArea.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:StopPoint:BP:7:LOC"))
+	retiredStopArea.SetPartnerStatus("partner", true)
+	retiredStopArea.Save()
+
+	// StopArea collected with the partner but never discovered
+	collectedStopArea := referential.Model().StopAreas().New()
+	collectedStopArea.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:StopPoint:BP:8:LOC"))
+	collectedStopArea.CollectedAlways = true
+	collectedStopArea.SetPartnerStatus("partner", true)
+	collectedStopArea.Save()
+
+	// Previous discovery
+	partner.RegisterDiscoveredStopAreas([]string{"NINOXE:StopPoint:BP:7:LOC"})
+	partner.replaceAnnouncedStopPoints(map[string]struct{}{"NINOXE:StopPoint:BP:7:LOC": {}})
+
+	connector := NewSIRIStopPointsDiscoveryRequestCollector(partner)
+	connector.SetSubscriber(model.NewUpdateManager(referential))
+	connector.RequestStopPoints()
+
+	stopArea, _ = referential.Model().StopAreas().Find(stopArea.Id())
+	if stopArea.Name != "Test" || !stopArea.CollectedAlways {
+		t.Errorf("Known StopArea should be updated, got: %v %v", stopArea.Name, stopArea.CollectedAlways)
+	}
+	if stopArea.Longitude != 2.35 || stopArea.Latitude != 48.85 {
+		t.Errorf("Known StopArea coordinates should be updated, got: %v %v", stopArea.Longitude, stopArea.Latitude)
+	}
+
+	if _, ok := referential.Model().StopAreas().FindByObjectId(model.NewObjectID("objectidKind", "NINOXE:StopPoint:SP:16:LOC")); !ok {
+		t.Error("Unknown StopArea should be created")
+	}
+
+	retiredStopArea, _ = referential.Model().StopAreas().Find(retiredStopArea.Id())
+	if retiredStopArea.CollectedAlways {
+		t.Errorf("StopArea no longer announced should be retired")
+	}
+	collectedStopArea, _ = referential.Model().StopAreas().Find(collectedStopArea.Id())
+	if !collectedStopArea.CollectedAlways {
+		t.Errorf("StopArea never discovered shouldn't be retired")
+	}
+
+	if _, ok := partner.discoveredStopAreas["NINOXE:StopPoint:BP:7:LOC"]; ok {
+		t.Errorf("StopArea no longer announced shouldn't be discovered anymore")
+	}
+	if _, ok := partner.discoveredStopAreas["NINOXE:StopPoint:SP:16:LOC"]; !ok {
+		t.Errorf("Announced StopArea should be discovered")
+	}
+}
+
+func Test_SIRIStopPointsDiscoveryRequestCollector_RequestStopPoints_OtherPartners(t *testing.T) {
+	audit.SetCurrentLogstash(audit.NewFakeLogStash())
+	bigQuery := audit.NewFakeBigQuery()
+	audit.SetCurrentBigQuery("referential", bigQuery)
+	defer audit.SetCurrentBigQuery("referential", audit.NewNullBigQuery())
+
+	ts := discoveryTestServer(t, "testdata/stoppointsdiscovery-response-soap.xml")
+	defer ts.Close()
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_url"] = ts.URL
+	partner.Settings["remote_objectid_kind"] = "objectidKind"
+	partner.Save()
+
+	otherPartner := referential.Partners().New("other")
+	otherPartner.Settings["remote_objectid_kind"] = "objectidKind"
+	otherPartner.Save()
+
+	announcedStopArea := referential.Model().StopAreas().New()
+	announcedStopArea.SetObjectID(model.NewObjectID("objectidKind", "NINOXE:StopPoint:BP:7:LOC"))
+	announcedStopArea.CollectedAlways = true
+	announcedStopArea.Save()
+
+	// Previous discoveries
+	partner.replaceAnnouncedStopPoints(map[string]struct{}{"NINOXE:StopPoint:BP:7:LOC": {}})
+	otherPartner.replaceAnnouncedStopPoints(map[string]struct{}{"NINOXE:StopPoint:BP:7:LOC": {}})
+
+	connector := NewSIRIStopPointsDiscoveryRequestCollector(partner)
+	connector.SetSubscriber(model.NewUpdateManager(referential))
+	connector.RequestStopPoints()
+
+	announcedStopArea, _ = referential.Model().StopAreas().Find(announcedStopArea.Id())
+	if !announcedStopArea.CollectedAlways {
+		t.Errorf("StopArea still announced by another partner shouldn't be retired")
+	}
+
+	var diffMessage *audit.BigQueryMessage
+	for _, message := range bigQuery.Messages() {
+		if message.Type == "StopPointsDiscoveryReconciliation" {
+			diffMessage = message
+		}
+	}
+	if diffMessage == nil {
+		t.Fatal("A BigQuery message should report the discovery changes")
+	}
+	if len(diffMessage.StopAreas) != 2 {
+		t.Errorf("Wrong discovery changes: %v", diffMessage.StopAreas)
+	}
+}
+
+func Test_Partner_DiscoveryInterval(t *testing.T) {
+	partner := NewPartner()
+
+	if partner.DiscoveryInterval(DISCOVERY_INTERVAL_LINES) != time.Hour {
+		t.Errorf("Default discovery interval should be one hour, got: %v", partner.DiscoveryInterval(DISCOVERY_INTERVAL_LINES))
+	}
+
+	partner.Settings[DISCOVERY_INTERVAL] = "30m"
+	partner.Settings[DISCOVERY_INTERVAL_STOP_POINTS] = "6h"
+	if partner.DiscoveryInterval(DISCOVERY_INTERVAL_LINES) != 30*time.Minute {
+		t.Errorf("Lines discovery interval should use discovery_interval, got: %v", partner.DiscoveryInterval(DISCOVERY_INTERVAL_LINES))
+	}
+	if partner.DiscoveryInterval(DISCOVERY_INTERVAL_STOP_POINTS) != 6*time.Hour {
+		t.Errorf("Wrong stop points discovery interval: %v", partner.DiscoveryInterval(DISCOVERY_INTERVAL_STOP_POINTS))
+	}
+}
@@ -16,6 +16,7 @@ type SubscriptionRequestDispatcher interface {
 	CancelSubscription(*siri.XMLDeleteSubscriptionRequest, *audit.BigQueryMessage) *siri.SIRIDeleteSubscriptionResponse
 	HandleSubscriptionTerminatedNotification(*siri.XMLSubscriptionTerminatedNotification)
 	HandleNotifySubscriptionTerminated(*siri.XMLNotifySubscriptionTerminated)
+	HandleDataSupply(*siri.XMLDataSupplyRequest, *audit.BigQueryMessage, func(*siri.SIRIDataSupplyResponse) error) error
 }
 
 type SIRISubscriptionRequestDispatcherFactory struct{}
@@ -61,12 +62,17 @@ func (connector *SIRISubscriptionRequestDispatcher) Dispatch(request *siri.XMLSu
 
 	if len(request.XMLSubscriptionGMEntries()) > 0 {
 		gmbc, ok := connector.Partner().Connector(SIRI_GENERAL_MESSAGE_SUBSCRIPTION_BROADCASTER)
-		if !ok {
-			return nil, fmt.Errorf("no GeneralMessageSubscriptionBroadcaster Connector")
+		if ok {
+			response.ResponseStatus = gmbc.(*SIRIGeneralMessageSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
+		} else {
+			var entries []subscriptionRequestEntry
+			for _, entry := range request.XMLSubscriptionGMEntries() {
+				entries = append(entries, entry)
+			}
+			response.ResponseStatus = capabilityNotSupportedStatuses(entries, "GeneralMessage", response.ResponseTimestamp)
+			message.Status = "Error"
 		}
 
-		response.ResponseStatus = gmbc.(*SIRIGeneralMessageSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
-
 		logSIRISubscriptionResponse(logStashEvent, &response, "GeneralMessageSubscriptionBroadcaster")
 		logStashEvent["siriType"] = "GeneralMessageSubscriptionRequest"
 		audit.CurrentLogStash().WriteEvent(logStashEvent)
@@ -75,12 +81,17 @@ func (connector *SIRISubscriptionRequestDispatcher) Dispatch(request *siri.XMLSu
 
 	if len(request.XMLSubscriptionSMEntries()) > 0 {
 		smbc, ok := connector.Partner().Connector(SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER)
-		if !ok {
-			return nil, fmt.Errorf("no StopMonitoringSubscriptionBroadcaster Connector")
+		if ok {
+			response.ResponseStatus = smbc.(*SIRIStopMonitoringSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
+		} else {
+			var entries []subscriptionRequestEntry
+			for _, entry := range request.XMLSubscriptionSMEntries() {
+				entries = append(entries, entry)
+			}
+			response.ResponseStatus = capabilityNotSupportedStatuses(entries, "StopMonitoring", response.ResponseTimestamp)
+			message.Status = "Error"
 		}
 
-		response.ResponseStatus = smbc.(*SIRIStopMonitoringSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
-
 		logSIRISubscriptionResponse(logStashEvent, &response, "StopMonitoringSubscriptionBroadcaster")
 		logStashEvent["siriType"] = "StopMonitoringSubscriptionRequest"
 		audit.CurrentLogStash().WriteEvent(logStashEvent)
@@ -88,19 +99,62 @@ func (connector *SIRISubscriptionRequestDispatcher) Dispatch(request *siri.XMLSu
 	}
 
 	if len(request.XMLSubscriptionETTEntries()) > 0 {
-		smbc, ok := connector.Partner().Connector(SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER)
-		if !ok {
-			return nil, fmt.Errorf("no EstimatedTimeTableSubscriptionBroadcaster Connector")
+		etbc, ok := connector.Partner().Connector(SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER)
+		if ok {
+			response.ResponseStatus = etbc.(*SIRIEstimatedTimeTableSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
+		} else {
+			var entries []subscriptionRequestEntry
+			for _, entry := range request.XMLSubscriptionETTEntries() {
+				entries = append(entries, entry)
+			}
+			response.ResponseStatus = capabilityNotSupportedStatuses(entries, "EstimatedTimetable", response.ResponseTimestamp)
+			message.Status = "Error"
 		}
 
-		response.ResponseStatus = smbc.(*SIRIEstimatedTimeTableSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
-
 		logSIRISubscriptionResponse(logStashEvent, &response, "EstimatedTimeTableSubscriptionBroadcaster")
 		logStashEvent["siriType"] = "EstimatedTimetableSubscriptionRequest"
 		audit.CurrentLogStash().WriteEvent(logStashEvent)
 		return &response, nil
 	}
 
+	if len(request.XMLSubscriptionPTEntries()) > 0 {
+		ptbc, ok := connector.Partner().Connector(SIRI_PRODUCTION_TIMETABLE_SUBSCRIPTION_BROADCASTER)
+		if ok {
+			response.ResponseStatus = ptbc.(*SIRIProductionTimetableSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
+		} else {
+			var entries []subscriptionRequestEntry
+			for _, entry := range request.XMLSubscriptionPTEntries() {
+				entries = append(entries, entry)
+			}
+			response.ResponseStatus = capabilityNotSupportedStatuses(entries, "ProductionTimetable", response.ResponseTimestamp)
+			message.Status = "Error"
+		}
+
+		logSIRISubscriptionResponse(logStashEvent, &response, "ProductionTimetableSubscriptionBroadcaster")
+		logStashEvent["siriType"] = "ProductionTimetableSubscriptionRequest"
+		audit.CurrentLogStash().WriteEvent(logStashEvent)
+		return &response, nil
+	}
+
+	if len(request.XMLSubscriptionFMEntries()) > 0 {
+		fmbc, ok := connector.Partner().Connector(SIRI_FACILITY_MONITORING_SUBSCRIPTION_BROADCASTER)
+		if ok {
+			response.ResponseStatus = fmbc.(*SIRIFacilityMonitoringSubscriptionBroadcaster).HandleSubscriptionRequest(request, message)
+		} else {
+			var entries []subscriptionRequestEntry
+			for _, entry := range request.XMLSubscriptionFMEntries() {
+				entries = append(entries, entry)
+			}
+			response.ResponseStatus = capabilityNotSupportedStatuses(entries, "FacilityMonitoring", response.ResponseTimestamp)
+			message.Status = "Error"
+		}
+
+		logSIRISubscriptionResponse(logStashEvent, &response, "FacilityMonitoringSubscriptionBroadcaster")
+		logStashEvent["siriType"] = "FacilityMonitoringSubscriptionRequest"
+		audit.CurrentLogStash().WriteEvent(logStashEvent)
+		return &response, nil
+	}
+
 	return nil, fmt.Errorf("subscription not supported")
 }
 
@@ -184,6 +238,71 @@ func (connector *SIRISubscriptionRequestDispatcher) HandleNotifySubscriptionTerm
 	audit.CurrentLogStash().WriteEvent(logStashEvent)
 }
 
+// Writes the notifications buffered for a subscriber using the fetched
+// delivery: the notifications of the subscription notified by the
+// NotificationRef DataReady, or of all the subscriptions without
+// NotificationRef. The notifications are removed from the queue only when write
+// succeeds.
+func (connector *SIRISubscriptionRequestDispatcher) HandleDataSupply(request *siri.XMLDataSupplyRequest, message *audit.BigQueryMessage, write func(*siri.SIRIDataSupplyResponse) error) error {
+	logStashEvent := connector.newLogStashEvent()
+	defer audit.CurrentLogStash().WriteEvent(logStashEvent)
+
+	logXMLDataSupplyRequest(logStashEvent, request)
+	message.RequestIdentifier = request.MessageIdentifier()
+
+	response := &siri.SIRIDataSupplyResponse{
+		Address:                   connector.Partner().Address(),
+		ProducerRef:               connector.Partner().ProducerRef(),
+		RequestMessageRef:         request.MessageIdentifier(),
+		ResponseMessageIdentifier: connector.Partner().IdentifierGenerator(RESPONSE_MESSAGE_IDENTIFIER).NewMessageIdentifier(),
+		ResponseTimestamp:         connector.Clock().Now(),
+	}
+	message.ResponseIdentifier = response.ResponseMessageIdentifier
+
+	var subscriptionIdentifier string
+	// AllData would require to rebuild the complete state of each subscription
+	if request.AllData() {
+		response.ErrorType = "CapabilityNotSupportedError"
+		response.ErrorText = "AllData isn't supported"
+	} else if request.NotificationRef() != "" {
+		var ok bool
+		subscriptionIdentifier, ok = connector.NotificationQueue().DataReadySubscription(request.NotificationRef())
+		if !ok {
+			response.ErrorType = "InvalidDataReferencesError"
+			response.ErrorText = fmt.Sprintf("Unknown NotificationRef %v", request.NotificationRef())
+		}
+	}
+	if response.ErrorType != "" {
+		message.Status = "Error"
+		message.ErrorDetails = response.ErrorText
+		logStashEvent["errorType"] = response.ErrorType
+		logStashEvent["errorDescription"] = response.ErrorText
+		return write(response)
+	}
+	response.Status = true
+
+	return connector.NotificationQueue().Fetch(subscriptionIdentifier, 0, func(notifications []*QueuedNotification, more bool) error {
+		response.MoreData = more
+
+		subIds := []string{}
+		for _, notification := range notifications {
+			deliveries, err := notification.DeliveriesXML()
+			if err != nil {
+				return err
+			}
+			response.Deliveries = append(response.Deliveries, deliveries...)
+			subIds = append(subIds, notification.SubscriptionIdentifier)
+		}
+
+		message.SubscriptionIdentifiers = subIds
+
+		logStashEvent["notificationCount"] = strconv.Itoa(len(notifications))
+		logStashEvent["moreData"] = strconv.FormatBool(more)
+
+		return write(response)
+	})
+}
+
 func (connector *SIRISubscriptionRequestDispatcher) newLogStashEvent() audit.LogStashEvent {
 	return connector.partner.NewLogStashEvent()
 }
@@ -262,3 +381,13 @@ func logXMLSubscriptionTerminatedNotification(logStashEvent audit.LogStashEvent,
 	}
 	logStashEvent["errorDescription"] = response.ErrorDescription()
 }
+
+func logXMLDataSupplyRequest(logStashEvent audit.LogStashEvent, request *siri.XMLDataSupplyRequest) {
+	logStashEvent["siriType"] = "DataSupplyRequest"
+	logStashEvent["messageIdentifier"] = request.MessageIdentifier()
+	logStashEvent["requestorRef"] = request.RequestorRef()
+	logStashEvent["notificationRef"] = request.NotificationRef()
+	logStashEvent["allData"] = strconv.FormatBool(request.AllData())
+	logStashEvent["requestTimestamp"] = request.RequestTimestamp().String()
+	logStashEvent["requestXML"] = request.RawXML()
+}
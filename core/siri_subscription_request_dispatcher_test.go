@@ -1,10 +1,12 @@
 package core
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 
 func Test_SubscriptionRequest_Dispatch_ETT(t *testing.T) {
 	uuid.SetDefaultUUIDGenerator(uuid.NewFakeUUIDGenerator())
+	clock.SetDefaultClock(clock.NewFakeClock())
 
 	referentials := NewMemoryReferentials()
 	referential := referentials.New("Un Referential Plutot Cool")
@@ -55,6 +58,8 @@ func Test_SubscriptionRequest_Dispatch_ETT(t *testing.T) {
 }
 
 func Test_SubscriptionRequest_Dispatch_SM(t *testing.T) {
+	clock.SetDefaultClock(clock.NewFakeClock())
+
 	referentials := NewMemoryReferentials()
 	referential := referentials.New("Un Referential Plutot Cool")
 	referential.model = model.NewMemoryModel()
@@ -106,6 +111,8 @@ func Test_SubscriptionRequest_Dispatch_SM(t *testing.T) {
 }
 
 func Test_SubscriptionRequest_Dispatch_GM(t *testing.T) {
+	clock.SetDefaultClock(clock.NewFakeClock())
+
 	referentials := NewMemoryReferentials()
 	referential := referentials.New("Un Referential Plutot Cool")
 	referential.model = model.NewMemoryModel()
@@ -526,3 +533,230 @@ func Test_HandleNotifySubscriptionTerminated(t *testing.T) {
 		t.Errorf("Subscriptions should not be found")
 	}
 }
+
+type testSMSubscriptionEntry struct {
+	identifier, monitoringRef, lineRef, detailLevel string
+	terminationTime                                 time.Time
+}
+
+func testSMSubscriptionRequest(t *testing.T, entries ...testSMSubscriptionEntry) *siri.XMLSubscriptionRequest {
+	t.Helper()
+
+	var requests strings.Builder
+	for _, entry := range entries {
+		lineRef := ""
+		if entry.lineRef != "" {
+			lineRef = fmt.Sprintf("<siri:LineRef>%s</siri:LineRef>", entry.lineRef)
+		}
+		if entry.detailLevel != "" {
+			lineRef += fmt.Sprintf("<siri:DetailLevel>%s</siri:DetailLevel>", entry.detailLevel)
+		}
+		fmt.Fprintf(&requests, `
+        <StopMonitoringSubscriptionRequest>
+          <SubscriberRef>subscriber</SubscriberRef>
+          <SubscriptionIdentifier>%s</SubscriptionIdentifier>
+          <InitialTerminationTime>%s</InitialTerminationTime>
+          <StopMonitoringRequest>
+            <MessageIdentifier>%s</MessageIdentifier>
+            <MonitoringRef>%s</MonitoringRef>
+            %s
+          </StopMonitoringRequest>
+        </StopMonitoringSubscriptionRequest>`, entry.identifier, entry.terminationTime.Format(time.RFC3339), entry.identifier, entry.monitoringRef, lineRef)
+	}
+
+	body := fmt.Sprintf(`<?xml version='1.0' encoding='utf-8'?>
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+  <SOAP-ENV:Body>
+    <ws:Subscribe>
+      <SubscriptionRequestInfo>
+        <siri:RequestorRef>subscriber</siri:RequestorRef>
+        <siri:MessageIdentifier>message</siri:MessageIdentifier>
+      </SubscriptionRequestInfo>
+      <Request>%s
+      </Request>
+    </ws:Subscribe>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`, requests.String())
+
+	request, err := siri.NewXMLSubscriptionRequestFromContent([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func checkResponseStatus(t *testing.T, rs siri.SIRIResponseStatus, errorType string) {
+	t.Helper()
+	if errorType == "" {
+		if !rs.Status {
+			t.Errorf("ResponseStatus %v should be accepted, got %v: %v", rs.SubscriptionRef, rs.ErrorType, rs.ErrorText)
+		}
+		return
+	}
+	if rs.Status || rs.ErrorType != errorType {
+		t.Errorf("ResponseStatus %v should be refused with %v, got %v %v", rs.SubscriptionRef, errorType, rs.Status, rs.ErrorType)
+	}
+}
+
+func Test_SubscriptionRequest_Dispatch_Validation(t *testing.T) {
+	fakeClock := clock.NewFakeClock()
+	clock.SetDefaultClock(fakeClock)
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	referential.model = model.NewMemoryModel()
+
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "_internal"
+	partner.Settings[BROADCAST_SUBSCRIPTIONS_MAXIMUM_COUNT] = "2"
+	partner.Settings[BROADCAST_SUBSCRIPTIONS_MAXIMUM_DURATION] = "24h"
+	partner.ConnectorTypes = []string{SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER}
+	partner.RefreshConnectors()
+	referential.Partners().Save(partner)
+
+	connector, _ := partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER)
+
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID("_internal", "stopArea"))
+	stopArea.Save()
+
+	now := fakeClock.Now()
+	request := testSMSubscriptionRequest(t,
+		testSMSubscriptionEntry{identifier: "1", monitoringRef: "stopArea", terminationTime: now.Add(48 * time.Hour)},
+		testSMSubscriptionEntry{identifier: "1", monitoringRef: "stopArea", terminationTime: now.Add(time.Hour)},
+		testSMSubscriptionEntry{identifier: "2", monitoringRef: "unknown", terminationTime: now.Add(time.Hour)},
+		testSMSubscriptionEntry{identifier: "3", monitoringRef: "stopArea", lineRef: "unknown", terminationTime: now.Add(time.Hour)},
+		testSMSubscriptionEntry{identifier: "4", monitoringRef: "stopArea", terminationTime: now.Add(-time.Hour)},
+		testSMSubscriptionEntry{identifier: "5", monitoringRef: "stopArea", terminationTime: now.Add(time.Hour)},
+		testSMSubscriptionEntry{identifier: "6", monitoringRef: "stopArea", terminationTime: now.Add(time.Hour)},
+		testSMSubscriptionEntry{identifier: "7", monitoringRef: "stopArea", detailLevel: "unknown", terminationTime: now.Add(time.Hour)},
+	)
+
+	message := &audit.BigQueryMessage{}
+	response, err := connector.(*SIRISubscriptionRequestDispatcher).Dispatch(request, message)
+	if err != nil {
+		t.Fatalf("Error while handling subscription request: %v", err)
+	}
+	if len(response.ResponseStatus) != 8 {
+		t.Fatalf("Wrong ResponseStatus size want 8 got: %v", len(response.ResponseStatus))
+	}
+
+	checkResponseStatus(t, response.ResponseStatus[0], "")
+	checkResponseStatus(t, response.ResponseStatus[1], "InvalidDataReferencesError")
+	checkResponseStatus(t, response.ResponseStatus[2], "InvalidDataReferencesError")
+	checkResponseStatus(t, response.ResponseStatus[3], "InvalidDataReferencesError")
+	checkResponseStatus(t, response.ResponseStatus[4], "InvalidDataReferencesError")
+	checkResponseStatus(t, response.ResponseStatus[5], "")
+	checkResponseStatus(t, response.ResponseStatus[6], "AccessNotAllowedError")
+	checkResponseStatus(t, response.ResponseStatus[7], "InvalidDataReferencesError")
+
+	if expected := now.Add(24 * time.Hour); !response.ResponseStatus[0].ValidUntil.Equal(expected) {
+		t.Errorf("ValidUntil should be bounded by the maximum duration:\n got: %v\n want: %v", response.ResponseStatus[0].ValidUntil, expected)
+	}
+	if message.Status != "Error" {
+		t.Errorf("BigQueryMessage Status should be Error, got: %v", message.Status)
+	}
+	if len(partner.Subscriptions().FindAll()) != 2 {
+		t.Errorf("Only two subscriptions should be created, got: %v", len(partner.Subscriptions().FindAll()))
+	}
+}
+
+func Test_SubscriptionRequest_Dispatch_CapabilityNotSupported(t *testing.T) {
+	clock.SetDefaultClock(clock.NewFakeClock())
+
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	referential.model = model.NewMemoryModel()
+
+	partner := referential.Partners().New("partner")
+	partner.Settings["remote_objectid_kind"] = "_internal"
+	partner.ConnectorTypes = []string{SIRI_ESTIMATED_TIMETABLE_SUBSCRIPTION_BROADCASTER}
+	partner.RefreshConnectors()
+	referential.Partners().Save(partner)
+
+	connector, _ := partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER)
+
+	file, _ := os.Open("testdata/stopmonitoringsubscription-request-soap.xml")
+	body, _ := ioutil.ReadAll(file)
+	request, _ := siri.NewXMLSubscriptionRequestFromContent(body)
+
+	response, err := connector.(*SIRISubscriptionRequestDispatcher).Dispatch(request, &audit.BigQueryMessage{})
+	if err != nil {
+		t.Fatalf("Error while handling subscription request: %v", err)
+	}
+	if len(response.ResponseStatus) != 1 {
+		t.Fatalf("Wrong ResponseStatus size want 1 got: %v", len(response.ResponseStatus))
+	}
+	checkResponseStatus(t, response.ResponseStatus[0], "CapabilityNotSupportedError")
+}
+
+func Test_HandleDataSupply(t *testing.T) {
+	partner := createTestPartnerManager().New("partner")
+	partner.Settings[BROADCAST_DELIVERY_MODE] = DELIVERY_MODE_FETCHED
+	partner.CreateSubscriptionRequestDispatcher()
+	c, _ := partner.Connector(SIRI_SUBSCRIPTION_REQUEST_DISPATCHER)
+	connector := c.(*SIRISubscriptionRequestDispatcher)
+
+	queue := connector.NotificationQueue()
+	queue.Push(newTestQueuedNotification("1"), &fakeNotificationSender{})
+
+	dataSupply := func(request string, write func(*siri.SIRIDataSupplyResponse) error) (*siri.SIRIDataSupplyResponse, error) {
+		content := fmt.Sprintf(`<sw:DataSupply xmlns:sw="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<DataSupplyInfo>
+		<siri:RequestTimestamp>2017-01-01T12:00:00.000Z</siri:RequestTimestamp>
+		<siri:ConsumerRef>Subscriber</siri:ConsumerRef>
+		<siri:MessageIdentifier>DataSupply:1</siri:MessageIdentifier>
+	</DataSupplyInfo>
+	<Request>%v</Request>
+</sw:DataSupply>`, request)
+		xmlRequest, err := siri.NewXMLDataSupplyRequestFromContent([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response *siri.SIRIDataSupplyResponse
+		err = connector.HandleDataSupply(xmlRequest, &audit.BigQueryMessage{}, func(r *siri.SIRIDataSupplyResponse) error {
+			response = r
+			return write(r)
+		})
+		return response, err
+	}
+	written := func(*siri.SIRIDataSupplyResponse) error { return nil }
+
+	for _, tt := range []struct {
+		request   string
+		errorType string
+	}{
+		{"<siri:AllData>true</siri:AllData>", "CapabilityNotSupportedError"},
+		{"<siri:NotificationRef>DataReady:1</siri:NotificationRef>", "InvalidDataReferencesError"},
+	} {
+		response, err := dataSupply(tt.request, written)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Status || response.ErrorType != tt.errorType {
+			t.Errorf("DataSupply %v should be refused with %v, got: %v %v", tt.request, tt.errorType, response.Status, response.ErrorType)
+		}
+		if status := queue.Status(); status.Pending != 1 {
+			t.Errorf("Refused DataSupply %v shouldn't fetch notifications: %#v", tt.request, status)
+		}
+	}
+
+	if _, err := dataSupply("", func(*siri.SIRIDataSupplyResponse) error { return fmt.Errorf("write failed") }); err == nil {
+		t.Errorf("DataSupply should return the write error")
+	}
+	if status := queue.Status(); status.Pending != 1 {
+		t.Errorf("Notifications shouldn't be fetched when the response isn't written: %#v", status)
+	}
+
+	response, err := dataSupply("", written)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Status || len(response.Deliveries) != 1 {
+		t.Errorf("DataSupply should return the pending notification, got: %v %v", response.Status, len(response.Deliveries))
+	}
+	if status := queue.Status(); status.Pending != 0 || status.Delivered != 1 {
+		t.Errorf("Wrong queue status after DataSupply: %#v", status)
+	}
+}
@@ -0,0 +1,507 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+const (
+	STOP_AREA_MATCHING_MODE_REFERENT = "referent"
+	STOP_AREA_MATCHING_MODE_OBJECTID = "objectid"
+
+	DEFAULT_STOP_AREA_MATCHING_THRESHOLD          = 0.9
+	DEFAULT_STOP_AREA_MATCHING_MINIMUM_CONFIDENCE = 0.6
+	DEFAULT_STOP_AREA_MATCHING_MAXIMUM_DISTANCE   = 200.0
+
+	stopAreaMatchingNameWeight     = 0.5
+	stopAreaMatchingDistanceWeight = 0.3
+	stopAreaMatchingLinesWeight    = 0.2
+)
+
+type StopAreaMatchCandidate struct {
+	StopAreaId model.StopAreaId
+	Name       string
+	Confidence float64
+
+	NameSimilarity float64
+	Distance       *float64 `json:",omitempty"`
+	SharedLines    *float64 `json:",omitempty"`
+
+	criteria int
+}
+
+// A StopArea created by a partner which could be the same stop than an
+// existing one
+type StopAreaMatch struct {
+	StopAreaId model.StopAreaId
+	ObjectId   model.ObjectID
+	Name       string
+	Partner    string
+	CreatedAt  time.Time
+	Candidates []*StopAreaMatchCandidate
+}
+
+// Searches existing StopAreas matching the StopAreas discovered by a partner.
+//
+// Candidates are scored with their name similarity, their distance and their
+// shared lines. Without location, only the StopAreas sharing a name word are
+// scored. When a single candidate reaches the
+// REFERENTIAL_SETTING_STOP_AREA_MATCHING_THRESHOLD with at least two
+// criteria, the match is applied automatically. Otherwise, the candidates
+// above the REFERENTIAL_SETTING_STOP_AREA_MATCHING_MINIMUM_CONFIDENCE are
+// kept for review.
+//
+// A match is applied by defining the candidate as ReferentId of the
+// discovered StopArea, or by moving the discovered objectid to the candidate
+// when REFERENTIAL_SETTING_STOP_AREA_MATCHING_MODE is objectid.
+type StopAreaMatcher struct {
+	clock.ClockConsumer
+
+	mutex       *sync.RWMutex
+	referential *Referential
+
+	pending  map[model.StopAreaId]*StopAreaMatch
+	rejected map[model.StopAreaId]struct{}
+}
+
+func NewStopAreaMatcher(referential *Referential) *StopAreaMatcher {
+	return &StopAreaMatcher{
+		mutex:       &sync.RWMutex{},
+		referential: referential,
+		pending:     make(map[model.StopAreaId]*StopAreaMatch),
+		rejected:    make(map[model.StopAreaId]struct{}),
+	}
+}
+
+func (matcher *StopAreaMatcher) enabled() bool {
+	enabled, _ := strconv.ParseBool(matcher.referential.Setting(REFERENTIAL_SETTING_STOP_AREA_MATCHING))
+	return enabled
+}
+
+func (matcher *StopAreaMatcher) floatSetting(setting string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(matcher.referential.Setting(setting), 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// Searches matches for the StopAreas with the given objectids
+func (matcher *StopAreaMatcher) Match(partner string, objectids []model.ObjectID) {
+	if !matcher.enabled() {
+		return
+	}
+
+	threshold := matcher.floatSetting(REFERENTIAL_SETTING_STOP_AREA_MATCHING_THRESHOLD, DEFAULT_STOP_AREA_MATCHING_THRESHOLD)
+	minimum := matcher.floatSetting(REFERENTIAL_SETTING_STOP_AREA_MATCHING_MINIMUM_CONFIDENCE, DEFAULT_STOP_AREA_MATCHING_MINIMUM_CONFIDENCE)
+
+	// Built on the first discovered StopArea without location
+	var index *stopAreaNameIndex
+
+	for _, objectid := range objectids {
+		candidates, stopArea, ok := matcher.candidates(objectid, minimum, &index)
+		if !ok || len(candidates) == 0 {
+			continue
+		}
+
+		best := candidates[0]
+		if best.Confidence >= threshold && best.criteria >= 2 && (len(candidates) == 1 || candidates[1].Confidence < threshold) {
+			if err := matcher.apply(stopArea.Id(), best.StopAreaId); err != nil {
+				logger.Log.Debugf("Can't apply StopArea match %v -> %v: %v", stopArea.Id(), best.StopAreaId, err)
+			} else {
+				logger.Log.Debugf("StopArea %v matched with %v (confidence %.2f)", stopArea.Id(), best.StopAreaId, best.Confidence)
+			}
+			continue
+		}
+
+		matcher.mutex.Lock()
+		matcher.pending[stopArea.Id()] = &StopAreaMatch{
+			StopAreaId: stopArea.Id(),
+			ObjectId:   objectid,
+			Name:       stopArea.Name,
+			Partner:    partner,
+			CreatedAt:  matcher.Clock().Now(),
+			Candidates: candidates,
+		}
+		matcher.mutex.Unlock()
+	}
+}
+
+// Returns the candidates for the StopArea with the given objectid, sorted by
+// decreasing confidence.
+//
+// Without location, the candidates are searched in the name index, which is
+// built once and shared by all the StopAreas of the same Match call.
+func (matcher *StopAreaMatcher) candidates(objectid model.ObjectID, minimum float64, index **stopAreaNameIndex) ([]*StopAreaMatchCandidate, *model.StopArea, bool) {
+	tx := matcher.referential.NewTransaction()
+	defer tx.Close()
+
+	stopArea, ok := tx.Model().StopAreas().FindByObjectId(objectid)
+	if !ok || stopArea.ReferentId != "" || len(stopArea.ObjectIDs()) > 1 {
+		return nil, nil, false
+	}
+
+	matcher.mutex.RLock()
+	_, rejected := matcher.rejected[stopArea.Id()]
+	_, pending := matcher.pending[stopArea.Id()]
+	matcher.mutex.RUnlock()
+	if rejected || pending {
+		return nil, nil, false
+	}
+
+	name := normalizeStopAreaName(stopArea.Name)
+
+	var existingStopAreas []*model.StopArea
+	var existingNames [][]rune
+	if stopArea.HasLocation() {
+		maximumDistance := matcher.floatSetting(REFERENTIAL_SETTING_STOP_AREA_MATCHING_MAXIMUM_DISTANCE, DEFAULT_STOP_AREA_MATCHING_MAXIMUM_DISTANCE)
+		nearStopAreas := tx.Model().StopAreas().FindNear(stopArea.Latitude, stopArea.Longitude, maximumDistance)
+		for i := range nearStopAreas {
+			existingStopAreas = append(existingStopAreas, &nearStopAreas[i])
+			existingNames = append(existingNames, normalizeStopAreaName(nearStopAreas[i].Name))
+		}
+	} else {
+		if *index == nil {
+			*index = newStopAreaNameIndex(tx.Model().StopAreas().FindAll())
+		}
+		for _, i := range (*index).lookup(name) {
+			existingStopAreas = append(existingStopAreas, &(*index).stopAreas[i])
+			existingNames = append(existingNames, (*index).names[i])
+		}
+	}
+
+	var candidates []*StopAreaMatchCandidate
+	for i, existing := range existingStopAreas {
+		if existing.Id() == stopArea.Id() || existing.ReferentId != "" {
+			continue
+		}
+		if _, ok := existing.ObjectID(objectid.Kind()); ok {
+			continue
+		}
+
+		candidate := matcher.score(&stopArea, existing, name, existingNames[i])
+		if candidate.Confidence >= minimum {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, &stopArea, true
+}
+
+func (matcher *StopAreaMatcher) score(stopArea, existing *model.StopArea, name, existingName []rune) *StopAreaMatchCandidate {
+	candidate := &StopAreaMatchCandidate{
+		StopAreaId:     existing.Id(),
+		Name:           existing.Name,
+		NameSimilarity: normalizedNameSimilarity(name, existingName),
+		criteria:       1,
+	}
+	weighted := candidate.NameSimilarity * stopAreaMatchingNameWeight
+	weights := stopAreaMatchingNameWeight
+
+	if stopArea.HasLocation() && existing.HasLocation() {
+		maximumDistance := matcher.floatSetting(REFERENTIAL_SETTING_STOP_AREA_MATCHING_MAXIMUM_DISTANCE, DEFAULT_STOP_AREA_MATCHING_MAXIMUM_DISTANCE)
+		distance := existing.DistanceTo(stopArea.Latitude, stopArea.Longitude)
+		candidate.Distance = &distance
+		candidate.criteria++
+
+		weighted += math.Max(0, 1-distance/maximumDistance) * stopAreaMatchingDistanceWeight
+		weights += stopAreaMatchingDistanceWeight
+	}
+
+	if len(stopArea.LineIds) != 0 && len(existing.LineIds) != 0 {
+		shared := sharedLines(stopArea.LineIds, existing.LineIds)
+		candidate.SharedLines = &shared
+		candidate.criteria++
+
+		weighted += shared * stopAreaMatchingLinesWeight
+		weights += stopAreaMatchingLinesWeight
+	}
+
+	candidate.Confidence = weighted / weights
+	return candidate
+}
+
+func (matcher *StopAreaMatcher) apply(stopAreaId, candidateId model.StopAreaId) error {
+	tx := matcher.referential.NewTransaction()
+	defer tx.Close()
+
+	stopArea, ok := tx.Model().StopAreas().Find(stopAreaId)
+	if !ok {
+		return fmt.Errorf("StopArea not found: %v", stopAreaId)
+	}
+	candidate, ok := tx.Model().StopAreas().Find(candidateId)
+	if !ok {
+		return fmt.Errorf("StopArea not found: %v", candidateId)
+	}
+
+	if matcher.referential.Setting(REFERENTIAL_SETTING_STOP_AREA_MATCHING_MODE) == STOP_AREA_MATCHING_MODE_OBJECTID {
+		for _, objectid := range stopArea.ObjectIDs() {
+			candidate.SetObjectID(objectid)
+		}
+		for _, lineId := range stopArea.LineIds {
+			candidate.LineIds.Add(lineId)
+		}
+		tx.Model().StopAreas().Save(&candidate)
+		matcher.replaceStopAreaId(tx, stopArea.Id(), candidate.Id())
+		tx.Model().StopAreas().Delete(&stopArea)
+	} else {
+		stopArea.ReferentId = candidate.Id()
+		tx.Model().StopAreas().Save(&stopArea)
+	}
+
+	return tx.Commit()
+}
+
+// Moves the StopVisits, Facilities and StopArea relations of the deleted
+// StopArea to its replacement
+func (matcher *StopAreaMatcher) replaceStopAreaId(tx *model.Transaction, deletedId, replacementId model.StopAreaId) {
+	stopVisits := tx.Model().StopVisits().FindByStopAreaId(deletedId)
+	for i := range stopVisits {
+		stopVisits[i].StopAreaId = replacementId
+		tx.Model().StopVisits().Save(&stopVisits[i])
+	}
+
+	facilities := tx.Model().Facilities().FindByStopAreaId(deletedId)
+	for i := range facilities {
+		facilities[i].StopAreaId = replacementId
+		tx.Model().Facilities().Save(&facilities[i])
+	}
+
+	stopAreas := tx.Model().StopAreas().FindAll()
+	for i := range stopAreas {
+		stopArea := &stopAreas[i]
+		if stopArea.Id() == deletedId || stopArea.Id() == replacementId {
+			continue
+		}
+		if stopArea.ParentId != deletedId && stopArea.ReferentId != deletedId {
+			continue
+		}
+		if stopArea.ParentId == deletedId {
+			stopArea.ParentId = replacementId
+		}
+		if stopArea.ReferentId == deletedId {
+			stopArea.ReferentId = replacementId
+		}
+		tx.Model().StopAreas().Save(stopArea)
+	}
+}
+
+// Forgets the pending and rejected matches. The StopAreas are loaded again
+// by the daily model reload, their matches are searched again with the next
+// discoveries
+func (matcher *StopAreaMatcher) Reset() {
+	matcher.mutex.Lock()
+	defer matcher.mutex.Unlock()
+
+	matcher.pending = make(map[model.StopAreaId]*StopAreaMatch)
+	matcher.rejected = make(map[model.StopAreaId]struct{})
+}
+
+// Returns the matches waiting for a review
+func (matcher *StopAreaMatcher) Pending() []*StopAreaMatch {
+	matcher.mutex.RLock()
+	defer matcher.mutex.RUnlock()
+
+	matches := make([]*StopAreaMatch, 0, len(matcher.pending))
+	for _, match := range matcher.pending {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].StopAreaId < matches[j].StopAreaId
+	})
+	return matches
+}
+
+func (matcher *StopAreaMatcher) Find(stopAreaId model.StopAreaId) (*StopAreaMatch, bool) {
+	matcher.mutex.RLock()
+	defer matcher.mutex.RUnlock()
+
+	match, ok := matcher.pending[stopAreaId]
+	return match, ok
+}
+
+// Applies the pending match with the given candidate
+func (matcher *StopAreaMatcher) Accept(stopAreaId, candidateId model.StopAreaId) error {
+	match, ok := matcher.Find(stopAreaId)
+	if !ok {
+		return fmt.Errorf("no pending match for StopArea %v", stopAreaId)
+	}
+
+	found := false
+	for _, candidate := range match.Candidates {
+		if candidate.StopAreaId == candidateId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("StopArea %v isn't a candidate", candidateId)
+	}
+
+	if err := matcher.apply(stopAreaId, candidateId); err != nil {
+		return err
+	}
+
+	matcher.mutex.Lock()
+	delete(matcher.pending, stopAreaId)
+	matcher.mutex.Unlock()
+	return nil
+}
+
+// Dismisses the pending match. The StopArea won't be matched again
+func (matcher *StopAreaMatcher) Reject(stopAreaId model.StopAreaId) bool {
+	matcher.mutex.Lock()
+	defer matcher.mutex.Unlock()
+
+	if _, ok := matcher.pending[stopAreaId]; !ok {
+		return false
+	}
+	delete(matcher.pending, stopAreaId)
+	matcher.rejected[stopAreaId] = struct{}{}
+	return true
+}
+
+var stopAreaNameReplacer = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i",
+	"ô", "o", "ö", "o",
+	"ù", "u", "û", "u", "ü", "u",
+	"ç", "c",
+)
+
+// Lowercases the name, removes accents and punctuation
+func normalizeStopAreaName(name string) []rune {
+	name = stopAreaNameReplacer.Replace(strings.ToLower(name))
+
+	normalized := make([]rune, 0, len(name))
+	space := true
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized = append(normalized, r)
+			space = false
+			continue
+		}
+		if !space {
+			normalized = append(normalized, ' ')
+			space = true
+		}
+	}
+	if len(normalized) > 0 && normalized[len(normalized)-1] == ' ' {
+		normalized = normalized[:len(normalized)-1]
+	}
+	return normalized
+}
+
+// Returns a similarity between 0 and 1 based on the Levenshtein distance of
+// the normalized names
+func nameSimilarity(name1, name2 string) float64 {
+	return normalizedNameSimilarity(normalizeStopAreaName(name1), normalizeStopAreaName(name2))
+}
+
+func normalizedNameSimilarity(a, b []rune) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	length := len(a)
+	if len(b) > length {
+		length = len(b)
+	}
+	return 1 - float64(previous[len(b)])/float64(length)
+}
+
+// Normalized names of the StopAreas, indexed by word
+type stopAreaNameIndex struct {
+	stopAreas []model.StopArea
+	names     [][]rune
+	byWord    map[string][]int
+}
+
+func newStopAreaNameIndex(stopAreas []model.StopArea) *stopAreaNameIndex {
+	index := &stopAreaNameIndex{
+		stopAreas: stopAreas,
+		names:     make([][]rune, len(stopAreas)),
+		byWord:    make(map[string][]int),
+	}
+	for i := range stopAreas {
+		index.names[i] = normalizeStopAreaName(stopAreas[i].Name)
+		for _, word := range strings.Fields(string(index.names[i])) {
+			index.byWord[word] = append(index.byWord[word], i)
+		}
+	}
+	return index
+}
+
+// Returns the positions of the StopAreas sharing at least one word with the
+// given normalized name
+func (index *stopAreaNameIndex) lookup(name []rune) []int {
+	seen := make(map[int]struct{})
+	var positions []int
+	for _, word := range strings.Fields(string(name)) {
+		for _, i := range index.byWord[word] {
+			if _, ok := seen[i]; ok {
+				continue
+			}
+			seen[i] = struct{}{}
+			positions = append(positions, i)
+		}
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Returns the Jaccard index of the two line lists
+func sharedLines(lines1, lines2 model.StopAreaLineIds) float64 {
+	union := make(map[model.LineId]bool)
+	for _, line := range lines1 {
+		union[line] = false
+	}
+	shared := 0
+	for _, line := range lines2 {
+		if seen, ok := union[line]; ok && !seen {
+			union[line] = true
+			shared++
+		} else if !ok {
+			union[line] = false
+		}
+	}
+	return float64(shared) / float64(len(union))
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"testing"
+
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+func prepareStopAreaMatcher() (*Referential, *StopAreaMatcher) {
+	referentials := NewMemoryReferentials()
+	referential := referentials.New("referential")
+	referential.Settings[REFERENTIAL_SETTING_STOP_AREA_MATCHING] = "true"
+	return referential, referential.StopAreaMatcher()
+}
+
+func saveMatchedStopArea(referential *Referential, kind, value, name string, latitude, longitude float64) *model.StopArea {
+	stopArea := referential.Model().StopAreas().New()
+	stopArea.SetObjectID(model.NewObjectID(kind, value))
+	stopArea.Name = name
+	stopArea.Latitude = latitude
+	stopArea.Longitude = longitude
+	referential.Model().StopAreas().Save(&stopArea)
+	return &stopArea
+}
+
+func Test_nameSimilarity(t *testing.T) {
+	tests := []struct {
+		name1, name2 string
+		expected     float64
+	}{
+		{"Gare de Lyon", "GARE DE LYON", 1},
+		{"Hôtel de Ville", "Hotel-de-Ville", 1},
+		{"abcd", "abce", 0.75},
+		{"", "Gare", 0},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.name1, tt.name2); got != tt.expected {
+			t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tt.name1, tt.name2, got, tt.expected)
+		}
+	}
+}
+
+func Test_StopAreaMatcher_Disabled(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+	referential.Settings[REFERENTIAL_SETTING_STOP_AREA_MATCHING] = ""
+
+	saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 48.8443, 2.3743)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 48.8443, 2.3743)
+
+	matcher.Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	stopArea, _ := referential.Model().StopAreas().Find(discovered.Id())
+	if stopArea.ReferentId != "" || len(matcher.Pending()) != 0 {
+		t.Errorf("StopArea shouldn't be matched when matching is disabled")
+	}
+}
+
+func Test_StopAreaMatcher_AutomaticMatch(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+
+	existing := saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 48.8443, 2.3743)
+	saveMatchedStopArea(referential, "internal", "2", "Bastille", 48.8531, 2.3691)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "GARE DE LYON", 48.8444, 2.3743)
+
+	matcher.Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	stopArea, _ := referential.Model().StopAreas().Find(discovered.Id())
+	if stopArea.ReferentId != existing.Id() {
+		t.Errorf("Wrong ReferentId:\n got: %v\n want: %v", stopArea.ReferentId, existing.Id())
+	}
+	if len(matcher.Pending()) != 0 {
+		t.Errorf("Automatic match shouldn't be pending: %v", matcher.Pending())
+	}
+}
+
+func Test_StopAreaMatcher_ObjectIDMode(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+	referential.Settings[REFERENTIAL_SETTING_STOP_AREA_MATCHING_MODE] = STOP_AREA_MATCHING_MODE_OBJECTID
+
+	existing := saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 48.8443, 2.3743)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 48.8443, 2.3743)
+
+	matcher.Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	if _, ok := referential.Model().StopAreas().Find(discovered.Id()); ok {
+		t.Errorf("Discovered StopArea should be deleted")
+	}
+	stopArea, ok := referential.Model().StopAreas().FindByObjectId(model.NewObjectID("partner", "A"))
+	if !ok || stopArea.Id() != existing.Id() {
+		t.Errorf("Existing StopArea should have the partner objectid")
+	}
+}
+
+func Test_StopAreaMatcher_ObjectIDMode_References(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+	referential.Settings[REFERENTIAL_SETTING_STOP_AREA_MATCHING_MODE] = STOP_AREA_MATCHING_MODE_OBJECTID
+
+	existing := saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 48.8443, 2.3743)
+	existing.LineIds = model.StopAreaLineIds{"line-1"}
+	referential.Model().StopAreas().Save(existing)
+
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 48.8443, 2.3743)
+	discovered.LineIds = model.StopAreaLineIds{"line-1", "line-2"}
+	referential.Model().StopAreas().Save(discovered)
+
+	child := saveMatchedStopArea(referential, "partner", "A-1", "Gare de Lyon Quai 1", 0, 0)
+	child.ParentId = discovered.Id()
+	referential.Model().StopAreas().Save(child)
+
+	stopVisit := referential.Model().StopVisits().New()
+	stopVisit.StopAreaId = discovered.Id()
+	referential.Model().StopVisits().Save(&stopVisit)
+
+	facility := referential.Model().Facilities().New()
+	facility.StopAreaId = discovered.Id()
+	referential.Model().Facilities().Save(&facility)
+
+	matcher.Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	if _, ok := referential.Model().StopAreas().Find(discovered.Id()); ok {
+		t.Fatalf("Discovered StopArea should be deleted")
+	}
+	if sv, _ := referential.Model().StopVisits().Find(stopVisit.Id()); sv.StopAreaId != existing.Id() {
+		t.Errorf("Wrong StopVisit StopAreaId:\n got: %v\n want: %v", sv.StopAreaId, existing.Id())
+	}
+	if f, _ := referential.Model().Facilities().Find(facility.Id()); f.StopAreaId != existing.Id() {
+		t.Errorf("Wrong Facility StopAreaId:\n got: %v\n want: %v", f.StopAreaId, existing.Id())
+	}
+	if sa, _ := referential.Model().StopAreas().Find(child.Id()); sa.ParentId != existing.Id() {
+		t.Errorf("Wrong child ParentId:\n got: %v\n want: %v", sa.ParentId, existing.Id())
+	}
+	stopArea, _ := referential.Model().StopAreas().Find(existing.Id())
+	if len(stopArea.LineIds) != 2 || !stopArea.LineIds.Contains("line-2") {
+		t.Errorf("Existing StopArea should have the discovered lines: %v", stopArea.LineIds)
+	}
+}
+
+func Test_StopAreaMatcher_Review(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+
+	// Without location, a match is never applied automatically
+	existing := saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 0, 0)
+	saveMatchedStopArea(referential, "internal", "2", "Mairie", 0, 0)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 0, 0)
+
+	matcher.Match("partner", []model.ObjectID{model.NewObjectID("partner", "A")})
+
+	match, ok := matcher.Find(discovered.Id())
+	if !ok {
+		t.Fatalf("Match should be pending")
+	}
+	if len(match.Candidates) != 1 || match.Candidates[0].StopAreaId != existing.Id() || match.Candidates[0].Confidence != 1 {
+		t.Fatalf("Wrong candidates: %v", match.Candidates)
+	}
+
+	if err := matcher.Accept(discovered.Id(), "unknown"); err == nil {
+		t.Errorf("Accept should refuse an unknown candidate")
+	}
+	if err := matcher.Accept(discovered.Id(), existing.Id()); err != nil {
+		t.Fatal(err)
+	}
+
+	stopArea, _ := referential.Model().StopAreas().Find(discovered.Id())
+	if stopArea.ReferentId != existing.Id() {
+		t.Errorf("Wrong ReferentId:\n got: %v\n want: %v", stopArea.ReferentId, existing.Id())
+	}
+	if len(matcher.Pending()) != 0 {
+		t.Errorf("Accepted match shouldn't be pending")
+	}
+}
+
+func Test_StopAreaMatcher_Reject(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+
+	saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 0, 0)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 0, 0)
+	objectids := []model.ObjectID{model.NewObjectID("partner", "A")}
+
+	matcher.Match("partner", objectids)
+	if !matcher.Reject(discovered.Id()) {
+		t.Fatalf("Reject should find the pending match")
+	}
+
+	matcher.Match("partner", objectids)
+	if len(matcher.Pending()) != 0 {
+		t.Errorf("Rejected match shouldn't be proposed again")
+	}
+}
+
+func Test_StopAreaMatcher_Reset(t *testing.T) {
+	referential, matcher := prepareStopAreaMatcher()
+
+	saveMatchedStopArea(referential, "internal", "1", "Gare de Lyon", 0, 0)
+	discovered := saveMatchedStopArea(referential, "partner", "A", "Gare de Lyon", 0, 0)
+	objectids := []model.ObjectID{model.NewObjectID("partner", "A")}
+
+	matcher.Match("partner", objectids)
+	matcher.Reject(discovered.Id())
+
+	matcher.Reset()
+	if len(matcher.Pending()) != 0 {
+		t.Errorf("Reset should remove the pending matches")
+	}
+
+	matcher.Match("partner", objectids)
+	if _, ok := matcher.Find(discovered.Id()); !ok {
+		t.Errorf("Rejected match should be proposed again after Reset")
+	}
+}
+
+func Test_stopAreaNameIndex(t *testing.T) {
+	stopAreas := make([]model.StopArea, 3)
+	for i, name := range []string{"Gare de Lyon", "Mairie", "Lyon Perrache"} {
+		stopAreas[i].Name = name
+	}
+	index := newStopAreaNameIndex(stopAreas)
+
+	positions := index.lookup(normalizeStopAreaName("LYON"))
+	if len(positions) != 2 || positions[0] != 0 || positions[1] != 2 {
+		t.Errorf("Wrong positions for LYON: %v", positions)
+	}
+	if positions := index.lookup(normalizeStopAreaName("Bastille")); len(positions) != 0 {
+		t.Errorf("Wrong positions for Bastille: %v", positions)
+	}
+}
@@ -77,6 +77,8 @@ func (smb *StopMonitoringBroadcaster) run() {
 
 			smb.prepareSIRIStopMonitoringNotify()
 			smb.prepareNotMonitored()
+			// Redeliver the notifications which failed previously
+			smb.connector.NotificationQueue().Deliver()
 
 			c = smb.Clock().After(5 * time.Second)
 		}
@@ -150,6 +152,15 @@ func (smb *SMBroadcaster) prepareSIRIStopMonitoringNotify() {
 		// Initialize builder
 		stopMonitoringBuilder := NewBroadcastStopMonitoringBuilder(tx, smb.connector.Partner(), SIRI_STOP_MONITORING_SUBSCRIPTION_BROADCASTER)
 		stopMonitoringBuilder.StopVisitTypes = sub.SubscriptionOption("StopVisitTypes")
+		if detailLevel := sub.SubscriptionOption("DetailLevel"); detailLevel != "" {
+			stopMonitoringBuilder.DetailLevel = detailLevel
+		}
+		if previousCalls, err := strconv.Atoi(sub.SubscriptionOption("MaximumPreviousCalls")); err == nil {
+			stopMonitoringBuilder.MaximumPreviousCalls = previousCalls
+		}
+		if onwardCalls, err := strconv.Atoi(sub.SubscriptionOption("MaximumOnwardCalls")); err == nil {
+			stopMonitoringBuilder.MaximumOnwardCalls = onwardCalls
+		}
 
 		// maximumStopVisits, _ := strconv.Atoi(sub.SubscriptionOption("MaximumStopVisits"))
 		monitoredStopVisits := make(map[model.StopVisitId]struct{}) //Making sure not to send 2 times the same SV
@@ -275,6 +286,21 @@ func (smb *SMBroadcaster) handleMonitoredStopVisit(stopVisit model.StopVisit, de
 }
 
 func (smb *SMBroadcaster) sendNotification(notify *siri.SIRINotifyStopMonitoring) {
+	queue := smb.connector.NotificationQueue()
+	queue.Push(&QueuedNotification{
+		Type:                   NOTIFY_STOP_MONITORING,
+		SubscriptionIdentifier: notify.Deliveries[0].SubscriptionIdentifier,
+		StopMonitoring:         notify,
+	}, smb.connector)
+	queue.Deliver()
+}
+
+func (connector *SIRIStopMonitoringSubscriptionBroadcaster) deliverNotification(notification *QueuedNotification) error {
+	smb := &SMBroadcaster{connector: connector}
+	return smb.deliverNotification(notification.StopMonitoring)
+}
+
+func (smb *SMBroadcaster) deliverNotification(notify *siri.SIRINotifyStopMonitoring) error {
 	logStashEvent := smb.newLogStashEvent()
 	message := smb.newBQEvent()
 
@@ -293,6 +319,7 @@ func (smb *SMBroadcaster) sendNotification(notify *siri.SIRINotifyStopMonitoring
 	}
 
 	audit.CurrentBigQuery(string(smb.connector.Partner().Referential().Slug())).WriteEvent(message)
+	return err
 }
 
 func (smb *SMBroadcaster) newBQEvent() *audit.BigQueryMessage {
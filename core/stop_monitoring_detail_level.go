@@ -0,0 +1,217 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+// SIRI StopMonitoring DetailLevel values
+//
+// The normal DetailLevel returns all the elements, without the calls unless
+// MaximumNumberOfCalls is defined. The calls DetailLevel adds all the
+// PreviousCalls and OnwardCalls. Ara has no other data to provide, so the full
+// DetailLevel is handled as calls.
+const (
+	SM_DETAIL_LEVEL_MINIMUM = "minimum"
+	SM_DETAIL_LEVEL_BASIC   = "basic"
+	SM_DETAIL_LEVEL_NORMAL  = "normal"
+	SM_DETAIL_LEVEL_CALLS   = "calls"
+	SM_DETAIL_LEVEL_FULL    = "full"
+)
+
+// Elements of the MonitoredVehicleJourney required by SIRI, kept with every
+// DetailLevel and whitelist
+var smMandatoryElements = []string{
+	"LineRef",
+	"DirectionRef",
+	"FramedVehicleJourneyRef",
+}
+
+// Optional elements kept with the minimum DetailLevel: what a display needs
+// to show a time, a line name and a destination
+var smMinimumElements = []string{
+	"PublishedLineName",
+	"DestinationName",
+	"AimedArrivalTime",
+	"ExpectedArrivalTime",
+	"ActualArrivalTime",
+	"AimedDepartureTime",
+	"ExpectedDepartureTime",
+	"ActualDepartureTime",
+}
+
+// Optional elements added to the minimum ones with the basic DetailLevel
+var smBasicElements = []string{
+	"JourneyPatternRef",
+	"VehicleMode",
+	"RouteRef",
+	"DirectionName",
+	"OperatorRef",
+	"OriginRef",
+	"OriginName",
+	"DestinationRef",
+	"VehicleJourneyName",
+	"StopPointName",
+	"Order",
+	"DestinationDisplay",
+	"ArrivalStatus",
+	"ArrivalPlatformName",
+	"DepartureStatus",
+	"DeparturePlatformName",
+}
+
+func ValidSMDetailLevel(detailLevel string) bool {
+	switch detailLevel {
+	case SM_DETAIL_LEVEL_MINIMUM, SM_DETAIL_LEVEL_BASIC, SM_DETAIL_LEVEL_NORMAL, SM_DETAIL_LEVEL_CALLS, SM_DETAIL_LEVEL_FULL:
+		return true
+	}
+	return false
+}
+
+// Returns true when the DetailLevel requires all the calls
+func smDetailLevelWithCalls(detailLevel string) bool {
+	return detailLevel == SM_DETAIL_LEVEL_CALLS || detailLevel == SM_DETAIL_LEVEL_FULL
+}
+
+// Selects the optional elements of a MonitoredStopVisit according to the
+// requested DetailLevel and to the Partner BROADCAST_STOP_MONITORING_FIELDS
+// whitelist.
+//
+// The elements are identified by their SIRI name (LineRef,
+// FramedVehicleJourneyRef, ExpectedDepartureTime, ...). The StopVisit and
+// VehicleJourney attributes and references are identified by their key.
+// Mandatory elements (RecordedAtTime, ItemIdentifier, MonitoringRef,
+// StopPointRef, Monitored, VehicleAtStop and smMandatoryElements) are always
+// kept. PreviousCalls and OnwardCalls are only removed by the whitelist, since
+// they're requested with the calls DetailLevel or MaximumNumberOfCalls.
+type smElementFilter struct {
+	mandatoryElements map[string]struct{}
+	levelElements     map[string]struct{}
+	whitelist         map[string]struct{}
+}
+
+func newSMElementFilter(detailLevel string, whitelist []string) *smElementFilter {
+	filter := &smElementFilter{
+		mandatoryElements: elementSet(smMandatoryElements),
+	}
+
+	switch detailLevel {
+	case SM_DETAIL_LEVEL_MINIMUM:
+		filter.levelElements = elementSet(smMinimumElements)
+	case SM_DETAIL_LEVEL_BASIC:
+		filter.levelElements = elementSet(smMinimumElements, smBasicElements)
+	}
+
+	if len(whitelist) != 0 {
+		filter.whitelist = elementSet(whitelist)
+	}
+
+	return filter
+}
+
+func elementSet(lists ...[]string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, list := range lists {
+		for _, element := range list {
+			if element = strings.TrimSpace(element); element != "" {
+				set[element] = struct{}{}
+			}
+		}
+	}
+	return set
+}
+
+func (filter *smElementFilter) empty() bool {
+	return filter.levelElements == nil && filter.whitelist == nil
+}
+
+func (filter *smElementFilter) whitelisted(element string) bool {
+	if filter.whitelist == nil {
+		return true
+	}
+	_, ok := filter.whitelist[element]
+	return ok
+}
+
+func (filter *smElementFilter) keep(element string) bool {
+	if _, ok := filter.mandatoryElements[element]; ok {
+		return true
+	}
+	if filter.levelElements != nil {
+		if _, ok := filter.levelElements[element]; !ok {
+			return false
+		}
+	}
+	return filter.whitelisted(element)
+}
+
+// Removes the elements which aren't kept by the filter. The Attributes and
+// References maps are replaced by filtered copies
+func (filter *smElementFilter) apply(stopVisit *siri.SIRIMonitoredStopVisit) {
+	if filter.empty() {
+		return
+	}
+
+	values := map[string]*string{
+		"LineRef":            &stopVisit.LineRef,
+		"PublishedLineName":  &stopVisit.PublishedLineName,
+		"DestinationName":    &stopVisit.DestinationName,
+		"OriginName":         &stopVisit.OriginName,
+		"VehicleJourneyName": &stopVisit.VehicleJourneyName,
+		"StopPointName":      &stopVisit.StopPointName,
+		"ArrivalStatus":      &stopVisit.ArrivalStatus,
+		"DepartureStatus":    &stopVisit.DepartureStatus,
+	}
+	for element, value := range values {
+		if !filter.keep(element) {
+			*value = ""
+		}
+	}
+
+	if !filter.keep("FramedVehicleJourneyRef") {
+		stopVisit.DataFrameRef = ""
+		stopVisit.DatedVehicleJourneyRef = ""
+	}
+	if !filter.keep("Order") {
+		stopVisit.Order = 0
+	}
+
+	times := map[string]*time.Time{
+		"AimedArrivalTime":      &stopVisit.AimedArrivalTime,
+		"ExpectedArrivalTime":   &stopVisit.ExpectedArrivalTime,
+		"ActualArrivalTime":     &stopVisit.ActualArrivalTime,
+		"AimedDepartureTime":    &stopVisit.AimedDepartureTime,
+		"ExpectedDepartureTime": &stopVisit.ExpectedDepartureTime,
+		"ActualDepartureTime":   &stopVisit.ActualDepartureTime,
+	}
+	for element, value := range times {
+		if !filter.keep(element) {
+			*value = time.Time{}
+		}
+	}
+
+	if !filter.whitelisted("PreviousCalls") {
+		stopVisit.PreviousCalls = nil
+	}
+	if !filter.whitelisted("OnwardCalls") {
+		stopVisit.OnwardCalls = nil
+	}
+
+	stopVisit.Attributes = filter.filterMaps(stopVisit.Attributes)
+	stopVisit.References = filter.filterMaps(stopVisit.References)
+}
+
+func (filter *smElementFilter) filterMaps(maps map[string]map[string]string) map[string]map[string]string {
+	filtered := make(map[string]map[string]string, len(maps))
+	for name, values := range maps {
+		filtered[name] = make(map[string]string)
+		for key, value := range values {
+			if filter.keep(key) {
+				filtered[name][key] = value
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+func testSIRIMonitoredStopVisit() *siri.SIRIMonitoredStopVisit {
+	return &siri.SIRIMonitoredStopVisit{
+		ItemIdentifier:         "item",
+		StopPointRef:           "stop",
+		StopPointName:          "Stop",
+		LineRef:                "line",
+		PublishedLineName:      "Line",
+		DestinationName:        "Destination",
+		OriginName:             "Origin",
+		DatedVehicleJourneyRef: "vj",
+		DataFrameRef:           "2017-01-01",
+		DepartureStatus:        "onTime",
+		Order:                  4,
+		ExpectedDepartureTime:  time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Attributes: map[string]map[string]string{
+			"VehicleJourneyAttributes": {"DirectionRef": "Aller", "Occupancy": "full"},
+		},
+		References: map[string]map[string]string{
+			"StopVisitReferences": {"OperatorRef": "operator"},
+		},
+	}
+}
+
+func Test_smElementFilter_Normal(t *testing.T) {
+	stopVisit := testSIRIMonitoredStopVisit()
+	newSMElementFilter(SM_DETAIL_LEVEL_NORMAL, nil).apply(stopVisit)
+
+	if stopVisit.OriginName != "Origin" || stopVisit.Attributes["VehicleJourneyAttributes"]["Occupancy"] != "full" {
+		t.Errorf("Normal DetailLevel shouldn't remove elements: %v", stopVisit)
+	}
+}
+
+func Test_smElementFilter_Minimum(t *testing.T) {
+	stopVisit := testSIRIMonitoredStopVisit()
+	attributes := stopVisit.Attributes["VehicleJourneyAttributes"]
+
+	newSMElementFilter(SM_DETAIL_LEVEL_MINIMUM, nil).apply(stopVisit)
+
+	if stopVisit.LineRef != "line" || stopVisit.DestinationName != "Destination" || stopVisit.DataFrameRef == "" || stopVisit.ExpectedDepartureTime.IsZero() {
+		t.Errorf("Minimum DetailLevel should keep display elements: %v", stopVisit)
+	}
+	if stopVisit.ItemIdentifier != "item" || stopVisit.StopPointRef != "stop" || stopVisit.DatedVehicleJourneyRef != "vj" {
+		t.Errorf("Mandatory elements should be kept: %v", stopVisit)
+	}
+	if stopVisit.OriginName != "" || stopVisit.StopPointName != "" || stopVisit.Order != 0 || stopVisit.DepartureStatus != "" {
+		t.Errorf("Minimum DetailLevel should remove optional elements: %v", stopVisit)
+	}
+	if stopVisit.Attributes["VehicleJourneyAttributes"]["DirectionRef"] != "Aller" || stopVisit.Attributes["VehicleJourneyAttributes"]["Occupancy"] != "" {
+		t.Errorf("Wrong filtered attributes: %v", stopVisit.Attributes)
+	}
+	if stopVisit.References["StopVisitReferences"]["OperatorRef"] != "" {
+		t.Errorf("Wrong filtered references: %v", stopVisit.References)
+	}
+	if attributes["Occupancy"] != "full" {
+		t.Errorf("Original attributes shouldn't be modified")
+	}
+}
+
+func Test_smElementFilter_Whitelist(t *testing.T) {
+	stopVisit := testSIRIMonitoredStopVisit()
+	newSMElementFilter(SM_DETAIL_LEVEL_BASIC, []string{"LineRef", " OperatorRef", "Occupancy"}).apply(stopVisit)
+
+	if stopVisit.LineRef != "line" || stopVisit.References["StopVisitReferences"]["OperatorRef"] != "operator" {
+		t.Errorf("Whitelisted elements should be kept: %v", stopVisit)
+	}
+	if stopVisit.PublishedLineName != "" || stopVisit.DestinationName != "" {
+		t.Errorf("Elements not whitelisted should be removed: %v", stopVisit)
+	}
+	if stopVisit.DataFrameRef == "" || stopVisit.DatedVehicleJourneyRef == "" || stopVisit.Attributes["VehicleJourneyAttributes"]["DirectionRef"] != "Aller" {
+		t.Errorf("Mandatory elements should be kept: %v", stopVisit)
+	}
+	// Occupancy is whitelisted but not part of the basic DetailLevel
+	if stopVisit.Attributes["VehicleJourneyAttributes"]["Occupancy"] != "" {
+		t.Errorf("Elements outside the DetailLevel should be removed: %v", stopVisit.Attributes)
+	}
+}
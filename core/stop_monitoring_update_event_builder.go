@@ -49,6 +49,10 @@ func newStopMonitoringUpdateEvents() *StopMonitoringUpdateEvents {
 func (builder *StopMonitoringUpdateEventBuilder) buildUpdateEvents(xmlStopVisitEvent *siri.XMLMonitoredStopVisit) {
 	origin := string(builder.partner.Slug())
 
+	if !builder.partner.CanCollectOperator(xmlStopVisitEvent.OperatorRef()) {
+		return
+	}
+
 	// StopAreas
 	stopAreaObjectId := model.NewObjectID(builder.remoteObjectidKind, xmlStopVisitEvent.StopPointRef())
 
@@ -15,6 +15,21 @@ import (
 
 type SubscriptionId string
 
+const (
+	SUBSCRIPTION_HEALTH_PENDING = "pending"
+	SUBSCRIPTION_HEALTH_ACTIVE  = "active"
+	SUBSCRIPTION_HEALTH_SILENT  = "silent"
+	SUBSCRIPTION_HEALTH_FAILED  = "failed"
+
+	// After this number of failed subscription requests, the resource isn't requested anymore
+	SUBSCRIPTION_MAXIMUM_RETRY_COUNT = 10
+
+	// InitialTerminationTime of the subscriptions requested to the partners
+	COLLECT_SUBSCRIPTION_DURATION = 48 * time.Hour
+	// Default delay before the end of a collect subscription to renew it
+	DEFAULT_SUBSCRIPTIONS_RENEWAL_MARGIN = 1 * time.Hour
+)
+
 type Subscription struct {
 	sync.RWMutex
 	clock.ClockConsumer
@@ -27,15 +42,23 @@ type Subscription struct {
 
 	resourcesByObjectID map[string]*SubscribedResource
 	subscriptionOptions map[string]string
+
+	lastNotificationAt     time.Time
+	lastHeartbeatAt        time.Time
+	lastNotificationSentAt time.Time
+	notificationErrors     int
+	health                 string
 }
 
 type SubscribedResource struct {
 	sync.RWMutex
 
-	Reference        model.Reference
-	RetryCount       int
-	SubscribedAt     time.Time
-	SubscribedUntil  time.Time
+	Reference       model.Reference
+	RetryCount      int
+	SubscribedAt    time.Time
+	SubscribedUntil time.Time
+	// End of the subscription requested to the partner
+	TerminationTime  time.Time
 	lastStates       map[string]lastState
 	resourcesOptions map[string]string
 }
@@ -48,6 +71,27 @@ func NewResource(ref model.Reference) SubscribedResource {
 	}
 }
 
+func (sr *SubscribedResource) MarshalJSON() ([]byte, error) {
+	type Alias SubscribedResource
+	aux := struct {
+		*Alias
+		TerminationTime *time.Time `json:",omitempty"`
+	}{
+		Alias: (*Alias)(sr),
+	}
+	if !sr.TerminationTime.IsZero() {
+		aux.TerminationTime = &sr.TerminationTime
+	}
+	return json.Marshal(&aux)
+}
+
+// Resets the resource to send a new subscription request
+func (sr *SubscribedResource) Resubscribe() {
+	sr.SubscribedAt = time.Time{}
+	sr.TerminationTime = time.Time{}
+	sr.RetryCount = 0
+}
+
 func (sr *SubscribedResource) ResourcesOptions() map[string]string {
 	return sr.resourcesOptions
 }
@@ -65,6 +109,30 @@ func (sr *SubscribedResource) SetLastState(s string, l lastState) {
 	sr.Unlock()
 }
 
+func (sr *SubscribedResource) DeleteLastState(s string) {
+	sr.Lock()
+	delete(sr.lastStates, s)
+	sr.Unlock()
+}
+
+// Forgets the states sent to the subscriber, to send again the complete state
+func (sr *SubscribedResource) ClearLastStates() {
+	sr.Lock()
+	sr.lastStates = make(map[string]lastState)
+	sr.Unlock()
+}
+
+// Returns the kind of the last state of each model sent to the subscriber
+func (sr *SubscribedResource) LastStateKinds() map[string]string {
+	kinds := make(map[string]string)
+	sr.RLock()
+	for id, state := range sr.lastStates {
+		kinds[id] = lastStateKind(state)
+	}
+	sr.RUnlock()
+	return kinds
+}
+
 type APISubscription struct {
 	Kind       string
 	References []model.Reference
@@ -104,6 +172,144 @@ func (subscription *Subscription) SetSubscriptionOption(key, value string) {
 	subscription.Unlock()
 }
 
+func (subscription *Subscription) LastNotificationAt() (t time.Time) {
+	subscription.RLock()
+	t = subscription.lastNotificationAt
+	subscription.RUnlock()
+	return
+}
+
+func (subscription *Subscription) SetLastNotificationAt(t time.Time) {
+	subscription.Lock()
+	subscription.lastNotificationAt = t
+	subscription.Unlock()
+}
+
+func (subscription *Subscription) LastHeartbeatAt() (t time.Time) {
+	subscription.RLock()
+	t = subscription.lastHeartbeatAt
+	subscription.RUnlock()
+	return
+}
+
+// Records a heartbeat of the partner, like a CheckStatus request
+func (subscription *Subscription) SetLastHeartbeatAt(t time.Time) {
+	subscription.Lock()
+	subscription.lastHeartbeatAt = t
+	subscription.Unlock()
+}
+
+// Returns the last time the partner showed the subscription is alive: the last
+// notification, the last heartbeat or the last successful subscription request
+func (subscription *Subscription) LastActivityAt() time.Time {
+	subscription.RLock()
+	defer subscription.RUnlock()
+
+	return subscription.lastActivityAt()
+}
+
+func (subscription *Subscription) lastActivityAt() time.Time {
+	lastActivity := subscription.lastNotificationAt
+	if subscription.lastHeartbeatAt.After(lastActivity) {
+		lastActivity = subscription.lastHeartbeatAt
+	}
+	for _, resource := range subscription.resourcesByObjectID {
+		if resource.SubscribedAt.After(lastActivity) {
+			lastActivity = resource.SubscribedAt
+		}
+	}
+	return lastActivity
+}
+
+// Checks the resources of a collect subscription and updates its health.
+//
+// The resources are renewed when their TerminationTime is in the renewalMargin.
+// When the partner showed no activity during resubscribeAfter, the subscription
+// is silent and its resources are subscribed again.
+func (subscription *Subscription) CheckCollectHealth(now time.Time, renewalMargin, resubscribeAfter time.Duration) string {
+	subscription.Lock()
+	defer subscription.Unlock()
+
+	health := SUBSCRIPTION_HEALTH_PENDING
+	for key, resource := range subscription.resourcesByObjectID {
+		if resource.RetryCount > SUBSCRIPTION_MAXIMUM_RETRY_COUNT {
+			health = SUBSCRIPTION_HEALTH_FAILED
+			continue
+		}
+		if resource.SubscribedAt.IsZero() {
+			continue
+		}
+		if health == SUBSCRIPTION_HEALTH_PENDING {
+			health = SUBSCRIPTION_HEALTH_ACTIVE
+		}
+		if !resource.TerminationTime.IsZero() && resource.TerminationTime.Before(now.Add(renewalMargin)) {
+			logger.Log.Debugf("Renew resource %v of subscription with id %v", key, subscription.id)
+			resource.Resubscribe()
+		}
+	}
+
+	if health == SUBSCRIPTION_HEALTH_ACTIVE && resubscribeAfter > 0 {
+		if lastActivity := subscription.lastActivityAt(); lastActivity.Before(now.Add(-resubscribeAfter)) {
+			logger.Log.Printf("No activity since %v for subscription with id %v, resubscribe", lastActivity, subscription.id)
+			health = SUBSCRIPTION_HEALTH_SILENT
+			for _, resource := range subscription.resourcesByObjectID {
+				if resource.RetryCount <= SUBSCRIPTION_MAXIMUM_RETRY_COUNT {
+					resource.Resubscribe()
+				}
+			}
+		}
+	}
+
+	subscription.health = health
+	return health
+}
+
+func (subscription *Subscription) LastNotificationSentAt() (t time.Time) {
+	subscription.RLock()
+	t = subscription.lastNotificationSentAt
+	subscription.RUnlock()
+	return
+}
+
+func (subscription *Subscription) NotificationSent(t time.Time) {
+	subscription.Lock()
+	subscription.lastNotificationSentAt = t
+	subscription.Unlock()
+}
+
+func (subscription *Subscription) NotificationFailed() {
+	subscription.Lock()
+	subscription.notificationErrors++
+	subscription.Unlock()
+}
+
+// Returns the number of notifications which couldn't be delivered to the
+// subscriber (broadcast) or the number of failed subscription requests sent to
+// the partner (collect)
+func (subscription *Subscription) ErrorCount() int {
+	subscription.RLock()
+	defer subscription.RUnlock()
+
+	errors := subscription.notificationErrors
+	for _, resource := range subscription.resourcesByObjectID {
+		errors += resource.RetryCount
+	}
+	return errors
+}
+
+func (subscription *Subscription) Health() (h string) {
+	subscription.RLock()
+	h = subscription.health
+	subscription.RUnlock()
+	return
+}
+
+func (subscription *Subscription) SetHealth(health string) {
+	subscription.Lock()
+	subscription.health = health
+	subscription.Unlock()
+}
+
 func (subscription *Subscription) SetKind(kind string) {
 	subscription.kind = kind
 }
@@ -142,16 +348,22 @@ func (subscription *Subscription) MarshalJSON() ([]byte, error) {
 	subscription.RUnlock()
 
 	aux := struct {
-		Id         SubscriptionId        `json:"SubscriptionRef,omitempty"`
-		ExternalId string                `json:"ExternalId,omitempty"`
-		Kind       string                `json:",omitempty"`
-		Resources  []*SubscribedResource `json:",omitempty"`
+		Id                 SubscriptionId        `json:"SubscriptionRef,omitempty"`
+		ExternalId         string                `json:"ExternalId,omitempty"`
+		Kind               string                `json:",omitempty"`
+		Health             string                `json:",omitempty"`
+		LastNotificationAt *time.Time            `json:",omitempty"`
+		Resources          []*SubscribedResource `json:",omitempty"`
 	}{
 		Id:         subscription.id,
 		ExternalId: subscription.externalId,
 		Kind:       subscription.kind,
+		Health:     subscription.Health(),
 		Resources:  resources,
 	}
+	if lastNotificationAt := subscription.LastNotificationAt(); !lastNotificationAt.IsZero() {
+		aux.LastNotificationAt = &lastNotificationAt
+	}
 	return json.Marshal(&aux)
 }
 
@@ -253,6 +465,7 @@ type Subscriptions interface {
 	FindByKind(string) (*Subscription, bool)
 	FindSubscriptionsByKind(string) []*Subscription
 	FindBroadcastSubscriptions() []*Subscription
+	FindCollectSubscriptions() []*Subscription
 	Save(Subscription *Subscription) bool
 	Delete(Subscription *Subscription) bool
 	DeleteById(id SubscriptionId)
@@ -307,6 +520,19 @@ func (manager *MemorySubscriptions) FindSubscriptionsByKind(kind string) (subscr
 	return subscriptions
 }
 
+// Returns the subscriptions requested to the partner
+func (manager *MemorySubscriptions) FindCollectSubscriptions() (subscriptions []*Subscription) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	for _, subscription := range manager.byIdentifier {
+		if subscription.ExternalId() == "" {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions
+}
+
 func (manager *MemorySubscriptions) FindBroadcastSubscriptions() (subscriptions []*Subscription) {
 	manager.mutex.RLock()
 	defer manager.mutex.RUnlock()
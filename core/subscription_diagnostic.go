@@ -0,0 +1,102 @@
+package core
+
+import (
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/model"
+)
+
+type SubscriptionDiagnostic struct {
+	Id         SubscriptionId `json:"SubscriptionRef"`
+	ExternalId string         `json:",omitempty"`
+	Kind       string
+	Health     string `json:",omitempty"`
+
+	LastNotificationReceivedAt *time.Time `json:",omitempty"`
+	LastNotificationSentAt     *time.Time `json:",omitempty"`
+	Errors                     int
+
+	Options   map[string]string
+	Resources []*SubscribedResourceDiagnostic
+}
+
+type SubscribedResourceDiagnostic struct {
+	Reference       model.Reference
+	RetryCount      int
+	SubscribedAt    time.Time
+	SubscribedUntil time.Time
+	TerminationTime *time.Time `json:",omitempty"`
+	// Kind of the last state sent for each model, by model id
+	LastStates map[string]string
+}
+
+// Returns the detailed state of the Subscription, used to understand why a
+// subscriber receives or not some data
+func (subscription *Subscription) Diagnostic() *SubscriptionDiagnostic {
+	diagnostic := &SubscriptionDiagnostic{
+		Id:         subscription.Id(),
+		ExternalId: subscription.ExternalId(),
+		Kind:       subscription.Kind(),
+		Health:     subscription.Health(),
+		Errors:     subscription.ErrorCount(),
+		Options:    make(map[string]string),
+	}
+	if t := subscription.LastNotificationAt(); !t.IsZero() {
+		diagnostic.LastNotificationReceivedAt = &t
+	}
+	if t := subscription.LastNotificationSentAt(); !t.IsZero() {
+		diagnostic.LastNotificationSentAt = &t
+	}
+
+	subscription.RLock()
+	for key, value := range subscription.subscriptionOptions {
+		diagnostic.Options[key] = value
+	}
+	subscription.RUnlock()
+
+	for _, resource := range subscription.ResourcesByObjectIDCopy() {
+		resourceDiagnostic := &SubscribedResourceDiagnostic{
+			Reference:       resource.Reference,
+			RetryCount:      resource.RetryCount,
+			SubscribedAt:    resource.SubscribedAt,
+			SubscribedUntil: resource.SubscribedUntil,
+			LastStates:      resource.LastStateKinds(),
+		}
+		if !resource.TerminationTime.IsZero() {
+			t := resource.TerminationTime
+			resourceDiagnostic.TerminationTime = &t
+		}
+		diagnostic.Resources = append(diagnostic.Resources, resourceDiagnostic)
+	}
+
+	return diagnostic
+}
+
+// Returns true if one of the Subscription resources or the LineRef option
+// matches one of the given ObjectIDs
+func (subscription *Subscription) Concerns(objectids []model.ObjectID) bool {
+	lineRef := subscription.SubscriptionOption("LineRef")
+	for _, objectid := range objectids {
+		if subscription.Resource(objectid) != nil {
+			return true
+		}
+		if lineRef != "" && lineRef == objectid.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func lastStateKind(state lastState) string {
+	switch state.(type) {
+	case *stopAreaLastChange:
+		return "StopArea"
+	case *stopMonitoringLastChange:
+		return "StopMonitoring"
+	case *estimatedTimeTableLastChange:
+		return "EstimatedTimetable"
+	case *generalMessageLastChange:
+		return "GeneralMessage"
+	}
+	return "Unknown"
+}
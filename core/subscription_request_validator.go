@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/siri"
+)
+
+type subscriptionRequestEntry interface {
+	MessageIdentifier() string
+	SubscriberRef() string
+	SubscriptionIdentifier() string
+}
+
+// Checks the SubscriptionRequest entries against the partner limits.
+//
+// A validator is used for a single SubscriptionRequest to detect duplicated
+// SubscriptionIdentifiers.
+type subscriptionRequestValidator struct {
+	partner *Partner
+	now     time.Time
+
+	identifiers map[string]struct{}
+}
+
+func newSubscriptionRequestValidator(partner *Partner, now time.Time) *subscriptionRequestValidator {
+	return &subscriptionRequestValidator{
+		partner:     partner,
+		now:         now,
+		identifiers: make(map[string]struct{}),
+	}
+}
+
+// Returns false when the entry is refused, the ResponseStatus then contains
+// the SIRI ErrorCondition. When the entry is accepted, the ResponseStatus
+// ValidUntil is defined, bounded by the partner maximum duration
+func (validator *subscriptionRequestValidator) validate(rs *siri.SIRIResponseStatus, kind string, initialTerminationTime time.Time) bool {
+	identifier := rs.SubscriptionRef
+
+	if identifier == "" {
+		return refuseSubscriptionEntry(rs, "InvalidDataReferencesError", "Empty SubscriptionIdentifier")
+	}
+	if _, ok := validator.identifiers[identifier]; ok {
+		return refuseSubscriptionEntry(rs, "InvalidDataReferencesError", fmt.Sprintf("Duplicated SubscriptionIdentifier '%s' in request", identifier))
+	}
+	validator.identifiers[identifier] = struct{}{}
+
+	if initialTerminationTime.IsZero() || !initialTerminationTime.After(validator.now) {
+		return refuseSubscriptionEntry(rs, "InvalidDataReferencesError", fmt.Sprintf("InitialTerminationTime '%s' is not in the future", initialTerminationTime.Format(time.RFC3339)))
+	}
+
+	existing, ok := validator.partner.Subscriptions().FindByExternalId(identifier)
+	if ok && existing.Kind() != kind {
+		return refuseSubscriptionEntry(rs, "InvalidDataReferencesError", fmt.Sprintf("SubscriptionIdentifier '%s' is already used by a %s subscription", identifier, existing.Kind()))
+	}
+
+	if max := validator.partner.BroadcastSubscriptionsMaximumCount(); !ok && max > 0 && validator.broadcastSubscriptionsCount() >= max {
+		return refuseSubscriptionEntry(rs, "AccessNotAllowedError", fmt.Sprintf("Maximum number of subscriptions (%d) reached", max))
+	}
+
+	rs.ValidUntil = initialTerminationTime
+	if max := validator.partner.BroadcastSubscriptionsMaximumDuration(); max > 0 && rs.ValidUntil.Sub(validator.now) > max {
+		rs.ValidUntil = validator.now.Add(max)
+	}
+
+	return true
+}
+
+func (validator *subscriptionRequestValidator) broadcastSubscriptionsCount() (count int) {
+	for _, subscription := range validator.partner.Subscriptions().FindAll() {
+		if subscription.ExternalId() != "" {
+			count++
+		}
+	}
+	return
+}
+
+func refuseSubscriptionEntry(rs *siri.SIRIResponseStatus, errorType, errorText string) bool {
+	rs.Status = false
+	rs.ErrorType = errorType
+	rs.ErrorText = errorText
+	return false
+}
+
+// Refuses all the given entries when the associated broadcaster isn't defined
+func capabilityNotSupportedStatuses(entries []subscriptionRequestEntry, capability string, now time.Time) (resps []siri.SIRIResponseStatus) {
+	for _, entry := range entries {
+		rs := siri.SIRIResponseStatus{
+			RequestMessageRef: entry.MessageIdentifier(),
+			SubscriberRef:     entry.SubscriberRef(),
+			SubscriptionRef:   entry.SubscriptionIdentifier(),
+			ResponseTimestamp: now,
+		}
+		refuseSubscriptionEntry(&rs, "CapabilityNotSupportedError", fmt.Sprintf("%s subscriptions aren't supported", capability))
+		resps = append(resps, rs)
+	}
+	return
+}
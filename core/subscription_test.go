@@ -114,3 +114,32 @@ func Test_Subscription_byIdentifier(t *testing.T) {
 		t.Errorf("Should have found the subscription")
 	}
 }
+
+func Test_Subscription_Diagnostic(t *testing.T) {
+	subscription := &Subscription{
+		resourcesByObjectID: make(map[string]*SubscribedResource),
+		subscriptionOptions: make(map[string]string),
+	}
+	subscription.id = "6ba7b814-9dad-11d1-0-00c04fd430c8"
+	subscription.externalId = "externalId"
+	subscription.kind = "StopMonitoringBroadcast"
+	resource := subscription.CreateAddNewResource(*model.NewReference(model.NewObjectID("test", "value")))
+	resource.SetLastState("stopVisit", &stopMonitoringLastChange{})
+	subscription.NotificationFailed()
+
+	diagnostic := subscription.Diagnostic()
+	if diagnostic.Errors != 1 {
+		t.Errorf("Wrong error count:\n got: %v\n want: 1", diagnostic.Errors)
+	}
+	if len(diagnostic.Resources) != 1 {
+		t.Fatalf("Diagnostic should have one resource, got: %v", len(diagnostic.Resources))
+	}
+	if kind := diagnostic.Resources[0].LastStates["stopVisit"]; kind != "StopMonitoring" {
+		t.Errorf("Wrong last state kind:\n got: %v\n want: StopMonitoring", kind)
+	}
+
+	resource.ClearLastStates()
+	if len(resource.LastStateKinds()) != 0 {
+		t.Errorf("Resource last states should be cleared")
+	}
+}
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE referentials ADD COLUMN identifier_mappings text;
+
+-- +migrate Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE referentials DROP COLUMN IF EXISTS identifier_mappings;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE referentials ADD COLUMN connection_links text;
+
+-- +migrate Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE referentials DROP COLUMN IF EXISTS connection_links;
\ No newline at end of file
@@ -3,21 +3,25 @@ package model
 import "database/sql"
 
 type DatabaseReferential struct {
-	ReferentialId  string         `db:"referential_id"`
-	OrganisationId sql.NullString `db:"organisation_id"`
-	Slug           string         `db:"slug"`
-	Name           string         `db:"name"`
-	Settings       string         `db:"settings"`
-	Tokens         string         `db:"tokens"`
+	ReferentialId      string         `db:"referential_id"`
+	OrganisationId     sql.NullString `db:"organisation_id"`
+	Slug               string         `db:"slug"`
+	Name               string         `db:"name"`
+	Settings           string         `db:"settings"`
+	Tokens             string         `db:"tokens"`
+	IdentifierMappings string         `db:"identifier_mappings"`
+	ConnectionLinks    string         `db:"connection_links"`
 }
 
 type SelectReferential struct {
-	ReferentialId  string         `db:"referential_id"`
-	OrganisationId sql.NullString `db:"organisation_id"`
-	Slug           string
-	Name           sql.NullString
-	Settings       sql.NullString
-	Tokens         sql.NullString
+	ReferentialId      string         `db:"referential_id"`
+	OrganisationId     sql.NullString `db:"organisation_id"`
+	Slug               string
+	Name               sql.NullString
+	Settings           sql.NullString
+	Tokens             sql.NullString
+	IdentifierMappings sql.NullString `db:"identifier_mappings"`
+	ConnectionLinks    sql.NullString `db:"connection_links"`
 }
 
 type DatabasePartner struct {
@@ -22,3 +22,26 @@ func NewDate(reference time.Time) Date {
 func (date *Date) String() string {
 	return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
 }
+
+func (date *Date) IsZero() bool {
+	return *date == Date{}
+}
+
+func (date *Date) AddDays(days int) Date {
+	return NewDate(time.Date(date.Year, date.Month, date.Day+days, 0, 0, 0, 0, time.UTC))
+}
+
+func (date *Date) Before(other Date) bool {
+	if date.Year != other.Year {
+		return date.Year < other.Year
+	}
+	if date.Month != other.Month {
+		return date.Month < other.Month
+	}
+	return date.Day < other.Day
+}
+
+// Returns the beginning of the day in the given location
+func (date *Date) StartOfDay(location *time.Location) time.Time {
+	return time.Date(date.Year, date.Month, date.Day, 0, 0, 0, 0, location)
+}
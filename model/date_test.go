@@ -8,3 +8,24 @@ func Test_Date_String(t *testing.T) {
 		t.Errorf("Date.String() returns wrong value, got: %s, required: %s", date.String(), expected)
 	}
 }
+
+func Test_Date_AddDays(t *testing.T) {
+	date := Date{Year: 2017, Month: 12, Day: 31}
+	if next := date.AddDays(1); next != (Date{Year: 2018, Month: 1, Day: 1}) {
+		t.Errorf("Date.AddDays(1) returns wrong value, got: %s, required: 2018-01-01", next.String())
+	}
+	if previous := date.AddDays(-31); previous != (Date{Year: 2017, Month: 11, Day: 30}) {
+		t.Errorf("Date.AddDays(-31) returns wrong value, got: %s, required: 2017-11-30", previous.String())
+	}
+}
+
+func Test_Date_IsZero(t *testing.T) {
+	date := Date{}
+	if !date.IsZero() {
+		t.Errorf("Date.IsZero() should be true for %v", date)
+	}
+	date = Date{Year: 2017, Month: 1, Day: 1}
+	if date.IsZero() {
+		t.Errorf("Date.IsZero() should be false for %v", date)
+	}
+}
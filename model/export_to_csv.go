@@ -0,0 +1,387 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+/* Exports models in the CSV Structure read by the Loader
+
+Rows are written by model type (operator, stop_area, line, vehicle_journey,
+stop_visit) and ordered by Id, so two exports of the same model can be diffed.
+*/
+
+type Exporter struct {
+	csvWriter *csv.Writer
+	modelName string
+	result    Result
+}
+
+// Writes the database rows of the given model_name in the given file
+func ExportDatabaseToCSVFile(filePath string, referentialSlug string, modelName string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("exporter error: error while creating file: %v", err)
+	}
+	defer file.Close()
+
+	result, err := NewExporter(file, modelName).ExportDatabase(referentialSlug)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Debugf(result.PrintExportResult())
+	fmt.Println(result.PrintExportResult())
+
+	return nil
+}
+
+func NewExporter(writer io.Writer, modelName string) *Exporter {
+	return &Exporter{
+		csvWriter: csv.NewWriter(writer),
+		modelName: modelName,
+		result: Result{
+			Import: make(map[string]int64),
+			Errors: make(map[string][]string),
+		},
+	}
+}
+
+func (exporter *Exporter) ExportModel(model Model) (Result, error) {
+	exporters := []func(Model) error{
+		exporter.exportOperators,
+		exporter.exportStopAreas,
+		exporter.exportLines,
+		exporter.exportVehicleJourneys,
+		exporter.exportStopVisits,
+	}
+	for _, export := range exporters {
+		if err := export(model); err != nil {
+			return exporter.result, err
+		}
+	}
+	return exporter.flush()
+}
+
+func (exporter *Exporter) ExportDatabase(referentialSlug string) (Result, error) {
+	exporters := []func(string) error{
+		exporter.exportDatabaseOperators,
+		exporter.exportDatabaseStopAreas,
+		exporter.exportDatabaseLines,
+		exporter.exportDatabaseVehicleJourneys,
+		exporter.exportDatabaseStopVisits,
+	}
+	for _, export := range exporters {
+		if err := export(referentialSlug); err != nil {
+			return exporter.result, err
+		}
+	}
+	return exporter.flush()
+}
+
+func (exporter *Exporter) flush() (Result, error) {
+	exporter.csvWriter.Flush()
+	exporter.result.setTotalInserts()
+	return exporter.result, exporter.csvWriter.Error()
+}
+
+func (exporter *Exporter) write(klass string, record []string) error {
+	if err := exporter.csvWriter.Write(append([]string{klass}, record...)); err != nil {
+		return fmt.Errorf("exporter error: error while writing %v: %v", klass, err)
+	}
+	exporter.result.Import[klass]++
+	return nil
+}
+
+func (exporter *Exporter) exportOperators(model Model) error {
+	operators := model.Operators().FindAll()
+	sort.Slice(operators, func(i, j int) bool { return operators[i].id < operators[j].id })
+
+	for i := range operators {
+		operator := &operators[i]
+		record := []string{
+			string(operator.id),
+			exporter.modelName,
+			operator.Name,
+			toJSON(operator.ObjectIDs()),
+		}
+		if err := exporter.write(OPERATOR, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportStopAreas(model Model) error {
+	stopAreas := model.StopAreas().FindAll()
+	sort.Slice(stopAreas, func(i, j int) bool { return stopAreas[i].id < stopAreas[j].id })
+
+	for i := range stopAreas {
+		stopArea := &stopAreas[i]
+		lineIds := make([]string, len(stopArea.LineIds))
+		for i := range stopArea.LineIds {
+			lineIds[i] = string(stopArea.LineIds[i])
+		}
+		record := []string{
+			string(stopArea.id),
+			string(stopArea.ParentId),
+			string(stopArea.ReferentId),
+			exporter.modelName,
+			stopArea.Name,
+			toJSON(stopArea.ObjectIDs()),
+			toJSON(lineIds),
+			toJSON(attributesOrEmpty(stopArea.Attributes)),
+			referencesToJSON(stopArea.References),
+			strconv.FormatBool(stopArea.CollectedAlways),
+			strconv.FormatBool(stopArea.CollectChildren),
+			strconv.FormatBool(stopArea.CollectGeneralMessages),
+		}
+		if err := exporter.write(STOP_AREA, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportLines(model Model) error {
+	lines := model.Lines().FindAll()
+	sort.Slice(lines, func(i, j int) bool { return lines[i].id < lines[j].id })
+
+	for i := range lines {
+		line := &lines[i]
+		record := []string{
+			string(line.id),
+			exporter.modelName,
+			line.Name,
+			toJSON(line.ObjectIDs()),
+			toJSON(attributesOrEmpty(line.Attributes)),
+			referencesToJSON(line.References),
+			strconv.FormatBool(line.CollectGeneralMessages),
+		}
+		if err := exporter.write(LINE, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportVehicleJourneys(model Model) error {
+	vehicleJourneys := model.VehicleJourneys().FindAll()
+	sort.Slice(vehicleJourneys, func(i, j int) bool { return vehicleJourneys[i].id < vehicleJourneys[j].id })
+
+	for i := range vehicleJourneys {
+		vehicleJourney := &vehicleJourneys[i]
+		record := []string{
+			string(vehicleJourney.id),
+			exporter.modelName,
+			vehicleJourney.Name,
+			toJSON(vehicleJourney.ObjectIDs()),
+			string(vehicleJourney.LineId),
+			vehicleJourney.OriginName,
+			vehicleJourney.DestinationName,
+			toJSON(attributesOrEmpty(vehicleJourney.Attributes)),
+			referencesToJSON(vehicleJourney.References),
+		}
+		if err := exporter.write(VEHICLE_JOURNEY, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportStopVisits(model Model) error {
+	stopVisits := model.StopVisits().FindAll()
+	sort.Slice(stopVisits, func(i, j int) bool { return stopVisits[i].id < stopVisits[j].id })
+
+	for i := range stopVisits {
+		stopVisit := &stopVisits[i]
+		schedules := []*StopVisitSchedule{}
+		for _, kind := range stopVisitScheduleTypes {
+			if schedule := stopVisit.Schedules.Schedule(kind); !schedule.ArrivalTime().IsZero() || !schedule.DepartureTime().IsZero() {
+				schedules = append(schedules, schedule)
+			}
+		}
+		record := []string{
+			string(stopVisit.id),
+			exporter.modelName,
+			toJSON(stopVisit.ObjectIDs()),
+			string(stopVisit.StopAreaId),
+			string(stopVisit.VehicleJourneyId),
+			strconv.Itoa(stopVisit.PassageOrder),
+			toJSON(schedules),
+			toJSON(attributesOrEmpty(stopVisit.Attributes)),
+			referencesToJSON(stopVisit.References),
+		}
+		if err := exporter.write(STOP_VISIT, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportDatabaseOperators(referentialSlug string) error {
+	var selectOperators []SelectOperator
+	if err := exporter.selectRows(&selectOperators, "operators", referentialSlug); err != nil {
+		return err
+	}
+
+	for _, o := range selectOperators {
+		record := []string{
+			o.Id,
+			o.ModelName,
+			o.Name.String,
+			o.ObjectIDs.String,
+		}
+		if err := exporter.write(OPERATOR, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportDatabaseStopAreas(referentialSlug string) error {
+	var selectStopAreas []SelectStopArea
+	if err := exporter.selectRows(&selectStopAreas, "stop_areas", referentialSlug); err != nil {
+		return err
+	}
+
+	for _, sa := range selectStopAreas {
+		record := []string{
+			sa.Id,
+			sa.ParentId.String,
+			sa.ReferentId.String,
+			sa.ModelName,
+			sa.Name.String,
+			sa.ObjectIDs.String,
+			sa.LineIds.String,
+			sa.Attributes.String,
+			sa.References.String,
+			strconv.FormatBool(sa.CollectedAlways.Bool),
+			strconv.FormatBool(sa.CollectChildren.Bool),
+			strconv.FormatBool(sa.CollectGeneralMessages.Bool),
+		}
+		if err := exporter.write(STOP_AREA, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportDatabaseLines(referentialSlug string) error {
+	var selectLines []SelectLine
+	if err := exporter.selectRows(&selectLines, "lines", referentialSlug); err != nil {
+		return err
+	}
+
+	for _, l := range selectLines {
+		record := []string{
+			l.Id,
+			l.ModelName,
+			l.Name.String,
+			l.ObjectIDs.String,
+			l.Attributes.String,
+			l.References.String,
+			strconv.FormatBool(l.CollectGeneralMessages.Bool),
+		}
+		if err := exporter.write(LINE, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportDatabaseVehicleJourneys(referentialSlug string) error {
+	var selectVehicleJourneys []SelectVehicleJourney
+	if err := exporter.selectRows(&selectVehicleJourneys, "vehicle_journeys", referentialSlug); err != nil {
+		return err
+	}
+
+	for _, vj := range selectVehicleJourneys {
+		record := []string{
+			vj.Id,
+			vj.ModelName,
+			vj.Name.String,
+			vj.ObjectIDs.String,
+			vj.LineId.String,
+			vj.OriginName.String,
+			vj.DestinationName.String,
+			vj.Attributes.String,
+			vj.References.String,
+		}
+		if err := exporter.write(VEHICLE_JOURNEY, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) exportDatabaseStopVisits(referentialSlug string) error {
+	var selectStopVisits []SelectStopVisit
+	if err := exporter.selectRows(&selectStopVisits, "stop_visits", referentialSlug); err != nil {
+		return err
+	}
+
+	for _, sv := range selectStopVisits {
+		record := []string{
+			sv.Id,
+			sv.ModelName,
+			sv.ObjectIDs.String,
+			sv.StopAreaId.String,
+			sv.VehicleJourneyId.String,
+			strconv.FormatInt(sv.PassageOrder.Int64, 10),
+			sv.Schedules.String,
+			sv.Attributes.String,
+			sv.References.String,
+		}
+		if err := exporter.write(STOP_VISIT, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter *Exporter) selectRows(rows interface{}, table, referentialSlug string) error {
+	sqlQuery := fmt.Sprintf("select * from %s where referential_slug = $1 and model_name = $2 order by id", table)
+	if _, err := Database.Select(rows, sqlQuery, referentialSlug, exporter.modelName); err != nil {
+		return fmt.Errorf("exporter error: error while selecting %s: %v", table, err)
+	}
+	return nil
+}
+
+func attributesOrEmpty(attributes Attributes) Attributes {
+	if attributes == nil {
+		return NewAttributes()
+	}
+	return attributes
+}
+
+func referencesToJSON(references References) string {
+	if references.IsEmpty() {
+		return "{}"
+	}
+	return toJSON(references.GetReferences())
+}
+
+func toJSON(value interface{}) string {
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(jsonBytes)
+}
+
+func (r Result) PrintExportResult() string {
+	return fmt.Sprintf(`Export successful.
+  %v Operators
+  %v StopAreas
+  %v Lines
+  %v VehicleJourneys
+  %v StopVisits`, r.Import[OPERATOR], r.Import[STOP_AREA], r.Import[LINE], r.Import[VEHICLE_JOURNEY], r.Import[STOP_VISIT])
+}
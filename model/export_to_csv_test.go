@@ -0,0 +1,93 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Exporter_ExportModel(t *testing.T) {
+	model := NewMemoryModel()
+
+	operator := model.Operators().New()
+	operator.id = "03eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	operator.Name = "Name"
+	operator.SetObjectID(NewObjectID("internal", "operatorObjectid"))
+	model.Operators().Save(&operator)
+
+	stopArea := model.StopAreas().New()
+	stopArea.id = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	stopArea.Name = "Name"
+	stopArea.SetObjectID(NewObjectID("internal", "stopAreaObjectid"))
+	stopArea.LineIds = StopAreaLineIds{"f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+	model.StopAreas().Save(&stopArea)
+
+	line := model.Lines().New()
+	line.id = "f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	line.Name = "Name"
+	line.SetObjectID(NewObjectID("internal", "lineObjectid"))
+	model.Lines().Save(&line)
+
+	vehicleJourney := model.VehicleJourneys().New()
+	vehicleJourney.id = "01eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	vehicleJourney.Name = "Name"
+	vehicleJourney.LineId = line.id
+	vehicleJourney.OriginName = "origin"
+	vehicleJourney.DestinationName = "destination"
+	vehicleJourney.SetObjectID(NewObjectID("internal", "vehicleJourneyObjectid"))
+	model.VehicleJourneys().Save(&vehicleJourney)
+
+	stopVisit := model.StopVisits().New()
+	stopVisit.id = "02eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	stopVisit.StopAreaId = stopArea.id
+	stopVisit.VehicleJourneyId = vehicleJourney.id
+	stopVisit.PassageOrder = 1
+	stopVisit.Schedules.SetArrivalTime(STOP_VISIT_SCHEDULE_AIMED, time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC))
+	stopVisit.SetObjectID(NewObjectID("internal", "stopVisitObjectid"))
+	model.StopVisits().Save(&stopVisit)
+
+	var buffer bytes.Buffer
+	result, err := NewExporter(&buffer, "2017-01-01").ExportModel(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TotalInserts() != 5 {
+		t.Errorf("Wrong number of exported models:\n got: %v\n want: 5", result.TotalInserts())
+	}
+
+	expected := `operator,03eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,2017-01-01,Name,"{""internal"":""operatorObjectid""}"
+stop_area,a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,,,2017-01-01,Name,"{""internal"":""stopAreaObjectid""}","[""f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11""]",{},{},true,false,false
+line,f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,2017-01-01,Name,"{""internal"":""lineObjectid""}",{},{},false
+vehicle_journey,01eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,2017-01-01,Name,"{""internal"":""vehicleJourneyObjectid""}",f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,origin,destination,{},{}
+stop_visit,02eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,2017-01-01,"{""internal"":""stopVisitObjectid""}",a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,01eebc99-9c0b-4ef8-bb6d-6bb9bd380a11,1,"[{""ArrivalTime"":""2017-01-01T12:00:00Z"",""Kind"":""aimed""}]",{},{}
+`
+	if buffer.String() != expected {
+		t.Errorf("Wrong CSV export:\n got:\n%v\n want:\n%v", buffer.String(), expected)
+	}
+}
+
+func Test_Exporter_ExportDatabase(t *testing.T) {
+	InitTestDb(t)
+	defer CleanTestDb(t)
+
+	// Fill DB
+	LoadFromCSVFile("testdata/import.csv", "referential", false)
+
+	var buffer bytes.Buffer
+	result, err := NewExporter(&buffer, "2017-01-01").ExportDatabase("referential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalInserts() != 5 {
+		t.Errorf("Wrong number of exported rows:\n got: %v\n want: 5", result.TotalInserts())
+	}
+
+	// The export can be loaded again
+	CleanTestDb(t)
+	loadResult := NewLoader("referential", false, false).Load(strings.NewReader(buffer.String()))
+	if loadResult.ErrorCount() != 0 || loadResult.TotalInserts() != 5 {
+		t.Errorf("Export should be loadable:\n%v\n%v", loadResult.PrintResult(), loadResult.Errors)
+	}
+}
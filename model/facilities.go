@@ -0,0 +1,292 @@
+package model
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
+
+type FacilityId ModelId
+
+type FacilityStatus string
+
+const (
+	FACILITY_STATUS_AVAILABLE           FacilityStatus = "available"
+	FACILITY_STATUS_NOT_AVAILABLE       FacilityStatus = "notAvailable"
+	FACILITY_STATUS_PARTIALLY_AVAILABLE FacilityStatus = "partiallyAvailable"
+	FACILITY_STATUS_UNKNOWN             FacilityStatus = "unknown"
+)
+
+func NormalizeFacilityStatus(status string) FacilityStatus {
+	switch FacilityStatus(status) {
+	case FACILITY_STATUS_AVAILABLE, FACILITY_STATUS_NOT_AVAILABLE, FACILITY_STATUS_PARTIALLY_AVAILABLE:
+		return FacilityStatus(status)
+	default:
+		return FACILITY_STATUS_UNKNOWN
+	}
+}
+
+// A Facility is an equipment of a StopArea (lift, escalator, ...) which
+// can be temporarily unavailable.
+type Facility struct {
+	ObjectIDConsumer
+
+	model Model
+
+	id         FacilityId
+	StopAreaId StopAreaId `json:",omitempty"`
+
+	Origin      string         `json:",omitempty"`
+	Name        string         `json:",omitempty"`
+	Status      FacilityStatus `json:",omitempty"`
+	Description string         `json:",omitempty"`
+
+	ValidityStartTime time.Time `json:",omitempty"`
+	ValidityEndTime   time.Time `json:",omitempty"`
+	RecordedAt        time.Time `json:",omitempty"`
+
+	Attributes Attributes `json:",omitempty"`
+}
+
+func NewFacility(model Model) *Facility {
+	facility := &Facility{
+		model:      model,
+		Status:     FACILITY_STATUS_UNKNOWN,
+		Attributes: NewAttributes(),
+	}
+	facility.objectids = make(ObjectIDs)
+	return facility
+}
+
+func (facility *Facility) modelId() ModelId {
+	return ModelId(facility.id)
+}
+
+func (facility *Facility) Id() FacilityId {
+	return facility.id
+}
+
+func (facility *Facility) Save() (ok bool) {
+	ok = facility.model.Facilities().Save(facility)
+	return
+}
+
+func (facility *Facility) StopArea() (*StopArea, bool) {
+	if facility.model == nil {
+		return nil, false
+	}
+	stopArea, ok := facility.model.StopAreas().Find(facility.StopAreaId)
+	if !ok {
+		return nil, false
+	}
+	return &stopArea, true
+}
+
+// Returns true if the Facility status validity period is ended at the given time
+func (facility *Facility) IsExpired(t time.Time) bool {
+	return !facility.ValidityEndTime.IsZero() && t.After(facility.ValidityEndTime)
+}
+
+func (facility *Facility) MarshalJSON() ([]byte, error) {
+	type Alias Facility
+	aux := struct {
+		Id                FacilityId
+		ObjectIDs         ObjectIDs  `json:",omitempty"`
+		ValidityStartTime *time.Time `json:",omitempty"`
+		ValidityEndTime   *time.Time `json:",omitempty"`
+		RecordedAt        *time.Time `json:",omitempty"`
+		*Alias
+	}{
+		Id:    facility.id,
+		Alias: (*Alias)(facility),
+	}
+
+	if !facility.ObjectIDs().Empty() {
+		aux.ObjectIDs = facility.ObjectIDs()
+	}
+	if !facility.ValidityStartTime.IsZero() {
+		aux.ValidityStartTime = &facility.ValidityStartTime
+	}
+	if !facility.ValidityEndTime.IsZero() {
+		aux.ValidityEndTime = &facility.ValidityEndTime
+	}
+	if !facility.RecordedAt.IsZero() {
+		aux.RecordedAt = &facility.RecordedAt
+	}
+
+	return json.Marshal(&aux)
+}
+
+func (facility *Facility) UnmarshalJSON(data []byte) error {
+	type Alias Facility
+	aux := &struct {
+		ObjectIDs map[string]string
+		*Alias
+	}{
+		Alias: (*Alias)(facility),
+	}
+	err := json.Unmarshal(data, aux)
+	if err != nil {
+		return err
+	}
+
+	if aux.ObjectIDs != nil {
+		facility.ObjectIDConsumer.objectids = NewObjectIDsFromMap(aux.ObjectIDs)
+	}
+	facility.Status = NormalizeFacilityStatus(string(facility.Status))
+
+	return nil
+}
+
+type MemoryFacilities struct {
+	uuid.UUIDConsumer
+
+	model *MemoryModel
+
+	mutex          *sync.RWMutex
+	broadcastEvent func(event FacilityBroadcastEvent)
+	byIdentifier   map[FacilityId]*Facility
+	byObjectId     *ObjectIdIndex
+}
+
+type Facilities interface {
+	uuid.UUIDInterface
+
+	New() Facility
+	Find(id FacilityId) (Facility, bool)
+	FindByObjectId(objectid ObjectID) (Facility, bool)
+	FindByStopAreaId(id StopAreaId) []Facility
+	FindAll() []Facility
+	Save(facility *Facility) bool
+	Delete(facility *Facility) bool
+}
+
+func NewMemoryFacilities() *MemoryFacilities {
+	return &MemoryFacilities{
+		mutex:        &sync.RWMutex{},
+		byIdentifier: make(map[FacilityId]*Facility),
+		byObjectId:   NewObjectIdIndex(),
+	}
+}
+
+// Returns a copy of the Facilities for the given model. The StopArea of each
+// Facility is found again in the given model by ObjectID, Facilities whose
+// StopArea can't be found are ignored.
+func (manager *MemoryFacilities) Clone(model *MemoryModel) *MemoryFacilities {
+	clone := NewMemoryFacilities()
+	clone.model = model
+	clone.broadcastEvent = model.broadcastFMEvent
+
+	for _, facility := range manager.FindAll() {
+		stopArea, ok := manager.model.StopAreas().Find(facility.StopAreaId)
+		if !ok {
+			continue
+		}
+		stopAreaId, ok := findStopAreaIdByObjectIds(model, stopArea.ObjectIDs())
+		if !ok {
+			continue
+		}
+
+		cloneFacility := facility
+		cloneFacility.StopAreaId = stopAreaId
+		clone.Save(&cloneFacility)
+	}
+
+	return clone
+}
+
+func findStopAreaIdByObjectIds(model *MemoryModel, objectids ObjectIDs) (StopAreaId, bool) {
+	for _, objectid := range objectids {
+		if stopArea, ok := model.StopAreas().FindByObjectId(objectid); ok {
+			return stopArea.Id(), true
+		}
+	}
+	return "", false
+}
+
+func (manager *MemoryFacilities) New() Facility {
+	facility := NewFacility(manager.model)
+	return *facility
+}
+
+func (manager *MemoryFacilities) Find(id FacilityId) (Facility, bool) {
+	if id == "" {
+		return Facility{}, false
+	}
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	facility, ok := manager.byIdentifier[id]
+	if ok {
+		return *facility, true
+	}
+	return Facility{}, false
+}
+
+func (manager *MemoryFacilities) FindByObjectId(objectid ObjectID) (Facility, bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	id, ok := manager.byObjectId.Find(objectid)
+	if ok {
+		return *manager.byIdentifier[FacilityId(id)], true
+	}
+	return Facility{}, false
+}
+
+func (manager *MemoryFacilities) FindByStopAreaId(id StopAreaId) (facilities []Facility) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	for _, facility := range manager.byIdentifier {
+		if facility.StopAreaId == id {
+			facilities = append(facilities, *facility)
+		}
+	}
+	return
+}
+
+func (manager *MemoryFacilities) FindAll() (facilities []Facility) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	for _, facility := range manager.byIdentifier {
+		facilities = append(facilities, *facility)
+	}
+	return
+}
+
+func (manager *MemoryFacilities) Save(facility *Facility) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if facility.id == "" {
+		facility.id = FacilityId(manager.NewUUID())
+	}
+
+	facility.model = manager.model
+	manager.byIdentifier[facility.Id()] = facility
+	manager.byObjectId.Index(facility)
+
+	event := FacilityBroadcastEvent{
+		FacilityId: facility.id,
+	}
+
+	if manager.broadcastEvent != nil {
+		manager.broadcastEvent(event)
+	}
+	return true
+}
+
+func (manager *MemoryFacilities) Delete(facility *Facility) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	delete(manager.byIdentifier, facility.Id())
+	manager.byObjectId.Delete(ModelId(facility.id))
+
+	return true
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_Facility_MarshalJSON(t *testing.T) {
+	facility := Facility{
+		id:         "6ba7b814-9dad-11d1-0-00c04fd430c8",
+		StopAreaId: "StopAreaId",
+		Name:       "Lift 1",
+		Status:     FACILITY_STATUS_NOT_AVAILABLE,
+	}
+	facility.objectids = make(ObjectIDs)
+	facility.SetObjectID(NewObjectID("kind", "value"))
+	facility.ValidityEndTime = time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	expected := `{"Id":"6ba7b814-9dad-11d1-0-00c04fd430c8","ObjectIDs":{"kind":"value"},"ValidityEndTime":"2017-01-01T12:00:00Z","StopAreaId":"StopAreaId","Name":"Lift 1","Status":"notAvailable"}`
+	jsonBytes, err := facility.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(jsonBytes) != expected {
+		t.Errorf("Facility.MarshalJSON() returns wrong json:\n got: %s\n want: %s", string(jsonBytes), expected)
+	}
+}
+
+func Test_Facility_UnmarshalJSON(t *testing.T) {
+	test := `{
+		"ObjectIDs": { "kind": "value" },
+		"StopAreaId": "StopAreaId",
+		"Status": "partiallyAvailable",
+		"ValidityStartTime": "2017-01-01T12:00:00Z"
+}`
+
+	facility := Facility{}
+	if err := json.Unmarshal([]byte(test), &facility); err != nil {
+		t.Fatalf("Error while Unmarshalling Facility %v", err)
+	}
+
+	if facility.StopAreaId != "StopAreaId" {
+		t.Errorf("Got wrong Facility StopAreaId want StopAreaId got %v", facility.StopAreaId)
+	}
+	if facility.Status != FACILITY_STATUS_PARTIALLY_AVAILABLE {
+		t.Errorf("Got wrong Facility Status want partiallyAvailable got %v", facility.Status)
+	}
+	if !facility.ValidityStartTime.Equal(time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Got wrong Facility ValidityStartTime: %v", facility.ValidityStartTime)
+	}
+	if _, ok := facility.ObjectID("kind"); !ok {
+		t.Errorf("Facility should have an ObjectID with kind 'kind'")
+	}
+
+	test = `{ "Status": "broken" }`
+	facility = Facility{}
+	json.Unmarshal([]byte(test), &facility)
+	if facility.Status != FACILITY_STATUS_UNKNOWN {
+		t.Errorf("Unexpected Facility Status should be unknown, got %v", facility.Status)
+	}
+}
+
+func Test_Facility_IsExpired(t *testing.T) {
+	facility := NewFacility(nil)
+	now := time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	if facility.IsExpired(now) {
+		t.Errorf("Facility without validity period should never be expired")
+	}
+
+	facility.ValidityStartTime = now.Add(time.Hour)
+	if facility.IsExpired(now) {
+		t.Errorf("Facility should not be expired before its ValidityStartTime")
+	}
+
+	facility.ValidityEndTime = now.Add(-time.Minute)
+	if !facility.IsExpired(now) {
+		t.Errorf("Facility should be expired after its ValidityEndTime")
+	}
+}
+
+func Test_MemoryFacilities_FindByStopAreaId(t *testing.T) {
+	model := NewMemoryModel()
+
+	facility := model.Facilities().New()
+	facility.SetObjectID(NewObjectID("kind", "lift"))
+	facility.StopAreaId = "stopArea"
+	facility.Save()
+
+	other := model.Facilities().New()
+	other.StopAreaId = "otherStopArea"
+	other.Save()
+
+	facilities := model.Facilities().FindByStopAreaId("stopArea")
+	if len(facilities) != 1 || facilities[0].Id() != facility.Id() {
+		t.Fatalf("FindByStopAreaId should return the Facility of the StopArea, got: %v", facilities)
+	}
+
+	found, ok := model.Facilities().FindByObjectId(NewObjectID("kind", "lift"))
+	if !ok || found.Id() != facility.Id() {
+		t.Errorf("FindByObjectId should find the Facility")
+	}
+
+	model.Facilities().Delete(&facility)
+	if _, ok := model.Facilities().Find(facility.Id()); ok {
+		t.Errorf("Deleted Facility should not be found")
+	}
+	if len(model.Facilities().FindAll()) != 1 {
+		t.Errorf("FindAll should return the remaining Facility")
+	}
+}
+
+func Test_TransactionalFacilities_Commit(t *testing.T) {
+	model := NewMemoryModel()
+	facilities := NewTransactionalFacilities(model)
+
+	facility := facilities.New()
+	facility.StopAreaId = "stopArea"
+	facilities.Save(&facility)
+
+	if _, ok := model.Facilities().Find(facility.Id()); ok {
+		t.Errorf("Facility should not be saved in the model before commit")
+	}
+	if len(facilities.FindByStopAreaId("stopArea")) != 1 {
+		t.Errorf("Saved Facility should be found in the transaction")
+	}
+
+	facilities.Commit()
+	if _, ok := model.Facilities().Find(facility.Id()); !ok {
+		t.Errorf("Facility should be saved in the model after commit")
+	}
+}
+
+func Test_MemoryFacilities_Clone(t *testing.T) {
+	model := NewMemoryModel()
+
+	stopArea := model.StopAreas().New()
+	stopArea.SetObjectID(NewObjectID("kind", "stopArea"))
+	stopArea.Save()
+
+	facility := model.Facilities().New()
+	facility.SetObjectID(NewObjectID("kind", "lift"))
+	facility.StopAreaId = stopArea.Id()
+	facility.Status = FACILITY_STATUS_NOT_AVAILABLE
+	facility.Save()
+
+	orphan := model.Facilities().New()
+	orphan.SetObjectID(NewObjectID("kind", "orphan"))
+	orphan.StopAreaId = "unknown"
+	orphan.Save()
+
+	clone := model.Clone()
+
+	cloneFacility, ok := clone.Facilities().FindByObjectId(NewObjectID("kind", "lift"))
+	if !ok {
+		t.Fatalf("Facility should be kept in the cloned model")
+	}
+	cloneStopArea, _ := clone.StopAreas().FindByObjectId(NewObjectID("kind", "stopArea"))
+	if cloneFacility.StopAreaId != cloneStopArea.Id() {
+		t.Errorf("Facility should reference the cloned StopArea:\n got: %v\n want: %v", cloneFacility.StopAreaId, cloneStopArea.Id())
+	}
+	if cloneFacility.Status != FACILITY_STATUS_NOT_AVAILABLE {
+		t.Errorf("Wrong Facility Status: %v", cloneFacility.Status)
+	}
+	if _, ok := clone.Facilities().FindByObjectId(NewObjectID("kind", "orphan")); ok {
+		t.Errorf("Facility without StopArea should be ignored")
+	}
+}
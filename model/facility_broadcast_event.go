@@ -0,0 +1,5 @@
+package model
+
+type FacilityBroadcastEvent struct {
+	FacilityId FacilityId
+}
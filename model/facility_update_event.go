@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type FacilityUpdateEvent struct {
+	Origin string
+
+	ObjectId         ObjectID
+	StopAreaObjectId ObjectID
+
+	Name          string
+	FacilityClass string
+	Status        FacilityStatus
+	Description   string
+
+	ValidityStartTime time.Time
+	ValidityEndTime   time.Time
+}
+
+func NewFacilityUpdateEvent() *FacilityUpdateEvent {
+	return &FacilityUpdateEvent{}
+}
+
+func (ue *FacilityUpdateEvent) EventKind() EventKind {
+	return FACILITY_EVENT
+}
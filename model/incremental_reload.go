@@ -0,0 +1,768 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+/* Incremental reload of the MemoryModel
+
+The models of the current day are loaded from the database in a reference
+MemoryModel and compared with the live model. Only the attributes read from
+the database are updated, so the real-time state (schedules, statuses,
+collect state, origins) and the model ids used by the subscriptions are kept.
+
+A reference model is matched with a live one by Id, then by ObjectID. Live
+models created by a previous load and missing in the new one are removed.
+VehicleJourneys and StopVisits created by the collect are removed once they're
+older than the first service date loaded.
+*/
+
+type ReloadResult struct {
+	Added   map[string]int
+	Changed map[string]int
+	Removed map[string]int
+	// Models of a service date ignored because their ObjectID is already used
+	// by the model of another service date
+	Ignored map[string]int
+}
+
+func NewReloadResult() *ReloadResult {
+	return &ReloadResult{
+		Added:   make(map[string]int),
+		Changed: make(map[string]int),
+		Removed: make(map[string]int),
+		Ignored: make(map[string]int),
+	}
+}
+
+func (r *ReloadResult) String() string {
+	s := "Incremental reload:"
+	for _, klass := range []string{OPERATOR, STOP_AREA, LINE, VEHICLE_JOURNEY, STOP_VISIT} {
+		s += fmt.Sprintf("\n  %v: %v added, %v changed, %v removed, %v ignored", klass, r.Added[klass], r.Changed[klass], r.Removed[klass], r.Ignored[klass])
+	}
+	return s
+}
+
+type loadedIds map[ModelId]struct{}
+
+func (ids loadedIds) include(id ModelId) bool {
+	_, ok := ids[id]
+	return ok
+}
+
+// Loads the models of the current day (and of the other service dates) and applies the differences to the model.
+// The current day is defined by the referential clock
+func (model *MemoryModel) IncrementalReload(referentialSlug string, referentialClock clock.Clock) (*ReloadResult, error) {
+	date := NewDate(referentialClock.Now())
+
+	references := []*MemoryModel{}
+	for _, serviceDate := range model.serviceDatesAround(date) {
+		reference, err := loadReferenceModel(referentialSlug, serviceDate)
+		if err != nil {
+			return nil, err
+		}
+		// The model date is applied first
+		if serviceDate == date {
+			references = append([]*MemoryModel{reference}, references...)
+		} else {
+			references = append(references, reference)
+		}
+	}
+
+	result := model.ApplyReload(references...)
+	logger.Log.Debugf(result.String())
+	return result, nil
+}
+
+func loadReferenceModel(referentialSlug string, date Date) (*MemoryModel, error) {
+	reference := NewMemoryModel()
+	reference.setDate(date)
+
+	loaders := []func(string) error{
+		reference.stopAreas.Load,
+		reference.lines.Load,
+		reference.operators.Load,
+		reference.vehicleJourneys.Load,
+		reference.stopVisits.Load,
+	}
+	for _, load := range loaders {
+		if err := load(referentialSlug); err != nil {
+			return nil, err
+		}
+	}
+	return reference, nil
+}
+
+// Applies in a single Transaction the differences between the references and the model.
+// The first reference defines the new model Date.
+func (model *MemoryModel) ApplyReload(references ...*MemoryModel) *ReloadResult {
+	tx := NewTransaction(model)
+	defer tx.Close()
+
+	reload := newIncrementalReload(tx, references[0].Date())
+	reload.previousIds = model.getLoadedIds()
+	firstDate := reload.mainDate
+	for _, reference := range references {
+		reload.apply(reference)
+		if date := reference.Date(); date.Before(firstDate) {
+			firstDate = date
+		}
+	}
+	reload.removeMissingModels()
+	reload.removePastModels(firstDate)
+
+	tx.Commit()
+
+	model.setDate(reload.mainDate)
+	model.setLoadedIds(reload.loadedIds)
+
+	return reload.result
+}
+
+// Adds the models of the other service dates, after the load of the model date
+func (model *MemoryModel) loadServiceDates(referentialSlug string) {
+	modelDate := model.Date()
+	for _, date := range model.ServiceDates() {
+		if date == modelDate {
+			continue
+		}
+		reference, err := loadReferenceModel(referentialSlug, date)
+		if err != nil {
+			logger.Log.Debugf("Error while loading service date %v: %v", date.String(), err)
+			continue
+		}
+
+		tx := NewTransaction(model)
+		reload := newIncrementalReload(tx, modelDate)
+		reload.apply(reference)
+		tx.Commit()
+		tx.Close()
+
+		model.addLoadedIds(reload.loadedIds)
+	}
+}
+
+// Remembers the models loaded from the database, only them can be removed by an incremental reload
+func (model *MemoryModel) rememberLoadedModels() {
+	ids := make(loadedIds)
+	for _, id := range model.modelIds() {
+		ids[id] = struct{}{}
+	}
+	model.setLoadedIds(ids)
+}
+
+func (model *MemoryModel) getLoadedIds() loadedIds {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	return model.loadedIds
+}
+
+func (model *MemoryModel) setLoadedIds(ids loadedIds) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	model.loadedIds = ids
+}
+
+func (model *MemoryModel) addLoadedIds(ids loadedIds) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	if model.loadedIds == nil {
+		model.loadedIds = make(loadedIds)
+	}
+	for id := range ids {
+		model.loadedIds[id] = struct{}{}
+	}
+}
+
+func (model *MemoryModel) modelIds() (ids []ModelId) {
+	model.stopAreas.mutex.RLock()
+	for id := range model.stopAreas.byIdentifier {
+		ids = append(ids, ModelId(id))
+	}
+	model.stopAreas.mutex.RUnlock()
+
+	model.lines.mutex.RLock()
+	for id := range model.lines.byIdentifier {
+		ids = append(ids, ModelId(id))
+	}
+	model.lines.mutex.RUnlock()
+
+	model.operators.mutex.RLock()
+	for id := range model.operators.byIdentifier {
+		ids = append(ids, ModelId(id))
+	}
+	model.operators.mutex.RUnlock()
+
+	model.vehicleJourneys.mutex.RLock()
+	for id := range model.vehicleJourneys.byIdentifier {
+		ids = append(ids, ModelId(id))
+	}
+	model.vehicleJourneys.mutex.RUnlock()
+
+	model.stopVisits.mutex.RLock()
+	for id := range model.stopVisits.byIdentifier {
+		ids = append(ids, ModelId(id))
+	}
+	model.stopVisits.mutex.RUnlock()
+
+	return
+}
+
+type incrementalReload struct {
+	tx        *Transaction
+	reference *MemoryModel
+	mainDate  Date
+
+	previousIds loadedIds
+	loadedIds   loadedIds
+	result      *ReloadResult
+
+	// Reference ids to live ids
+	stopAreaIds       map[StopAreaId]StopAreaId
+	lineIds           map[LineId]LineId
+	vehicleJourneyIds map[VehicleJourneyId]VehicleJourneyId
+
+	// VehicleJourneys of other service dates ignored because of an ObjectID conflict
+	ignoredVehicleJourneys map[VehicleJourneyId]struct{}
+}
+
+func newIncrementalReload(tx *Transaction, mainDate Date) *incrementalReload {
+	return &incrementalReload{
+		tx:        tx,
+		mainDate:  mainDate,
+		loadedIds: make(loadedIds),
+		result:    NewReloadResult(),
+	}
+}
+
+func (reload *incrementalReload) apply(reference *MemoryModel) {
+	reload.reference = reference
+	reload.stopAreaIds = make(map[StopAreaId]StopAreaId)
+	reload.lineIds = make(map[LineId]LineId)
+	reload.vehicleJourneyIds = make(map[VehicleJourneyId]VehicleJourneyId)
+	reload.ignoredVehicleJourneys = make(map[VehicleJourneyId]struct{})
+
+	reload.reloadLines()
+	reload.reloadOperators()
+	reload.reloadStopAreas()
+	reload.reloadVehicleJourneys()
+	reload.reloadStopVisits()
+}
+
+/*
+VehicleJourneys and StopVisits of different service dates can share the same
+database ids and the same ObjectIDs. A live model found by id or by ObjectID is
+only used when it has the same service date (or no service date when created
+by the collect). A new model whose database id is already used by another
+service date receives a new id.
+
+The reference model must be ignored when it belongs to another service date
+and the ObjectID is already used by a live model. The models of the model
+date always take the ObjectID.
+*/
+func (reload *incrementalReload) sameServiceDate(live *Date, reference *Date) (same bool, ignore bool) {
+	if live.IsZero() || *live == *reference {
+		return true, false
+	}
+	return false, *reference != reload.mainDate
+}
+
+// Compares the attributes loaded from the database
+func (reload *incrementalReload) changed(live, reference interface{}) bool {
+	r, err := Equal(live, reference)
+	if err != nil {
+		logger.Log.Debugf("Error while comparing models in incremental reload: %v", err)
+		return true
+	}
+	return !r.Equal
+}
+
+func (reload *incrementalReload) count(counter map[string]int, klass string) {
+	counter[klass]++
+}
+
+type stopAreaLoadedAttributes struct {
+	Name                   string
+	ParentId               StopAreaId
+	ReferentId             StopAreaId
+	LineIds                StopAreaLineIds
+	CollectedAlways        bool
+	CollectChildren        bool
+	CollectGeneralMessages bool
+	Attributes             Attributes
+	References             map[string]string
+	ObjectIDs              map[string]string
+}
+
+func (reload *incrementalReload) stopAreaAttributes(stopArea *StopArea) *stopAreaLoadedAttributes {
+	return &stopAreaLoadedAttributes{
+		Name:                   stopArea.Name,
+		ParentId:               stopArea.ParentId,
+		ReferentId:             stopArea.ReferentId,
+		LineIds:                stopArea.LineIds,
+		CollectedAlways:        stopArea.CollectedAlways,
+		CollectChildren:        stopArea.CollectChildren,
+		CollectGeneralMessages: stopArea.CollectGeneralMessages,
+		Attributes:             attributesOrEmpty(stopArea.Attributes),
+		References:             stopArea.References.GetSiriReferences(),
+		ObjectIDs:              stopArea.ObjectIDsResponse(),
+	}
+}
+
+func (reload *incrementalReload) reloadStopAreas() {
+	stopAreas := reload.reference.stopAreas.FindAll()
+
+	// Ids are resolved first, as StopAreas reference each other
+	live := make(map[StopAreaId]*StopArea)
+	for i := range stopAreas {
+		stopArea, ok := reload.tx.Model().StopAreas().Find(stopAreas[i].id)
+		if !ok {
+			for _, objectid := range stopAreas[i].ObjectIDs() {
+				if stopArea, ok = reload.tx.Model().StopAreas().FindByObjectId(objectid); ok {
+					break
+				}
+			}
+		}
+		if ok {
+			reload.stopAreaIds[stopAreas[i].id] = stopArea.id
+			live[stopAreas[i].id] = &stopArea
+		} else {
+			reload.stopAreaIds[stopAreas[i].id] = stopAreas[i].id
+		}
+	}
+
+	for i := range stopAreas {
+		reference := &stopAreas[i]
+		reference.ParentId = reload.stopAreaId(reference.ParentId)
+		reference.ReferentId = reload.stopAreaId(reference.ReferentId)
+		for j := range reference.LineIds {
+			reference.LineIds[j] = reload.lineId(reference.LineIds[j])
+		}
+
+		stopArea, ok := live[reference.id]
+		if !ok {
+			reload.loadedIds[ModelId(reference.id)] = struct{}{}
+			reload.tx.Model().StopAreas().Save(reference)
+			reload.count(reload.result.Added, STOP_AREA)
+			continue
+		}
+		reload.loadedIds[ModelId(stopArea.id)] = struct{}{}
+
+		if !reload.changed(reload.stopAreaAttributes(stopArea), reload.stopAreaAttributes(reference)) {
+			continue
+		}
+		stopArea.Name = reference.Name
+		stopArea.ParentId = reference.ParentId
+		stopArea.ReferentId = reference.ReferentId
+		stopArea.LineIds = reference.LineIds
+		stopArea.CollectedAlways = reference.CollectedAlways
+		stopArea.CollectChildren = reference.CollectChildren
+		stopArea.CollectGeneralMessages = reference.CollectGeneralMessages
+		stopArea.Attributes = reference.Attributes
+		stopArea.References = reference.References
+		for _, objectid := range reference.ObjectIDs() {
+			stopArea.SetObjectID(objectid)
+		}
+		reload.tx.Model().StopAreas().Save(stopArea)
+		reload.count(reload.result.Changed, STOP_AREA)
+	}
+}
+
+type lineLoadedAttributes struct {
+	Name                   string
+	CollectGeneralMessages bool
+	Attributes             Attributes
+	References             map[string]string
+	ObjectIDs              map[string]string
+}
+
+func (reload *incrementalReload) lineAttributes(line *Line) *lineLoadedAttributes {
+	return &lineLoadedAttributes{
+		Name:                   line.Name,
+		CollectGeneralMessages: line.CollectGeneralMessages,
+		Attributes:             attributesOrEmpty(line.Attributes),
+		References:             line.References.GetSiriReferences(),
+		ObjectIDs:              line.ObjectIDsResponse(),
+	}
+}
+
+func (reload *incrementalReload) reloadLines() {
+	lines := reload.reference.lines.FindAll()
+	for i := range lines {
+		reference := &lines[i]
+
+		line, ok := reload.tx.Model().Lines().Find(reference.id)
+		if !ok {
+			for _, objectid := range reference.ObjectIDs() {
+				if line, ok = reload.tx.Model().Lines().FindByObjectId(objectid); ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			reload.lineIds[reference.id] = reference.id
+			reload.loadedIds[ModelId(reference.id)] = struct{}{}
+			reload.tx.Model().Lines().Save(reference)
+			reload.count(reload.result.Added, LINE)
+			continue
+		}
+		reload.lineIds[reference.id] = line.id
+		reload.loadedIds[ModelId(line.id)] = struct{}{}
+
+		if !reload.changed(reload.lineAttributes(&line), reload.lineAttributes(reference)) {
+			continue
+		}
+		line.Name = reference.Name
+		line.CollectGeneralMessages = reference.CollectGeneralMessages
+		line.Attributes = reference.Attributes
+		line.References = reference.References
+		for _, objectid := range reference.ObjectIDs() {
+			line.SetObjectID(objectid)
+		}
+		reload.tx.Model().Lines().Save(&line)
+		reload.count(reload.result.Changed, LINE)
+	}
+}
+
+type operatorLoadedAttributes struct {
+	Name      string
+	ObjectIDs map[string]string
+}
+
+func (reload *incrementalReload) reloadOperators() {
+	operators := reload.reference.operators.FindAll()
+	for i := range operators {
+		reference := &operators[i]
+
+		operator, ok := reload.tx.Model().Operators().Find(reference.id)
+		if !ok {
+			for _, objectid := range reference.ObjectIDs() {
+				if operator, ok = reload.tx.Model().Operators().FindByObjectId(objectid); ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			reload.loadedIds[ModelId(reference.id)] = struct{}{}
+			reload.tx.Model().Operators().Save(reference)
+			reload.count(reload.result.Added, OPERATOR)
+			continue
+		}
+		reload.loadedIds[ModelId(operator.id)] = struct{}{}
+
+		liveAttributes := &operatorLoadedAttributes{Name: operator.Name, ObjectIDs: operator.ObjectIDsResponse()}
+		referenceAttributes := &operatorLoadedAttributes{Name: reference.Name, ObjectIDs: reference.ObjectIDsResponse()}
+		if !reload.changed(liveAttributes, referenceAttributes) {
+			continue
+		}
+		operator.Name = reference.Name
+		for _, objectid := range reference.ObjectIDs() {
+			operator.SetObjectID(objectid)
+		}
+		reload.tx.Model().Operators().Save(&operator)
+		reload.count(reload.result.Changed, OPERATOR)
+	}
+}
+
+type vehicleJourneyLoadedAttributes struct {
+	ServiceDate     Date
+	Name            string
+	LineId          LineId
+	OriginName      string
+	DestinationName string
+	Attributes      Attributes
+	References      map[string]string
+	ObjectIDs       map[string]string
+}
+
+func (reload *incrementalReload) vehicleJourneyAttributes(vehicleJourney *VehicleJourney) *vehicleJourneyLoadedAttributes {
+	return &vehicleJourneyLoadedAttributes{
+		ServiceDate:     vehicleJourney.ServiceDate,
+		Name:            vehicleJourney.Name,
+		LineId:          vehicleJourney.LineId,
+		OriginName:      vehicleJourney.OriginName,
+		DestinationName: vehicleJourney.DestinationName,
+		Attributes:      attributesOrEmpty(vehicleJourney.Attributes),
+		References:      vehicleJourney.References.GetSiriReferences(),
+		ObjectIDs:       vehicleJourney.ObjectIDsResponse(),
+	}
+}
+
+func (reload *incrementalReload) reloadVehicleJourneys() {
+	vehicleJourneys := reload.reference.vehicleJourneys.FindAll()
+	for i := range vehicleJourneys {
+		reference := &vehicleJourneys[i]
+		reference.LineId = reload.lineId(reference.LineId)
+
+		vehicleJourney, ok := reload.tx.Model().VehicleJourneys().Find(reference.id)
+		idUsed := false
+		if ok {
+			// The database id can be used by the VehicleJourney of another service date
+			ok, _ = reload.sameServiceDate(&vehicleJourney.ServiceDate, &reference.ServiceDate)
+			idUsed = !ok
+		}
+		if !ok {
+			for _, objectid := range reference.ObjectIDs() {
+				if vehicleJourney, ok = reload.tx.Model().VehicleJourneys().FindByObjectId(objectid); ok {
+					break
+				}
+			}
+			if ok {
+				same, ignore := reload.sameServiceDate(&vehicleJourney.ServiceDate, &reference.ServiceDate)
+				if ignore {
+					logger.Log.Debugf("Ignore VehicleJourney %v of service date %v: ObjectID already used", reference.id, reference.ServiceDate.String())
+					reload.ignoredVehicleJourneys[reference.id] = struct{}{}
+					reload.result.Ignored[VEHICLE_JOURNEY]++
+					continue
+				}
+				ok = same
+			}
+		}
+		if !ok {
+			referenceId := reference.id
+			if idUsed {
+				reference.id = ""
+			}
+			reload.tx.Model().VehicleJourneys().Save(reference)
+			reload.vehicleJourneyIds[referenceId] = reference.id
+			reload.loadedIds[ModelId(reference.id)] = struct{}{}
+			reload.count(reload.result.Added, VEHICLE_JOURNEY)
+			continue
+		}
+		reload.vehicleJourneyIds[reference.id] = vehicleJourney.id
+		reload.loadedIds[ModelId(vehicleJourney.id)] = struct{}{}
+
+		if !reload.changed(reload.vehicleJourneyAttributes(&vehicleJourney), reload.vehicleJourneyAttributes(reference)) {
+			continue
+		}
+		vehicleJourney.ServiceDate = reference.ServiceDate
+		vehicleJourney.Name = reference.Name
+		vehicleJourney.LineId = reference.LineId
+		vehicleJourney.OriginName = reference.OriginName
+		vehicleJourney.DestinationName = reference.DestinationName
+		vehicleJourney.Attributes = reference.Attributes
+		vehicleJourney.References = reference.References
+		for _, objectid := range reference.ObjectIDs() {
+			vehicleJourney.SetObjectID(objectid)
+		}
+		reload.tx.Model().VehicleJourneys().Save(&vehicleJourney)
+		reload.count(reload.result.Changed, VEHICLE_JOURNEY)
+	}
+}
+
+type stopVisitLoadedAttributes struct {
+	ServiceDate      Date
+	StopAreaId       StopAreaId
+	VehicleJourneyId VehicleJourneyId
+	PassageOrder     int
+	AimedArrival     string
+	AimedDeparture   string
+	Attributes       Attributes
+	References       map[string]string
+	ObjectIDs        map[string]string
+}
+
+func (reload *incrementalReload) stopVisitAttributes(stopVisit *StopVisit) *stopVisitLoadedAttributes {
+	aimed := stopVisit.Schedules.Schedule(STOP_VISIT_SCHEDULE_AIMED)
+	return &stopVisitLoadedAttributes{
+		ServiceDate:      stopVisit.ServiceDate,
+		StopAreaId:       stopVisit.StopAreaId,
+		VehicleJourneyId: stopVisit.VehicleJourneyId,
+		PassageOrder:     stopVisit.PassageOrder,
+		AimedArrival:     aimed.ArrivalTime().String(),
+		AimedDeparture:   aimed.DepartureTime().String(),
+		Attributes:       attributesOrEmpty(stopVisit.Attributes),
+		References:       stopVisit.References.GetSiriReferences(),
+		ObjectIDs:        stopVisit.ObjectIDsResponse(),
+	}
+}
+
+func (reload *incrementalReload) reloadStopVisits() {
+	stopVisits := reload.reference.stopVisits.FindAll()
+	for i := range stopVisits {
+		reference := &stopVisits[i]
+		if _, ok := reload.ignoredVehicleJourneys[reference.VehicleJourneyId]; ok {
+			reload.result.Ignored[STOP_VISIT]++
+			continue
+		}
+		reference.StopAreaId = reload.stopAreaId(reference.StopAreaId)
+		reference.VehicleJourneyId = reload.vehicleJourneyId(reference.VehicleJourneyId)
+
+		stopVisit, ok := reload.tx.Model().StopVisits().Find(reference.id)
+		idUsed := false
+		if ok {
+			// The database id can be used by the StopVisit of another service date
+			ok, _ = reload.sameServiceDate(&stopVisit.ServiceDate, &reference.ServiceDate)
+			idUsed = !ok
+		}
+		if !ok {
+			for _, objectid := range reference.ObjectIDs() {
+				if stopVisit, ok = reload.tx.Model().StopVisits().FindByObjectId(objectid); ok {
+					break
+				}
+			}
+			if ok {
+				same, ignore := reload.sameServiceDate(&stopVisit.ServiceDate, &reference.ServiceDate)
+				if ignore {
+					logger.Log.Debugf("Ignore StopVisit %v of service date %v: ObjectID already used", reference.id, reference.ServiceDate.String())
+					reload.result.Ignored[STOP_VISIT]++
+					continue
+				}
+				ok = same
+			}
+		}
+		if !ok {
+			if idUsed {
+				reference.id = ""
+			}
+			reload.tx.Model().StopVisits().Save(reference)
+			reload.loadedIds[ModelId(reference.id)] = struct{}{}
+			reload.count(reload.result.Added, STOP_VISIT)
+			continue
+		}
+		reload.loadedIds[ModelId(stopVisit.id)] = struct{}{}
+
+		if !reload.changed(reload.stopVisitAttributes(&stopVisit), reload.stopVisitAttributes(reference)) {
+			continue
+		}
+		// Only the aimed schedules are loaded, the collected ones are kept
+		aimed := reference.Schedules.Schedule(STOP_VISIT_SCHEDULE_AIMED)
+		stopVisit.Schedules.SetSchedule(STOP_VISIT_SCHEDULE_AIMED, aimed.DepartureTime(), aimed.ArrivalTime())
+		stopVisit.ServiceDate = reference.ServiceDate
+		stopVisit.StopAreaId = reference.StopAreaId
+		stopVisit.VehicleJourneyId = reference.VehicleJourneyId
+		stopVisit.PassageOrder = reference.PassageOrder
+		stopVisit.Attributes = reference.Attributes
+		stopVisit.References = reference.References
+		for _, objectid := range reference.ObjectIDs() {
+			stopVisit.SetObjectID(objectid)
+		}
+		reload.tx.Model().StopVisits().Save(&stopVisit)
+		reload.count(reload.result.Changed, STOP_VISIT)
+	}
+}
+
+// Removes the models of the previous load which aren't loaded anymore
+func (reload *incrementalReload) removeMissingModels() {
+	missing := func(id ModelId) bool {
+		return reload.previousIds.include(id) && !reload.loadedIds.include(id)
+	}
+
+	stopVisits := reload.tx.Model().StopVisits().FindAll()
+	for i := range stopVisits {
+		if missing(ModelId(stopVisits[i].id)) {
+			reload.tx.Model().StopVisits().Delete(&stopVisits[i])
+			reload.count(reload.result.Removed, STOP_VISIT)
+		}
+	}
+	vehicleJourneys := reload.tx.Model().VehicleJourneys().FindAll()
+	for i := range vehicleJourneys {
+		if missing(ModelId(vehicleJourneys[i].id)) {
+			reload.tx.Model().VehicleJourneys().Delete(&vehicleJourneys[i])
+			reload.count(reload.result.Removed, VEHICLE_JOURNEY)
+		}
+	}
+	lines := reload.tx.Model().Lines().FindAll()
+	for i := range lines {
+		if missing(ModelId(lines[i].id)) {
+			reload.tx.Model().Lines().Delete(&lines[i])
+			reload.count(reload.result.Removed, LINE)
+		}
+	}
+	operators := reload.tx.Model().Operators().FindAll()
+	for i := range operators {
+		if missing(ModelId(operators[i].id)) {
+			reload.tx.Model().Operators().Delete(&operators[i])
+			reload.count(reload.result.Removed, OPERATOR)
+		}
+	}
+	stopAreas := reload.tx.Model().StopAreas().FindAll()
+	for i := range stopAreas {
+		if missing(ModelId(stopAreas[i].id)) {
+			reload.tx.Model().StopAreas().Delete(&stopAreas[i])
+			reload.count(reload.result.Removed, STOP_AREA)
+		}
+	}
+}
+
+// Removes the VehicleJourneys and StopVisits which have never been loaded from
+// the database and are older than the first service date loaded. Without
+// service date, a StopVisit is removed when its schedules end before this
+// date, and a VehicleJourney when all its StopVisits are removed
+func (reload *incrementalReload) removePastModels(firstDate Date) {
+	collected := func(id ModelId) bool {
+		return !reload.previousIds.include(id) && !reload.loadedIds.include(id)
+	}
+	past := func(serviceDate *Date, reference time.Time) bool {
+		if !serviceDate.IsZero() {
+			return serviceDate.Before(firstDate)
+		}
+		return !reference.IsZero() && reference.Before(firstDate.StartOfDay(reference.Location()))
+	}
+
+	// Number of StopVisits kept by VehicleJourney
+	kept := make(map[VehicleJourneyId]int)
+	emptied := make(map[VehicleJourneyId]struct{})
+	stopVisits := reload.tx.Model().StopVisits().FindAll()
+	for i := range stopVisits {
+		stopVisit := &stopVisits[i]
+		id := ModelId(stopVisit.id)
+		switch {
+		case !collected(id):
+			if reload.loadedIds.include(id) {
+				kept[stopVisit.VehicleJourneyId]++
+			}
+		case past(&stopVisit.ServiceDate, stopVisit.ReferenceTime()):
+			reload.tx.Model().StopVisits().Delete(stopVisit)
+			reload.count(reload.result.Removed, STOP_VISIT)
+			emptied[stopVisit.VehicleJourneyId] = struct{}{}
+		default:
+			kept[stopVisit.VehicleJourneyId]++
+		}
+	}
+
+	vehicleJourneys := reload.tx.Model().VehicleJourneys().FindAll()
+	for i := range vehicleJourneys {
+		vehicleJourney := &vehicleJourneys[i]
+		if !collected(ModelId(vehicleJourney.id)) {
+			continue
+		}
+		if vehicleJourney.ServiceDate.IsZero() {
+			if _, ok := emptied[vehicleJourney.id]; !ok || kept[vehicleJourney.id] != 0 {
+				continue
+			}
+		} else if !vehicleJourney.ServiceDate.Before(firstDate) {
+			continue
+		}
+		reload.tx.Model().VehicleJourneys().Delete(vehicleJourney)
+		reload.count(reload.result.Removed, VEHICLE_JOURNEY)
+	}
+}
+
+func (reload *incrementalReload) stopAreaId(id StopAreaId) StopAreaId {
+	if liveId, ok := reload.stopAreaIds[id]; ok {
+		return liveId
+	}
+	return id
+}
+
+func (reload *incrementalReload) lineId(id LineId) LineId {
+	if liveId, ok := reload.lineIds[id]; ok {
+		return liveId
+	}
+	return id
+}
+
+func (reload *incrementalReload) vehicleJourneyId(id VehicleJourneyId) VehicleJourneyId {
+	if liveId, ok := reload.vehicleJourneyIds[id]; ok {
+		return liveId
+	}
+	return id
+}
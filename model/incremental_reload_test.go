@@ -0,0 +1,274 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_MemoryModel_ApplyReload(t *testing.T) {
+	model := NewMemoryModel()
+
+	stopArea := model.StopAreas().New()
+	stopArea.SetObjectID(NewObjectID("internal", "stopArea"))
+	stopArea.Name = "Old name"
+	stopArea.Save()
+
+	line := model.Lines().New()
+	line.SetObjectID(NewObjectID("internal", "line"))
+	line.Name = "Line"
+	line.Save()
+
+	removedLine := model.Lines().New()
+	removedLine.SetObjectID(NewObjectID("internal", "removed"))
+	removedLine.Save()
+
+	vehicleJourney := model.VehicleJourneys().New()
+	vehicleJourney.SetObjectID(NewObjectID("internal", "vehicleJourney"))
+	vehicleJourney.LineId = line.Id()
+	vehicleJourney.Save()
+
+	stopVisit := model.StopVisits().New()
+	stopVisit.SetObjectID(NewObjectID("internal", "stopVisit"))
+	stopVisit.StopAreaId = stopArea.Id()
+	stopVisit.VehicleJourneyId = vehicleJourney.Id()
+	stopVisit.PassageOrder = 1
+	stopVisit.Save()
+
+	model.rememberLoadedModels()
+
+	// Real-time state and collected models
+	expected := time.Date(2017, time.January, 1, 12, 5, 0, 0, time.UTC)
+	stopVisit.Schedules.SetArrivalTime(STOP_VISIT_SCHEDULE_EXPECTED, expected)
+	stopVisit.Save()
+
+	collectedLine := model.Lines().New()
+	collectedLine.SetObjectID(NewObjectID("partner", "collected"))
+	collectedLine.Save()
+
+	// The new day uses other ids
+	reference := NewMemoryModel()
+	reference.date = Date{Year: 2017, Month: time.January, Day: 2}
+
+	newStopArea := reference.StopAreas().New()
+	newStopArea.SetObjectID(NewObjectID("internal", "stopArea"))
+	newStopArea.Name = "New name"
+	newStopArea.Save()
+
+	newLine := reference.Lines().New()
+	newLine.SetObjectID(NewObjectID("internal", "line"))
+	newLine.Name = "Line"
+	newLine.Save()
+
+	newVehicleJourney := reference.VehicleJourneys().New()
+	newVehicleJourney.SetObjectID(NewObjectID("internal", "vehicleJourney"))
+	newVehicleJourney.LineId = newLine.Id()
+	newVehicleJourney.Save()
+
+	addedVehicleJourney := reference.VehicleJourneys().New()
+	addedVehicleJourney.SetObjectID(NewObjectID("internal", "added"))
+	addedVehicleJourney.LineId = newLine.Id()
+	addedVehicleJourney.Save()
+
+	newStopVisit := reference.StopVisits().New()
+	newStopVisit.SetObjectID(NewObjectID("internal", "stopVisit"))
+	newStopVisit.StopAreaId = newStopArea.Id()
+	newStopVisit.VehicleJourneyId = newVehicleJourney.Id()
+	newStopVisit.PassageOrder = 1
+	newStopVisit.Save()
+
+	result := model.ApplyReload(reference)
+
+	checks := []struct {
+		name     string
+		counter  map[string]int
+		klass    string
+		expected int
+	}{
+		{"Changed StopAreas", result.Changed, STOP_AREA, 1},
+		{"Changed Lines", result.Changed, LINE, 0},
+		{"Removed Lines", result.Removed, LINE, 1},
+		{"Added VehicleJourneys", result.Added, VEHICLE_JOURNEY, 1},
+		{"Changed VehicleJourneys", result.Changed, VEHICLE_JOURNEY, 0},
+		{"Changed StopVisits", result.Changed, STOP_VISIT, 0},
+	}
+	for _, check := range checks {
+		if got := check.counter[check.klass]; got != check.expected {
+			t.Errorf("Wrong %v:\n got: %v\n want: %v", check.name, got, check.expected)
+		}
+	}
+
+	if model.Date() != reference.date {
+		t.Errorf("Model Date should be updated:\n got: %v\n want: %v", model.Date(), reference.date)
+	}
+
+	updatedStopArea, ok := model.StopAreas().Find(stopArea.Id())
+	if !ok {
+		t.Fatalf("StopArea should keep its id")
+	}
+	if updatedStopArea.Name != "New name" {
+		t.Errorf("Wrong StopArea Name:\n got: %v\n want: New name", updatedStopArea.Name)
+	}
+
+	updatedStopVisit, ok := model.StopVisits().Find(stopVisit.Id())
+	if !ok {
+		t.Fatalf("StopVisit should keep its id")
+	}
+	if updatedStopVisit.StopAreaId != stopArea.Id() || updatedStopVisit.VehicleJourneyId != vehicleJourney.Id() {
+		t.Errorf("StopVisit should reference live models: %v %v", updatedStopVisit.StopAreaId, updatedStopVisit.VehicleJourneyId)
+	}
+	if got := updatedStopVisit.Schedules.Schedule(STOP_VISIT_SCHEDULE_EXPECTED).ArrivalTime(); !got.Equal(expected) {
+		t.Errorf("StopVisit should keep its expected schedule:\n got: %v\n want: %v", got, expected)
+	}
+
+	added, ok := model.VehicleJourneys().FindByObjectId(NewObjectID("internal", "added"))
+	if !ok {
+		t.Fatalf("Added VehicleJourney should be found")
+	}
+	if added.LineId != line.Id() {
+		t.Errorf("Added VehicleJourney should reference the live Line:\n got: %v\n want: %v", added.LineId, line.Id())
+	}
+
+	if _, ok := model.Lines().Find(removedLine.Id()); ok {
+		t.Errorf("Line missing in the new load should be removed")
+	}
+	if _, ok := model.Lines().Find(collectedLine.Id()); !ok {
+		t.Errorf("Collected Line should be kept")
+	}
+}
+
+func Test_MemoryModel_ServiceDates(t *testing.T) {
+	model := NewMemoryModel()
+	model.date = Date{Year: 2017, Month: time.January, Day: 1}
+	model.SetServiceDays(1, 1)
+
+	expected := []Date{
+		{Year: 2016, Month: time.December, Day: 31},
+		{Year: 2017, Month: time.January, Day: 1},
+		{Year: 2017, Month: time.January, Day: 2},
+	}
+	if dates := model.ServiceDates(); !reflect.DeepEqual(dates, expected) {
+		t.Errorf("Wrong ServiceDates:\n got: %v\n want: %v", dates, expected)
+	}
+}
+
+func Test_MemoryModel_ApplyReload_ServiceDates(t *testing.T) {
+	model := NewMemoryModel()
+	today := Date{Year: 2017, Month: time.January, Day: 2}
+	yesterday := today.AddDays(-1)
+
+	// The database ids are shared by the models of the different service dates
+	newReference := func(date Date, vehicleJourneyObjectIds ...string) *MemoryModel {
+		reference := NewMemoryModel()
+		reference.date = date
+		for i, objectid := range vehicleJourneyObjectIds {
+			vehicleJourney := reference.VehicleJourneys().New()
+			vehicleJourney.id = VehicleJourneyId(fmt.Sprintf("vj-%v", i))
+			vehicleJourney.SetObjectID(NewObjectID("internal", objectid))
+			vehicleJourney.ServiceDate = date
+			vehicleJourney.Save()
+
+			stopVisit := reference.StopVisits().New()
+			stopVisit.id = StopVisitId(fmt.Sprintf("sv-%v", i))
+			stopVisit.SetObjectID(NewObjectID("internal", objectid+"-1"))
+			stopVisit.VehicleJourneyId = vehicleJourney.Id()
+			stopVisit.ServiceDate = date
+			stopVisit.Save()
+		}
+		return reference
+	}
+
+	// The night service of yesterday uses the same database id than the daily
+	// service of today, the daily one of yesterday uses the same ObjectID than today
+	result := model.ApplyReload(newReference(today, "daily"), newReference(yesterday, "night", "daily"))
+
+	if result.Added[VEHICLE_JOURNEY] != 2 || result.Added[STOP_VISIT] != 2 {
+		t.Errorf("Wrong added models:\n got: %v\n want: 2 VehicleJourneys and 2 StopVisits", result.Added)
+	}
+	if result.Ignored[VEHICLE_JOURNEY] != 1 || result.Ignored[STOP_VISIT] != 1 {
+		t.Errorf("Wrong ignored models:\n got: %v\n want: 1 VehicleJourney and 1 StopVisit", result.Ignored)
+	}
+
+	daily, ok := model.VehicleJourneys().FindByObjectId(NewObjectID("internal", "daily"))
+	if !ok || daily.ServiceDate != today {
+		t.Errorf("Daily VehicleJourney should be the one of the model date: %v", daily.ServiceDate)
+	}
+	night, ok := model.VehicleJourneys().FindByObjectId(NewObjectID("internal", "night"))
+	if !ok || night.ServiceDate != yesterday {
+		t.Errorf("Night VehicleJourney should be the one of the previous day: %v", night.ServiceDate)
+	}
+	stopVisit, ok := model.StopVisits().FindByObjectId(NewObjectID("internal", "night-1"))
+	if !ok || stopVisit.VehicleJourneyId != night.Id() || stopVisit.ServiceDate != yesterday {
+		t.Errorf("Night StopVisit should be loaded with its service date")
+	}
+	if daily.Id() == night.Id() {
+		t.Errorf("Night VehicleJourney should not replace the daily one of the same database id")
+	}
+	if stopVisit, ok := model.StopVisits().FindByObjectId(NewObjectID("internal", "daily-1")); !ok || stopVisit.ServiceDate != today || stopVisit.VehicleJourneyId != daily.Id() {
+		t.Errorf("Daily StopVisit should be the one of the model date")
+	}
+
+	// A new reload with the same references doesn't change anything
+	result = model.ApplyReload(newReference(today, "daily"), newReference(yesterday, "night", "daily"))
+	if result.Added[VEHICLE_JOURNEY] != 0 || result.Changed[VEHICLE_JOURNEY] != 0 || result.Removed[VEHICLE_JOURNEY] != 0 || result.Changed[STOP_VISIT] != 0 {
+		t.Errorf("Wrong reload result: %v", result)
+	}
+
+	// The next day, the daily VehicleJourney of the previous model date is replaced
+	tomorrow := today.AddDays(1)
+	result = model.ApplyReload(newReference(tomorrow, "daily"))
+	if result.Added[VEHICLE_JOURNEY] != 1 || result.Removed[VEHICLE_JOURNEY] != 2 {
+		t.Errorf("Wrong reload result: %v", result)
+	}
+	if daily, _ := model.VehicleJourneys().FindByObjectId(NewObjectID("internal", "daily")); daily.ServiceDate != tomorrow {
+		t.Errorf("Daily VehicleJourney should be the one of the new model date: %v", daily.ServiceDate)
+	}
+}
+
+func Test_MemoryModel_ApplyReload_PastCollectedModels(t *testing.T) {
+	model := NewMemoryModel()
+	today := Date{Year: 2017, Month: time.January, Day: 2}
+
+	// Collected models, never loaded from the database
+	pastVehicleJourney := model.VehicleJourneys().New()
+	pastVehicleJourney.Save()
+	pastStopVisit := model.StopVisits().New()
+	pastStopVisit.VehicleJourneyId = pastVehicleJourney.Id()
+	pastStopVisit.Schedules.SetArrivalTime(STOP_VISIT_SCHEDULE_EXPECTED, time.Date(2017, time.January, 1, 23, 0, 0, 0, time.UTC))
+	pastStopVisit.Save()
+
+	currentVehicleJourney := model.VehicleJourneys().New()
+	currentVehicleJourney.Save()
+	currentStopVisit := model.StopVisits().New()
+	currentStopVisit.VehicleJourneyId = currentVehicleJourney.Id()
+	currentStopVisit.Schedules.SetArrivalTime(STOP_VISIT_SCHEDULE_EXPECTED, time.Date(2017, time.January, 2, 8, 0, 0, 0, time.UTC))
+	currentStopVisit.Save()
+
+	datedVehicleJourney := model.VehicleJourneys().New()
+	datedVehicleJourney.ServiceDate = today.AddDays(-1)
+	datedVehicleJourney.Save()
+
+	reference := NewMemoryModel()
+	reference.date = today
+	result := model.ApplyReload(reference)
+
+	if result.Removed[VEHICLE_JOURNEY] != 2 || result.Removed[STOP_VISIT] != 1 {
+		t.Errorf("Wrong removed models:\n got: %v\n want: 2 VehicleJourneys and 1 StopVisit", result.Removed)
+	}
+	if _, ok := model.StopVisits().Find(pastStopVisit.Id()); ok {
+		t.Errorf("Past collected StopVisit should be removed")
+	}
+	if _, ok := model.VehicleJourneys().Find(pastVehicleJourney.Id()); ok {
+		t.Errorf("VehicleJourney without StopVisit should be removed")
+	}
+	if _, ok := model.VehicleJourneys().Find(datedVehicleJourney.Id()); ok {
+		t.Errorf("VehicleJourney of a past service date should be removed")
+	}
+	if _, ok := model.StopVisits().Find(currentStopVisit.Id()); !ok {
+		t.Errorf("Current collected StopVisit should be kept")
+	}
+	if _, ok := model.VehicleJourneys().Find(currentVehicleJourney.Id()); !ok {
+		t.Errorf("Current collected VehicleJourney should be kept")
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"sync"
+
 	"bitbucket.org/enroute-mobi/ara/clock"
 	"bitbucket.org/enroute-mobi/ara/logger"
 )
@@ -15,6 +17,7 @@ type ModelInstance interface {
 
 type Model interface {
 	Date() Date
+	ServiceDates() []Date
 	Referential() string
 	Lines() Lines
 	Situations() Situations
@@ -23,12 +26,20 @@ type Model interface {
 	VehicleJourneys() VehicleJourneys
 	Operators() Operators
 	Vehicles() Vehicles
+	Facilities() Facilities
 }
 
 type MemoryModel struct {
+	// Protects the model date and the service days, changed by the reloads
+	mutex *sync.RWMutex
+
 	date        Date
 	referential string
 
+	// Number of service dates loaded before and after the model date
+	previousServiceDays int
+	nextServiceDays     int
+
 	stopAreas       *MemoryStopAreas
 	stopVisits      *MemoryStopVisits
 	vehicleJourneys *MemoryVehicleJourneys
@@ -36,15 +47,21 @@ type MemoryModel struct {
 	vehicles        *MemoryVehicles
 	situations      *MemorySituations
 	operators       *MemoryOperators
+	facilities      *MemoryFacilities
+
+	// Models loaded from the database, see IncrementalReload
+	loadedIds loadedIds
 
 	SMEventsChan chan StopMonitoringBroadcastEvent
 	GMEventsChan chan GeneralMessageBroadcastEvent
+	FMEventsChan chan FacilityBroadcastEvent
 }
 
 // Optionnal argument for tests
 func NewMemoryModel(referential ...string) *MemoryModel {
 	model := &MemoryModel{
-		date: NewDate(clock.DefaultClock().Now()),
+		mutex: &sync.RWMutex{},
+		date:  NewDate(clock.DefaultClock().Now()),
 	}
 
 	if len(referential) != 0 {
@@ -82,6 +99,11 @@ func NewMemoryModel(referential ...string) *MemoryModel {
 	vehicles.model = model
 	model.vehicles = vehicles
 
+	facilities := NewMemoryFacilities()
+	facilities.model = model
+	model.facilities = facilities
+	model.facilities.broadcastEvent = model.broadcastFMEvent
+
 	return model
 }
 
@@ -93,6 +115,10 @@ func (model *MemoryModel) SetBroadcastGMChan(broadcastGMEventChan chan GeneralMe
 	model.GMEventsChan = broadcastGMEventChan
 }
 
+func (model *MemoryModel) SetBroadcastFMChan(broadcastFMEventChan chan FacilityBroadcastEvent) {
+	model.FMEventsChan = broadcastFMEventChan
+}
+
 func (model *MemoryModel) Referential() string {
 	return model.referential
 }
@@ -117,28 +143,77 @@ func (model *MemoryModel) broadcastGMEvent(event GeneralMessageBroadcastEvent) {
 	}
 }
 
-func (model *MemoryModel) Reload(referentialSlug string) *MemoryModel {
-	model = NewMemoryModel()
-	model.date = NewDate(clock.DefaultClock().Now())
-	model.stopAreas.Load(referentialSlug)
-	model.lines.Load(referentialSlug)
-	model.operators.Load(referentialSlug)
-	model.vehicleJourneys.Load(referentialSlug)
-	return model
+func (model *MemoryModel) broadcastFMEvent(event FacilityBroadcastEvent) {
+	select {
+	case model.FMEventsChan <- event:
+	default:
+		logger.Log.Debugf("BrocasterManager FacilityBroadcastEvent queue is full")
+	}
+}
+
+// Returns a new model loaded from the database, with the same service dates
+// than IncrementalReload. The collected Facilities are kept, since they aren't
+// saved in the database.
+func (model *MemoryModel) Reload(referentialSlug string, referentialClock clock.Clock) *MemoryModel {
+	model.mutex.RLock()
+	previousServiceDays, nextServiceDays := model.previousServiceDays, model.nextServiceDays
+	model.mutex.RUnlock()
+
+	reloaded := NewMemoryModel()
+	reloaded.SetServiceDays(previousServiceDays, nextServiceDays)
+	if _, err := reloaded.IncrementalReload(referentialSlug, referentialClock); err != nil {
+		logger.Log.Debugf("Error while loading model: %v", err)
+	}
+	reloaded.facilities = model.facilities.Clone(reloaded)
+	return reloaded
 }
 
 func (model *MemoryModel) Clone() *MemoryModel {
 	clone := NewMemoryModel()
 	clone.stopAreas = model.stopAreas.Clone(clone)
 	clone.lines = model.lines.Clone(clone)
+	clone.facilities = model.facilities.Clone(clone)
 	clone.date = NewDate(clock.DefaultClock().Now())
 	return clone
 }
 
 func (model *MemoryModel) Date() Date {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
 	return model.date
 }
 
+func (model *MemoryModel) setDate(date Date) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	model.date = date
+}
+
+func (model *MemoryModel) SetServiceDays(previous, next int) {
+	model.mutex.Lock()
+	defer model.mutex.Unlock()
+
+	model.previousServiceDays = previous
+	model.nextServiceDays = next
+}
+
+// Returns the service dates loaded in the model, around the model date
+func (model *MemoryModel) ServiceDates() []Date {
+	return model.serviceDatesAround(model.Date())
+}
+
+func (model *MemoryModel) serviceDatesAround(date Date) (dates []Date) {
+	model.mutex.RLock()
+	defer model.mutex.RUnlock()
+
+	for days := -model.previousServiceDays; days <= model.nextServiceDays; days++ {
+		dates = append(dates, date.AddDays(days))
+	}
+	return
+}
+
 func (model *MemoryModel) Situations() Situations {
 	return model.situations
 }
@@ -167,6 +242,10 @@ func (model *MemoryModel) Vehicles() Vehicles {
 	return model.vehicles
 }
 
+func (model *MemoryModel) Facilities() Facilities {
+	return model.facilities
+}
+
 func (model *MemoryModel) NewTransaction() *Transaction {
 	return NewTransaction(model)
 }
@@ -193,5 +272,7 @@ func (model *MemoryModel) Load(referentialSlug string) error {
 	if err != nil {
 		logger.Log.Debugf("Error while loading Operators: %v", err)
 	}
+	model.rememberLoadedModels()
+	model.loadServiceDates(referentialSlug)
 	return nil
 }
@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"bitbucket.org/enroute-mobi/ara/uuid"
 )
@@ -80,6 +81,7 @@ type MemoryOperators struct {
 
 	model *MemoryModel
 
+	mutex        *sync.RWMutex
 	byIdentifier map[OperatorId]*Operator
 }
 
@@ -96,6 +98,7 @@ type Operators interface {
 
 func NewMemoryOperators() *MemoryOperators {
 	return &MemoryOperators{
+		mutex:        &sync.RWMutex{},
 		byIdentifier: make(map[OperatorId]*Operator),
 	}
 }
@@ -106,6 +109,9 @@ func (manager *MemoryOperators) New() Operator {
 }
 
 func (manager *MemoryOperators) Find(id OperatorId) (Operator, bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
 	operator, ok := manager.byIdentifier[id]
 	if ok {
 		return *operator, true
@@ -115,6 +121,9 @@ func (manager *MemoryOperators) Find(id OperatorId) (Operator, bool) {
 }
 
 func (manager *MemoryOperators) FindAll() (operators []Operator) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
 	if len(manager.byIdentifier) == 0 {
 		return []Operator{}
 	}
@@ -125,6 +134,9 @@ func (manager *MemoryOperators) FindAll() (operators []Operator) {
 }
 
 func (manager *MemoryOperators) FindByObjectId(objectid ObjectID) (Operator, bool) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
 	for _, operator := range manager.byIdentifier {
 		operatorObjectId, _ := operator.ObjectID(objectid.Kind())
 		if operatorObjectId.Value() == objectid.Value() {
@@ -135,6 +147,9 @@ func (manager *MemoryOperators) FindByObjectId(objectid ObjectID) (Operator, boo
 }
 
 func (manager *MemoryOperators) Save(operator *Operator) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
 	if operator.Id() == "" {
 		operator.id = OperatorId(manager.NewUUID())
 	}
@@ -144,6 +159,9 @@ func (manager *MemoryOperators) Save(operator *Operator) bool {
 }
 
 func (manager *MemoryOperators) Delete(operator *Operator) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
 	delete(manager.byIdentifier, operator.Id())
 	return true
 }
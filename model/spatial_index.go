@@ -0,0 +1,193 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	EARTH_RADIUS = 6371000.0 // meters
+
+	// Size of a SpatialIndex cell in degrees (about 1km in latitude)
+	SPATIAL_INDEX_CELL_SIZE = 0.01
+	// Beyond this number of cells, a query scans all the indexed locations
+	SPATIAL_INDEX_MAXIMUM_CELLS = 10000
+)
+
+type LocationExtractor func(ModelInstance) (latitude float64, longitude float64, ok bool)
+
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+func NewBoundingBox(latitude1, longitude1, latitude2, longitude2 float64) BoundingBox {
+	return BoundingBox{
+		MinLatitude:  math.Min(latitude1, latitude2),
+		MinLongitude: math.Min(longitude1, longitude2),
+		MaxLatitude:  math.Max(latitude1, latitude2),
+		MaxLongitude: math.Max(longitude1, longitude2),
+	}
+}
+
+func (box BoundingBox) Contains(latitude, longitude float64) bool {
+	return latitude >= box.MinLatitude && latitude <= box.MaxLatitude &&
+		longitude >= box.MinLongitude && longitude <= box.MaxLongitude
+}
+
+type location struct {
+	latitude  float64
+	longitude float64
+}
+
+type spatialCell struct {
+	latitude  int
+	longitude int
+}
+
+// Returns the great-circle distance in meters between two points
+func Distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	phi1 := latitude1 * math.Pi / 180
+	phi2 := latitude2 * math.Pi / 180
+	deltaPhi := (latitude2 - latitude1) * math.Pi / 180
+	deltaLambda := (longitude2 - longitude1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * EARTH_RADIUS * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// SpatialIndex stores model locations in a regular latitude/longitude grid
+type SpatialIndex struct {
+	extractor    LocationExtractor
+	byCell       map[spatialCell][]ModelId
+	byIdentifier map[ModelId]location
+}
+
+func NewSpatialIndex(extractor LocationExtractor) *SpatialIndex {
+	return &SpatialIndex{
+		extractor:    extractor,
+		byCell:       make(map[spatialCell][]ModelId),
+		byIdentifier: make(map[ModelId]location),
+	}
+}
+
+func cellOf(latitude, longitude float64) spatialCell {
+	return spatialCell{
+		latitude:  int(math.Floor(latitude / SPATIAL_INDEX_CELL_SIZE)),
+		longitude: int(math.Floor(longitude / SPATIAL_INDEX_CELL_SIZE)),
+	}
+}
+
+func (index *SpatialIndex) Index(model ModelInstance) {
+	modelId := model.modelId()
+	latitude, longitude, ok := index.extractor(model)
+	if !ok {
+		index.Delete(modelId)
+		return
+	}
+
+	newLocation := location{latitude: latitude, longitude: longitude}
+	currentLocation, ok := index.byIdentifier[modelId]
+	if ok {
+		if currentLocation == newLocation {
+			return
+		}
+		index.removeFromCell(cellOf(currentLocation.latitude, currentLocation.longitude), modelId)
+	}
+
+	cell := cellOf(latitude, longitude)
+	index.byCell[cell] = append(index.byCell[cell], modelId)
+	index.byIdentifier[modelId] = newLocation
+}
+
+func (index *SpatialIndex) Delete(modelId ModelId) {
+	currentLocation, ok := index.byIdentifier[modelId]
+	if !ok {
+		return
+	}
+
+	index.removeFromCell(cellOf(currentLocation.latitude, currentLocation.longitude), modelId)
+	delete(index.byIdentifier, modelId)
+}
+
+func (index *SpatialIndex) removeFromCell(cell spatialCell, modelId ModelId) {
+	for i, indexedModelId := range index.byCell[cell] {
+		if indexedModelId == modelId {
+			index.byCell[cell] = append(index.byCell[cell][:i], index.byCell[cell][i+1:]...)
+			if len(index.byCell[cell]) == 0 {
+				delete(index.byCell, cell)
+			}
+			return
+		}
+	}
+}
+
+// Returns the ModelIds located in the given BoundingBox
+func (index *SpatialIndex) FindInBoundingBox(box BoundingBox) (modelIds []ModelId) {
+	index.eachCandidate(box, func(modelId ModelId, l location) {
+		if box.Contains(l.latitude, l.longitude) {
+			modelIds = append(modelIds, modelId)
+		}
+	})
+	return
+}
+
+// Returns the ModelIds located at less than radius meters of the given point,
+// ordered by distance
+func (index *SpatialIndex) FindNear(latitude, longitude, radius float64) []ModelId {
+	latitudeDelta := radius / EARTH_RADIUS * 180 / math.Pi
+	longitudeDelta := 180.0
+	if cos := math.Cos(latitude * math.Pi / 180); cos > 0 {
+		longitudeDelta = math.Min(latitudeDelta/cos, 180)
+	}
+	box := NewBoundingBox(latitude-latitudeDelta, longitude-longitudeDelta, latitude+latitudeDelta, longitude+longitudeDelta)
+
+	type candidate struct {
+		modelId  ModelId
+		distance float64
+	}
+	var candidates []candidate
+	index.eachCandidate(box, func(modelId ModelId, l location) {
+		distance := Distance(latitude, longitude, l.latitude, l.longitude)
+		if distance <= radius {
+			candidates = append(candidates, candidate{modelId: modelId, distance: distance})
+		}
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance == candidates[j].distance {
+			return candidates[i].modelId < candidates[j].modelId
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	modelIds := make([]ModelId, len(candidates))
+	for i := range candidates {
+		modelIds[i] = candidates[i].modelId
+	}
+	return modelIds
+}
+
+func (index *SpatialIndex) eachCandidate(box BoundingBox, f func(ModelId, location)) {
+	min := cellOf(box.MinLatitude, box.MinLongitude)
+	max := cellOf(box.MaxLatitude, box.MaxLongitude)
+
+	cellCount := (max.latitude - min.latitude + 1) * (max.longitude - min.longitude + 1)
+	if cellCount > SPATIAL_INDEX_MAXIMUM_CELLS || cellCount > len(index.byCell) {
+		for modelId, l := range index.byIdentifier {
+			f(modelId, l)
+		}
+		return
+	}
+
+	for lat := min.latitude; lat <= max.latitude; lat++ {
+		for lon := min.longitude; lon <= max.longitude; lon++ {
+			for _, modelId := range index.byCell[spatialCell{latitude: lat, longitude: lon}] {
+				f(modelId, index.byIdentifier[modelId])
+			}
+		}
+	}
+}
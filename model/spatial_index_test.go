@@ -0,0 +1,84 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Distance(t *testing.T) {
+	// Paris Notre-Dame -> Paris Tour Eiffel
+	distance := Distance(48.8530, 2.3498, 48.8584, 2.2945)
+	if math.Abs(distance-4100) > 100 {
+		t.Errorf("Wrong distance:\n got: %v\n want: ~4100", distance)
+	}
+
+	if distance := Distance(48.8530, 2.3498, 48.8530, 2.3498); distance != 0 {
+		t.Errorf("Wrong distance for the same point:\n got: %v\n want: 0", distance)
+	}
+}
+
+func Test_SpatialIndex_FindNear(t *testing.T) {
+	index := NewSpatialIndex(extractStopAreaLocation)
+
+	near := &StopArea{id: "near", Latitude: 48.8530, Longitude: 2.3498}
+	nearest := &StopArea{id: "nearest", Latitude: 48.8531, Longitude: 2.3499}
+	far := &StopArea{id: "far", Latitude: 48.8584, Longitude: 2.2945}
+	withoutLocation := &StopArea{id: "withoutLocation"}
+
+	index.Index(near)
+	index.Index(nearest)
+	index.Index(far)
+	index.Index(withoutLocation)
+
+	modelIds := index.FindNear(48.8532, 2.3500, 1000)
+	if len(modelIds) != 2 {
+		t.Fatalf("Wrong number of ModelIds found:\n got: %v\n want: 2", modelIds)
+	}
+	if modelIds[0] != "nearest" || modelIds[1] != "near" {
+		t.Errorf("ModelIds should be ordered by distance:\n got: %v\n want: [nearest near]", modelIds)
+	}
+
+	if modelIds := index.FindNear(48.8532, 2.3500, 10000); len(modelIds) != 3 {
+		t.Errorf("Wrong number of ModelIds found with a large radius:\n got: %v\n want: 3", modelIds)
+	}
+}
+
+func Test_SpatialIndex_Reindex(t *testing.T) {
+	index := NewSpatialIndex(extractStopAreaLocation)
+
+	stopArea := &StopArea{id: "stopArea", Latitude: 48.8530, Longitude: 2.3498}
+	index.Index(stopArea)
+
+	stopArea.Latitude = 45.7640
+	stopArea.Longitude = 4.8357
+	index.Index(stopArea)
+
+	if modelIds := index.FindNear(48.8530, 2.3498, 1000); len(modelIds) != 0 {
+		t.Errorf("StopArea shouldn't be found at its previous location: %v", modelIds)
+	}
+	if modelIds := index.FindNear(45.7640, 4.8357, 1000); len(modelIds) != 1 {
+		t.Errorf("StopArea should be found at its new location: %v", modelIds)
+	}
+
+	index.Delete(stopArea.modelId())
+	if modelIds := index.FindNear(45.7640, 4.8357, 1000); len(modelIds) != 0 {
+		t.Errorf("StopArea shouldn't be found after Delete: %v", modelIds)
+	}
+	if len(index.byCell) != 0 {
+		t.Errorf("Index cells should be empty after Delete: %v", index.byCell)
+	}
+}
+
+func Test_SpatialIndex_FindInBoundingBox(t *testing.T) {
+	index := NewSpatialIndex(extractStopAreaLocation)
+
+	inside := &StopArea{id: "inside", Latitude: 48.85, Longitude: 2.35}
+	outside := &StopArea{id: "outside", Latitude: 48.95, Longitude: 2.35}
+	index.Index(inside)
+	index.Index(outside)
+
+	modelIds := index.FindInBoundingBox(NewBoundingBox(48.90, 2.30, 48.80, 2.40))
+	if len(modelIds) != 1 || modelIds[0] != "inside" {
+		t.Errorf("Wrong ModelIds found in BoundingBox:\n got: %v\n want: [inside]", modelIds)
+	}
+}
@@ -201,6 +201,15 @@ func (stopArea *StopArea) SetPartnerStatus(partner string, status bool) {
 	stopArea.Monitored = stopArea.Origins.Monitored()
 }
 
+func (stopArea *StopArea) HasLocation() bool {
+	return stopArea.Latitude != 0 || stopArea.Longitude != 0
+}
+
+// Returns the distance in meters between the StopArea and the given point
+func (stopArea *StopArea) DistanceTo(latitude, longitude float64) float64 {
+	return Distance(stopArea.Latitude, stopArea.Longitude, latitude, longitude)
+}
+
 func (stopArea *StopArea) Save() (ok bool) {
 	ok = stopArea.model.StopAreas().Save(stopArea)
 	return
@@ -214,6 +223,7 @@ type MemoryStopAreas struct {
 	mutex        *sync.RWMutex
 	byIdentifier map[StopAreaId]*StopArea
 	byObjectId   *ObjectIdIndex
+	byLocation   *SpatialIndex
 
 	broadcastEvent func(event StopMonitoringBroadcastEvent)
 }
@@ -227,6 +237,8 @@ type StopAreas interface {
 	FindByLineId(id LineId) []StopArea
 	FindByOrigin(origin string) []StopAreaId
 	FindAll() []StopArea
+	FindNear(latitude, longitude, radius float64) []StopArea
+	FindInBoundingBox(box BoundingBox) []StopArea
 	FindFamily(id StopAreaId) []StopAreaId
 	FindAscendants(id StopAreaId) (stopAreas []StopArea)
 	FindAscendantsWithObjectIdKind(stopAreaId StopAreaId, kind string) (stopAreaIds []ObjectID)
@@ -239,7 +251,16 @@ func NewMemoryStopAreas() *MemoryStopAreas {
 		mutex:        &sync.RWMutex{},
 		byIdentifier: make(map[StopAreaId]*StopArea),
 		byObjectId:   NewObjectIdIndex(),
+		byLocation:   NewSpatialIndex(extractStopAreaLocation),
+	}
+}
+
+func extractStopAreaLocation(instance ModelInstance) (float64, float64, bool) {
+	stopArea := instance.(*StopArea)
+	if !stopArea.HasLocation() {
+		return 0, 0, false
 	}
+	return stopArea.Latitude, stopArea.Longitude, true
 }
 
 func (manager *MemoryStopAreas) Clone(model *MemoryModel) *MemoryStopAreas {
@@ -322,6 +343,28 @@ func (manager *MemoryStopAreas) FindAll() (stopAreas []StopArea) {
 	return
 }
 
+func (manager *MemoryStopAreas) FindNear(latitude, longitude, radius float64) (stopAreas []StopArea) {
+	manager.mutex.RLock()
+
+	for _, id := range manager.byLocation.FindNear(latitude, longitude, radius) {
+		stopAreas = append(stopAreas, *(manager.byIdentifier[StopAreaId(id)].copy()))
+	}
+
+	manager.mutex.RUnlock()
+	return
+}
+
+func (manager *MemoryStopAreas) FindInBoundingBox(box BoundingBox) (stopAreas []StopArea) {
+	manager.mutex.RLock()
+
+	for _, id := range manager.byLocation.FindInBoundingBox(box) {
+		stopAreas = append(stopAreas, *(manager.byIdentifier[StopAreaId(id)].copy()))
+	}
+
+	manager.mutex.RUnlock()
+	return
+}
+
 func (manager *MemoryStopAreas) Save(stopArea *StopArea) bool {
 	if stopArea.Id() == "" {
 		stopArea.id = StopAreaId(manager.NewUUID())
@@ -332,6 +375,7 @@ func (manager *MemoryStopAreas) Save(stopArea *StopArea) bool {
 	stopArea.model = manager.model
 	manager.byIdentifier[stopArea.Id()] = stopArea
 	manager.byObjectId.Index(stopArea)
+	manager.byLocation.Index(stopArea)
 
 	manager.mutex.Unlock()
 
@@ -353,6 +397,7 @@ func (manager *MemoryStopAreas) Delete(stopArea *StopArea) bool {
 
 	delete(manager.byIdentifier, stopArea.Id())
 	manager.byObjectId.Delete(ModelId(stopArea.id))
+	manager.byLocation.Delete(ModelId(stopArea.id))
 
 	return true
 }
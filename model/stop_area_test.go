@@ -204,6 +204,30 @@ func Test_MemoryStopAreas_FindAll(t *testing.T) {
 	}
 }
 
+func Test_MemoryStopAreas_FindNear(t *testing.T) {
+	stopAreas := NewMemoryStopAreas()
+
+	nearStopArea := stopAreas.New()
+	nearStopArea.Latitude = 48.8530
+	nearStopArea.Longitude = 2.3498
+	stopAreas.Save(&nearStopArea)
+
+	farStopArea := stopAreas.New()
+	farStopArea.Latitude = 48.8584
+	farStopArea.Longitude = 2.2945
+	stopAreas.Save(&farStopArea)
+
+	foundStopAreas := stopAreas.FindNear(48.8532, 2.3500, 500)
+	if len(foundStopAreas) != 1 || foundStopAreas[0].Id() != nearStopArea.Id() {
+		t.Errorf("FindNear should return the near StopArea, got %v", foundStopAreas)
+	}
+
+	stopAreas.Delete(&nearStopArea)
+	if foundStopAreas := stopAreas.FindNear(48.8532, 2.3500, 500); len(foundStopAreas) != 0 {
+		t.Errorf("Deleted StopArea should not be findable by location")
+	}
+}
+
 func Test_MemoryStopAreas_Delete(t *testing.T) {
 	stopAreas := NewMemoryStopAreas()
 	existingStopArea := stopAreas.New()
@@ -29,6 +29,9 @@ type StopVisit struct {
 	collected   bool
 	collectedAt time.Time
 
+	// Operating day of the StopVisit, when loaded from the database
+	ServiceDate Date `json:"-"`
+
 	StopAreaId       StopAreaId       `json:",omitempty"`
 	VehicleJourneyId VehicleJourneyId `json:",omitempty"`
 	Attributes       Attributes
@@ -476,6 +479,7 @@ func (manager *MemoryStopVisits) Load(referentialSlug string) error {
 	for _, sv := range selectStopVisits {
 		stopVisit := manager.New()
 		stopVisit.id = StopVisitId(sv.Id)
+		stopVisit.ServiceDate = modelName
 		if sv.StopAreaId.Valid {
 			stopVisit.StopAreaId = StopAreaId(sv.StopAreaId.String)
 		}
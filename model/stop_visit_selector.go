@@ -55,6 +55,34 @@ func StopVisitSelectorByLine(objectid ObjectID) StopVisitSelector {
 	}
 }
 
+// Selects the StopVisits operated by the given Operator. The OperatorRef of the
+// StopVisit is used, or the one of its VehicleJourney when the StopVisit has
+// none. The Operator ObjectID with the same kind is compared when the Operator
+// is known, the collected OperatorRef otherwise.
+func StopVisitSelectorByOperator(objectid ObjectID) StopVisitSelector {
+	return func(stopVisit StopVisit) bool {
+		reference, ok := stopVisit.Reference("OperatorRef")
+		if !ok || reference.ObjectId == nil {
+			vehicleJourney := stopVisit.VehicleJourney()
+			if vehicleJourney == nil {
+				return false
+			}
+			reference, ok = vehicleJourney.Reference("OperatorRef")
+			if !ok || reference.ObjectId == nil {
+				return false
+			}
+		}
+
+		operator, ok := stopVisit.model.Operators().FindByObjectId(*reference.ObjectId)
+		if ok {
+			if operatorObjectid, ok := operator.ObjectID(objectid.Kind()); ok {
+				return operatorObjectid.Value() == objectid.Value()
+			}
+		}
+		return reference.ObjectId.Value() == objectid.Value()
+	}
+}
+
 func CompositeStopVisitSelector(selectors []StopVisitSelector) StopVisitSelector {
 	return func(stopVisit StopVisit) bool {
 		for _, selector := range selectors {
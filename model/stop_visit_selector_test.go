@@ -140,3 +140,43 @@ func Test_CompositeStopVisitSelector(t *testing.T) {
 		t.Errorf("Selector should return false, got true")
 	}
 }
+
+func Test_StopVisitSelectorByOperator(t *testing.T) {
+	selector := StopVisitSelectorByOperator(NewObjectID("kind", "OP1"))
+
+	model := NewMemoryModel()
+
+	operator := model.Operators().New()
+	operator.SetObjectID(NewObjectID("internal", "op1"))
+	operator.SetObjectID(NewObjectID("kind", "OP1"))
+	operator.Save()
+
+	vehicleJourney := model.VehicleJourneys().New()
+	vehicleJourney.References.SetObjectId("OperatorRef", NewObjectID("internal", "op1"))
+	vehicleJourney.Save()
+
+	stopVisit := model.StopVisits().New()
+	stopVisit.VehicleJourneyId = vehicleJourney.Id()
+	stopVisit.Save()
+
+	if !selector(stopVisit) {
+		t.Errorf("Selector should select the StopVisit with the VehicleJourney Operator")
+	}
+
+	stopVisit.References.SetObjectId("OperatorRef", NewObjectID("internal", "op2"))
+	if selector(stopVisit) {
+		t.Errorf("Selector should use the StopVisit Operator before the VehicleJourney one")
+	}
+
+	// Unknown Operator
+	stopVisit.References.SetObjectId("OperatorRef", NewObjectID("internal", "OP1"))
+	if !selector(stopVisit) {
+		t.Errorf("Selector should compare the collected OperatorRef of an unknown Operator")
+	}
+
+	stopVisit2 := model.StopVisits().New()
+	stopVisit2.Save()
+	if selector(stopVisit2) {
+		t.Errorf("Selector should not select a StopVisit without Operator")
+	}
+}
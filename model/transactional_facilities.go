@@ -0,0 +1,106 @@
+package model
+
+import "bitbucket.org/enroute-mobi/ara/uuid"
+
+type TransactionalFacilities struct {
+	uuid.UUIDConsumer
+
+	model   Model
+	saved   map[FacilityId]*Facility
+	deleted map[FacilityId]*Facility
+}
+
+func NewTransactionalFacilities(model Model) *TransactionalFacilities {
+	facilities := TransactionalFacilities{model: model}
+	facilities.resetCaches()
+	return &facilities
+}
+
+func (manager *TransactionalFacilities) resetCaches() {
+	manager.saved = make(map[FacilityId]*Facility)
+	manager.deleted = make(map[FacilityId]*Facility)
+}
+
+func (manager *TransactionalFacilities) New() Facility {
+	return *NewFacility(manager.model)
+}
+
+func (manager *TransactionalFacilities) Find(id FacilityId) (Facility, bool) {
+	facility, ok := manager.saved[id]
+	if ok {
+		return *facility, ok
+	}
+
+	return manager.model.Facilities().Find(id)
+}
+
+func (manager *TransactionalFacilities) FindByObjectId(objectid ObjectID) (Facility, bool) {
+	for _, facility := range manager.saved {
+		facilityObjectId, _ := facility.ObjectID(objectid.Kind())
+		if facilityObjectId.Value() == objectid.Value() {
+			return *facility, true
+		}
+	}
+	return manager.model.Facilities().FindByObjectId(objectid)
+}
+
+func (manager *TransactionalFacilities) FindByStopAreaId(id StopAreaId) (facilities []Facility) {
+	// Check saved Facilities
+	for _, facility := range manager.saved {
+		if facility.StopAreaId == id {
+			facilities = append(facilities, *facility)
+		}
+	}
+
+	// Check model Facilities
+	for _, modelFacility := range manager.model.Facilities().FindByStopAreaId(id) {
+		_, ok := manager.saved[modelFacility.Id()]
+		if !ok {
+			facilities = append(facilities, modelFacility)
+		}
+	}
+	return
+}
+
+func (manager *TransactionalFacilities) FindAll() []Facility {
+	facilities := []Facility{}
+	for _, savedFacility := range manager.saved {
+		facilities = append(facilities, *savedFacility)
+	}
+	modelFacilities := manager.model.Facilities().FindAll()
+	for i := range modelFacilities {
+		_, ok := manager.saved[modelFacilities[i].Id()]
+		if !ok {
+			facilities = append(facilities, modelFacilities[i])
+		}
+	}
+	return facilities
+}
+
+func (manager *TransactionalFacilities) Save(facility *Facility) bool {
+	if facility.Id() == "" {
+		facility.id = FacilityId(manager.NewUUID())
+	}
+	manager.saved[facility.Id()] = facility
+	return true
+}
+
+func (manager *TransactionalFacilities) Delete(facility *Facility) bool {
+	manager.deleted[facility.Id()] = facility
+	return true
+}
+
+func (manager *TransactionalFacilities) Commit() error {
+	for _, facility := range manager.deleted {
+		manager.model.Facilities().Delete(facility)
+	}
+	for _, facility := range manager.saved {
+		manager.model.Facilities().Save(facility)
+	}
+	return nil
+}
+
+func (manager *TransactionalFacilities) Rollback() error {
+	manager.resetCaches()
+	return nil
+}
@@ -11,6 +11,7 @@ type TransactionalModel struct {
 	vehicleJourneys *TransactionalVehicleJourneys
 	operators       *TransactionalOperators
 	vehicles        *TransactionalVehicles
+	facilities      *TransactionalFacilities
 }
 
 func NewTransactionalModel(parent Model) *TransactionalModel {
@@ -25,6 +26,7 @@ func NewTransactionalModel(parent Model) *TransactionalModel {
 	model.vehicleJourneys = NewTransactionalVehicleJourneys(parent)
 	model.operators = NewTransactionalOperators(parent)
 	model.vehicles = NewTransactionalVehicles(parent)
+	model.facilities = NewTransactionalFacilities(parent)
 	return model
 }
 
@@ -32,6 +34,10 @@ func (model *TransactionalModel) Date() Date {
 	return model.parent.Date()
 }
 
+func (model *TransactionalModel) ServiceDates() []Date {
+	return model.parent.ServiceDates()
+}
+
 func (model *TransactionalModel) Referential() string {
 	return model.referential
 }
@@ -64,6 +70,10 @@ func (model *TransactionalModel) Vehicles() Vehicles {
 	return model.vehicles
 }
 
+func (model *TransactionalModel) Facilities() Facilities {
+	return model.facilities
+}
+
 func (model *TransactionalModel) NewTransaction() *Transaction {
 	return NewTransaction(model)
 }
@@ -91,6 +101,9 @@ func (model *TransactionalModel) Commit() error {
 	if err = model.vehicles.Commit(); err != nil {
 		return err
 	}
+	if err = model.facilities.Commit(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -117,6 +130,9 @@ func (model *TransactionalModel) Rollback() error {
 	if err = model.vehicles.Rollback(); err != nil {
 		return err
 	}
+	if err = model.facilities.Rollback(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -1,6 +1,10 @@
 package model
 
-import "bitbucket.org/enroute-mobi/ara/uuid"
+import (
+	"sort"
+
+	"bitbucket.org/enroute-mobi/ara/uuid"
+)
 
 type TransactionalStopAreas struct {
 	uuid.UUIDConsumer
@@ -78,6 +82,40 @@ func (manager *TransactionalStopAreas) FindAll() []StopArea {
 	return stopAreas
 }
 
+func (manager *TransactionalStopAreas) FindNear(latitude, longitude, radius float64) []StopArea {
+	stopAreas := manager.withSavedStopAreas(manager.model.StopAreas().FindNear(latitude, longitude, radius), func(stopArea *StopArea) bool {
+		return stopArea.HasLocation() && stopArea.DistanceTo(latitude, longitude) <= radius
+	})
+	sort.SliceStable(stopAreas, func(i, j int) bool {
+		return stopAreas[i].DistanceTo(latitude, longitude) < stopAreas[j].DistanceTo(latitude, longitude)
+	})
+	return stopAreas
+}
+
+func (manager *TransactionalStopAreas) FindInBoundingBox(box BoundingBox) []StopArea {
+	return manager.withSavedStopAreas(manager.model.StopAreas().FindInBoundingBox(box), func(stopArea *StopArea) bool {
+		return stopArea.HasLocation() && box.Contains(stopArea.Latitude, stopArea.Longitude)
+	})
+}
+
+// Replaces the model StopAreas by their saved version and adds the saved
+// StopAreas which match the given condition
+func (manager *TransactionalStopAreas) withSavedStopAreas(modelStopAreas []StopArea, match func(*StopArea) bool) (stopAreas []StopArea) {
+	for _, savedStopArea := range manager.saved {
+		if match(savedStopArea) {
+			stopAreas = append(stopAreas, *(savedStopArea.copy()))
+		}
+	}
+	for _, stopArea := range modelStopAreas {
+		_, saved := manager.saved[stopArea.Id()]
+		_, deleted := manager.deleted[stopArea.Id()]
+		if !saved && !deleted {
+			stopAreas = append(stopAreas, stopArea)
+		}
+	}
+	return
+}
+
 func (manager *TransactionalStopAreas) FindByOrigin(origin string) (stopAreaIds []StopAreaId) {
 	for _, stopAreaId := range manager.model.StopAreas().FindByOrigin(origin) {
 		_, ok := manager.deleted[stopAreaId]
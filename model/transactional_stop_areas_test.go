@@ -66,6 +66,37 @@ func Test_TransactionalStopAreas_FindAll(t *testing.T) {
 	}
 }
 
+func Test_TransactionalStopAreas_FindNear(t *testing.T) {
+	model := NewMemoryModel()
+
+	movedStopArea := model.StopAreas().New()
+	movedStopArea.Latitude = 48.8530
+	movedStopArea.Longitude = 2.3498
+	model.StopAreas().Save(&movedStopArea)
+
+	deletedStopArea := model.StopAreas().New()
+	deletedStopArea.Latitude = 48.8531
+	deletedStopArea.Longitude = 2.3499
+	model.StopAreas().Save(&deletedStopArea)
+
+	stopAreas := NewTransactionalStopAreas(model)
+
+	savedStopArea := stopAreas.New()
+	savedStopArea.Latitude = 48.8532
+	savedStopArea.Longitude = 2.3500
+	stopAreas.Save(&savedStopArea)
+
+	movedStopArea.Latitude = 45.7640
+	movedStopArea.Longitude = 4.8357
+	stopAreas.Save(&movedStopArea)
+	stopAreas.Delete(&deletedStopArea)
+
+	foundStopAreas := stopAreas.FindNear(48.8532, 2.3500, 500)
+	if len(foundStopAreas) != 1 || foundStopAreas[0].Id() != savedStopArea.Id() {
+		t.Errorf("FindNear should only return the saved StopArea, got %v", foundStopAreas)
+	}
+}
+
 func Test_TransactionalStopAreas_Save(t *testing.T) {
 	model := NewMemoryModel()
 	stopAreas := NewTransactionalStopAreas(model)
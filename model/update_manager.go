@@ -35,6 +35,8 @@ func (manager *UpdateManager) Update(event UpdateEvent) {
 		manager.updateStopVisit(event.(*StopVisitUpdateEvent))
 	case VEHICLE_EVENT:
 		manager.updateVehicle(event.(*VehicleUpdateEvent))
+	case FACILITY_EVENT:
+		manager.updateFacility(event.(*FacilityUpdateEvent))
 	case STATUS_EVENT:
 		manager.updateStatus(event.(*StatusUpdateEvent))
 	case NOT_COLLECTED_EVENT:
@@ -152,11 +154,28 @@ func (manager *UpdateManager) updateVehicleJourney(event *VehicleJourneyUpdateEv
 
 	vj.Monitored = event.Monitored
 
+	references := event.References()
+	if operatorRef, ok := references.Get("OperatorRef"); ok {
+		vj.References.Set("OperatorRef", operatorRef)
+		manager.discoverOperator(tx, *operatorRef.ObjectId)
+	}
+
 	tx.Model().VehicleJourneys().Save(&vj)
 	tx.Commit()
 	tx.Close()
 }
 
+// Creates the Operator when the collected OperatorRef is unknown
+func (manager *UpdateManager) discoverOperator(tx *Transaction, objectid ObjectID) {
+	if _, ok := tx.Model().Operators().FindByObjectId(objectid); ok {
+		return
+	}
+
+	operator := tx.Model().Operators().New()
+	operator.SetObjectID(objectid)
+	tx.Model().Operators().Save(&operator)
+}
+
 func (manager *UpdateManager) updateStopVisit(event *StopVisitUpdateEvent) {
 	tx := manager.transactionProvider.NewTransaction()
 
@@ -259,6 +278,44 @@ func (manager *UpdateManager) updateVehicle(event *VehicleUpdateEvent) {
 	tx.Close()
 }
 
+func (manager *UpdateManager) updateFacility(event *FacilityUpdateEvent) {
+	tx := manager.transactionProvider.NewTransaction()
+	defer tx.Close()
+
+	facility, found := tx.Model().Facilities().FindByObjectId(event.ObjectId)
+	if !found {
+		stopArea, ok := tx.Model().StopAreas().FindByObjectId(event.StopAreaObjectId)
+		if !ok {
+			logger.Log.Debugf("Facility update event without corresponding stop area: %v", event.StopAreaObjectId.String())
+			return
+		}
+
+		facility = tx.Model().Facilities().New()
+
+		facility.SetObjectID(event.ObjectId)
+		facility.StopAreaId = stopArea.Id()
+		facility.Origin = event.Origin
+	}
+
+	if event.Name != "" {
+		facility.Name = event.Name
+	}
+	if event.FacilityClass != "" {
+		if facility.Attributes == nil {
+			facility.Attributes = NewAttributes()
+		}
+		facility.Attributes.Set("FacilityClass", event.FacilityClass)
+	}
+	facility.Status = event.Status
+	facility.Description = event.Description
+	facility.ValidityStartTime = event.ValidityStartTime
+	facility.ValidityEndTime = event.ValidityEndTime
+	facility.RecordedAt = manager.Clock().Now()
+
+	tx.Model().Facilities().Save(&facility)
+	tx.Commit()
+}
+
 func (manager *UpdateManager) updateStatus(event *StatusUpdateEvent) {
 	tx := manager.transactionProvider.NewTransaction()
 
@@ -108,3 +108,89 @@ func Test_UpdateManager_UpdateNotCollected(t *testing.T) {
 		t.Errorf("StopVisit Collected should be updated")
 	}
 }
+
+func Test_UpdateManager_UpdateFacility(t *testing.T) {
+	model := NewMemoryModel()
+	manager := newUpdateManager(model)
+
+	stopArea := model.StopAreas().New()
+	stopArea.SetObjectID(NewObjectID("kind", "stopArea"))
+	stopArea.Save()
+
+	event := NewFacilityUpdateEvent()
+	event.Origin = "partner"
+	event.ObjectId = NewObjectID("kind", "lift")
+	event.StopAreaObjectId = NewObjectID("kind", "stopArea")
+	event.FacilityClass = "lift"
+	event.Status = FACILITY_STATUS_NOT_AVAILABLE
+	event.Description = "Under maintenance"
+	manager.Update(event)
+
+	facility, ok := model.Facilities().FindByObjectId(NewObjectID("kind", "lift"))
+	if !ok {
+		t.Fatalf("Facility should be created by the update event")
+	}
+	if facility.StopAreaId != stopArea.Id() {
+		t.Errorf("Facility should be attached to the StopArea, got %v", facility.StopAreaId)
+	}
+	if facility.Status != FACILITY_STATUS_NOT_AVAILABLE || facility.Description != "Under maintenance" {
+		t.Errorf("Wrong Facility status: %v %v", facility.Status, facility.Description)
+	}
+	if facility.Attributes["FacilityClass"] != "lift" || facility.Origin != "partner" {
+		t.Errorf("Wrong Facility attributes: %v %v", facility.Attributes, facility.Origin)
+	}
+
+	event.Status = FACILITY_STATUS_AVAILABLE
+	event.Description = ""
+	manager.Update(event)
+
+	facility, _ = model.Facilities().FindByObjectId(NewObjectID("kind", "lift"))
+	if facility.Status != FACILITY_STATUS_AVAILABLE || facility.Description != "" {
+		t.Errorf("Facility status should be updated: %v %v", facility.Status, facility.Description)
+	}
+	if len(model.Facilities().FindAll()) != 1 {
+		t.Errorf("Facility shouldn't be duplicated")
+	}
+
+	unknown := NewFacilityUpdateEvent()
+	unknown.ObjectId = NewObjectID("kind", "escalator")
+	unknown.StopAreaObjectId = NewObjectID("kind", "unknown")
+	manager.Update(unknown)
+	if _, ok := model.Facilities().FindByObjectId(unknown.ObjectId); ok {
+		t.Errorf("Facility without known StopArea shouldn't be created")
+	}
+}
+
+func Test_UpdateManager_UpdateVehicleJourney_OperatorRef(t *testing.T) {
+	model := NewMemoryModel()
+	objectid := NewObjectID("kind", "value")
+
+	l := model.Lines().New()
+	l.SetObjectID(objectid)
+	l.Save()
+
+	manager := newUpdateManager(model)
+
+	references := NewReferences()
+	references.SetObjectId("OperatorRef", NewObjectID("kind", "operator"))
+	event := &VehicleJourneyUpdateEvent{
+		ObjectId:     objectid,
+		LineObjectId: objectid,
+		attributes:   NewAttributes(),
+		references:   &references,
+	}
+
+	manager.Update(event)
+
+	vj, ok := model.VehicleJourneys().FindByObjectId(objectid)
+	if !ok {
+		t.Fatal("VehicleJourney should be created")
+	}
+	operatorRef, ok := vj.Reference("OperatorRef")
+	if !ok || operatorRef.ObjectId.Value() != "operator" {
+		t.Errorf("VehicleJourney should have the collected OperatorRef, got: %v", operatorRef)
+	}
+	if _, ok := model.Operators().FindByObjectId(NewObjectID("kind", "operator")); !ok {
+		t.Errorf("Unknown Operator should be created")
+	}
+}
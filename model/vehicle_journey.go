@@ -18,6 +18,9 @@ type VehicleJourney struct {
 
 	id VehicleJourneyId
 
+	// Operating day of the VehicleJourney, when loaded from the database
+	ServiceDate Date `json:"-"`
+
 	LineId          LineId `json:",omitempty"`
 	Name            string `json:",omitempty"`
 	OriginName      string `json:",omitempty"`
@@ -271,6 +274,7 @@ func (manager *MemoryVehicleJourneys) Load(referentialSlug string) error {
 	for _, vj := range selectVehicleJourneys {
 		vehicleJourney := manager.New()
 		vehicleJourney.id = VehicleJourneyId(vj.Id)
+		vehicleJourney.ServiceDate = modelName
 		if vj.Name.Valid {
 			vehicleJourney.Name = vj.Name.String
 		}
@@ -84,5 +84,6 @@ func (ue *VehicleJourneyUpdateEvent) References() References {
 	ue.references.SetObjectId("RouteRef", NewObjectID(ue.ObjectidKind, ue.SiriXML.RouteRef()))
 	ue.references.SetObjectId("DestinationRef", NewObjectID(ue.ObjectidKind, ue.SiriXML.DestinationRef()))
 	ue.references.SetObjectId("OriginRef", NewObjectID(ue.ObjectidKind, ue.SiriXML.OriginRef()))
+	ue.references.SetObjectId("OperatorRef", NewObjectID(ue.ObjectidKind, ue.SiriXML.OperatorRef()))
 	return *ue.references
 }
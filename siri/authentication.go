@@ -0,0 +1,213 @@
+package siri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+	"bitbucket.org/enroute-mobi/ara/version"
+)
+
+const (
+	AUTHENTICATION_BASIC  = "basic"
+	AUTHENTICATION_HEADER = "header"
+	AUTHENTICATION_OAUTH2 = "oauth2"
+
+	// The OAuth2 token is refreshed before its expiry
+	OAUTH2_TOKEN_EXPIRY_MARGIN = 30 * time.Second
+	OAUTH2_TOKEN_TIMEOUT       = 10 * time.Second
+)
+
+// HTTP authentication of the requests sent to a partner, in addition to the
+// RequestorRef of the SIRI payload
+type AuthenticationSettings struct {
+	Type string
+
+	// Basic
+	Username string
+	Password string
+
+	// Static header (like an API key)
+	HeaderName  string
+	HeaderValue string
+
+	// OAuth2 client credentials
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Scope        string
+}
+
+type authenticator interface {
+	authenticate(request *http.Request) error
+	// Called when the partner rejects the credentials
+	reset()
+}
+
+func newAuthenticator(settings AuthenticationSettings, httpClient *http.Client) (authenticator, error) {
+	switch settings.Type {
+	case "":
+		return nil, nil
+	case AUTHENTICATION_BASIC:
+		return &basicAuthenticator{username: settings.Username, password: settings.Password}, nil
+	case AUTHENTICATION_HEADER:
+		if settings.HeaderName == "" {
+			return nil, fmt.Errorf("header authentication without header name")
+		}
+		return &headerAuthenticator{name: settings.HeaderName, value: settings.HeaderValue}, nil
+	case AUTHENTICATION_OAUTH2:
+		if settings.TokenUrl == "" {
+			return nil, fmt.Errorf("oauth2 authentication without token url")
+		}
+		return &oauth2Authenticator{
+			tokenUrl:     settings.TokenUrl,
+			clientId:     settings.ClientId,
+			clientSecret: settings.ClientSecret,
+			scope:        settings.Scope,
+			httpClient:   httpClient,
+			mutex:        &sync.Mutex{},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown authentication type %v", settings.Type)
+}
+
+type basicAuthenticator struct {
+	username string
+	password string
+}
+
+func (authenticator *basicAuthenticator) authenticate(request *http.Request) error {
+	request.SetBasicAuth(authenticator.username, authenticator.password)
+	return nil
+}
+
+func (authenticator *basicAuthenticator) reset() {}
+
+type headerAuthenticator struct {
+	name  string
+	value string
+}
+
+func (authenticator *headerAuthenticator) authenticate(request *http.Request) error {
+	request.Header.Set(authenticator.name, authenticator.value)
+	return nil
+}
+
+func (authenticator *headerAuthenticator) reset() {}
+
+// Uses a bearer token obtained with the OAuth2 client credentials grant. The
+// token is cached until its expiry.
+type oauth2Authenticator struct {
+	clock.ClockConsumer
+
+	tokenUrl     string
+	clientId     string
+	clientSecret string
+	scope        string
+
+	httpClient *http.Client
+
+	mutex     *sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (authenticator *oauth2Authenticator) authenticate(request *http.Request) error {
+	token, err := authenticator.currentToken()
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (authenticator *oauth2Authenticator) reset() {
+	authenticator.mutex.Lock()
+	authenticator.token = ""
+	authenticator.mutex.Unlock()
+}
+
+func (authenticator *oauth2Authenticator) currentToken() (string, error) {
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	now := authenticator.Clock().Now()
+	if authenticator.token != "" && (authenticator.expiresAt.IsZero() || now.Before(authenticator.expiresAt)) {
+		return authenticator.token, nil
+	}
+
+	response, err := authenticator.requestToken()
+	if err != nil {
+		return "", err
+	}
+
+	authenticator.token = response.AccessToken
+	authenticator.expiresAt = time.Time{}
+	if response.ExpiresIn > 0 {
+		lifetime := time.Duration(response.ExpiresIn) * time.Second
+		if lifetime > 2*OAUTH2_TOKEN_EXPIRY_MARGIN {
+			lifetime -= OAUTH2_TOKEN_EXPIRY_MARGIN
+		}
+		authenticator.expiresAt = now.Add(lifetime)
+	}
+	return authenticator.token, nil
+}
+
+func (authenticator *oauth2Authenticator) requestToken() (*oauth2TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if authenticator.scope != "" {
+		form.Set("scope", authenticator.scope)
+	}
+
+	ctx, cncl := context.WithTimeout(context.Background(), OAUTH2_TOKEN_TIMEOUT)
+	defer cncl()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, authenticator.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(url.QueryEscape(authenticator.clientId), url.QueryEscape(authenticator.clientSecret))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", version.ApplicationName())
+
+	response, err := authenticator.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("can't request OAuth2 token: %v", err)
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't request OAuth2 token: HTTP status %v", response.StatusCode)
+	}
+
+	token := &oauth2TokenResponse{}
+	if err := json.NewDecoder(response.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("invalid OAuth2 token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("invalid OAuth2 token response: no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported OAuth2 token type %v", token.TokenType)
+	}
+	return token, nil
+}
@@ -0,0 +1,130 @@
+package siri
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+func newTestCheckStatusRequest() *SIRICheckStatusRequest {
+	return &SIRICheckStatusRequest{
+		RequestorRef:      "Ara",
+		RequestTimestamp:  time.Now(),
+		MessageIdentifier: "Ara:Message::6ba7b814-9dad-11d1-32-00c04fd430c8:LOC",
+	}
+}
+
+// Returns the check status response when the request has the expected Authorization header
+func createAuthenticatedHTTPServer(t *testing.T, header string, expected func() string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) != expected() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		file, err := testSOAPFile("checkstatus-response")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		w.Header().Set("Content-Type", "text/xml")
+		io.Copy(w, file)
+	}))
+}
+
+func Test_SOAPClient_BasicAuthentication(t *testing.T) {
+	ts := createAuthenticatedHTTPServer(t, "Authorization", func() string { return "Basic YXJhOnNlY3JldA==" })
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.Authentication = AuthenticationSettings{Type: AUTHENTICATION_BASIC, Username: "ara", Password: "secret"}
+	if _, err := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings).CheckStatus(newTestCheckStatusRequest()); err != nil {
+		t.Errorf("Request with basic authentication should succeed: %v", err)
+	}
+
+	if _, err := NewSOAPClient(SOAPClientUrls{Url: ts.URL}).CheckStatus(newTestCheckStatusRequest()); err == nil {
+		t.Errorf("Request without authentication should fail")
+	}
+}
+
+func Test_SOAPClient_HeaderAuthentication(t *testing.T) {
+	ts := createAuthenticatedHTTPServer(t, "X-Api-Key", func() string { return "secret" })
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.Authentication = AuthenticationSettings{Type: AUTHENTICATION_HEADER, HeaderName: "X-Api-Key", HeaderValue: "secret"}
+	if _, err := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings).CheckStatus(newTestCheckStatusRequest()); err != nil {
+		t.Errorf("Request with header authentication should succeed: %v", err)
+	}
+}
+
+func Test_SOAPClient_OAuth2Authentication(t *testing.T) {
+	tokens := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientId, clientSecret, _ := r.BasicAuth()
+		if clientId != "ara" || clientSecret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "siri" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokens++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, tokens)
+	}))
+	defer tokenServer.Close()
+
+	// The server only accepts the last token
+	ts := createAuthenticatedHTTPServer(t, "Authorization", func() string { return fmt.Sprintf("Bearer token-%d", tokens) })
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.Authentication = AuthenticationSettings{
+		Type:         AUTHENTICATION_OAUTH2,
+		TokenUrl:     tokenServer.URL,
+		ClientId:     "ara",
+		ClientSecret: "secret",
+		Scope:        "siri",
+	}
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings)
+	fakeClock := clock.NewFakeClock()
+	client.authenticator.(*oauth2Authenticator).SetClock(fakeClock)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CheckStatus(newTestCheckStatusRequest()); err != nil {
+			t.Fatalf("Request with OAuth2 authentication should succeed: %v", err)
+		}
+	}
+	if tokens != 1 {
+		t.Errorf("OAuth2 token should be cached, got %v token requests", tokens)
+	}
+
+	fakeClock.Advance(time.Hour)
+	if _, err := client.CheckStatus(newTestCheckStatusRequest()); err != nil {
+		t.Fatalf("Request with refreshed OAuth2 token should succeed: %v", err)
+	}
+	if tokens != 2 {
+		t.Errorf("OAuth2 token should be refreshed after expiry, got %v token requests", tokens)
+	}
+
+	// Token revoked by the partner
+	tokens++
+	if _, err := client.CheckStatus(newTestCheckStatusRequest()); err != nil {
+		t.Fatalf("Request should succeed with a new OAuth2 token after a 401: %v", err)
+	}
+	if tokens != 4 {
+		t.Errorf("OAuth2 token should be requested after a 401, got %v token requests", tokens)
+	}
+}
+
+func Test_SOAPClient_InvalidAuthentication(t *testing.T) {
+	settings := DefaultSOAPClientSettings()
+	settings.Authentication = AuthenticationSettings{Type: "dummy"}
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: "http://localhost"}, settings)
+
+	if client.SettingsError() == nil {
+		t.Errorf("SOAPClient should return the settings error")
+	}
+}
@@ -0,0 +1,156 @@
+package siri
+
+import (
+	"sync"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+type CircuitBreakerState string
+
+const (
+	CIRCUIT_BREAKER_CLOSED    CircuitBreakerState = "closed"
+	CIRCUIT_BREAKER_OPEN      CircuitBreakerState = "open"
+	CIRCUIT_BREAKER_HALF_OPEN CircuitBreakerState = "half-open"
+)
+
+/*
+Short-circuits the requests to a failing partner.
+
+The circuit is opened after threshold consecutive failures. No request is sent
+until timeout is expired, then a single request is allowed (half-open). The
+circuit is closed again when this request succeeds.
+
+A zero threshold disables the circuit breaker.
+*/
+type CircuitBreaker struct {
+	clock.ClockConsumer
+
+	mutex *sync.Mutex
+
+	threshold int
+	timeout   time.Duration
+
+	state     CircuitBreakerState
+	failures  int
+	openedAt  time.Time
+	lastError string
+
+	onStateChange func(previous, state CircuitBreakerState)
+}
+
+type CircuitBreakerStatus struct {
+	State     CircuitBreakerState
+	Failures  int
+	OpenedAt  time.Time `json:",omitempty"`
+	LastError string    `json:",omitempty"`
+}
+
+func NewCircuitBreaker(threshold int, timeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		mutex:     &sync.Mutex{},
+		threshold: threshold,
+		timeout:   timeout,
+		state:     CIRCUIT_BREAKER_CLOSED,
+	}
+}
+
+// Defines a function called (outside of the breaker lock) each time the state changes
+func (breaker *CircuitBreaker) OnStateChange(f func(previous, state CircuitBreakerState)) {
+	breaker.mutex.Lock()
+	breaker.onStateChange = f
+	breaker.mutex.Unlock()
+}
+
+// Returns true when a request can be sent
+func (breaker *CircuitBreaker) Allow() bool {
+	if breaker.threshold <= 0 {
+		return true
+	}
+
+	breaker.mutex.Lock()
+	switch breaker.state {
+	case CIRCUIT_BREAKER_OPEN:
+		if breaker.Clock().Since(breaker.openedAt) < breaker.timeout {
+			breaker.mutex.Unlock()
+			return false
+		}
+		notify := breaker.setState(CIRCUIT_BREAKER_HALF_OPEN)
+		breaker.mutex.Unlock()
+		notify()
+		return true
+	case CIRCUIT_BREAKER_HALF_OPEN:
+		// A request is already testing the partner
+		breaker.mutex.Unlock()
+		return false
+	}
+	breaker.mutex.Unlock()
+	return true
+}
+
+func (breaker *CircuitBreaker) Success() {
+	if breaker.threshold <= 0 {
+		return
+	}
+
+	breaker.mutex.Lock()
+	breaker.failures = 0
+	notify := breaker.setState(CIRCUIT_BREAKER_CLOSED)
+	breaker.mutex.Unlock()
+	notify()
+}
+
+func (breaker *CircuitBreaker) Failure(err error) {
+	if breaker.threshold <= 0 {
+		return
+	}
+
+	breaker.mutex.Lock()
+	breaker.failures++
+	if err != nil {
+		breaker.lastError = err.Error()
+	}
+	notify := func() {}
+	if breaker.state == CIRCUIT_BREAKER_HALF_OPEN || breaker.failures >= breaker.threshold {
+		breaker.openedAt = breaker.Clock().Now()
+		notify = breaker.setState(CIRCUIT_BREAKER_OPEN)
+	}
+	breaker.mutex.Unlock()
+	notify()
+}
+
+func (breaker *CircuitBreaker) State() CircuitBreakerState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	return breaker.state
+}
+
+func (breaker *CircuitBreaker) Status() CircuitBreakerStatus {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	return CircuitBreakerStatus{
+		State:     breaker.state,
+		Failures:  breaker.failures,
+		OpenedAt:  breaker.openedAt,
+		LastError: breaker.lastError,
+	}
+}
+
+// Must be called with the lock. Returns the notification to call once unlocked
+func (breaker *CircuitBreaker) setState(state CircuitBreakerState) func() {
+	previous := breaker.state
+	if previous == state {
+		return func() {}
+	}
+	breaker.state = state
+	if state == CIRCUIT_BREAKER_CLOSED {
+		breaker.openedAt = time.Time{}
+	}
+
+	onStateChange := breaker.onStateChange
+	if onStateChange == nil {
+		return func() {}
+	}
+	return func() { onStateChange(previous, state) }
+}
@@ -0,0 +1,85 @@
+package siri
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
+)
+
+func Test_CircuitBreaker_Disabled(t *testing.T) {
+	breaker := NewCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		breaker.Failure(errors.New("failure"))
+	}
+	if !breaker.Allow() {
+		t.Errorf("Disabled CircuitBreaker should always allow requests")
+	}
+	if breaker.State() != CIRCUIT_BREAKER_CLOSED {
+		t.Errorf("Disabled CircuitBreaker should stay closed, got: %v", breaker.State())
+	}
+}
+
+func Test_CircuitBreaker_Open(t *testing.T) {
+	fakeClock := clock.NewFakeClock()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.SetClock(fakeClock)
+
+	var changes []CircuitBreakerState
+	breaker.OnStateChange(func(previous, state CircuitBreakerState) { changes = append(changes, state) })
+
+	breaker.Failure(errors.New("first failure"))
+	if breaker.State() != CIRCUIT_BREAKER_CLOSED || !breaker.Allow() {
+		t.Fatalf("CircuitBreaker should stay closed after a single failure")
+	}
+
+	breaker.Failure(errors.New("second failure"))
+	if breaker.State() != CIRCUIT_BREAKER_OPEN {
+		t.Fatalf("CircuitBreaker should be open after threshold failures, got: %v", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Errorf("Open CircuitBreaker shouldn't allow requests")
+	}
+
+	status := breaker.Status()
+	if status.Failures != 2 || status.LastError != "second failure" || !status.OpenedAt.Equal(fakeClock.Now()) {
+		t.Errorf("Wrong CircuitBreaker status: %#v", status)
+	}
+
+	fakeClock.Advance(time.Minute)
+	if !breaker.Allow() {
+		t.Fatalf("CircuitBreaker should allow a request after timeout")
+	}
+	if breaker.State() != CIRCUIT_BREAKER_HALF_OPEN {
+		t.Errorf("CircuitBreaker should be half-open after timeout, got: %v", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Errorf("Half-open CircuitBreaker should allow a single request")
+	}
+
+	breaker.Failure(errors.New("third failure"))
+	if breaker.State() != CIRCUIT_BREAKER_OPEN {
+		t.Errorf("CircuitBreaker should be open again after a half-open failure, got: %v", breaker.State())
+	}
+
+	fakeClock.Advance(time.Minute)
+	breaker.Allow()
+	breaker.Success()
+	if breaker.State() != CIRCUIT_BREAKER_CLOSED {
+		t.Errorf("CircuitBreaker should be closed after a success, got: %v", breaker.State())
+	}
+	if status := breaker.Status(); status.Failures != 0 || !status.OpenedAt.IsZero() {
+		t.Errorf("Wrong CircuitBreaker status after success: %#v", status)
+	}
+
+	expected := []CircuitBreakerState{CIRCUIT_BREAKER_OPEN, CIRCUIT_BREAKER_HALF_OPEN, CIRCUIT_BREAKER_OPEN, CIRCUIT_BREAKER_HALF_OPEN, CIRCUIT_BREAKER_CLOSED}
+	if len(changes) != len(expected) {
+		t.Fatalf("Wrong state changes:\n got: %v\n want: %v", changes, expected)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Wrong state changes:\n got: %v\n want: %v", changes, expected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package siri
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jbowtie/gokogiri"
+	"github.com/jbowtie/gokogiri/xml"
+)
+
+type XMLGetConnectionMonitoring struct {
+	XMLConnectionMonitoringRequest
+
+	requestorRef string
+}
+
+type XMLConnectionMonitoringRequest struct {
+	LightRequestXMLStructure
+
+	previewInterval time.Duration
+	startTime       time.Time
+
+	connectionLinkRefs []string
+}
+
+func NewXMLGetConnectionMonitoring(node xml.Node) *XMLGetConnectionMonitoring {
+	xmlGetConnectionMonitoring := &XMLGetConnectionMonitoring{}
+	xmlGetConnectionMonitoring.node = NewXMLNode(node)
+	return xmlGetConnectionMonitoring
+}
+
+func NewXMLGetConnectionMonitoringFromContent(content []byte) (*XMLGetConnectionMonitoring, error) {
+	doc, err := gokogiri.ParseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	request := NewXMLGetConnectionMonitoring(doc.Root().XmlNode)
+	return request, nil
+}
+
+func (request *XMLGetConnectionMonitoring) RequestorRef() string {
+	if request.requestorRef == "" {
+		request.requestorRef = request.findStringChildContent("RequestorRef")
+	}
+	return request.requestorRef
+}
+
+func (request *XMLConnectionMonitoringRequest) ConnectionLinkRefs() []string {
+	if len(request.connectionLinkRefs) == 0 {
+		nodes := request.findNodes("ConnectionLinkRef")
+		for _, node := range nodes {
+			request.connectionLinkRefs = append(request.connectionLinkRefs, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.connectionLinkRefs
+}
+
+func (request *XMLConnectionMonitoringRequest) PreviewInterval() time.Duration {
+	if request.previewInterval == 0 {
+		request.previewInterval = request.findDurationChildContent("PreviewInterval")
+	}
+	return request.previewInterval
+}
+
+func (request *XMLConnectionMonitoringRequest) StartTime() time.Time {
+	if request.startTime.IsZero() {
+		request.startTime = request.findTimeChildContent("StartTime")
+	}
+	return request.startTime
+}
@@ -0,0 +1,92 @@
+package siri
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+type SIRIConnectionMonitoringResponse struct {
+	SIRIConnectionMonitoringFeederDelivery
+
+	Address                   string
+	ProducerRef               string
+	ResponseMessageIdentifier string
+}
+
+type SIRIConnectionMonitoringFeederDelivery struct {
+	RequestMessageRef string
+
+	ResponseTimestamp time.Time
+
+	Status      bool
+	ErrorType   string
+	ErrorNumber int
+	ErrorText   string
+
+	MonitoredFeederArrivals             []*SIRIMonitoredFeederArrival
+	MonitoredFeederArrivalCancellations []*SIRIMonitoredFeederArrivalCancellation
+}
+
+type SIRIMonitoredFeederArrival struct {
+	RecordedAtTime time.Time
+
+	ItemIdentifier    string
+	ConnectionLinkRef string
+	StopAreaRef       string
+
+	LineRef                string
+	DirectionRef           string
+	DataFrameRef           string
+	DatedVehicleJourneyRef string
+	PublishedLineName      string
+	VehicleAtStop          bool
+
+	AimedArrivalTime    time.Time
+	ExpectedArrivalTime time.Time
+}
+
+type SIRIMonitoredFeederArrivalCancellation struct {
+	RecordedAtTime time.Time
+
+	ItemRef           string
+	ConnectionLinkRef string
+	StopAreaRef       string
+
+	LineRef                string
+	DirectionRef           string
+	DataFrameRef           string
+	DatedVehicleJourneyRef string
+	Reason                 string
+}
+
+func (response *SIRIConnectionMonitoringResponse) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "connection_monitoring_response.template", response); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (delivery *SIRIConnectionMonitoringFeederDelivery) ErrorString() string {
+	return fmt.Sprintf("%v: %v", delivery.errorType(), delivery.ErrorText)
+}
+
+func (delivery *SIRIConnectionMonitoringFeederDelivery) errorType() string {
+	if delivery.ErrorType == "OtherError" {
+		return fmt.Sprintf("%v %v", delivery.ErrorType, delivery.ErrorNumber)
+	}
+	return delivery.ErrorType
+}
+
+func (delivery *SIRIConnectionMonitoringFeederDelivery) BuildConnectionMonitoringFeederDeliveryXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "connection_monitoring_feeder_delivery.template", delivery); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
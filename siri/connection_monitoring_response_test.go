@@ -0,0 +1,62 @@
+package siri
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_SIRIConnectionMonitoringResponse_BuildXML(t *testing.T) {
+	aimed := time.Date(2016, time.September, 7, 9, 11, 0, 0, time.UTC)
+	response := &SIRIConnectionMonitoringResponse{
+		ProducerRef:               "producer",
+		ResponseMessageIdentifier: "response",
+		SIRIConnectionMonitoringFeederDelivery: SIRIConnectionMonitoringFeederDelivery{
+			RequestMessageRef: "request",
+			ResponseTimestamp: aimed,
+			Status:            true,
+			MonitoredFeederArrivals: []*SIRIMonitoredFeederArrival{
+				{
+					ItemIdentifier:         "link:rer-1",
+					ConnectionLinkRef:      "link",
+					StopAreaRef:            "rail",
+					LineRef:                "RER",
+					DataFrameRef:           "2016-09-07",
+					DatedVehicleJourneyRef: "rer-1",
+					AimedArrivalTime:       aimed,
+				},
+			},
+			MonitoredFeederArrivalCancellations: []*SIRIMonitoredFeederArrivalCancellation{
+				{
+					ItemRef:                "link:rer-2",
+					ConnectionLinkRef:      "link",
+					StopAreaRef:            "rail",
+					LineRef:                "RER",
+					DatedVehicleJourneyRef: "rer-2",
+				},
+			},
+		},
+	}
+
+	xml, err := response.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"<sw:GetConnectionMonitoringResponse",
+		"<siri:ConnectionMonitoringFeederDelivery",
+		"<siri:ItemIdentifier>link:rer-1</siri:ItemIdentifier>",
+		"<siri:DatedVehicleJourneyRef>rer-1</siri:DatedVehicleJourneyRef>",
+		"<siri:AimedArrivalTime>2016-09-07T09:11:00.000Z</siri:AimedArrivalTime>",
+		"<siri:MonitoredFeederArrivalCancellation>",
+		"<siri:ItemRef>link:rer-2</siri:ItemRef>",
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("XML should contain %v:\n%v", expected, xml)
+		}
+	}
+	if strings.Contains(xml, "ExpectedArrivalTime") {
+		t.Errorf("XML shouldn't contain an undefined ExpectedArrivalTime:\n%v", xml)
+	}
+}
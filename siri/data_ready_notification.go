@@ -0,0 +1,26 @@
+package siri
+
+import (
+	"bytes"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+// Sent to a subscriber using the fetched delivery, to announce that
+// notifications can be fetched with a DataSupplyRequest
+type SIRIDataReadyNotification struct {
+	Address           string
+	ProducerRef       string
+	MessageIdentifier string
+	RequestTimestamp  time.Time
+}
+
+func (notification *SIRIDataReadyNotification) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "data_ready_notification.template", notification); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,87 @@
+package siri
+
+import (
+	"bytes"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"github.com/jbowtie/gokogiri"
+	"github.com/jbowtie/gokogiri/xml"
+)
+
+type XMLDataSupplyRequest struct {
+	RequestXMLStructure
+
+	consumerRef     string
+	notificationRef string
+	allData         Bool
+}
+
+type SIRIDataSupplyResponse struct {
+	Address                   string
+	ProducerRef               string
+	RequestMessageRef         string
+	ResponseMessageIdentifier string
+	ResponseTimestamp         time.Time
+
+	Status    bool
+	ErrorType string
+	ErrorText string
+
+	MoreData bool
+	// Deliveries XML, as sent in the notifications
+	Deliveries []string
+}
+
+func NewXMLDataSupplyRequest(node xml.Node) *XMLDataSupplyRequest {
+	xmlDataSupplyRequest := &XMLDataSupplyRequest{}
+	xmlDataSupplyRequest.node = NewXMLNode(node)
+	return xmlDataSupplyRequest
+}
+
+func NewXMLDataSupplyRequestFromContent(content []byte) (*XMLDataSupplyRequest, error) {
+	doc, err := gokogiri.ParseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	request := NewXMLDataSupplyRequest(doc.Root().XmlNode)
+	return request, nil
+}
+
+// Returns the RequestorRef or the ConsumerRef of the request
+func (request *XMLDataSupplyRequest) RequestorRef() string {
+	if requestorRef := request.RequestXMLStructure.RequestorRef(); requestorRef != "" {
+		return requestorRef
+	}
+	return request.ConsumerRef()
+}
+
+func (request *XMLDataSupplyRequest) ConsumerRef() string {
+	if request.consumerRef == "" {
+		request.consumerRef = request.findStringChildContent("ConsumerRef")
+	}
+	return request.consumerRef
+}
+
+func (request *XMLDataSupplyRequest) NotificationRef() string {
+	if request.notificationRef == "" {
+		request.notificationRef = request.findStringChildContent("NotificationRef")
+	}
+	return request.notificationRef
+}
+
+func (request *XMLDataSupplyRequest) AllData() bool {
+	if !request.allData.Defined {
+		request.allData.SetValue(request.findBoolChildContent("AllData"))
+	}
+	return request.allData.Value
+}
+
+func (response *SIRIDataSupplyResponse) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "data_supply_response.template", response); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,71 @@
+package siri
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_XMLDataSupplyRequest(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/data_supply_request.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := NewXMLDataSupplyRequestFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "Subscriber"; request.RequestorRef() != expected {
+		t.Errorf("Wrong RequestorRef:\n got: %v\nwant: %v", request.RequestorRef(), expected)
+	}
+	if expected := "DataSupply:1"; request.MessageIdentifier() != expected {
+		t.Errorf("Wrong MessageIdentifier:\n got: %v\nwant: %v", request.MessageIdentifier(), expected)
+	}
+	if expected := "DataReady:1"; request.NotificationRef() != expected {
+		t.Errorf("Wrong NotificationRef:\n got: %v\nwant: %v", request.NotificationRef(), expected)
+	}
+	if !request.AllData() {
+		t.Errorf("Wrong AllData:\n got: false\nwant: true")
+	}
+}
+
+func Test_SIRIDataSupplyResponse_BuildXML(t *testing.T) {
+	notify := &SIRINotifyGeneralMessage{
+		RequestMessageRef:      "Ref",
+		SubscriberRef:          "Subscriber",
+		SubscriptionIdentifier: "Subscription",
+		ResponseTimestamp:      time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Status:                 true,
+	}
+	delivery, err := notify.BuildNotifyGeneralMessageDeliveryXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := &SIRIDataSupplyResponse{
+		ProducerRef:               "Ara",
+		RequestMessageRef:         "DataSupply:1",
+		ResponseMessageIdentifier: "DataSupplyResponse:1",
+		ResponseTimestamp:         time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Status:                    true,
+		MoreData:                  true,
+		Deliveries:                []string{delivery},
+	}
+	xml, err := response.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"<siri:RequestMessageRef>DataSupply:1</siri:RequestMessageRef>",
+		"<siri:Status>true</siri:Status>",
+		"<siri:MoreData>true</siri:MoreData>",
+		"<siri:SubscriptionRef>Subscription</siri:SubscriptionRef>",
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("DataSupplyResponse should contain %v:\n%v", expected, xml)
+		}
+	}
+}
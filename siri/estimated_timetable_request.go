@@ -20,7 +20,17 @@ type XMLEstimatedTimetableRequest struct {
 	previewInterval time.Duration
 	startTime       time.Time
 
-	lines []string
+	lines              []string
+	lineDirections     []*XMLLineDirection
+	operators          []string
+	vehicleJourneyRefs []string
+}
+
+type XMLLineDirection struct {
+	XMLStructure
+
+	lineRef      string
+	directionRef string
 }
 
 func NewXMLGetEstimatedTimetable(node xml.Node) *XMLGetEstimatedTimetable {
@@ -48,6 +58,58 @@ func (request *XMLEstimatedTimetableRequest) Lines() []string {
 	return request.lines
 }
 
+func NewXMLLineDirection(node XMLNode) *XMLLineDirection {
+	lineDirection := &XMLLineDirection{}
+	lineDirection.node = node
+	return lineDirection
+}
+
+func (request *XMLEstimatedTimetableRequest) LineDirections() []*XMLLineDirection {
+	if request.lineDirections == nil {
+		lineDirections := []*XMLLineDirection{}
+		nodes := request.findNodes("LineDirection")
+		for _, node := range nodes {
+			lineDirections = append(lineDirections, NewXMLLineDirection(node))
+		}
+		request.lineDirections = lineDirections
+	}
+	return request.lineDirections
+}
+
+func (lineDirection *XMLLineDirection) LineRef() string {
+	if lineDirection.lineRef == "" {
+		lineDirection.lineRef = lineDirection.findStringChildContent("LineRef")
+	}
+	return lineDirection.lineRef
+}
+
+func (lineDirection *XMLLineDirection) DirectionRef() string {
+	if lineDirection.directionRef == "" {
+		lineDirection.directionRef = lineDirection.findStringChildContent("DirectionRef")
+	}
+	return lineDirection.directionRef
+}
+
+func (request *XMLEstimatedTimetableRequest) VehicleJourneyRefs() []string {
+	if len(request.vehicleJourneyRefs) == 0 {
+		nodes := request.findNodes("VehicleJourneyRef")
+		for _, node := range nodes {
+			request.vehicleJourneyRefs = append(request.vehicleJourneyRefs, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.vehicleJourneyRefs
+}
+
+func (request *XMLEstimatedTimetableRequest) OperatorRefs() []string {
+	if len(request.operators) == 0 {
+		nodes := request.findNodes("OperatorRef")
+		for _, node := range nodes {
+			request.operators = append(request.operators, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.operators
+}
+
 func (request *XMLGetEstimatedTimetable) RequestorRef() string {
 	if request.requestorRef == "" {
 		request.requestorRef = request.findStringChildContent("RequestorRef")
@@ -57,7 +119,7 @@ func (request *XMLGetEstimatedTimetable) RequestorRef() string {
 
 func (request *XMLEstimatedTimetableRequest) PreviewInterval() time.Duration {
 	if request.previewInterval == 0 {
-		request.previewInterval = request.findDurationChildContent("PreviewInterval")
+		request.previewInterval = request.findSignedDurationChildContent("PreviewInterval")
 	}
 	return request.previewInterval
 }
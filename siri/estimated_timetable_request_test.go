@@ -3,6 +3,7 @@ package siri
 import (
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -55,3 +56,47 @@ func Test_XMLGetEstimatedTimetable_Lines(t *testing.T) {
 		t.Errorf("Wrong first line:\n got: %v\nwant: %v", request.Lines()[1], expected)
 	}
 }
+
+func Test_XMLGetEstimatedTimetable_Filters(t *testing.T) {
+	file, err := os.Open("testdata/estimated_timetable_request_filters.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := NewXMLGetEstimatedTimetableFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := time.Hour; request.PreviewInterval() != expected {
+		t.Errorf("Wrong PreviewInterval:\n got: %v\nwant: %v", request.PreviewInterval(), expected)
+	}
+	if expected := time.Date(2016, time.September, 7, 10, 0, 0, 0, time.UTC); !request.StartTime().Equal(expected) {
+		t.Errorf("Wrong StartTime:\n got: %v\nwant: %v", request.StartTime(), expected)
+	}
+	if len(request.Lines()) != 2 {
+		t.Errorf("GetEstimatedTimetable request has wrong number of lines: %v", request.Lines())
+	}
+
+	lineDirections := request.LineDirections()
+	if len(lineDirections) != 2 {
+		t.Fatalf("GetEstimatedTimetable request has wrong number of line directions: %v", len(lineDirections))
+	}
+	if lineDirections[0].LineRef() != "NINOXE:Line:2:LOC" || lineDirections[0].DirectionRef() != "Aller" {
+		t.Errorf("Wrong first LineDirection: %v %v", lineDirections[0].LineRef(), lineDirections[0].DirectionRef())
+	}
+	if lineDirections[1].LineRef() != "NINOXE:Line:3:LOC" || lineDirections[1].DirectionRef() != "" {
+		t.Errorf("Wrong second LineDirection: %v %v", lineDirections[1].LineRef(), lineDirections[1].DirectionRef())
+	}
+
+	if expected := []string{"NINOXE:Company:15563880:LOC"}; !reflect.DeepEqual(request.OperatorRefs(), expected) {
+		t.Errorf("Wrong OperatorRefs:\n got: %v\nwant: %v", request.OperatorRefs(), expected)
+	}
+	if expected := []string{"NINOXE:VehicleJourney:201"}; !reflect.DeepEqual(request.VehicleJourneyRefs(), expected) {
+		t.Errorf("Wrong VehicleJourneyRefs:\n got: %v\nwant: %v", request.VehicleJourneyRefs(), expected)
+	}
+}
@@ -0,0 +1,99 @@
+package siri
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"github.com/jbowtie/gokogiri"
+	"github.com/jbowtie/gokogiri/xml"
+)
+
+type XMLGetFacilityMonitoring struct {
+	XMLFacilityMonitoringRequest
+
+	requestorRef string
+}
+
+type XMLFacilityMonitoringRequest struct {
+	LightRequestXMLStructure
+
+	stopPointRefs []string
+	facilityRefs  []string
+}
+
+type SIRIGetFacilityMonitoringRequest struct {
+	SIRIFacilityMonitoringRequest
+
+	RequestorRef string
+}
+
+type SIRIFacilityMonitoringRequest struct {
+	MessageIdentifier string
+
+	RequestTimestamp time.Time
+
+	StopPointRef string
+	FacilityRef  string
+}
+
+func NewXMLGetFacilityMonitoring(node xml.Node) *XMLGetFacilityMonitoring {
+	xmlGetFacilityMonitoring := &XMLGetFacilityMonitoring{}
+	xmlGetFacilityMonitoring.node = NewXMLNode(node)
+	return xmlGetFacilityMonitoring
+}
+
+func NewXMLGetFacilityMonitoringFromContent(content []byte) (*XMLGetFacilityMonitoring, error) {
+	doc, err := gokogiri.ParseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	request := NewXMLGetFacilityMonitoring(doc.Root().XmlNode)
+	return request, nil
+}
+
+func (request *XMLGetFacilityMonitoring) RequestorRef() string {
+	if request.requestorRef == "" {
+		request.requestorRef = request.findStringChildContent("RequestorRef")
+	}
+	return request.requestorRef
+}
+
+func (request *XMLFacilityMonitoringRequest) StopPointRefs() []string {
+	if len(request.stopPointRefs) == 0 {
+		nodes := request.findNodes("StopPointRef")
+		for _, node := range nodes {
+			request.stopPointRefs = append(request.stopPointRefs, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.stopPointRefs
+}
+
+func (request *XMLFacilityMonitoringRequest) FacilityRefs() []string {
+	if len(request.facilityRefs) == 0 {
+		nodes := request.findNodes("FacilityRef")
+		for _, node := range nodes {
+			request.facilityRefs = append(request.facilityRefs, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.facilityRefs
+}
+
+func (request *SIRIGetFacilityMonitoringRequest) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "get_facility_monitoring_request.template", request); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (request *SIRIFacilityMonitoringRequest) BuildFacilityMonitoringRequestXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "facility_monitoring_request.template", request); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
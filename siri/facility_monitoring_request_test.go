@@ -0,0 +1,76 @@
+package siri
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getXMLGetFacilityMonitoring(t *testing.T) *XMLGetFacilityMonitoring {
+	file, err := os.Open("testdata/facility_monitoring_request.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := NewXMLGetFacilityMonitoringFromContent(content)
+	return request
+}
+
+func Test_XMLGetFacilityMonitoring(t *testing.T) {
+	request := getXMLGetFacilityMonitoring(t)
+
+	if expected := "test"; request.RequestorRef() != expected {
+		t.Errorf("Wrong RequestorRef:\n got: %v\nwant: %v", request.RequestorRef(), expected)
+	}
+	if expected := "FacilityMonitoring:Test:0"; request.MessageIdentifier() != expected {
+		t.Errorf("Wrong MessageIdentifier:\n got: %v\nwant: %v", request.MessageIdentifier(), expected)
+	}
+	if refs := request.StopPointRefs(); len(refs) != 1 || refs[0] != "NINOXE:StopPoint:SP:24:LOC" {
+		t.Errorf("Wrong StopPointRefs: %v", refs)
+	}
+	if refs := request.FacilityRefs(); len(refs) != 1 || refs[0] != "NINOXE:Facility:Lift:1:LOC" {
+		t.Errorf("Wrong FacilityRefs: %v", refs)
+	}
+}
+
+func Test_SIRIGetFacilityMonitoringRequest_BuildXML(t *testing.T) {
+	request := &SIRIGetFacilityMonitoringRequest{
+		RequestorRef: "test",
+	}
+	request.MessageIdentifier = "FacilityMonitoring:Test:0"
+	request.RequestTimestamp = time.Date(2016, time.September, 7, 9, 11, 25, 174000000, time.UTC)
+	request.StopPointRef = "NINOXE:StopPoint:SP:24:LOC"
+
+	xml, err := request.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"<sw:GetFacilityMonitoring",
+		"<siri:RequestorRef>test</siri:RequestorRef>",
+		"<siri:StopPointRef>NINOXE:StopPoint:SP:24:LOC</siri:StopPointRef>",
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("GetFacilityMonitoring request should contain %v:\n%v", expected, xml)
+		}
+	}
+	if strings.Contains(xml, "FacilityRef") {
+		t.Errorf("GetFacilityMonitoring request shouldn't contain a FacilityRef:\n%v", xml)
+	}
+
+	parsed, err := NewXMLGetFacilityMonitoringFromContent([]byte(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := parsed.StopPointRefs(); len(refs) != 1 || refs[0] != request.StopPointRef {
+		t.Errorf("Wrong StopPointRefs in built request: %v", refs)
+	}
+}
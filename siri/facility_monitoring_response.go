@@ -0,0 +1,218 @@
+package siri
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+	"github.com/jbowtie/gokogiri"
+	"github.com/jbowtie/gokogiri/xml"
+)
+
+type XMLFacilityMonitoringResponse struct {
+	ResponseXMLStructureWithStatus
+
+	xmlFacilityConditions []*XMLFacilityCondition
+}
+
+type XMLFacilityCondition struct {
+	XMLStructure
+
+	facilityRef   string
+	facilityClass string
+	name          string
+	stopPointRef  string
+	status        string
+	description   string
+
+	validityStartTime time.Time
+	validityEndTime   time.Time
+}
+
+type SIRIFacilityMonitoringResponse struct {
+	SIRIFacilityMonitoringDelivery
+
+	Address                   string
+	ProducerRef               string
+	ResponseMessageIdentifier string
+}
+
+type SIRIFacilityMonitoringDelivery struct {
+	RequestMessageRef string
+
+	ResponseTimestamp time.Time
+
+	Status      bool
+	ErrorType   string
+	ErrorNumber int
+	ErrorText   string
+
+	FacilityConditions []*SIRIFacilityCondition
+}
+
+type SIRIFacilityCondition struct {
+	FacilityRef   string
+	FacilityClass string
+	Name          string
+	StopPointRef  string
+
+	Status      string
+	Description string
+
+	ValidityStartTime time.Time
+	ValidityEndTime   time.Time
+}
+
+func NewXMLFacilityMonitoringResponseFromContent(content []byte) (*XMLFacilityMonitoringResponse, error) {
+	doc, err := gokogiri.ParseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	response := NewXMLFacilityMonitoringResponse(doc.Root().XmlNode)
+	return response, nil
+}
+
+func NewXMLFacilityMonitoringResponse(node xml.Node) *XMLFacilityMonitoringResponse {
+	xmlFacilityMonitoringResponse := &XMLFacilityMonitoringResponse{}
+	xmlFacilityMonitoringResponse.node = NewXMLNode(node)
+	return xmlFacilityMonitoringResponse
+}
+
+func NewXMLFacilityCondition(node XMLNode) *XMLFacilityCondition {
+	facilityCondition := &XMLFacilityCondition{}
+	facilityCondition.node = node
+	return facilityCondition
+}
+
+func (response *XMLFacilityMonitoringResponse) ErrorString() string {
+	return fmt.Sprintf("%v: %v", response.errorType(), response.ErrorText())
+}
+
+func (response *XMLFacilityMonitoringResponse) errorType() string {
+	if response.ErrorType() == "OtherError" {
+		return fmt.Sprintf("%v %v", response.ErrorType(), response.ErrorNumber())
+	}
+	return response.ErrorType()
+}
+
+func (response *XMLFacilityMonitoringResponse) XMLFacilityConditions() []*XMLFacilityCondition {
+	if len(response.xmlFacilityConditions) == 0 {
+		nodes := response.findNodes("FacilityCondition")
+		for _, node := range nodes {
+			response.xmlFacilityConditions = append(response.xmlFacilityConditions, NewXMLFacilityCondition(node))
+		}
+	}
+	return response.xmlFacilityConditions
+}
+
+// Returns the FacilityRef or the FacilityCode of the Facility
+func (condition *XMLFacilityCondition) FacilityRef() string {
+	if condition.facilityRef == "" {
+		condition.facilityRef = condition.findStringChildContent("FacilityRef")
+		if condition.facilityRef == "" {
+			condition.facilityRef = condition.findStringChildContent("FacilityCode")
+		}
+	}
+	return condition.facilityRef
+}
+
+func (condition *XMLFacilityCondition) FacilityClass() string {
+	if condition.facilityClass == "" {
+		condition.facilityClass = condition.findStringChildContent("FacilityClass")
+	}
+	return condition.facilityClass
+}
+
+// Returns the Description of the Facility
+func (condition *XMLFacilityCondition) Name() string {
+	if condition.name == "" {
+		if nodes := condition.findDirectChildrenNodes("Facility"); len(nodes) != 0 {
+			facility := XMLStructure{node: nodes[0]}
+			condition.name = facility.findStringChildContent("Description")
+		}
+	}
+	return condition.name
+}
+
+func (condition *XMLFacilityCondition) StopPointRef() string {
+	if condition.stopPointRef == "" {
+		condition.stopPointRef = condition.findStringChildContent("StopPointRef")
+	}
+	return condition.stopPointRef
+}
+
+func (condition *XMLFacilityCondition) Status() string {
+	if condition.status == "" {
+		condition.status = condition.facilityStatus().findStringChildContent("Status")
+	}
+	return condition.status
+}
+
+// Returns the Description of the FacilityStatus
+func (condition *XMLFacilityCondition) Description() string {
+	if condition.description == "" {
+		condition.description = condition.facilityStatus().findStringChildContent("Description")
+	}
+	return condition.description
+}
+
+func (condition *XMLFacilityCondition) ValidityStartTime() time.Time {
+	if condition.validityStartTime.IsZero() {
+		condition.validityStartTime = condition.findTimeChildContent("StartTime")
+	}
+	return condition.validityStartTime
+}
+
+func (condition *XMLFacilityCondition) ValidityEndTime() time.Time {
+	if condition.validityEndTime.IsZero() {
+		condition.validityEndTime = condition.findTimeChildContent("EndTime")
+	}
+	return condition.validityEndTime
+}
+
+func (condition *XMLFacilityCondition) facilityStatus() *XMLStructure {
+	nodes := condition.findDirectChildrenNodes("FacilityStatus")
+	if len(nodes) == 0 {
+		return &XMLStructure{node: condition.node}
+	}
+	return &XMLStructure{node: nodes[0]}
+}
+
+func (response *SIRIFacilityMonitoringResponse) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "facility_monitoring_response.template", response); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (delivery *SIRIFacilityMonitoringDelivery) ErrorString() string {
+	return fmt.Sprintf("%v: %v", delivery.errorType(), delivery.ErrorText)
+}
+
+func (delivery *SIRIFacilityMonitoringDelivery) errorType() string {
+	if delivery.ErrorType == "OtherError" {
+		return fmt.Sprintf("%v %v", delivery.ErrorType, delivery.ErrorNumber)
+	}
+	return delivery.ErrorType
+}
+
+func (delivery *SIRIFacilityMonitoringDelivery) BuildFacilityMonitoringDeliveryXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "facility_monitoring_delivery.template", delivery); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (condition *SIRIFacilityCondition) BuildFacilityConditionXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "facility_condition.template", condition); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
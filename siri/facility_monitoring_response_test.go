@@ -0,0 +1,146 @@
+package siri
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func getXMLFacilityMonitoringResponse(t *testing.T) *XMLFacilityMonitoringResponse {
+	file, err := os.Open("testdata/facility_monitoring_response.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := NewXMLFacilityMonitoringResponseFromContent(content)
+	return response
+}
+
+func Test_XMLFacilityMonitoringResponse(t *testing.T) {
+	response := getXMLFacilityMonitoringResponse(t)
+
+	if !response.Status() {
+		t.Errorf("Response should have a true Status")
+	}
+
+	conditions := response.XMLFacilityConditions()
+	if len(conditions) != 2 {
+		t.Fatalf("Response should have 2 FacilityConditions, got %v", len(conditions))
+	}
+
+	lift := conditions[0]
+	if expected := "NINOXE:Facility:Lift:1:LOC"; lift.FacilityRef() != expected {
+		t.Errorf("Wrong FacilityRef:\n got: %v\nwant: %v", lift.FacilityRef(), expected)
+	}
+	if expected := "Lift to platform 1"; lift.Name() != expected {
+		t.Errorf("Wrong Name:\n got: %v\nwant: %v", lift.Name(), expected)
+	}
+	if expected := "lift"; lift.FacilityClass() != expected {
+		t.Errorf("Wrong FacilityClass:\n got: %v\nwant: %v", lift.FacilityClass(), expected)
+	}
+	if expected := "NINOXE:StopPoint:SP:24:LOC"; lift.StopPointRef() != expected {
+		t.Errorf("Wrong StopPointRef:\n got: %v\nwant: %v", lift.StopPointRef(), expected)
+	}
+	if expected := "notAvailable"; lift.Status() != expected {
+		t.Errorf("Wrong Status:\n got: %v\nwant: %v", lift.Status(), expected)
+	}
+	if expected := "Under maintenance"; lift.Description() != expected {
+		t.Errorf("Wrong Description:\n got: %v\nwant: %v", lift.Description(), expected)
+	}
+	if expected := time.Date(2017, time.January, 1, 8, 0, 0, 0, time.UTC); !lift.ValidityStartTime().Equal(expected) {
+		t.Errorf("Wrong ValidityStartTime:\n got: %v\nwant: %v", lift.ValidityStartTime(), expected)
+	}
+	if expected := time.Date(2017, time.January, 1, 18, 0, 0, 0, time.UTC); !lift.ValidityEndTime().Equal(expected) {
+		t.Errorf("Wrong ValidityEndTime:\n got: %v\nwant: %v", lift.ValidityEndTime(), expected)
+	}
+
+	escalator := conditions[1]
+	if expected := "NINOXE:Facility:Escalator:2:LOC"; escalator.FacilityRef() != expected {
+		t.Errorf("Wrong FacilityRef:\n got: %v\nwant: %v", escalator.FacilityRef(), expected)
+	}
+	if expected := "available"; escalator.Status() != expected {
+		t.Errorf("Wrong Status:\n got: %v\nwant: %v", escalator.Status(), expected)
+	}
+	if escalator.Name() != "" || escalator.Description() != "" {
+		t.Errorf("Escalator shouldn't have a Name or Description")
+	}
+}
+
+func Test_SIRIFacilityMonitoringResponse_BuildXML(t *testing.T) {
+	response := &SIRIFacilityMonitoringResponse{
+		Address:                   "http://appli.chouette.mobi/siri_france/siri",
+		ProducerRef:               "NINOXE:default",
+		ResponseMessageIdentifier: "NAVINEO:SM:RQ:107",
+	}
+	response.RequestMessageRef = "FacilityMonitoring:Test:0"
+	response.ResponseTimestamp = time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC)
+	response.Status = true
+	response.FacilityConditions = []*SIRIFacilityCondition{
+		{
+			FacilityRef:       "NINOXE:Facility:Lift:1:LOC",
+			FacilityClass:     "lift",
+			Name:              "Lift to platform 1",
+			StopPointRef:      "NINOXE:StopPoint:SP:24:LOC",
+			Status:            "notAvailable",
+			Description:       "Under maintenance",
+			ValidityStartTime: time.Date(2017, time.January, 1, 8, 0, 0, 0, time.UTC),
+			ValidityEndTime:   time.Date(2017, time.January, 1, 18, 0, 0, 0, time.UTC),
+		},
+	}
+
+	xml, err := response.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := NewXMLFacilityMonitoringResponseFromContent([]byte(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conditions := parsed.XMLFacilityConditions()
+	if len(conditions) != 1 {
+		t.Fatalf("Built response should have 1 FacilityCondition:\n%v", xml)
+	}
+	condition := conditions[0]
+	if condition.FacilityRef() != "NINOXE:Facility:Lift:1:LOC" || condition.StopPointRef() != "NINOXE:StopPoint:SP:24:LOC" {
+		t.Errorf("Wrong Facility in built response:\n%v", xml)
+	}
+	if condition.Status() != "notAvailable" || condition.Description() != "Under maintenance" {
+		t.Errorf("Wrong FacilityStatus in built response:\n%v", xml)
+	}
+	if !condition.ValidityEndTime().Equal(response.FacilityConditions[0].ValidityEndTime) {
+		t.Errorf("Wrong ValidityPeriod in built response:\n%v", xml)
+	}
+}
+
+func Test_SIRINotifyFacilityMonitoring_BuildXML(t *testing.T) {
+	notify := &SIRINotifyFacilityMonitoring{
+		ProducerRef:            "NINOXE:default",
+		SubscriberRef:          "subscriber",
+		SubscriptionIdentifier: "subscription",
+		ResponseTimestamp:      time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Status:                 true,
+		FacilityConditions: []*SIRIFacilityCondition{
+			{FacilityRef: "NINOXE:Facility:Lift:1:LOC", Status: "available"},
+		},
+	}
+
+	xml, err := notify.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := NewXMLFacilityMonitoringResponseFromContent([]byte(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conditions := parsed.XMLFacilityConditions(); len(conditions) != 1 || conditions[0].Status() != "available" {
+		t.Errorf("Wrong FacilityConditions in notification:\n%v", xml)
+	}
+}
@@ -0,0 +1,41 @@
+package siri
+
+import "time"
+
+type XMLFacilityMonitoringSubscriptionRequestEntry struct {
+	XMLFacilityMonitoringRequest
+
+	subscriberRef          string
+	subscriptionRef        string
+	initialTerminationTime time.Time
+}
+
+func NewXMLFacilityMonitoringSubscriptionRequestEntry(node XMLNode) *XMLFacilityMonitoringSubscriptionRequestEntry {
+	xmlFacilityMonitoringSubscriptionRequest := &XMLFacilityMonitoringSubscriptionRequestEntry{}
+	xmlFacilityMonitoringSubscriptionRequest.node = node
+	return xmlFacilityMonitoringSubscriptionRequest
+}
+
+func (request *XMLFacilityMonitoringSubscriptionRequestEntry) SubscriberRef() string {
+	if request.subscriberRef == "" {
+		request.subscriberRef = request.findStringChildContent("SubscriberRef")
+	}
+	return request.subscriberRef
+}
+
+func (request *XMLFacilityMonitoringSubscriptionRequestEntry) SubscriptionIdentifier() string {
+	if request.subscriptionRef == "" {
+		request.subscriptionRef = request.findStringChildContent("SubscriptionIdentifier")
+		if request.subscriptionRef == "" {
+			request.subscriptionRef = request.findStringChildContent("SubscriptionRef")
+		}
+	}
+	return request.subscriptionRef
+}
+
+func (request *XMLFacilityMonitoringSubscriptionRequestEntry) InitialTerminationTime() time.Time {
+	if request.initialTerminationTime.IsZero() {
+		request.initialTerminationTime = request.findTimeChildContent("InitialTerminationTime")
+	}
+	return request.initialTerminationTime
+}
@@ -0,0 +1,28 @@
+package siri
+
+import (
+	"bytes"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+// Sent periodically to the subscribers which defined a HeartbeatInterval
+type SIRIHeartbeatNotification struct {
+	Address           string
+	ProducerRef       string
+	MessageIdentifier string
+
+	RequestTimestamp   time.Time
+	ServiceStartedTime time.Time
+	Status             bool
+}
+
+func (notification *SIRIHeartbeatNotification) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "heartbeat_notification.template", notification); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -1,6 +1,10 @@
 package siri
 
 import (
+	"bytes"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
 	"github.com/jbowtie/gokogiri"
 	"github.com/jbowtie/gokogiri/xml"
 )
@@ -23,3 +27,19 @@ func NewXMLLinesDiscoveryRequestFromContent(content []byte) (*XMLLinesDiscoveryR
 	request := NewXMLLinesDiscoveryRequest(doc.Root().XmlNode)
 	return request, nil
 }
+
+type SIRILinesDiscoveryRequest struct {
+	MessageIdentifier string
+	RequestorRef      string
+
+	RequestTimestamp time.Time
+}
+
+func (request *SIRILinesDiscoveryRequest) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "lines_discovery_request.template", request); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
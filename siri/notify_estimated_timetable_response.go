@@ -44,3 +44,12 @@ func (notify *SIRINotifyEstimatedTimeTable) BuildXML() (string, error) {
 	}
 	return buffer.String(), nil
 }
+
+func (notify *SIRINotifyEstimatedTimeTable) BuildNotifyEstimatedTimetableDeliveryXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "notify_estimated_timetable_delivery.template", notify); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,55 @@
+package siri
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"bitbucket.org/enroute-mobi/ara/logger"
+)
+
+type SIRINotifyFacilityMonitoring struct {
+	Address                   string
+	RequestMessageRef         string
+	ProducerRef               string
+	ResponseMessageIdentifier string
+	SubscriberRef             string
+	SubscriptionIdentifier    string
+
+	ResponseTimestamp time.Time
+	Status            bool
+	ErrorType         string
+	ErrorNumber       int
+	ErrorText         string
+
+	FacilityConditions []*SIRIFacilityCondition
+}
+
+func (notify *SIRINotifyFacilityMonitoring) ErrorString() string {
+	return fmt.Sprintf("%v: %v", notify.errorType(), notify.ErrorText)
+}
+
+func (notify *SIRINotifyFacilityMonitoring) errorType() string {
+	if notify.ErrorType == "OtherError" {
+		return fmt.Sprintf("%v %v", notify.ErrorType, notify.ErrorNumber)
+	}
+	return notify.ErrorType
+}
+
+func (notify *SIRINotifyFacilityMonitoring) BuildXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "facility_monitoring_notify.template", notify); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (notify *SIRINotifyFacilityMonitoring) BuildNotifyFacilityMonitoringDeliveryXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "notify_facility_monitoring_delivery.template", notify); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -115,3 +115,12 @@ func (notify *SIRINotifyGeneralMessage) BuildXML() (string, error) {
 	}
 	return buffer.String(), nil
 }
+
+func (notify *SIRINotifyGeneralMessage) BuildNotifyGeneralMessageDeliveryXML() (string, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, "notify_general_message_delivery.template", notify); err != nil {
+		logger.Log.Debugf("Error while executing template: %v", err)
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,72 @@
+package siri
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jbowtie/gokogiri"
+	"github.com/jbowtie/gokogiri/xml"
+)
+
+type XMLGetProductionTimetable struct {
+	XMLProductionTimetableRequest
+
+	requestorRef string
+}
+
+type XMLProductionTimetableRequest struct {
+	LightRequestXMLStructure
+
+	startTime time.Time
+	endTime   time.Time
+
+	lines []string
+}
+
+func NewXMLGetProductionTimetable(node xml.Node) *XMLGetProductionTimetable {
+	xmlGetProductionTimetable := &XMLGetProductionTimetable{}
+	xmlGetProductionTimetable.node = NewXMLNode(node)
+	return xmlGetProductionTimetable
+}
+
+func NewXMLGetProductionTimetableFromContent(content []byte) (*XMLGetProductionTimetable, error) {
+	doc, err := gokogiri.ParseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	request := NewXMLGetProductionTimetable(doc.Root().XmlNode)
+	return request, nil
+}
+
+func (request *XMLGetProductionTimetable) RequestorRef() string {
+	if request.requestorRef == "" {
+		request.requestorRef = request.findStringChildContent("RequestorRef")
+	}
+	return request.requestorRef
+}
+
+func (request *XMLProductionTimetableRequest) Lines() []string {
+	if len(request.lines) == 0 {
+		nodes := request.findNodes("LineRef")
+		for _, node := range nodes {
+			request.lines = append(request.lines, strings.TrimSpace(node.NativeNode().Content()))
+		}
+	}
+	return request.lines
+}
+
+// Returns the StartTime of the ValidityPeriod
+func (request *XMLProductionTimetableRequest) StartTime() time.Time {
+	if request.startTime.IsZero() {
+		request.startTime = request.findTimeChildContent("StartTime")
+	}
+	return request.startTime
+}
+
+// Returns the EndTime of the ValidityPeriod
+func (request *XMLProductionTimetableRequest) EndTime() time.Time {
+	if request.endTime.IsZero() {
+		request.endTime = request.findTimeChildContent("EndTime")
+	}
+	return request.endTime
+}
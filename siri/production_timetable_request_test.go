@@ -0,0 +1,106 @@
+package siri
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func getXMLGetProductionTimetable(t *testing.T) *XMLGetProductionTimetable {
+	file, err := os.Open("testdata/production_timetable_request.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := NewXMLGetProductionTimetableFromContent(content)
+	return request
+}
+
+func Test_XMLGetProductionTimetable_RequestorRef(t *testing.T) {
+	request := getXMLGetProductionTimetable(t)
+	if expected := "test"; request.RequestorRef() != expected {
+		t.Errorf("Wrong RequestorRef:\n got: %v\nwant: %v", request.RequestorRef(), expected)
+	}
+}
+
+func Test_XMLGetProductionTimetable_MessageIdentifier(t *testing.T) {
+	request := getXMLGetProductionTimetable(t)
+	if expected := "ProductionTimetable:Test:0"; request.MessageIdentifier() != expected {
+		t.Errorf("Wrong MessageIdentifier:\n got: %v\nwant: %v", request.MessageIdentifier(), expected)
+	}
+}
+
+func Test_XMLGetProductionTimetable_ValidityPeriod(t *testing.T) {
+	request := getXMLGetProductionTimetable(t)
+	if expected := time.Date(2016, time.September, 7, 6, 0, 0, 0, time.UTC); !request.StartTime().Equal(expected) {
+		t.Errorf("Wrong StartTime:\n got: %v\nwant: %v", request.StartTime(), expected)
+	}
+	if expected := time.Date(2016, time.September, 7, 22, 0, 0, 0, time.UTC); !request.EndTime().Equal(expected) {
+		t.Errorf("Wrong EndTime:\n got: %v\nwant: %v", request.EndTime(), expected)
+	}
+}
+
+func Test_XMLGetProductionTimetable_Lines(t *testing.T) {
+	request := getXMLGetProductionTimetable(t)
+	if len(request.Lines()) != 2 {
+		t.Fatalf("GetProductionTimetable request has wrong number of lines: %v", request.Lines())
+	}
+	if expected := "NINOXE:Line:2:LOC"; request.Lines()[0] != expected {
+		t.Errorf("Wrong first line:\n got: %v\nwant: %v", request.Lines()[0], expected)
+	}
+	if expected := "NINOXE:Line:3:LOC"; request.Lines()[1] != expected {
+		t.Errorf("Wrong second line:\n got: %v\nwant: %v", request.Lines()[1], expected)
+	}
+}
+
+func Test_XMLSubscriptionRequest_PTEntries(t *testing.T) {
+	content := []byte(`<ws:Subscribe xmlns:ws="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<SubscriptionRequestInfo>
+		<siri:RequestTimestamp>2016-09-07T09:11:25.174Z</siri:RequestTimestamp>
+		<siri:RequestorRef>test</siri:RequestorRef>
+		<siri:MessageIdentifier>Subscription:Test:0</siri:MessageIdentifier>
+	</SubscriptionRequestInfo>
+	<Request>
+		<siri:ProductionTimetableSubscriptionRequest>
+			<siri:SubscriberRef>subscriber</siri:SubscriberRef>
+			<siri:SubscriptionIdentifier>PT:Subscription:1</siri:SubscriptionIdentifier>
+			<siri:InitialTerminationTime>2016-09-08T09:11:25.174Z</siri:InitialTerminationTime>
+			<siri:ProductionTimetableRequest>
+				<siri:MessageIdentifier>ProductionTimetable:Test:1</siri:MessageIdentifier>
+				<siri:Lines>
+					<siri:LineRef>NINOXE:Line:2:LOC</siri:LineRef>
+				</siri:Lines>
+			</siri:ProductionTimetableRequest>
+		</siri:ProductionTimetableSubscriptionRequest>
+	</Request>
+</ws:Subscribe>`)
+
+	request, err := NewXMLSubscriptionRequestFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := request.XMLSubscriptionPTEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Wrong number of ProductionTimetable entries: %v", len(entries))
+	}
+	entry := entries[0]
+	if expected := "PT:Subscription:1"; entry.SubscriptionIdentifier() != expected {
+		t.Errorf("Wrong SubscriptionIdentifier:\n got: %v\nwant: %v", entry.SubscriptionIdentifier(), expected)
+	}
+	if expected := "subscriber"; entry.SubscriberRef() != expected {
+		t.Errorf("Wrong SubscriberRef:\n got: %v\nwant: %v", entry.SubscriberRef(), expected)
+	}
+	if expected := time.Date(2016, time.September, 8, 9, 11, 25, 174000000, time.UTC); !entry.InitialTerminationTime().Equal(expected) {
+		t.Errorf("Wrong InitialTerminationTime:\n got: %v\nwant: %v", entry.InitialTerminationTime(), expected)
+	}
+	if lines := entry.Lines(); len(lines) != 1 || lines[0] != "NINOXE:Line:2:LOC" {
+		t.Errorf("Wrong Lines: %v", lines)
+	}
+}
@@ -0,0 +1,67 @@
+package siri
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_SIRIProductionTimetableResponse_BuildXML(t *testing.T) {
+	aimed := time.Date(2016, time.September, 7, 9, 11, 0, 0, time.UTC)
+	response := &SIRIProductionTimetableResponse{
+		ProducerRef:               "producer",
+		ResponseMessageIdentifier: "response",
+		SIRIProductionTimetableDelivery: SIRIProductionTimetableDelivery{
+			RequestMessageRef: "request",
+			ResponseTimestamp: aimed,
+			Status:            true,
+			DatedTimetableVersionFrames: []*SIRIDatedTimetableVersionFrame{
+				{
+					RecordedAtTime: aimed,
+					LineRef:        "NINOXE:Line:2:LOC",
+					DirectionRef:   "Aller",
+					DatedVehicleJourneys: []*SIRIDatedVehicleJourney{
+						{
+							DatedVehicleJourneyCode: "NINOXE:VehicleJourney:1",
+							PublishedLineName:       "Ligne 2",
+							Attributes:              map[string]string{},
+							References:              map[string]string{"DestinationRef": "NINOXE:StopPoint:SP:2:LOC"},
+							DatedCalls: []*SIRIDatedCall{
+								{
+									StopPointRef:       "NINOXE:StopPoint:SP:1:LOC",
+									StopPointName:      "Arrêt 1",
+									Order:              1,
+									AimedDepartureTime: aimed,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	xml, err := response.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"<sw:GetProductionTimetableResponse",
+		"<siri:ProductionTimetableDelivery",
+		"<siri:DatedTimetableVersionFrame>",
+		"<siri:LineRef>NINOXE:Line:2:LOC</siri:LineRef>",
+		"<siri:DirectionRef>Aller</siri:DirectionRef>",
+		"<siri:DatedVehicleJourneyCode>NINOXE:VehicleJourney:1</siri:DatedVehicleJourneyCode>",
+		"<siri:DestinationRef>NINOXE:StopPoint:SP:2:LOC</siri:DestinationRef>",
+		"<siri:StopPointRef>NINOXE:StopPoint:SP:1:LOC</siri:StopPointRef>",
+		"<siri:AimedDepartureTime>2016-09-07T09:11:00.000Z</siri:AimedDepartureTime>",
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("XML should contain %v:\n%v", expected, xml)
+		}
+	}
+	if strings.Contains(xml, "AimedArrivalTime") {
+		t.Errorf("XML shouldn't contain an undefined AimedArrivalTime:\n%v", xml)
+	}
+}
@@ -74,6 +74,37 @@ type SIRIMonitoredStopVisit struct {
 
 	// Références
 	References map[string]map[string]string
+
+	PreviousCalls []*SIRIPreviousCall
+	OnwardCalls   []*SIRIOnwardCall
+}
+
+type SIRIPreviousCall struct {
+	StopPointRef  string
+	StopPointName string
+
+	Order int
+
+	AimedArrivalTime  time.Time
+	ActualArrivalTime time.Time
+
+	AimedDepartureTime  time.Time
+	ActualDepartureTime time.Time
+}
+
+type SIRIOnwardCall struct {
+	StopPointRef    string
+	StopPointName   string
+	ArrivalStatus   string
+	DepartureStatus string
+
+	Order int
+
+	AimedArrivalTime    time.Time
+	ExpectedArrivalTime time.Time
+
+	AimedDepartureTime    time.Time
+	ExpectedDepartureTime time.Time
 }
 
 func (response *SIRIStopMonitoringResponse) BuildXML() (string, error) {
@@ -1,6 +1,7 @@
 package siri
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -136,3 +137,40 @@ func Test_SIRIStopMonitoringResponse_BuildXML(t *testing.T) {
 		t.Errorf("Wrong XML for Request:\n got:\n%v\nwant:\n%v", xml, expectedXML)
 	}
 }
+
+func Test_SIRIMonitoredStopVisit_BuildCallsXML(t *testing.T) {
+	aimed := time.Date(2016, time.September, 21, 20, 14, 46, 0, time.UTC)
+	stopVisit := &SIRIMonitoredStopVisit{
+		StopPointRef: "stop-2",
+		Order:        2,
+		PreviousCalls: []*SIRIPreviousCall{
+			{StopPointRef: "stop-1", Order: 1, AimedDepartureTime: aimed},
+		},
+		OnwardCalls: []*SIRIOnwardCall{
+			{StopPointRef: "stop-3", Order: 3, ExpectedArrivalTime: aimed, ArrivalStatus: "onTime"},
+		},
+	}
+
+	xml, err := stopVisit.BuildMonitoredStopVisitXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousCalls := strings.Index(xml, "<siri:PreviousCalls>")
+	monitoredCall := strings.Index(xml, "<siri:MonitoredCall>")
+	onwardCalls := strings.Index(xml, "<siri:OnwardCalls>")
+	if previousCalls < 0 || onwardCalls < 0 || previousCalls > monitoredCall || onwardCalls < monitoredCall {
+		t.Fatalf("PreviousCalls and OnwardCalls should surround the MonitoredCall:\n%v", xml)
+	}
+	for _, expected := range []string{
+		"<siri:StopPointRef>stop-1</siri:StopPointRef>",
+		"<siri:AimedDepartureTime>2016-09-21T20:14:46.000Z</siri:AimedDepartureTime>",
+		"<siri:StopPointRef>stop-3</siri:StopPointRef>",
+		"<siri:ExpectedArrivalTime>2016-09-21T20:14:46.000Z</siri:ExpectedArrivalTime>",
+		"<siri:ArrivalStatus>onTime</siri:ArrivalStatus>",
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("XML should contain %v:\n%v", expected, xml)
+		}
+	}
+}
@@ -25,6 +25,7 @@ type MonitoredVehicleJourney struct {
 	FramedVehicleJourneyRef *FramedVehicleJourneyRef `json:",omitempty"`
 	PublishedLineName       string                   `json:",omitempty"`
 	DirectionName           string                   `json:",omitempty"`
+	OperatorRef             string                   `json:",omitempty"`
 	OriginRef               string                   `json:",omitempty"`
 	OriginName              string                   `json:",omitempty"`
 	DestinationRef          string                   `json:",omitempty"`
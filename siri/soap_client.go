@@ -3,6 +3,7 @@ package siri
 import (
 	"compress/gzip"
 	"context"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"bitbucket.org/enroute-mobi/ara/clock"
 	"bitbucket.org/enroute-mobi/ara/version"
 	"github.com/jbowtie/gokogiri/xml"
 	"golang.org/x/text/encoding/charmap"
@@ -31,9 +33,47 @@ type Request interface {
 }
 
 type SOAPClient struct {
+	clock.ClockConsumer
+
 	SOAPClientUrls
+	SOAPClientSettings
+
+	httpClient        *http.Client
+	circuitBreaker    *CircuitBreaker
+	clientCertificate *x509.Certificate
+	authenticator     authenticator
+	settingsError     error
+}
+
+// Timeouts, retries and circuit breaker of the SOAPClient
+type SOAPClientSettings struct {
+	Timeout             time.Duration
+	SubscriptionTimeout time.Duration
+	CheckStatusTimeout  time.Duration
+	NotificationTimeout time.Duration
 
-	httpClient *http.Client
+	// Number of retries of idempotent requests and notifications
+	Retries int
+	// Delay before the first retry, doubled after each attempt
+	RetryBackoff time.Duration
+
+	// Consecutive failures opening the circuit breaker (0 disables it)
+	CircuitBreakerThreshold int
+	CircuitBreakerTimeout   time.Duration
+
+	TLS            TLSSettings
+	Authentication AuthenticationSettings
+}
+
+func DefaultSOAPClientSettings() SOAPClientSettings {
+	return SOAPClientSettings{
+		Timeout:               5 * time.Second,
+		SubscriptionTimeout:   30 * time.Second,
+		CheckStatusTimeout:    9 * time.Second,
+		NotificationTimeout:   5 * time.Second,
+		RetryBackoff:          500 * time.Millisecond,
+		CircuitBreakerTimeout: 30 * time.Second,
+	}
 }
 
 type SOAPClientUrls struct {
@@ -47,9 +87,14 @@ type soapClientArguments struct {
 	requestType      requestType
 	expectedResponse string
 	acceptGzip       bool
+	retry            bool
 }
 
 func NewSOAPClient(urls SOAPClientUrls) *SOAPClient {
+	return NewSOAPClientWithSettings(urls, DefaultSOAPClientSettings())
+}
+
+func NewSOAPClientWithSettings(urls SOAPClientUrls, settings SOAPClientSettings) *SOAPClient {
 	// Customize the Transport based on DefaultTransport
 	// DefaultTransport for reference
 	// var DefaultTransport RoundTripper = &Transport{
@@ -76,10 +121,45 @@ func NewSOAPClient(urls SOAPClientUrls) *SOAPClient {
 		Transport: netTransport,
 	}
 
-	return &SOAPClient{
-		SOAPClientUrls: urls,
-		httpClient:     httpClient,
+	client := &SOAPClient{
+		SOAPClientUrls:     urls,
+		SOAPClientSettings: settings,
+		httpClient:         httpClient,
+		circuitBreaker:     NewCircuitBreaker(settings.CircuitBreakerThreshold, settings.CircuitBreakerTimeout),
+	}
+
+	// Requests fail with the settings error instead of being sent without the expected TLS or authentication
+	if !settings.TLS.IsZero() {
+		var err error
+		netTransport.TLSClientConfig, client.clientCertificate, err = settings.TLS.Config()
+		if err != nil {
+			client.settingsError = fmt.Errorf("invalid TLS settings: %v", err)
+			return client
+		}
 	}
+
+	authenticator, err := newAuthenticator(settings.Authentication, httpClient)
+	if err != nil {
+		client.settingsError = fmt.Errorf("invalid authentication settings: %v", err)
+		return client
+	}
+	client.authenticator = authenticator
+
+	return client
+}
+
+func (client *SOAPClient) CircuitBreaker() *CircuitBreaker {
+	return client.circuitBreaker
+}
+
+// Returns the client certificate used for mutual TLS, nil if not defined
+func (client *SOAPClient) ClientCertificate() *x509.Certificate {
+	return client.clientCertificate
+}
+
+// Returns the error while loading the TLS or authentication settings
+func (client *SOAPClient) SettingsError() error {
+	return client.settingsError
 }
 
 func (client *SOAPClient) responseFromFormat(body io.Reader, contentType string) io.Reader {
@@ -94,26 +174,81 @@ func (client *SOAPClient) responseFromFormat(body io.Reader, contentType string)
 	return body
 }
 
+var errUnauthorized = NewSiriError("SIRI CRITICAL: HTTP status 401")
+
+// Error returned without sending the request when the circuit breaker is open
+func NewCircuitBreakerOpenError() error {
+	return NewSiriError("SIRI CRITICAL: circuit breaker open")
+}
+
 func (client *SOAPClient) prepareAndSendRequest(args soapClientArguments) (xml.Node, error) {
-	// Wrap the request XML
-	soapEnvelope := NewSOAPEnvelopeBuffer()
-	xml, err := args.request.BuildXML()
+	if client.settingsError != nil {
+		return nil, NewSiriError(fmt.Sprintf("SIRI CRITICAL: %v", client.settingsError))
+	}
+
+	requestXML, err := args.request.BuildXML()
 	if err != nil {
 		return nil, err
 	}
 
-	soapEnvelope.WriteXML(xml)
+	attempts := 1
+	if args.retry {
+		attempts += client.Retries
+	}
+	backoff := client.RetryBackoff
+	reauthenticated := false
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		if !client.circuitBreaker.Allow() {
+			// The error of a previous attempt is more useful than the breaker state
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, NewCircuitBreakerOpenError()
+		}
+
+		node, temporary, err := client.sendRequest(args, requestXML)
+		if err == errUnauthorized && !reauthenticated {
+			// The credentials (like an expired OAuth2 token) are renewed once
+			client.authenticator.reset()
+			reauthenticated = true
+			attempt--
+			continue
+		}
+		if err == nil || !temporary {
+			// Only network errors and server errors show a failing partner
+			client.circuitBreaker.Success()
+			return node, err
+		}
+		client.circuitBreaker.Failure(err)
+		lastErr = err
+
+		// No retry once the failures opened the circuit breaker
+		if attempt >= attempts || client.circuitBreaker.State() == CIRCUIT_BREAKER_OPEN {
+			return nil, err
+		}
+		client.Clock().Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Returns temporary at true for network errors and HTTP server errors, which can be retried
+func (client *SOAPClient) sendRequest(args soapClientArguments, requestXML string) (node xml.Node, temporary bool, err error) {
+	// Wrap the request XML
+	soapEnvelope := NewSOAPEnvelopeBuffer()
+	soapEnvelope.WriteXML(requestXML)
 
 	// For tests
 	// logger.Log.Debugf("%v", soapEnvelope.String())
 
 	// Create http request
-	ctx, cncl := context.WithTimeout(context.Background(), getTimeOut(args.requestType))
+	ctx, cncl := context.WithTimeout(context.Background(), client.timeout(args.requestType))
 	defer cncl()
 
 	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, client.getURL(args.requestType), soapEnvelope)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if args.acceptGzip {
 		httpRequest.Header.Set("Accept-Encoding", "gzip, deflate")
@@ -121,29 +256,38 @@ func (client *SOAPClient) prepareAndSendRequest(args soapClientArguments) (xml.N
 	httpRequest.Header.Set("Content-Type", "text/xml; charset=utf-8")
 	httpRequest.Header.Set("User-Agent", version.ApplicationName())
 	httpRequest.ContentLength = soapEnvelope.Length()
+	if client.authenticator != nil {
+		if err := client.authenticator.authenticate(httpRequest); err != nil {
+			return nil, true, err
+		}
+	}
 
 	// Send http request
 	response, err := client.httpClient.Do(httpRequest)
 	if err != nil {
-		return nil, err
+		return nil, true, err
 	}
 	defer func() {
 		io.Copy(ioutil.Discard, response.Body)
 		response.Body.Close()
 	}()
 
+	if response.StatusCode == http.StatusUnauthorized && client.authenticator != nil {
+		return nil, false, errUnauthorized
+	}
+
 	// Do nothing if request is a notification
-	if args.requestType == NOTIFICATION {
-		return nil, nil
+	if args.requestType == NOTIFICATION && response.StatusCode < http.StatusInternalServerError {
+		return nil, false, nil
 	}
 
 	// Check response status
 	if response.StatusCode != http.StatusOK {
-		return nil, NewSiriError(strings.Join([]string{"SIRI CRITICAL: HTTP status ", strconv.Itoa(response.StatusCode)}, ""))
+		return nil, response.StatusCode >= http.StatusInternalServerError, NewSiriError(strings.Join([]string{"SIRI CRITICAL: HTTP status ", strconv.Itoa(response.StatusCode)}, ""))
 	}
 
 	if !strings.Contains(response.Header.Get("Content-Type"), "text/xml") {
-		return nil, NewSiriError(fmt.Sprintf("SIRI CRITICAL: HTTP Content-Type %v", response.Header.Get("Content-Type")))
+		return nil, false, NewSiriError(fmt.Sprintf("SIRI CRITICAL: HTTP Content-Type %v", response.Header.Get("Content-Type")))
 	}
 
 	// Check if response is gzip
@@ -151,7 +295,7 @@ func (client *SOAPClient) prepareAndSendRequest(args soapClientArguments) (xml.N
 	if args.acceptGzip && response.Header.Get("Content-Encoding") == "gzip" {
 		gzipReader, err := gzip.NewReader(response.Body)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		defer gzipReader.Close()
 		responseReader = gzipReader
@@ -162,12 +306,12 @@ func (client *SOAPClient) prepareAndSendRequest(args soapClientArguments) (xml.N
 	// Create SOAPEnvelope and check body type
 	envelope, err := NewSOAPEnvelope(responseReader)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if envelope.BodyType() != args.expectedResponse {
-		return nil, NewSiriError(fmt.Sprintf("SIRI CRITICAL: Wrong Soap from server: %v", envelope.BodyType()))
+		return nil, false, NewSiriError(fmt.Sprintf("SIRI CRITICAL: Wrong Soap from server: %v", envelope.BodyType()))
 	}
-	return envelope.Body(), nil
+	return envelope.Body(), false, nil
 }
 
 func (client *SOAPClient) getURL(requestType requestType) string {
@@ -184,14 +328,16 @@ func (client *SOAPClient) getURL(requestType requestType) string {
 	return client.Url
 }
 
-func getTimeOut(rt requestType) time.Duration {
+func (client *SOAPClient) timeout(rt requestType) time.Duration {
 	switch rt {
 	case SUBSCRIPTION:
-		return 30 * time.Second
+		return client.SubscriptionTimeout
 	case CHECK_STATUS:
-		return 9 * time.Second
+		return client.CheckStatusTimeout
+	case NOTIFICATION:
+		return client.NotificationTimeout
 	default:
-		return 5 * time.Second
+		return client.Timeout
 	}
 }
 
@@ -215,6 +361,7 @@ func (client *SOAPClient) StopDiscovery(request *SIRIStopPointsDiscoveryRequest)
 		request:          request,
 		expectedResponse: "StopPointsDiscoveryResponse",
 		acceptGzip:       true,
+		retry:            true,
 	})
 	if err != nil {
 		return nil, err
@@ -224,11 +371,27 @@ func (client *SOAPClient) StopDiscovery(request *SIRIStopPointsDiscoveryRequest)
 	return stopDiscovery, nil
 }
 
+func (client *SOAPClient) LinesDiscovery(request *SIRILinesDiscoveryRequest) (*XMLLinesDiscoveryResponse, error) {
+	node, err := client.prepareAndSendRequest(soapClientArguments{
+		request:          request,
+		expectedResponse: "LinesDiscoveryResponse",
+		acceptGzip:       true,
+		retry:            true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	linesDiscovery := NewXMLLinesDiscoveryResponse(node)
+	return linesDiscovery, nil
+}
+
 func (client *SOAPClient) StopMonitoring(request *SIRIGetStopMonitoringRequest) (*XMLStopMonitoringResponse, error) {
 	node, err := client.prepareAndSendRequest(soapClientArguments{
 		request:          request,
 		expectedResponse: "GetStopMonitoringResponse",
 		acceptGzip:       true,
+		retry:            true,
 	})
 	if err != nil {
 		return nil, err
@@ -243,6 +406,7 @@ func (client *SOAPClient) SituationMonitoring(request *SIRIGetGeneralMessageRequ
 		request:          request,
 		expectedResponse: "GetGeneralMessageResponse",
 		acceptGzip:       true,
+		retry:            true,
 	})
 	if err != nil {
 		return nil, err
@@ -252,6 +416,21 @@ func (client *SOAPClient) SituationMonitoring(request *SIRIGetGeneralMessageRequ
 	return generalMessage, nil
 }
 
+func (client *SOAPClient) FacilityMonitoring(request *SIRIGetFacilityMonitoringRequest) (*XMLFacilityMonitoringResponse, error) {
+	node, err := client.prepareAndSendRequest(soapClientArguments{
+		request:          request,
+		expectedResponse: "GetFacilityMonitoringResponse",
+		acceptGzip:       true,
+		retry:            true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	facilityMonitoring := NewXMLFacilityMonitoringResponse(node)
+	return facilityMonitoring, nil
+}
+
 func (client *SOAPClient) StopMonitoringSubscription(request *SIRIStopMonitoringSubscriptionRequest) (*XMLSubscriptionResponse, error) {
 	node, err := client.prepareAndSendRequest(soapClientArguments{
 		request:          request,
@@ -299,6 +478,7 @@ func (client *SOAPClient) NotifyStopMonitoring(request *SIRINotifyStopMonitoring
 	_, err := client.prepareAndSendRequest(soapClientArguments{
 		request:     request,
 		requestType: NOTIFICATION,
+		retry:       true,
 	})
 	if err != nil {
 		return err
@@ -307,6 +487,18 @@ func (client *SOAPClient) NotifyStopMonitoring(request *SIRINotifyStopMonitoring
 }
 
 func (client *SOAPClient) NotifyGeneralMessage(request *SIRINotifyGeneralMessage) error {
+	_, err := client.prepareAndSendRequest(soapClientArguments{
+		request:     request,
+		requestType: NOTIFICATION,
+		retry:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (client *SOAPClient) NotifyHeartbeat(request *SIRIHeartbeatNotification) error {
 	_, err := client.prepareAndSendRequest(soapClientArguments{
 		request:     request,
 		requestType: NOTIFICATION,
@@ -317,10 +509,47 @@ func (client *SOAPClient) NotifyGeneralMessage(request *SIRINotifyGeneralMessage
 	return nil
 }
 
+func (client *SOAPClient) DataReady(request *SIRIDataReadyNotification) error {
+	_, err := client.prepareAndSendRequest(soapClientArguments{
+		request:     request,
+		requestType: NOTIFICATION,
+		retry:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (client *SOAPClient) NotifyEstimatedTimeTable(request *SIRINotifyEstimatedTimeTable) error {
 	_, err := client.prepareAndSendRequest(soapClientArguments{
 		request:     request,
 		requestType: NOTIFICATION,
+		retry:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (client *SOAPClient) NotifyProductionTimetable(request *SIRINotifyProductionTimetable) error {
+	_, err := client.prepareAndSendRequest(soapClientArguments{
+		request:     request,
+		requestType: NOTIFICATION,
+		retry:       true,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (client *SOAPClient) NotifyFacilityMonitoring(request *SIRINotifyFacilityMonitoring) error {
+	_, err := client.prepareAndSendRequest(soapClientArguments{
+		request:     request,
+		requestType: NOTIFICATION,
+		retry:       true,
 	})
 	if err != nil {
 		return err
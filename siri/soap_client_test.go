@@ -7,8 +7,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"bitbucket.org/enroute-mobi/ara/clock"
 )
 
 func testSOAPFile(name string) (*os.File, error) {
@@ -146,3 +149,115 @@ func Test_SOAPClient_StopMonitoring(t *testing.T) {
 		t.Errorf("Wrong ResponseTimestamp in response:\n got: %v\n want: %v", response.ResponseTimestamp(), expected)
 	}
 }
+
+func Test_SOAPClient_StopMonitoring_Retry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		file, err := testSOAPFile("stopmonitoring-response")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		io.Copy(w, file)
+	}))
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.Retries = 1
+	settings.RetryBackoff = time.Minute
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings)
+	fakeClock := clock.NewFakeClock()
+	client.SetClock(fakeClock)
+
+	request := &SIRIGetStopMonitoringRequest{
+		RequestorRef: "Ara",
+	}
+	request.MessageIdentifier = "Ara:Message::6ba7b814-9dad-11d1-32-00c04fd430c8:LOC"
+	request.MonitoringRef = "STIF:StopArea:SP:6ba7b814-9dad-11d1-32-00c04fd430c8"
+	request.RequestTimestamp = time.Now()
+
+	result := make(chan error)
+	go func() {
+		_, err := client.StopMonitoring(request)
+		result <- err
+	}()
+
+	// The client waits the backoff before the retry
+	fakeClock.BlockUntil(1)
+	if r := atomic.LoadInt32(&requests); r != 1 {
+		t.Errorf("Wrong number of requests before the backoff:\n got: %v\n want: 1", r)
+	}
+	fakeClock.Advance(time.Minute)
+
+	if err := <-result; err != nil {
+		t.Fatalf("Request should succeed after a retry: %v", err)
+	}
+	if r := atomic.LoadInt32(&requests); r != 2 {
+		t.Errorf("Wrong number of requests:\n got: %v\n want: 2", r)
+	}
+}
+
+func Test_SOAPClient_CircuitBreaker(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.CircuitBreakerThreshold = 1
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings)
+
+	request := &SIRICheckStatusRequest{
+		RequestorRef:      "Ara",
+		RequestTimestamp:  time.Now(),
+		MessageIdentifier: "Ara:Message::6ba7b814-9dad-11d1-32-00c04fd430c8:LOC",
+	}
+	if _, err := client.CheckStatus(request); err == nil {
+		t.Fatalf("Request should fail")
+	}
+	if client.CircuitBreaker().State() != CIRCUIT_BREAKER_OPEN {
+		t.Fatalf("CircuitBreaker should be open, got: %v", client.CircuitBreaker().State())
+	}
+	if _, err := client.CheckStatus(request); err == nil || err.Error() != NewCircuitBreakerOpenError().Error() {
+		t.Errorf("Request should be short-circuited, got: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Wrong number of requests:\n got: %v\n want: 1", requests)
+	}
+}
+
+func Test_SOAPClient_CircuitBreaker_Retry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	settings := DefaultSOAPClientSettings()
+	settings.CircuitBreakerThreshold = 1
+	settings.Retries = 2
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings)
+
+	request := &SIRIGetStopMonitoringRequest{
+		RequestorRef: "Ara",
+	}
+	request.MessageIdentifier = "Ara:Message::6ba7b814-9dad-11d1-32-00c04fd430c8:LOC"
+	request.MonitoringRef = "STIF:StopArea:SP:6ba7b814-9dad-11d1-32-00c04fd430c8"
+	request.RequestTimestamp = time.Now()
+
+	_, err := client.StopMonitoring(request)
+	if err == nil || err.Error() == NewCircuitBreakerOpenError().Error() {
+		t.Errorf("Request should return the partner error, got: %v", err)
+	}
+	if r := atomic.LoadInt32(&requests); r != 1 {
+		t.Errorf("Request shouldn't be retried once the circuit breaker is open:\n got: %v\n want: 1", r)
+	}
+}
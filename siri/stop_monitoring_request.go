@@ -28,7 +28,13 @@ type LightXMLStopMonitoringRequest struct {
 	monitoringRef     string
 	stopVisitTypes    string
 	lineRef           string
+	operatorRef       string
+	detailLevel       string
 	maximumStopVisits int
+
+	numberOfCallsParsed bool
+	previousCalls       int
+	onwardCalls         int
 }
 
 type SIRIGetStopMonitoringRequest struct {
@@ -120,6 +126,20 @@ func (request *LightXMLStopMonitoringRequest) LineRef() string {
 	return request.lineRef
 }
 
+func (request *LightXMLStopMonitoringRequest) OperatorRef() string {
+	if request.operatorRef == "" {
+		request.operatorRef = request.findStringChildContent("OperatorRef")
+	}
+	return request.operatorRef
+}
+
+func (request *LightXMLStopMonitoringRequest) DetailLevel() string {
+	if request.detailLevel == "" {
+		request.detailLevel = request.findStringChildContent("DetailLevel")
+	}
+	return request.detailLevel
+}
+
 func (request *LightXMLStopMonitoringRequest) MaximumStopVisits() int {
 	if request.maximumStopVisits == 0 {
 		request.maximumStopVisits = request.findIntChildContent("MaximumStopVisits")
@@ -127,6 +147,25 @@ func (request *LightXMLStopMonitoringRequest) MaximumStopVisits() int {
 	return request.maximumStopVisits
 }
 
+// Returns the Previous and Onward values of MaximumNumberOfCalls. A value is
+// negative when it isn't defined
+func (request *LightXMLStopMonitoringRequest) MaximumNumberOfCalls() (previous int, onward int) {
+	if !request.numberOfCallsParsed {
+		request.numberOfCallsParsed = true
+		request.previousCalls, request.onwardCalls = -1, -1
+
+		if request.findNode("MaximumNumberOfCalls") != nil {
+			if request.findNode("Previous") != nil {
+				request.previousCalls = request.findIntChildContent("Previous")
+			}
+			if request.findNode("Onward") != nil {
+				request.onwardCalls = request.findIntChildContent("Onward")
+			}
+		}
+	}
+	return request.previousCalls, request.onwardCalls
+}
+
 func (request *XMLStopMonitoringRequest) PreviewInterval() time.Duration {
 	if request.previewInterval == 0 {
 		request.previewInterval = request.findDurationChildContent("PreviewInterval")
@@ -86,3 +86,29 @@ func Test_SIRIStopMonitoringRequest_BuildXML(t *testing.T) {
 		t.Errorf("Wrong XML for Request:\n got:\n%v\nwant:\n%v", xml, expectedXML)
 	}
 }
+
+func Test_XMLStopMonitoringRequest_MaximumNumberOfCalls(t *testing.T) {
+	content := []byte(`<siri:StopMonitoringRequest xmlns:siri="http://www.siri.org.uk/siri">
+	<siri:MonitoringRef>NINOXE:StopPoint:SP:24:LOC</siri:MonitoringRef>
+	<siri:MaximumNumberOfCalls>
+		<siri:Previous>2</siri:Previous>
+		<siri:Onward>0</siri:Onward>
+	</siri:MaximumNumberOfCalls>
+</siri:StopMonitoringRequest>`)
+	node, err := NewXMLNodeFromContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := &LightXMLStopMonitoringRequest{}
+	request.node = node
+
+	if previous, onward := request.MaximumNumberOfCalls(); previous != 2 || onward != 0 {
+		t.Errorf("Wrong MaximumNumberOfCalls:\n got: %v %v\n want: 2 0", previous, onward)
+	}
+
+	request = &LightXMLStopMonitoringRequest{}
+	request.node, _ = NewXMLNodeFromContent([]byte(`<siri:StopMonitoringRequest xmlns:siri="http://www.siri.org.uk/siri"/>`))
+	if previous, onward := request.MaximumNumberOfCalls(); previous != -1 || onward != -1 {
+		t.Errorf("Undefined MaximumNumberOfCalls should be negative:\n got: %v %v", previous, onward)
+	}
+}
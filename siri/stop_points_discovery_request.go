@@ -11,6 +11,23 @@ import (
 
 type XMLStopPointsDiscoveryRequest struct {
 	RequestXMLStructure
+
+	upperLeft  *XMLLocation
+	lowerRight *XMLLocation
+	circle     *XMLCircle
+}
+
+type XMLLocation struct {
+	XMLStructure
+
+	longitude float64
+	latitude  float64
+}
+
+type XMLCircle struct {
+	XMLLocation
+
+	radius float64
 }
 
 type SIRIStopPointsDiscoveryRequest struct {
@@ -35,6 +52,73 @@ func NewXMLStopPointsDiscoveryRequestFromContent(content []byte) (*XMLStopPoints
 	return request, nil
 }
 
+func NewXMLLocation(node XMLNode) *XMLLocation {
+	location := &XMLLocation{}
+	location.node = node
+	return location
+}
+
+func NewXMLCircle(node XMLNode) *XMLCircle {
+	circle := &XMLCircle{}
+	circle.node = node
+	return circle
+}
+
+// Returns the UpperLeft corner of the request BoundingBox, or nil when not defined
+func (request *XMLStopPointsDiscoveryRequest) UpperLeft() *XMLLocation {
+	if request.upperLeft == nil {
+		nodes := request.findNodes("UpperLeft")
+		if nodes != nil {
+			request.upperLeft = NewXMLLocation(nodes[0])
+		}
+	}
+	return request.upperLeft
+}
+
+// Returns the LowerRight corner of the request BoundingBox, or nil when not defined
+func (request *XMLStopPointsDiscoveryRequest) LowerRight() *XMLLocation {
+	if request.lowerRight == nil {
+		nodes := request.findNodes("LowerRight")
+		if nodes != nil {
+			request.lowerRight = NewXMLLocation(nodes[0])
+		}
+	}
+	return request.lowerRight
+}
+
+// Returns the request Circle, or nil when not defined
+func (request *XMLStopPointsDiscoveryRequest) Circle() *XMLCircle {
+	if request.circle == nil {
+		nodes := request.findNodes("Circle")
+		if nodes != nil {
+			request.circle = NewXMLCircle(nodes[0])
+		}
+	}
+	return request.circle
+}
+
+func (location *XMLLocation) Longitude() float64 {
+	if location.longitude == 0 {
+		location.longitude = location.findFloatChildContent("Longitude")
+	}
+	return location.longitude
+}
+
+func (location *XMLLocation) Latitude() float64 {
+	if location.latitude == 0 {
+		location.latitude = location.findFloatChildContent("Latitude")
+	}
+	return location.latitude
+}
+
+// Radius in meters
+func (circle *XMLCircle) Radius() float64 {
+	if circle.radius == 0 {
+		circle.radius = circle.findFloatChildContent("Radius")
+	}
+	return circle.radius
+}
+
 func NewSIRIStopPointsDiscoveryRequest(messageIdentifier, requestorRef string, requestTimestamp time.Time) *SIRIStopPointsDiscoveryRequest {
 	return &SIRIStopPointsDiscoveryRequest{
 		MessageIdentifier: messageIdentifier,
@@ -8,7 +8,11 @@ import (
 )
 
 func getXMLStopPointsDiscoveryRequest(t *testing.T) *XMLStopPointsDiscoveryRequest {
-	file, err := os.Open("testdata/stopdiscovery-request.xml")
+	return getXMLStopPointsDiscoveryRequestFromFile("testdata/stopdiscovery-request.xml", t)
+}
+
+func getXMLStopPointsDiscoveryRequestFromFile(path string, t *testing.T) *XMLStopPointsDiscoveryRequest {
+	file, err := os.Open(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -37,3 +41,49 @@ func Test_XMLStopPointsDiscoveryRequest_RequestTimestamp(t *testing.T) {
 		t.Errorf("Wrong RequestTimestamp:\n got: %v\nwant: %v", request.RequestTimestamp(), expected)
 	}
 }
+
+func Test_XMLStopPointsDiscoveryRequest_WithoutLocation(t *testing.T) {
+	request := getXMLStopPointsDiscoveryRequest(t)
+
+	if request.Circle() != nil {
+		t.Errorf("Circle should be nil, got: %v", request.Circle())
+	}
+	if request.UpperLeft() != nil || request.LowerRight() != nil {
+		t.Errorf("BoundingBox should be nil")
+	}
+}
+
+func Test_XMLStopPointsDiscoveryRequest_BoundingBox(t *testing.T) {
+	request := getXMLStopPointsDiscoveryRequestFromFile("testdata/stopdiscovery-request-bounding-box.xml", t)
+
+	upperLeft := request.UpperLeft()
+	if upperLeft == nil {
+		t.Fatalf("UpperLeft shouldn't be nil")
+	}
+	if upperLeft.Latitude() != 48.90 || upperLeft.Longitude() != 2.30 {
+		t.Errorf("Wrong UpperLeft:\n got: %v,%v\nwant: 48.9,2.3", upperLeft.Latitude(), upperLeft.Longitude())
+	}
+
+	lowerRight := request.LowerRight()
+	if lowerRight == nil {
+		t.Fatalf("LowerRight shouldn't be nil")
+	}
+	if lowerRight.Latitude() != 48.80 || lowerRight.Longitude() != 2.40 {
+		t.Errorf("Wrong LowerRight:\n got: %v,%v\nwant: 48.8,2.4", lowerRight.Latitude(), lowerRight.Longitude())
+	}
+}
+
+func Test_XMLStopPointsDiscoveryRequest_Circle(t *testing.T) {
+	request := getXMLStopPointsDiscoveryRequestFromFile("testdata/stopdiscovery-request-circle.xml", t)
+
+	circle := request.Circle()
+	if circle == nil {
+		t.Fatalf("Circle shouldn't be nil")
+	}
+	if circle.Latitude() != 48.85 || circle.Longitude() != 2.35 {
+		t.Errorf("Wrong Circle center:\n got: %v,%v\nwant: 48.85,2.35", circle.Latitude(), circle.Longitude())
+	}
+	if circle.Radius() != 500 {
+		t.Errorf("Wrong Circle Radius:\n got: %v\nwant: 500", circle.Radius())
+	}
+}
@@ -12,10 +12,13 @@ type XMLSubscriptionRequest struct {
 
 	changeBeforeUpdates string
 	incrementalUpdates  string
+	heartbeatInterval   string
 
 	smEntries  []*XMLStopMonitoringSubscriptionRequestEntry
 	gmEntries  []*XMLGeneralMessageSubscriptionRequestEntry
 	ettEntries []*XMLEstimatedTimetableSubscriptionRequestEntry
+	ptEntries  []*XMLProductionTimetableSubscriptionRequestEntry
+	fmEntries  []*XMLFacilityMonitoringSubscriptionRequestEntry
 }
 
 func NewXMLSubscriptionRequestFromContent(content []byte) (*XMLSubscriptionRequest, error) {
@@ -55,6 +58,28 @@ func (request *XMLSubscriptionRequest) XMLSubscriptionETTEntries() []*XMLEstimat
 	return request.ettEntries
 }
 
+func (request *XMLSubscriptionRequest) XMLSubscriptionPTEntries() []*XMLProductionTimetableSubscriptionRequestEntry {
+	if len(request.ptEntries) != 0 {
+		return request.ptEntries
+	}
+	nodes := request.findNodes("ProductionTimetableSubscriptionRequest")
+	for _, pt := range nodes {
+		request.ptEntries = append(request.ptEntries, NewXMLProductionTimetableSubscriptionRequestEntry(pt))
+	}
+	return request.ptEntries
+}
+
+func (request *XMLSubscriptionRequest) XMLSubscriptionFMEntries() []*XMLFacilityMonitoringSubscriptionRequestEntry {
+	if len(request.fmEntries) != 0 {
+		return request.fmEntries
+	}
+	nodes := request.findNodes("FacilityMonitoringSubscriptionRequest")
+	for _, fm := range nodes {
+		request.fmEntries = append(request.fmEntries, NewXMLFacilityMonitoringSubscriptionRequestEntry(fm))
+	}
+	return request.fmEntries
+}
+
 func (request *XMLSubscriptionRequest) XMLSubscriptionGMEntries() []*XMLGeneralMessageSubscriptionRequestEntry {
 	if len(request.gmEntries) != 0 {
 		return request.gmEntries
@@ -86,3 +111,11 @@ func (request *XMLSubscriptionRequest) IncrementalUpdates() string {
 	}
 	return request.incrementalUpdates
 }
+
+// Returns the HeartbeatInterval of the SubscriptionContext (like PT1M)
+func (request *XMLSubscriptionRequest) HeartbeatInterval() string {
+	if request.heartbeatInterval == "" {
+		request.heartbeatInterval = request.findStringChildContent("HeartbeatInterval")
+	}
+	return request.heartbeatInterval
+}
@@ -0,0 +1,65 @@
+package siri
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLS customization of the connections to a partner. All files are PEM encoded
+type TLSSettings struct {
+	// Client certificate and key files, used for mutual TLS
+	ClientCertificate string
+	ClientKey         string
+	// Trusted CA certificates, in addition to the system ones
+	CABundle string
+	// Server name expected in the partner certificate
+	ServerName string
+}
+
+func (settings TLSSettings) IsZero() bool {
+	return settings == TLSSettings{}
+}
+
+// Returns the TLS configuration and the client certificate (if defined)
+func (settings TLSSettings) Config() (*tls.Config, *x509.Certificate, error) {
+	config := &tls.Config{
+		ServerName: settings.ServerName,
+	}
+
+	var clientCertificate *x509.Certificate
+	if settings.ClientCertificate != "" || settings.ClientKey != "" {
+		if settings.ClientCertificate == "" || settings.ClientKey == "" {
+			return nil, nil, errors.New("client certificate and key must be both defined")
+		}
+
+		certificate, err := tls.LoadX509KeyPair(settings.ClientCertificate, settings.ClientKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't load client certificate: %v", err)
+		}
+		clientCertificate, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't parse client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if settings.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		bundle, err := ioutil.ReadFile(settings.CABundle)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't read CA bundle: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, nil, fmt.Errorf("no certificate found in CA bundle %v", settings.CABundle)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, clientCertificate, nil
+}
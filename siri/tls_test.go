@@ -0,0 +1,155 @@
+package siri
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes a self-signed client certificate and its key in the given directory
+func writeTestClientCertificate(t *testing.T, directory string, notAfter time.Time) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ara"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificateFile := filepath.Join(directory, "client.crt")
+	keyFile := filepath.Join(directory, "client.key")
+	ioutil.WriteFile(certificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return certificateFile, keyFile, certificate
+}
+
+func Test_TLSSettings_Config(t *testing.T) {
+	directory, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	certificateFile, keyFile, _ := writeTestClientCertificate(t, directory, notAfter)
+
+	settings := TLSSettings{
+		ClientCertificate: certificateFile,
+		ClientKey:         keyFile,
+		CABundle:          certificateFile,
+		ServerName:        "partner.example.com",
+	}
+	config, certificate, err := settings.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Certificates) != 1 || config.RootCAs == nil || config.ServerName != "partner.example.com" {
+		t.Errorf("Wrong TLS config: %#v", config)
+	}
+	if !certificate.NotAfter.Equal(notAfter) {
+		t.Errorf("Wrong client certificate expiry:\n got: %v\n want: %v", certificate.NotAfter, notAfter)
+	}
+
+	if _, _, err := (TLSSettings{ClientCertificate: certificateFile}).Config(); err == nil {
+		t.Errorf("Config should fail without client key")
+	}
+	if _, _, err := (TLSSettings{CABundle: keyFile}).Config(); err == nil {
+		t.Errorf("Config should fail with a CA bundle without certificate")
+	}
+}
+
+func Test_SOAPClient_MutualTLS(t *testing.T) {
+	directory, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	certificateFile, keyFile, clientCertificate := writeTestClientCertificate(t, directory, time.Now().Add(time.Hour))
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := testSOAPFile("checkstatus-response")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		w.Header().Set("Content-Type", "text/xml")
+		io.Copy(w, file)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCertificate)
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.StartTLS()
+	defer ts.Close()
+
+	serverCA := filepath.Join(directory, "server.crt")
+	ioutil.WriteFile(serverCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+
+	request := &SIRICheckStatusRequest{
+		RequestorRef:      "Ara",
+		RequestTimestamp:  time.Now(),
+		MessageIdentifier: "Ara:Message::6ba7b814-9dad-11d1-32-00c04fd430c8:LOC",
+	}
+
+	settings := DefaultSOAPClientSettings()
+	settings.TLS = TLSSettings{CABundle: serverCA}
+	if _, err := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings).CheckStatus(request); err == nil {
+		t.Errorf("Request without client certificate should fail")
+	}
+
+	settings.TLS.ClientCertificate = certificateFile
+	settings.TLS.ClientKey = keyFile
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: ts.URL}, settings)
+	if _, err := client.CheckStatus(request); err != nil {
+		t.Errorf("Request with client certificate should succeed: %v", err)
+	}
+	if client.ClientCertificate() == nil || !client.ClientCertificate().NotAfter.Equal(clientCertificate.NotAfter) {
+		t.Errorf("Wrong client certificate: %v", client.ClientCertificate())
+	}
+}
+
+func Test_SOAPClient_InvalidTLSSettings(t *testing.T) {
+	settings := DefaultSOAPClientSettings()
+	settings.TLS = TLSSettings{CABundle: "/nonexistent/ca.pem"}
+	client := NewSOAPClientWithSettings(SOAPClientUrls{Url: "https://localhost"}, settings)
+
+	if client.SettingsError() == nil {
+		t.Fatalf("SOAPClient should return the settings error")
+	}
+	request := &SIRICheckStatusRequest{RequestorRef: "Ara"}
+	if _, err := client.CheckStatus(request); err == nil {
+		t.Errorf("Request with invalid TLS settings should fail")
+	}
+}
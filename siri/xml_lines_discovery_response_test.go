@@ -0,0 +1,71 @@
+package siri
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func getXMLLinesDiscoveryResponse(t *testing.T) *XMLLinesDiscoveryResponse {
+	file, err := os.Open("testdata/linesdiscovery-response.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := NewXMLLinesDiscoveryResponseFromContent(content)
+	return response
+}
+
+func Test_XMLLinesDiscoveryResponse(t *testing.T) {
+	response := getXMLLinesDiscoveryResponse(t)
+
+	if !response.Status() {
+		t.Errorf("Wrong Status: %v", response.Status())
+	}
+
+	annotatedLines := response.AnnotatedLineRefs()
+	if len(annotatedLines) != 2 {
+		t.Fatalf("Wrong AnnotatedLineRefs count:\n got: %v\nwant: 2", len(annotatedLines))
+	}
+
+	if expected := "NINOXE:Line:1:LOC"; annotatedLines[0].LineRef() != expected {
+		t.Errorf("Wrong LineRef:\n got: %v\nwant: %v", annotatedLines[0].LineRef(), expected)
+	}
+	if expected := "Line 1"; annotatedLines[0].LineName() != expected {
+		t.Errorf("Wrong LineName:\n got: %v\nwant: %v", annotatedLines[0].LineName(), expected)
+	}
+	if !annotatedLines[0].Monitored() || annotatedLines[1].Monitored() {
+		t.Errorf("Wrong Monitored values: %v %v", annotatedLines[0].Monitored(), annotatedLines[1].Monitored())
+	}
+}
+
+func Test_SIRILinesDiscoveryRequest_BuildXML(t *testing.T) {
+	expectedXML := `<sw:LinesDiscovery xmlns:sw="http://wsdl.siri.org.uk" xmlns:siri="http://www.siri.org.uk/siri">
+	<Request>
+		<siri:RequestTimestamp>2016-09-21T20:14:46.000Z</siri:RequestTimestamp>
+		<siri:RequestorRef>test</siri:RequestorRef>
+		<siri:MessageIdentifier>LinesDiscovery:Test:0</siri:MessageIdentifier>
+	</Request>
+	<RequestExtension />
+</sw:LinesDiscovery>`
+
+	request := &SIRILinesDiscoveryRequest{
+		MessageIdentifier: "LinesDiscovery:Test:0",
+		RequestorRef:      "test",
+		RequestTimestamp:  time.Date(2016, time.September, 21, 20, 14, 46, 0, time.UTC),
+	}
+
+	xml, err := request.BuildXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xml != expectedXML {
+		t.Errorf("Wrong XML:\n got:\n%v\nwant:\n%v", xml, expectedXML)
+	}
+}
@@ -22,6 +22,9 @@ type XMLAnnotatedStopPointRef struct {
 
 	lineRefs []string
 
+	longitude float64
+	latitude  float64
+
 	monitored Bool
 }
 
@@ -93,6 +96,20 @@ func (annotatedStopPoint *XMLAnnotatedStopPointRef) LineRefs() []string {
 	return annotatedStopPoint.lineRefs
 }
 
+func (annotatedStopPoint *XMLAnnotatedStopPointRef) Longitude() float64 {
+	if annotatedStopPoint.longitude == 0 {
+		annotatedStopPoint.longitude = annotatedStopPoint.findFloatChildContent("Longitude")
+	}
+	return annotatedStopPoint.longitude
+}
+
+func (annotatedStopPoint *XMLAnnotatedStopPointRef) Latitude() float64 {
+	if annotatedStopPoint.latitude == 0 {
+		annotatedStopPoint.latitude = annotatedStopPoint.findFloatChildContent("Latitude")
+	}
+	return annotatedStopPoint.latitude
+}
+
 func (annotatedStopPoint *XMLAnnotatedStopPointRef) Monitored() bool {
 	if !annotatedStopPoint.monitored.Defined {
 		annotatedStopPoint.monitored.Parse(annotatedStopPoint.findStringChildContent("Monitored"))
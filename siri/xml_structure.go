@@ -247,6 +247,19 @@ func (xmlStruct *XMLStructure) findDurationChildContent(localName string) time.D
 	return time.Duration(years + months + days + hours + minutes + seconds)
 }
 
+// Returns a negative duration when the content starts with a minus sign
+func (xmlStruct *XMLStructure) findSignedDurationChildContent(localName string) time.Duration {
+	node := xmlStruct.findNode(localName)
+	if node == nil {
+		return 0
+	}
+	duration := xmlStruct.findDurationChildContent(localName)
+	if strings.HasPrefix(strings.TrimSpace(node.Content()), "-") {
+		return -duration
+	}
+	return duration
+}
+
 func parseDuration(value string) time.Duration {
 	if len(value) == 0 {
 		return 0
@@ -282,6 +295,18 @@ func (xmlStruct *XMLStructure) findIntChildContent(localName string) int {
 	return s
 }
 
+func (xmlStruct *XMLStructure) findFloatChildContent(localName string) float64 {
+	node := xmlStruct.findNode(localName)
+	if node == nil {
+		return 0
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(node.Content()), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
 func (xmlStruct *XMLStructure) RawXML() string {
 	return xmlStruct.node.NativeNode().String()
 }